@@ -7,9 +7,19 @@ import (
 	"os/signal"
 	"syscall"
 
+	"wim-service/internal/calibration"
 	"wim-service/internal/config"
+	"wim-service/internal/correlation"
 	"wim-service/internal/ftpwatcher"
+	"wim-service/internal/golongan"
 	"wim-service/internal/handler"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/reconcile"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
+	"wim-service/internal/vision"
+	"wim-service/internal/watchlist"
 )
 
 func main() {
@@ -31,24 +41,55 @@ func main() {
 	var dimensionHandler *handler.DimensionHandler
 	if cfg.DimensionEnabled {
 		log.Println("[ANPR] Vehicle Dimension Detection: ENABLED")
-		dimensionHandler, err = handler.NewDimensionHandler(
-			cfg.DB,
-			cfg.SiteUUID,
-			cfg.DimensionModelPath,
-			cfg.DimensionThreshold,
-		)
+		detector, err := vision.NewDetector(cfg.GetDetectorConfig())
+		if err != nil {
+			log.Fatal("[ANPR] Failed to create vehicle detector:", err)
+		}
+		if cfg.DimensionDetector == vision.DetectorHTTP {
+			log.Printf("[ANPR] Vehicle detector: inference server %s (batch %d, timeout %v, fallback %v)",
+				cfg.InferenceURL, cfg.InferenceBatchSize, cfg.InferenceTimeout, cfg.InferenceFallback)
+		} else {
+			log.Println("[ANPR] Vehicle detector: built-in segmentation")
+		}
+
+		dimensionHandler, err = handler.NewDimensionHandler(cfg.DB, cfg.SiteUUID, detector)
 		if err != nil {
 			log.Fatal("[ANPR] Failed to create dimension handler:", err)
 		}
 
-		calibration := cfg.GetCameraCalibration()
-		if err := dimensionHandler.SetCalibration(calibration); err != nil {
+		cameraCalibration, err := cfg.GetCameraCalibration()
+		if err != nil {
+			log.Fatal("[ANPR] Invalid camera calibration:", err)
+		}
+		if err := dimensionHandler.SetCalibration(cameraCalibration); err != nil {
 			log.Fatal("[ANPR] Failed to set camera calibration:", err)
 		}
 
 		log.Printf("[ANPR] Camera: %dx%d, Height: %.2fm, Tilt: %.2f°",
-			cfg.CameraImageWidth, cfg.CameraImageHeight,
-			cfg.CameraHeight, cfg.CameraTiltAngle)
+			cameraCalibration.ImageWidth, cameraCalibration.ImageHeight,
+			cameraCalibration.CameraHeightMeters, cameraCalibration.TiltAngleDegrees)
+		if g := cameraCalibration.Ground; g != nil {
+			log.Printf("[ANPR] Ground calibration: %d control points, RMS %.3fm, max %.3fm",
+				g.Residual.Points, g.Residual.RMSMeters, g.Residual.MaxMeters)
+			if g.Residual.Warning != "" {
+				log.Printf("[ANPR] WARNING: Ground calibration: %s", g.Residual.Warning)
+			}
+		}
+
+		// Kalibrasi per kamera dari database; kamera tanpa profil memakai CAMERA_* di atas
+		if cfg.CalibrationProfilesEnabled {
+			profiles := calibration.NewResolver(calibration.NewService(cfg.DB), cfg.SiteUUID, cfg.CalibrationProfileRefresh)
+			loaded, err := profiles.Load(context.Background())
+			if err != nil {
+				log.Printf("[ANPR] WARNING: Failed to load calibration profiles: %v", err)
+			}
+			for _, p := range loaded {
+				log.Printf("[ANPR] Calibration profile %s: %.2fm, tilt %.2f°, effective %s",
+					p.Ref(), p.CameraHeightMeters, p.TiltAngleDegrees, p.EffectiveFrom.Format("2006-01-02 15:04"))
+			}
+			log.Printf("[ANPR] Calibration Profiles: ENABLED (%d active, refresh %v)", len(loaded), cfg.CalibrationProfileRefresh)
+			dimensionHandler.SetCalibrationProfiles(profiles)
+		}
 	} else {
 		log.Println("[ANPR] Vehicle Dimension Detection: DISABLED")
 	}
@@ -71,6 +112,54 @@ func main() {
 	// Link dimension handler
 	if dimensionHandler != nil {
 		anprProcessor.SetDimensionHandler(dimensionHandler)
+
+		// Vision dimensions trigger over-dimension re-evaluation of matching axle captures
+		if cfg.ODOLEnabled {
+			log.Println("[ANPR] Over-Dimension Evaluation: ENABLED")
+			anprProcessor.SetOverDimensionEvaluator(odol.NewService(cfg.DB, cfg.GetODOLTolerances(), cfg.ODOLVisionWindow))
+			if cfg.ViolationEnabled {
+				anprProcessor.SetViolationService(violation.NewService(cfg.DB))
+			}
+		}
+	}
+
+	// Link ANPR/axle correlation. An ANPR capture merged with a waiting axle capture
+	// changes that capture's class, Golongan, overload, over-dimension and violations, which are
+	// recomputed with the services enabled for the axle pipeline.
+	if cfg.CorrelationEnabled {
+		log.Printf("[ANPR] Vehicle Correlation: ENABLED (window %v, review below score %.2f)", cfg.CorrelationWindow, cfg.CorrelationMinScore)
+		log.Printf("[ANPR] Clock Drift Estimation: %v (alert beyond %v, auto-apply %v)", cfg.ClockDriftEnabled, cfg.ClockDriftThreshold, cfg.ClockDriftAutoApply)
+		correlationService := correlation.NewService(cfg.DB, cfg.CorrelationWindow, cfg.GetCorrelationScoring(), cfg.GetClockDrift())
+		recompute := &correlation.Recomputer{}
+		if cfg.ClassResolutionEnabled {
+			recompute.Classes = vehicleclass.NewResolver(cfg.DB, cfg.ClassMinConfidence)
+		}
+		if cfg.GolonganEnabled {
+			recompute.Golongan = golongan.NewService(cfg.DB)
+		}
+		if cfg.OverloadEnabled {
+			recompute.Overload = overload.NewService(cfg.DB, cfg.OverloadTolerancePct)
+		}
+		if cfg.ODOLEnabled {
+			recompute.ODOL = odol.NewService(cfg.DB, cfg.GetODOLTolerances(), cfg.ODOLVisionWindow)
+		}
+		if cfg.ViolationEnabled {
+			recompute.Violations = violation.NewService(cfg.DB)
+		}
+		correlationService.Recompute = recompute
+		anprProcessor.SetCorrelationService(correlationService)
+	} else {
+		log.Println("[ANPR] Vehicle Correlation: DISABLED")
+	}
+
+	// Link watchlist matcher
+	if cfg.WatchlistEnabled {
+		log.Printf("[ANPR] Plate Watchlist: ENABLED (%d webhook(s))", len(cfg.WatchlistWebhookURLs))
+		watchlistService := watchlist.NewService(cfg.DB)
+		notifier := watchlist.NewNotifier(watchlistService, cfg.WatchlistWebhookURLs, cfg.WatchlistWebhookTimeout)
+		anprProcessor.SetWatchlistMatcher(watchlist.NewMatcher(watchlistService, notifier))
+	} else {
+		log.Println("[ANPR] Plate Watchlist: DISABLED")
 	}
 
 	// Create FTP watcher
@@ -95,6 +184,17 @@ func main() {
 	log.Println("========================================")
 	log.Println("")
 
+	// Start reconciliation job (orphaned objects / missing objects)
+	if cfg.ReconcileEnabled {
+		reconciler := reconcile.NewReconciler(cfg.DB, anprProcessor.Minio, anprProcessor.Bucket, reconcile.ANPRSpec)
+		reconciler.LookbackDays = cfg.ReconcileLookbackDays
+		reconciler.Grace = cfg.ReconcileGrace
+		reconciler.RemoveOrphans = cfg.ReconcileRemoveOrphans
+		go reconciler.Run(ctx, cfg.ReconcileInterval)
+	} else {
+		log.Println("[ANPR] Reconciliation job: DISABLED")
+	}
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"os"
 	"os/signal"
@@ -8,7 +9,14 @@ import (
 
 	"wim-service/internal/api"
 	"wim-service/internal/config"
+	"wim-service/internal/correlation"
+	"wim-service/internal/evidence"
+	"wim-service/internal/golongan"
 	"wim-service/internal/handler"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
 )
 
 func main() {
@@ -35,8 +43,46 @@ func main() {
 		log.Fatal("[API] Failed to create attachment handler:", err)
 	}
 
+	// Initialize evidence service (reads ANPR/AXLE buckets for re-verification)
+	evidenceService := evidence.NewService(cfg.DB)
+	if err := evidenceService.RegisterStore(evidence.CaptureANPR,
+		cfg.ANPRMinIOEndpoint, cfg.ANPRMinIOAccess, cfg.ANPRMinIOSecret, cfg.ANPRMinIOUseSSL); err != nil {
+		log.Fatal("[API] Failed to create ANPR evidence store:", err)
+	}
+	if err := evidenceService.RegisterStore(evidence.CaptureAXLE,
+		cfg.AxleMinIOEndpoint, cfg.AxleMinIOAccess, cfg.AxleMinIOSecret, cfg.AxleMinIOUseSSL); err != nil {
+		log.Fatal("[API] Failed to create AXLE evidence store:", err)
+	}
+
+	// Signed evidence bundles (per-site Ed25519 key)
+	signer, err := evidence.LoadOrCreateSigner(cfg.EvidenceSigningKeyPath)
+	if err != nil {
+		log.Fatal("[API] Failed to load evidence signing key:", err)
+	}
+	if err := evidenceService.ConfigureBundles(cfg.SiteUUID, cfg.SiteCode, signer,
+		cfg.EvidenceMinIOEndpoint, cfg.EvidenceMinIOAccess, cfg.EvidenceMinIOSecret,
+		cfg.EvidenceMinIOBucket, cfg.EvidenceMinIOUseSSL); err != nil {
+		log.Fatal("[API] Failed to configure evidence bundles:", err)
+	}
+	if err := evidenceService.PublishSigningKey(context.Background()); err != nil {
+		log.Printf("[API] WARNING: Failed to publish signing key: %v", err)
+	}
+	log.Printf("[API] Evidence signing key: %s", signer.KeyID)
+
 	// Create API server
-	apiServer := api.NewServer(cfg.DB, cfg.JWTSecret, attachmentHandler)
+	overloadService := overload.NewService(cfg.DB, cfg.OverloadTolerancePct)
+	classResolver := vehicleclass.NewResolver(cfg.DB, cfg.ClassMinConfidence)
+	odolService := odol.NewService(cfg.DB, cfg.GetODOLTolerances(), cfg.ODOLVisionWindow)
+	correlationService := correlation.NewService(cfg.DB, cfg.CorrelationWindow, cfg.GetCorrelationScoring(), cfg.GetClockDrift())
+	correlationService.Recompute = &correlation.Recomputer{
+		Classes:    classResolver,
+		Golongan:   golongan.NewService(cfg.DB),
+		Overload:   overloadService,
+		ODOL:       odolService,
+		Violations: violation.NewService(cfg.DB),
+	}
+
+	apiServer := api.NewServer(cfg.DB, cfg.JWTSecret, attachmentHandler, evidenceService, overloadService, classResolver, odolService, correlationService)
 
 	log.Println("")
 	log.Println("API Endpoints:")
@@ -49,6 +95,17 @@ func main() {
 	log.Println("Protected Endpoints (Require JWT Token):")
 	log.Printf("  - Profile:       GET  /api/auth/profile")
 	log.Printf("  - Upload Image:  POST /api/attachment/upload")
+	log.Printf("  - Watchlist:     GET/POST/PUT/DELETE /api/watchlist")
+	log.Printf("  - Evidence:      GET  /api/evidence/:type/:id, POST /api/evidence/:type/:id/verify")
+	log.Printf("  - Bundles:       POST /api/evidence/:type/:id/bundle, POST /api/evidence/bundles/verify")
+	log.Printf("  - Violations:    GET  /api/violations, POST /api/violations/:id/transition")
+	log.Printf("  - Golongan:      GET  /api/golongan/counts, GET/POST/PUT/DELETE /api/golongan/rules")
+	log.Printf("  - Vehicles:      GET  /api/vehicles, GET /api/vehicles/:id")
+	log.Printf("  - Overrides:     POST /api/vehicles/link, POST /api/vehicles/:id/unlink, POST /api/vehicles/:id/no-partner")
+	log.Printf("  - Clock Drift:   GET  /api/vehicles/clock-drift, DELETE /api/vehicles/clock-drift/:id")
+	log.Printf("  - Late Matches:  GET  /api/vehicles/late-matches, GET /api/vehicles/late-matches/counts")
+	log.Printf("  - Calibration:   GET/POST /api/calibration-profiles, GET /api/calibration-profiles/in-force")
+	log.Printf("  - Calibrate:     POST /api/calibration-profiles/calibrate (reference frame + markings)")
 	log.Println("")
 	log.Println("Press Ctrl+C to stop the API server")
 	log.Println("========================================")
@@ -8,8 +8,15 @@ import (
 	"syscall"
 
 	"wim-service/internal/config"
+	"wim-service/internal/correlation"
 	"wim-service/internal/ftpwatcher"
+	"wim-service/internal/golongan"
 	"wim-service/internal/handler"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/reconcile"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
 )
 
 func main() {
@@ -42,6 +49,66 @@ func main() {
 		log.Fatal("[AXLE] Failed to create AXLE processor:", err)
 	}
 
+	// Link vehicle class resolution (runs before overload evaluation)
+	if cfg.ClassResolutionEnabled {
+		log.Printf("[AXLE] Vehicle Class Resolution: ENABLED (min confidence %.2f)", cfg.ClassMinConfidence)
+		axleProcessor.SetClassResolver(vehicleclass.NewResolver(cfg.DB, cfg.ClassMinConfidence))
+	} else {
+		log.Println("[AXLE] Vehicle Class Resolution: DISABLED")
+	}
+
+	// Link toll Golongan assignment (uses the resolved class)
+	if cfg.GolonganEnabled {
+		log.Println("[AXLE] Toll Golongan: ENABLED")
+		axleProcessor.SetGolonganService(golongan.NewService(cfg.DB))
+	} else {
+		log.Println("[AXLE] Toll Golongan: DISABLED")
+	}
+
+	// Link overload evaluation
+	if cfg.OverloadEnabled {
+		log.Printf("[AXLE] Overload Evaluation: ENABLED (tolerance %.1f%%)", cfg.OverloadTolerancePct)
+		axleProcessor.SetOverloadEvaluator(overload.NewService(cfg.DB, cfg.OverloadTolerancePct))
+	} else {
+		log.Println("[AXLE] Overload Evaluation: DISABLED")
+	}
+
+	// Link over-dimension evaluation
+	if cfg.ODOLEnabled {
+		log.Printf("[AXLE] Over-Dimension Evaluation: ENABLED (tolerance L/W/H %d/%d/%d mm)",
+			cfg.ODOLLengthToleranceMM, cfg.ODOLWidthToleranceMM, cfg.ODOLHeightToleranceMM)
+		axleProcessor.SetOverDimensionEvaluator(odol.NewService(cfg.DB, cfg.GetODOLTolerances(), cfg.ODOLVisionWindow))
+	} else {
+		log.Println("[AXLE] Over-Dimension Evaluation: DISABLED")
+	}
+
+	// Link violation cases (raised from overload / over-dimension results)
+	if cfg.ViolationEnabled {
+		log.Println("[AXLE] Violation Cases: ENABLED")
+		axleProcessor.SetViolationService(violation.NewService(cfg.DB))
+	} else {
+		log.Println("[AXLE] Violation Cases: DISABLED")
+	}
+
+	// Link ANPR/axle correlation (runs first, so class, Golongan and violations follow the pairing).
+	// Late merges are recomputed with the same services as the live pipeline.
+	var correlationService *correlation.Service
+	if cfg.CorrelationEnabled {
+		log.Printf("[AXLE] Vehicle Correlation: ENABLED (window %v, review below score %.2f)", cfg.CorrelationWindow, cfg.CorrelationMinScore)
+		log.Printf("[AXLE] Clock Drift Estimation: %v (alert beyond %v, auto-apply %v)", cfg.ClockDriftEnabled, cfg.ClockDriftThreshold, cfg.ClockDriftAutoApply)
+		correlationService = correlation.NewService(cfg.DB, cfg.CorrelationWindow, cfg.GetCorrelationScoring(), cfg.GetClockDrift())
+		correlationService.Recompute = &correlation.Recomputer{
+			Classes:    axleProcessor.Classes,
+			Golongan:   axleProcessor.Golongan,
+			Overload:   axleProcessor.Overload,
+			ODOL:       axleProcessor.Dimension,
+			Violations: axleProcessor.Violations,
+		}
+		axleProcessor.SetCorrelationService(correlationService)
+	} else {
+		log.Println("[AXLE] Vehicle Correlation: DISABLED")
+	}
+
 	// Create FTP watcher
 	axleWatcher := ftpwatcher.New(
 		cfg.AxleFTPHost,
@@ -64,6 +131,27 @@ func main() {
 	log.Println("========================================")
 	log.Println("")
 
+	// Start reconciliation job (orphaned objects / missing objects)
+	if cfg.ReconcileEnabled {
+		reconciler := reconcile.NewReconciler(cfg.DB, axleProcessor.Minio, axleProcessor.Bucket, reconcile.AxleSpec)
+		reconciler.LookbackDays = cfg.ReconcileLookbackDays
+		reconciler.Grace = cfg.ReconcileGrace
+		reconciler.RemoveOrphans = cfg.ReconcileRemoveOrphans
+		go reconciler.Run(ctx, cfg.ReconcileInterval)
+	} else {
+		log.Println("[AXLE] Reconciliation job: DISABLED")
+	}
+
+	// Start late-arrival correlation reconciliation (one job per site is enough: it runs here only)
+	if correlationService != nil && cfg.CorrelationReconcileEnabled {
+		lateReconciler := correlation.NewReconciler(correlationService, cfg.SiteUUID)
+		lateReconciler.Horizon = cfg.CorrelationReconcileHorizon
+		lateReconciler.Grace = cfg.CorrelationReconcileGrace
+		go lateReconciler.Run(ctx, cfg.CorrelationReconcileInterval)
+	} else {
+		log.Println("[AXLE] Late-arrival correlation reconciliation: DISABLED")
+	}
+
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
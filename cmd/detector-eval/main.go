@@ -0,0 +1,119 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wim-service/internal/vision"
+	"wim-service/internal/vision/visiontest"
+)
+
+// Offline evaluation of the vehicle detector against a labelled image set. Needs no
+// database or MinIO:
+//
+//	go run cmd/detector-eval/main.go -labels internal/vision/testdata/detector/site/labels.json
+//	go run cmd/detector-eval/main.go -backend http -endpoint http://localhost:8500/detect -classes car,truck,bus
+//	go run cmd/detector-eval/main.go -backend http -stub   # HTTP backend against a local stub server
+//
+// Exits with status 1 when precision, recall or mean IoU falls below
+// -min-precision/-min-recall/-min-iou (set 0 to report only).
+func main() {
+	labelsPath := flag.String("labels", "internal/vision/testdata/detector/labels.json", "Path to the label file")
+	threshold := flag.Float64("threshold", 0.5, "Detector score threshold (DIMENSION_THRESHOLD)")
+	iou := flag.Float64("iou", 0.5, "IoU needed for a detection to match a label")
+	minPrecision := flag.Float64("min-precision", 0.9, "Fail when precision is below this")
+	minRecall := flag.Float64("min-recall", 0.9, "Fail when recall is below this")
+	minIoU := flag.Float64("min-iou", 0.75, "Fail when mean IoU of true positives is below this")
+	outDir := flag.String("out", "", "Write annotated frames to this directory (detections red)")
+	backend := flag.String("backend", vision.DetectorSegmentation, "Detector backend (segmentation, http)")
+	endpoint := flag.String("endpoint", "", "Inference server endpoint (-backend http)")
+	classes := flag.String("classes", "", "Comma separated class labels accepted from the inference server")
+	timeout := flag.Duration("timeout", 2*time.Second, "Inference request timeout")
+	fallback := flag.Bool("fallback", false, "Fall back to the built-in detector when the inference server fails")
+	stub := flag.Bool("stub", false, "Serve the inference protocol locally with the built-in detector and use it as -endpoint")
+	flag.Parse()
+
+	if *stub {
+		server := httptest.NewServer(visiontest.InferenceHandler(vision.NewSegmentationDetector("", 0), "vehicle"))
+		defer server.Close()
+		*endpoint = server.URL
+	}
+
+	set, err := vision.LoadLabelSet(*labelsPath)
+	if err != nil {
+		log.Fatal("[DETECTOR-EVAL] Failed to load labels:", err)
+	}
+	if len(set.Images) == 0 {
+		log.Fatalf("[DETECTOR-EVAL] Label set %s has no images", *labelsPath)
+	}
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0o755); err != nil {
+			log.Fatal("[DETECTOR-EVAL] Failed to create output directory:", err)
+		}
+	}
+
+	var classList []string
+	if *classes != "" {
+		classList = strings.Split(*classes, ",")
+	}
+	detector, err := vision.NewDetector(vision.DetectorConfig{
+		Backend:   *backend,
+		Threshold: *threshold,
+		Endpoint:  *endpoint,
+		Timeout:   *timeout,
+		BatchSize: 1, // Frame dievaluasi berurutan
+		Classes:   classList,
+		Fallback:  *fallback,
+	})
+	if err != nil {
+		log.Fatal("[DETECTOR-EVAL] Failed to create detector:", err)
+	}
+
+	report, err := vision.Evaluate(detector, set, *iou)
+	if err != nil {
+		log.Fatal("[DETECTOR-EVAL] Evaluation failed:", err)
+	}
+
+	fmt.Println("========================================")
+	fmt.Println("  VEHICLE DETECTOR EVALUATION")
+	fmt.Println("========================================")
+	fmt.Printf("  Label set:  %s (%d images)\n", *labelsPath, len(report.Images))
+	fmt.Printf("  Detector:   %s %s\n", *backend, *endpoint)
+	fmt.Printf("  Threshold:  %.2f   IoU: %.2f\n", *threshold, report.IoUThreshold)
+	fmt.Println("")
+	for _, r := range report.Images {
+		ious := make([]string, len(r.IoU))
+		for i, v := range r.IoU {
+			ious[i] = fmt.Sprintf("%.2f", v)
+		}
+		fmt.Printf("  %-20s %-10s labels=%d det=%d TP=%d FP=%d FN=%d IoU=[%s]\n",
+			r.Image, r.Camera, r.Labels, len(r.Detections), r.TruePositives, r.FalsePositives, r.FalseNegatives,
+			strings.Join(ious, " "))
+
+		if *outDir != "" {
+			out := filepath.Join(*outDir, strings.TrimSuffix(filepath.Base(r.Image), filepath.Ext(r.Image))+".png")
+			if err := vision.DrawBoundingBoxes(set.ImagePath(r.Image), r.Detections, out); err != nil {
+				log.Printf("[DETECTOR-EVAL] Warning: failed to write %s: %v", out, err)
+			}
+		}
+	}
+	fmt.Println("")
+	fmt.Printf("  TP=%d FP=%d FN=%d\n", report.TruePositives, report.FalsePositives, report.FalseNegatives)
+	fmt.Printf("  Precision:  %.3f\n", report.Precision)
+	fmt.Printf("  Recall:     %.3f\n", report.Recall)
+	fmt.Printf("  Mean IoU:   %.3f\n", report.MeanIoU)
+	fmt.Println("")
+
+	if report.Precision < *minPrecision || report.Recall < *minRecall || report.MeanIoU < *minIoU {
+		fmt.Println("  RESULT: BELOW TARGET")
+		os.Exit(1)
+	}
+	fmt.Println("  RESULT: OK")
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/ed25519"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"wim-service/internal/evidence"
+)
+
+// Offline verifier for signed evidence bundles. Needs no database, MinIO or network:
+//
+//	go run cmd/evidence-verify/main.go -bundle capture.zip -pubkey site_public_key.pem
+func main() {
+	bundlePath := flag.String("bundle", "", "Path to the evidence bundle (.zip)")
+	pubKeyPath := flag.String("pubkey", "", "Path to the site's published public key (PEM)")
+	flag.Parse()
+
+	if *bundlePath == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	data, err := os.ReadFile(*bundlePath)
+	if err != nil {
+		log.Fatal("[VERIFY] Failed to read bundle:", err)
+	}
+
+	var trusted ed25519.PublicKey
+	if *pubKeyPath != "" {
+		pemBytes, err := os.ReadFile(*pubKeyPath)
+		if err != nil {
+			log.Fatal("[VERIFY] Failed to read public key:", err)
+		}
+		if trusted, err = evidence.ParsePublicKeyPEM(pemBytes); err != nil {
+			log.Fatal("[VERIFY] Invalid public key:", err)
+		}
+	}
+
+	result, err := evidence.VerifyBundle(data, trusted)
+	if err != nil {
+		log.Fatal("[VERIFY] Failed to verify bundle:", err)
+	}
+
+	fmt.Println("========================================")
+	fmt.Println("  EVIDENCE BUNDLE VERIFICATION")
+	fmt.Println("========================================")
+	if m := result.Manifest; m != nil {
+		fmt.Printf("  Bundle:     %s\n", m.BundleID)
+		fmt.Printf("  Subject:    %s %s\n", m.SubjectType, m.SubjectID)
+		fmt.Printf("  Site:       %s (%s)\n", m.SiteCode, m.SiteID)
+		fmt.Printf("  Generated:  %s by %s\n", m.GeneratedAt.Format("2006-01-02 15:04:05 MST"), m.GeneratedBy)
+	}
+	fmt.Printf("  Key ID:     %s (trusted: %v)\n", result.KeyID, result.KeyTrusted)
+	fmt.Printf("  Signature:  %v\n", result.SignatureValid)
+	fmt.Println("")
+	for _, f := range result.Files {
+		status := "OK"
+		if !f.OK {
+			status = "FAILED"
+		}
+		fmt.Printf("  [%-6s] %s\n", status, f.Path)
+	}
+	for _, e := range result.Errors {
+		fmt.Printf("  ! %s\n", e)
+	}
+	fmt.Println("")
+
+	if !result.Valid {
+		fmt.Println("  RESULT: INVALID")
+		os.Exit(1)
+	}
+	fmt.Println("  RESULT: VALID")
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"wim-service/internal/axle"
+	"wim-service/internal/golongan"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type AxleHandler struct {
+	AxleService     *axle.Service
+	OverloadService *overload.Service
+	ClassResolver   *vehicleclass.Resolver
+	ODOLService     *odol.Service
+	Violations      *violation.Service
+	Golongan        *golongan.Service
+}
+
+func NewAxleHandler(axleService *axle.Service, overloadService *overload.Service, classResolver *vehicleclass.Resolver, odolService *odol.Service, violations *violation.Service, golonganService *golongan.Service) *AxleHandler {
+	return &AxleHandler{
+		AxleService:     axleService,
+		OverloadService: overloadService,
+		ClassResolver:   classResolver,
+		ODOLService:     odolService,
+		Violations:      violations,
+		Golongan:        golonganService,
+	}
+}
+
+func (h *AxleHandler) ListCaptures(c *fiber.Ctx) error {
+	filter := axle.CaptureFilter{
+		SiteID:         c.Query("site_id"),
+		PlateNo:        c.Query("plate_no"),
+		OverloadStatus: strings.ToUpper(c.Query("overload_status")),
+		ClassStatus:    strings.ToUpper(c.Query("class_status")),
+		QualityStatus:  strings.ToUpper(c.Query("quality_status")),
+		TollGolongan:   c.QueryInt("golongan", 0),
+		Limit:          c.QueryInt("limit", 100),
+		Offset:         c.QueryInt("offset", 0),
+	}
+	if v := c.Query("min_overload_pct"); v != "" {
+		pct, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid min_overload_pct",
+			})
+		}
+		filter.MinOverloadPct = &pct
+	}
+
+	if v := c.Query("review_required"); v != "" {
+		review, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid review_required",
+			})
+		}
+		filter.ReviewRequired = &review
+	}
+
+	if v := c.Query("over_dimension"); v != "" {
+		over, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid over_dimension",
+			})
+		}
+		filter.OverDimension = &over
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return badTimeQuery(c, "from")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return badTimeQuery(c, "to")
+	}
+
+	captures, err := h.AxleService.ListCaptures(c.Context(), filter)
+	if err != nil {
+		return axleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    captures,
+	})
+}
+
+func (h *AxleHandler) GetCapture(c *fiber.Ctx) error {
+	capture, err := h.AxleService.GetCapture(c.Context(), c.Params("id"))
+	if err != nil {
+		return axleError(c, err)
+	}
+	if capture.DimensionChecks, err = h.ODOLService.ListChecks(c.Context(), capture.ID); err != nil {
+		return axleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    capture,
+	})
+}
+
+func (h *AxleHandler) ListAxles(c *fiber.Ctx) error {
+	capture, err := h.AxleService.GetCapture(c.Context(), c.Params("id"))
+	if err != nil {
+		return axleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"axles":  capture.Axles,
+			"groups": capture.Groups,
+		},
+	})
+}
+
+// EvaluateOverload recomputes the overload result of a capture (e.g. after master data changes)
+func (h *AxleHandler) EvaluateOverload(c *fiber.Ctx) error {
+	result, err := h.OverloadService.EvaluateCapture(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, overload.ErrNotFound) {
+			err = axle.ErrNotFound
+		}
+		return axleError(c, err)
+	}
+
+	log.Printf("[AXLE] Overload of capture %s re-evaluated by %v: %s", result.CaptureID, c.Locals("username"), result.Status)
+	h.syncViolations(c, result.CaptureID)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// EvaluateDimensions recomputes the over-dimension checks of a capture
+// (e.g. after class limits change or a late vision measurement)
+func (h *AxleHandler) EvaluateDimensions(c *fiber.Ctx) error {
+	result, err := h.ODOLService.EvaluateAxleCapture(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, odol.ErrNotFound) {
+			err = axle.ErrNotFound
+		}
+		return axleError(c, err)
+	}
+
+	log.Printf("[AXLE] Over-dimension of capture %s re-evaluated by %v: %d check(s)", result.CaptureID, c.Locals("username"), len(result.Checks))
+	h.syncViolations(c, result.CaptureID)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// AssignGolongan recomputes the toll Golongan of a capture (e.g. after the mapping rules change)
+func (h *AxleHandler) AssignGolongan(c *fiber.Ctx) error {
+	result, err := h.Golongan.AssignCapture(c.Context(), c.Params("id"))
+	if err != nil {
+		if errors.Is(err, golongan.ErrNotFound) {
+			err = axle.ErrNotFound
+		}
+		return axleError(c, err)
+	}
+
+	log.Printf("[AXLE] Golongan of capture %s re-assigned by %v: %q", result.CaptureID, c.Locals("username"), result.GolonganLabel)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// Classify re-runs class resolution (also over a manual class when force=true)
+// and re-evaluates the Golongan, overload and over-dimension with the new class
+func (h *AxleHandler) Classify(c *fiber.Ctx) error {
+	res, err := h.ClassResolver.ResolveCapture(c.Context(), c.Params("id"), c.QueryBool("force", false))
+	if err != nil {
+		return classError(c, err)
+	}
+	return h.classResponse(c, res)
+}
+
+type assignClassRequest struct {
+	VehicleClassID string `json:"vehicle_class_id"`
+}
+
+// AssignClass sets the vehicle class of a capture manually (review queue)
+func (h *AxleHandler) AssignClass(c *fiber.Ctx) error {
+	var req assignClassRequest
+	if err := c.BodyParser(&req); err != nil || req.VehicleClassID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "vehicle_class_id is required",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	if _, err := h.AxleService.GetCapture(c.Context(), c.Params("id")); err != nil {
+		return axleError(c, err)
+	}
+	res, err := h.ClassResolver.AssignClass(c.Context(), c.Params("id"), req.VehicleClassID, username)
+	if err != nil {
+		return classError(c, err)
+	}
+
+	log.Printf("[AXLE] Capture %s assigned to class %s by %s", res.CaptureID, res.VehicleClassCode, username)
+	return h.classResponse(c, res)
+}
+
+func (h *AxleHandler) classResponse(c *fiber.Ctx, res *vehicleclass.Resolution) error {
+	data := h.reevaluate(c, res.CaptureID)
+	data["class"] = res
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
+
+// reevaluate recomputes the Golongan, overload, over-dimension and violations of a
+// capture; failures are logged so the remaining results are still returned
+func (h *AxleHandler) reevaluate(c *fiber.Ctx, captureID string) fiber.Map {
+	data := fiber.Map{}
+	if result, err := h.Golongan.AssignCapture(c.Context(), captureID); err != nil {
+		log.Printf("[AXLE] Warning: Golongan re-assignment failed for capture %s: %v", captureID, err)
+	} else {
+		data["golongan"] = result
+	}
+	if result, err := h.OverloadService.EvaluateCapture(c.Context(), captureID); err != nil {
+		log.Printf("[AXLE] Warning: Overload re-evaluation failed for capture %s: %v", captureID, err)
+	} else {
+		data["overload"] = result
+	}
+	if result, err := h.ODOLService.EvaluateAxleCapture(c.Context(), captureID); err != nil {
+		log.Printf("[AXLE] Warning: Over-dimension re-evaluation failed for capture %s: %v", captureID, err)
+	} else {
+		data["dimensions"] = result
+	}
+	if violations := h.syncViolations(c, captureID); violations != nil {
+		data["violations"] = violations
+	}
+	return data
+}
+
+// syncViolations raises or updates the violation cases of a re-evaluated capture;
+// failures are logged so the evaluation result is still returned
+func (h *AxleHandler) syncViolations(c *fiber.Ctx, captureID string) []violation.Violation {
+	username, _ := c.Locals("username").(string)
+	violations, err := h.Violations.SyncCapture(c.Context(), captureID, username)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Violation sync failed for capture %s: %v", captureID, err)
+		return nil
+	}
+	return violations
+}
+
+// parseTimeQuery reads an optional RFC3339 or YYYY-MM-DD query parameter
+func parseTimeQuery(c *fiber.Ctx, key string) (*time.Time, error) {
+	v := c.Query(key)
+	if v == "" {
+		return nil, nil
+	}
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return &t, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+func badTimeQuery(c *fiber.Ctx, key string) error {
+	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+		"success": false,
+		"message": "Invalid " + key + " (use RFC3339 or YYYY-MM-DD)",
+	})
+}
+
+func axleError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, axle.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Axle capture not found",
+		})
+	}
+
+	log.Printf("[AXLE] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,210 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"wim-service/internal/calibration"
+	"wim-service/internal/vision"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CalibrationHandler struct {
+	CalibrationService *calibration.Service
+}
+
+func NewCalibrationHandler(calibrationService *calibration.Service) *CalibrationHandler {
+	return &CalibrationHandler{
+		CalibrationService: calibrationService,
+	}
+}
+
+func (h *CalibrationHandler) ListProfiles(c *fiber.Ctx) error {
+	filter := calibration.Filter{
+		SiteID:     c.Query("site_id"),
+		ActiveOnly: c.QueryBool("active", false),
+	}
+	// camera_id= (kosong) memilih profil default site
+	if c.Request().URI().QueryArgs().Has("camera_id") {
+		camera := c.Query("camera_id")
+		filter.CameraID = &camera
+	}
+
+	var err error
+	if filter.At, err = parseTimeQuery(c, "at"); err != nil {
+		return badTimeQuery(c, "at")
+	}
+
+	profiles, err := h.CalibrationService.List(c.Context(), filter)
+	if err != nil {
+		return calibrationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    profiles,
+	})
+}
+
+// InForce menampilkan profil yang dipakai untuk capture kamera pada waktu tertentu;
+// 404 berarti kalibrasi CAMERA_* dari config yang dipakai
+func (h *CalibrationHandler) InForce(c *fiber.Ctx) error {
+	if c.Query("site_id") == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "site_id is required",
+		})
+	}
+	at := time.Now()
+	t, err := parseTimeQuery(c, "at")
+	if err != nil {
+		return badTimeQuery(c, "at")
+	}
+	if t != nil {
+		at = *t
+	}
+
+	profile, err := h.CalibrationService.InForce(c.Context(), c.Query("site_id"), c.Query("camera_id"), at)
+	if err != nil {
+		return calibrationError(c, err)
+	}
+	if profile == nil {
+		return calibrationError(c, calibration.ErrNotFound)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    profile,
+	})
+}
+
+func (h *CalibrationHandler) GetProfile(c *fiber.Ctx) error {
+	profile, err := h.CalibrationService.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return calibrationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    profile,
+	})
+}
+
+// CreateProfile membuat profil baru, atau versi baru jika site + camera_id sudah punya profil
+func (h *CalibrationHandler) CreateProfile(c *fiber.Ctx) error {
+	var req calibration.ProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	profile, err := h.CalibrationService.Create(c.Context(), req, username)
+	if err != nil {
+		return calibrationError(c, err)
+	}
+
+	log.Printf("[CALIBRATION] Profile %s for site %s created by %s", profile.Ref(), profile.SiteCode, username)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Calibration profile created",
+		"data":    profile,
+	})
+}
+
+// Calibrate menghitung kalibrasi dari frame referensi (multipart "image") dan survei
+// marka jalan (field "request", JSON), lalu menyimpannya sebagai versi profil baru
+// kecuali dry_run. Preview beranotasi dikembalikan sebagai data URL JPEG.
+func (h *CalibrationHandler) Calibrate(c *fiber.Ctx) error {
+	file, err := c.FormFile("image")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "No reference frame provided (multipart field \"image\")",
+		})
+	}
+	var req calibration.CalibrateRequest
+	if err := json.Unmarshal([]byte(c.FormValue("request")), &req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request field: " + err.Error(),
+		})
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return calibrationError(c, err)
+	}
+	defer f.Close()
+	frame, err := vision.DecodeImage(f)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid reference frame: " + err.Error(),
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	result, err := h.CalibrationService.Calibrate(c.Context(), frame, req, username)
+	if err != nil {
+		return calibrationError(c, err)
+	}
+
+	message := "Calibration solved (dry run)"
+	status := fiber.StatusOK
+	if result.Profile != nil {
+		message = "Calibration profile created"
+		status = fiber.StatusCreated
+		log.Printf("[CALIBRATION] Profile %s for site %s calibrated from %d markings by %s (distance RMS %.3fm)",
+			result.Profile.Ref(), result.Profile.SiteCode, len(result.ControlPoints), username, result.DistanceRMSMeters)
+	}
+
+	return c.Status(status).JSON(fiber.Map{
+		"success": true,
+		"message": message,
+		"data":    result,
+		"preview": "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(result.Preview),
+	})
+}
+
+// DeactivateProfile menonaktifkan versi profil (tetap disimpan untuk riwayat capture)
+func (h *CalibrationHandler) DeactivateProfile(c *fiber.Ctx) error {
+	if err := h.CalibrationService.Deactivate(c.Context(), c.Params("id")); err != nil {
+		return calibrationError(c, err)
+	}
+
+	log.Printf("[CALIBRATION] Profile %s deactivated by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Calibration profile deactivated",
+	})
+}
+
+func calibrationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, calibration.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Calibration profile not found",
+		})
+	}
+	if errors.Is(err, calibration.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[CALIBRATION] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,270 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"wim-service/internal/evidence"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type EvidenceHandler struct {
+	EvidenceService *evidence.Service
+}
+
+func NewEvidenceHandler(evidenceService *evidence.Service) *EvidenceHandler {
+	return &EvidenceHandler{
+		EvidenceService: evidenceService,
+	}
+}
+
+// GetCapture returns the evidence objects and stored digests of a capture
+func (h *EvidenceHandler) GetCapture(c *fiber.Ctx) error {
+	captureType, err := evidence.ParseCaptureType(c.Params("type"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	capture, err := h.EvidenceService.GetCapture(c.Context(), captureType, c.Params("id"))
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	h.logAccess(c, captureType, capture.ID, "", evidence.ActionView, "")
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    capture,
+	})
+}
+
+// Verify re-hashes the capture's MinIO objects and compares them with the stored digests
+func (h *EvidenceHandler) Verify(c *fiber.Ctx) error {
+	captureType, err := evidence.ParseCaptureType(c.Params("type"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	result, err := h.EvidenceService.Verify(c.Context(), captureType, c.Params("id"))
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	h.logAccess(c, captureType, result.CaptureID, "", evidence.ActionVerify, result.Result)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// DownloadObject streams one evidence object (xml, full_image, plate_image, image)
+func (h *EvidenceHandler) DownloadObject(c *fiber.Ctx) error {
+	captureType, err := evidence.ParseCaptureType(c.Params("type"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	kind := c.Params("kind")
+	reader, obj, err := h.EvidenceService.OpenObject(c.Context(), captureType, c.Params("id"), kind)
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	info, err := reader.Stat()
+	if err != nil {
+		reader.Close()
+		return evidenceError(c, fmt.Errorf("stat object: %w", err))
+	}
+
+	h.logAccess(c, captureType, c.Params("id"), kind, evidence.ActionDownload, "")
+
+	c.Set(fiber.HeaderContentType, info.ContentType)
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", path.Base(obj.Name)))
+	if obj.SHA256 != "" {
+		c.Set("X-Evidence-SHA256", obj.SHA256)
+	}
+	// fasthttp closes the reader once the body has been sent
+	return c.SendStream(reader, int(info.Size))
+}
+
+// ListCustody returns the chain-of-custody log of a capture
+func (h *EvidenceHandler) ListCustody(c *fiber.Ctx) error {
+	captureType, err := evidence.ParseCaptureType(c.Params("type"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	records, err := h.EvidenceService.ListAccess(c.Context(), captureType, c.Params("id"))
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    records,
+	})
+}
+
+// GenerateBundle builds and stores a signed evidence bundle for a capture
+func (h *EvidenceHandler) GenerateBundle(c *fiber.Ctx) error {
+	captureType, err := evidence.ParseCaptureType(c.Params("type"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	bundle, err := h.EvidenceService.BuildCaptureBundle(c.Context(), captureType, c.Params("id"), username)
+	if err != nil {
+		if errors.Is(err, evidence.ErrIntegrity) {
+			h.logAccess(c, captureType, c.Params("id"), "bundle", evidence.ActionVerify, evidence.ResultMismatch)
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"success": false,
+				"message": err.Error(),
+			})
+		}
+		return evidenceError(c, err)
+	}
+
+	h.logAccess(c, captureType, bundle.SubjectID, "bundle", evidence.ActionGenerate, "")
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Evidence bundle generated",
+		"data":    bundle,
+	})
+}
+
+// DownloadBundle streams the latest bundle generated for a capture
+func (h *EvidenceHandler) DownloadBundle(c *fiber.Ctx) error {
+	captureType, err := evidence.ParseCaptureType(c.Params("type"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	bundle, err := h.EvidenceService.LatestBundle(c.Context(), captureType, c.Params("id"))
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	reader, err := h.EvidenceService.OpenBundle(c.Context(), bundle)
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	h.logAccess(c, captureType, bundle.SubjectID, "bundle", evidence.ActionDownload, "")
+
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", path.Base(bundle.Object)))
+	c.Set("X-Evidence-SHA256", bundle.SHA256)
+	c.Set("X-Evidence-Key-ID", bundle.KeyID)
+	return c.SendStream(reader, int(bundle.SizeBytes))
+}
+
+// VerifyBundle checks an uploaded bundle against the published site keys
+func (h *EvidenceHandler) VerifyBundle(c *fiber.Ctx) error {
+	file, err := c.FormFile("bundle")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "No bundle file provided",
+		})
+	}
+
+	if file.Size > evidence.MaxBundleSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"success": false,
+			"message": fmt.Sprintf("Bundle is larger than %d MB", evidence.MaxBundleSize>>20),
+		})
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return evidenceError(c, fmt.Errorf("open upload: %w", err))
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, evidence.MaxBundleSize+1))
+	if err != nil {
+		return evidenceError(c, fmt.Errorf("read upload: %w", err))
+	}
+
+	result, err := h.EvidenceService.VerifyBundleWithRegistry(c.Context(), data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// ListSigningKeys returns the published site public keys for offline verification
+func (h *EvidenceHandler) ListSigningKeys(c *fiber.Ctx) error {
+	keys, err := h.EvidenceService.ListSigningKeys(c.Context())
+	if err != nil {
+		return evidenceError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    keys,
+	})
+}
+
+func (h *EvidenceHandler) logAccess(c *fiber.Ctx, captureType, id, kind, action, result string) {
+	if err := h.EvidenceService.LogAccess(c.Context(), captureType, id, kind, action, result, accessorFromCtx(c)); err != nil {
+		log.Printf("[EVIDENCE] Failed to record %s access to %s %s: %v", action, captureType, id, err)
+	}
+}
+
+func accessorFromCtx(c *fiber.Ctx) evidence.Accessor {
+	userID, _ := c.Locals("userID").(int)
+	username, _ := c.Locals("username").(string)
+	return evidence.Accessor{
+		UserID:    userID,
+		Username:  username,
+		IPAddress: c.IP(),
+		UserAgent: c.Get(fiber.HeaderUserAgent),
+	}
+}
+
+func evidenceError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, evidence.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Capture or evidence object not found",
+		})
+	}
+
+	log.Printf("[EVIDENCE] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"wim-service/internal/golongan"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type GolonganHandler struct {
+	GolonganService *golongan.Service
+}
+
+func NewGolonganHandler(golonganService *golongan.Service) *GolonganHandler {
+	return &GolonganHandler{
+		GolonganService: golonganService,
+	}
+}
+
+// Counts menampilkan jumlah kendaraan per site dan Golongan (golongan 0 = belum/tidak terpetakan)
+func (h *GolonganHandler) Counts(c *fiber.Ctx) error {
+	filter := golongan.CountFilter{SiteID: c.Query("site_id")}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return badTimeQuery(c, "from")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return badTimeQuery(c, "to")
+	}
+
+	counts, err := h.GolonganService.Counts(c.Context(), filter)
+	if err != nil {
+		return golonganError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    counts,
+	})
+}
+
+func (h *GolonganHandler) ListRules(c *fiber.Ctx) error {
+	rules, err := h.GolonganService.ListRules(c.Context(), c.QueryBool("active", false))
+	if err != nil {
+		return golonganError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+func (h *GolonganHandler) GetRule(c *fiber.Ctx) error {
+	rule, err := h.GolonganService.GetRule(c.Context(), c.Params("id"))
+	if err != nil {
+		return golonganError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+func (h *GolonganHandler) CreateRule(c *fiber.Ctx) error {
+	var req golongan.RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := h.GolonganService.CreateRule(c.Context(), req)
+	if err != nil {
+		return golonganError(c, err)
+	}
+
+	log.Printf("[GOLONGAN] Rule %q for golongan %s created by %v", rule.RuleName, rule.GolonganLabel, c.Locals("username"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule created",
+		"data":    rule,
+	})
+}
+
+func (h *GolonganHandler) UpdateRule(c *fiber.Ctx) error {
+	var req golongan.RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := h.GolonganService.UpdateRule(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return golonganError(c, err)
+	}
+
+	log.Printf("[GOLONGAN] Rule %s updated by %v", rule.ID, c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule updated",
+		"data":    rule,
+	})
+}
+
+func (h *GolonganHandler) DeleteRule(c *fiber.Ctx) error {
+	if err := h.GolonganService.DeleteRule(c.Context(), c.Params("id")); err != nil {
+		return golonganError(c, err)
+	}
+
+	log.Printf("[GOLONGAN] Rule %s deleted by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule deleted",
+	})
+}
+
+func golonganError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, golongan.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Golongan rule or capture not found",
+		})
+	}
+	if errors.Is(err, golongan.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[GOLONGAN] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
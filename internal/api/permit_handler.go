@@ -0,0 +1,138 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"wim-service/internal/permit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PermitHandler struct {
+	PermitService *permit.Service
+}
+
+func NewPermitHandler(permitService *permit.Service) *PermitHandler {
+	return &PermitHandler{
+		PermitService: permitService,
+	}
+}
+
+func (h *PermitHandler) ListPermits(c *fiber.Ctx) error {
+	filter := permit.Filter{
+		PlateNo:    c.Query("plate_no"),
+		SiteID:     c.Query("site_id"),
+		ActiveOnly: c.QueryBool("active", false),
+		Limit:      c.QueryInt("limit", 100),
+		Offset:     c.QueryInt("offset", 0),
+	}
+
+	var err error
+	if filter.At, err = parseTimeQuery(c, "at"); err != nil {
+		return badTimeQuery(c, "at")
+	}
+
+	permits, err := h.PermitService.List(c.Context(), filter)
+	if err != nil {
+		return permitError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    permits,
+	})
+}
+
+func (h *PermitHandler) GetPermit(c *fiber.Ctx) error {
+	p, err := h.PermitService.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return permitError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    p,
+	})
+}
+
+func (h *PermitHandler) CreatePermit(c *fiber.Ctx) error {
+	var req permit.PermitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	p, err := h.PermitService.Create(c.Context(), req, username)
+	if err != nil {
+		return permitError(c, err)
+	}
+
+	log.Printf("[PERMIT] Permit %s for %s created by %s", p.PermitNo, p.PlateNo, username)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Permit created",
+		"data":    p,
+	})
+}
+
+func (h *PermitHandler) UpdatePermit(c *fiber.Ctx) error {
+	var req permit.PermitRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	p, err := h.PermitService.Update(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return permitError(c, err)
+	}
+
+	log.Printf("[PERMIT] Permit %s updated by %v", p.PermitNo, c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Permit updated",
+		"data":    p,
+	})
+}
+
+func (h *PermitHandler) DeletePermit(c *fiber.Ctx) error {
+	if err := h.PermitService.Delete(c.Context(), c.Params("id")); err != nil {
+		return permitError(c, err)
+	}
+
+	log.Printf("[PERMIT] Permit %s deleted by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Permit deleted",
+	})
+}
+
+func permitError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, permit.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Permit not found",
+		})
+	}
+	if errors.Is(err, permit.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[PERMIT] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"log"
+	"strings"
+
+	"wim-service/internal/quality"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type QualityHandler struct {
+	QualityService *quality.Service
+}
+
+func NewQualityHandler(qualityService *quality.Service) *QualityHandler {
+	return &QualityHandler{
+		QualityService: qualityService,
+	}
+}
+
+// ListDeviceStats menampilkan jumlah kegagalan validasi per device, field dan flag
+func (h *QualityHandler) ListDeviceStats(c *fiber.Ctx) error {
+	filter := quality.StatFilter{
+		DeviceType: strings.ToUpper(c.Query("device_type")),
+		SiteID:     c.Query("site_id"),
+		DeviceID:   c.Query("device_id"),
+	}
+
+	stats, err := h.QualityService.ListDeviceStats(c.Context(), filter)
+	if err != nil {
+		log.Printf("[QUALITY] Request failed: %v", err)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    stats,
+	})
+}
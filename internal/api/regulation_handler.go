@@ -0,0 +1,147 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"wim-service/internal/regulation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type RegulationHandler struct {
+	RegulationService *regulation.Service
+}
+
+func NewRegulationHandler(regulationService *regulation.Service) *RegulationHandler {
+	return &RegulationHandler{
+		RegulationService: regulationService,
+	}
+}
+
+func (h *RegulationHandler) ListRules(c *fiber.Ctx) error {
+	filter := regulation.Filter{
+		RuleType:   strings.ToUpper(c.Query("rule_type")),
+		Code:       strings.ToUpper(c.Query("code")),
+		ActiveOnly: c.QueryBool("active", false),
+	}
+
+	var err error
+	if filter.At, err = parseTimeQuery(c, "at"); err != nil {
+		return badTimeQuery(c, "at")
+	}
+
+	rules, err := h.RegulationService.List(c.Context(), filter)
+	if err != nil {
+		return regulationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+// InForce menampilkan aturan yang dipakai untuk capture pada waktu, kelas jalan dan kelas kendaraan tertentu
+func (h *RegulationHandler) InForce(c *fiber.Ctx) error {
+	subj := regulation.Subject{
+		RuleType:       strings.ToUpper(c.Query("rule_type")),
+		RoadClass:      c.QueryInt("road_class", 2),
+		VehicleClassID: c.Query("vehicle_class_id"),
+		At:             time.Now(),
+	}
+	at, err := parseTimeQuery(c, "at")
+	if err != nil {
+		return badTimeQuery(c, "at")
+	}
+	if at != nil {
+		subj.At = *at
+	}
+
+	rule, err := h.RegulationService.InForce(c.Context(), subj)
+	if err != nil {
+		return regulationError(c, err)
+	}
+	if rule == nil {
+		return regulationError(c, regulation.ErrNotFound)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+func (h *RegulationHandler) GetRule(c *fiber.Ctx) error {
+	rule, err := h.RegulationService.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return regulationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+// CreateRule membuat aturan baru, atau versi baru jika code sudah ada
+func (h *RegulationHandler) CreateRule(c *fiber.Ctx) error {
+	var req regulation.RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	rule, err := h.RegulationService.Create(c.Context(), req, username)
+	if err != nil {
+		return regulationError(c, err)
+	}
+
+	log.Printf("[REGULATION] Rule %s created by %s", rule.Ref(), username)
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule created",
+		"data":    rule,
+	})
+}
+
+// DeactivateRule menonaktifkan versi aturan (tetap disimpan untuk riwayat pelanggaran)
+func (h *RegulationHandler) DeactivateRule(c *fiber.Ctx) error {
+	if err := h.RegulationService.Deactivate(c.Context(), c.Params("id")); err != nil {
+		return regulationError(c, err)
+	}
+
+	log.Printf("[REGULATION] Rule %s deactivated by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule deactivated",
+	})
+}
+
+func regulationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, regulation.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Rule not found",
+		})
+	}
+	if errors.Is(err, regulation.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[REGULATION] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
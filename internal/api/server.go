@@ -4,7 +4,19 @@ import (
 	"database/sql"
 	"log"
 	"wim-service/internal/auth"
+	"wim-service/internal/axle"
+	"wim-service/internal/calibration"
+	"wim-service/internal/correlation"
+	"wim-service/internal/evidence"
+	"wim-service/internal/golongan"
 	"wim-service/internal/handler"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/quality"
+	"wim-service/internal/regulation"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
+	"wim-service/internal/watchlist"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/cors"
@@ -13,13 +25,24 @@ import (
 )
 
 type Server struct {
-	App               *fiber.App
-	AuthService       *auth.AuthService
-	AuthHandler       *AuthHandler
-	AttachmentHandler *handler.AttachmentHandler
+	App                *fiber.App
+	AuthService        *auth.AuthService
+	AuthHandler        *AuthHandler
+	AttachmentHandler  *handler.AttachmentHandler
+	WatchlistHandler   *WatchlistHandler
+	EvidenceHandler    *EvidenceHandler
+	AxleHandler        *AxleHandler
+	ClassHandler       *VehicleClassHandler
+	ViolationHandler   *ViolationHandler
+	RegulationHandler  *RegulationHandler
+	PermitHandler      *PermitHandler
+	QualityHandler     *QualityHandler
+	GolonganHandler    *GolonganHandler
+	VehicleHandler     *VehicleHandler
+	CalibrationHandler *CalibrationHandler
 }
 
-func NewServer(db *sql.DB, jwtSecret string, attachmentHandler *handler.AttachmentHandler) *Server {
+func NewServer(db *sql.DB, jwtSecret string, attachmentHandler *handler.AttachmentHandler, evidenceService *evidence.Service, overloadService *overload.Service, classResolver *vehicleclass.Resolver, odolService *odol.Service, correlationService *correlation.Service) *Server {
 	app := fiber.New(fiber.Config{
 		AppName: "WIM Service API",
 	})
@@ -34,12 +57,27 @@ func NewServer(db *sql.DB, jwtSecret string, attachmentHandler *handler.Attachme
 
 	authService := auth.NewAuthService(db, jwtSecret)
 	authHandler := NewAuthHandler(authService)
+	watchlistHandler := NewWatchlistHandler(watchlist.NewService(db))
+	violationService := violation.NewService(db)
+	golonganService := golongan.NewService(db)
+	axleHandler := NewAxleHandler(axle.NewService(db), overloadService, classResolver, odolService, violationService, golonganService)
 
 	server := &Server{
-		App:               app,
-		AuthService:       authService,
-		AuthHandler:       authHandler,
-		AttachmentHandler: attachmentHandler,
+		App:                app,
+		AuthService:        authService,
+		AuthHandler:        authHandler,
+		AttachmentHandler:  attachmentHandler,
+		WatchlistHandler:   watchlistHandler,
+		EvidenceHandler:    NewEvidenceHandler(evidenceService),
+		AxleHandler:        axleHandler,
+		ClassHandler:       NewVehicleClassHandler(classResolver.Classes),
+		ViolationHandler:   NewViolationHandler(violationService),
+		RegulationHandler:  NewRegulationHandler(regulation.NewService(db)),
+		PermitHandler:      NewPermitHandler(violationService.Permits),
+		QualityHandler:     NewQualityHandler(quality.NewService(db)),
+		GolonganHandler:    NewGolonganHandler(golonganService),
+		VehicleHandler:     NewVehicleHandler(correlationService),
+		CalibrationHandler: NewCalibrationHandler(calibration.NewService(db)),
 	}
 
 	server.setupRoutes()
@@ -70,6 +108,122 @@ func (s *Server) setupRoutes() {
 	attachment := api.Group("/attachment")
 	attachment.Use(JWTMiddleware(s.AuthService))
 	attachment.Post("/upload", s.AttachmentHandler.UploadImage)
+
+	// Plate watchlist routes (protected - requires JWT)
+	wl := api.Group("/watchlist")
+	wl.Use(JWTMiddleware(s.AuthService))
+	wl.Get("/", s.WatchlistHandler.ListWatchlists)
+	wl.Post("/", s.WatchlistHandler.CreateWatchlist)
+	wl.Get("/hits", s.WatchlistHandler.ListHits)
+	wl.Put("/entries/:entryId", s.WatchlistHandler.UpdateEntry)
+	wl.Delete("/entries/:entryId", s.WatchlistHandler.DeleteEntry)
+	wl.Get("/:id", s.WatchlistHandler.GetWatchlist)
+	wl.Put("/:id", s.WatchlistHandler.UpdateWatchlist)
+	wl.Delete("/:id", s.WatchlistHandler.DeleteWatchlist)
+	wl.Get("/:id/entries", s.WatchlistHandler.ListEntries)
+	wl.Post("/:id/entries", s.WatchlistHandler.CreateEntry)
+
+	// Evidence integrity routes (protected - every access is recorded)
+	ev := api.Group("/evidence")
+	ev.Use(JWTMiddleware(s.AuthService))
+	ev.Get("/keys", s.EvidenceHandler.ListSigningKeys)
+	ev.Post("/bundles/verify", s.EvidenceHandler.VerifyBundle)
+	ev.Get("/:type/:id", s.EvidenceHandler.GetCapture)
+	ev.Post("/:type/:id/verify", s.EvidenceHandler.Verify)
+	ev.Get("/:type/:id/objects/:kind", s.EvidenceHandler.DownloadObject)
+	ev.Get("/:type/:id/custody", s.EvidenceHandler.ListCustody)
+	ev.Post("/:type/:id/bundle", s.EvidenceHandler.GenerateBundle)
+	ev.Get("/:type/:id/bundle", s.EvidenceHandler.DownloadBundle)
+
+	// Axle capture routes (protected - requires JWT)
+	ax := api.Group("/axle")
+	ax.Use(JWTMiddleware(s.AuthService))
+	ax.Get("/captures", s.AxleHandler.ListCaptures)
+	ax.Get("/captures/:id", s.AxleHandler.GetCapture)
+	ax.Get("/captures/:id/axles", s.AxleHandler.ListAxles)
+	ax.Post("/captures/:id/overload", s.AxleHandler.EvaluateOverload)
+	ax.Post("/captures/:id/dimensions", s.AxleHandler.EvaluateDimensions)
+	ax.Post("/captures/:id/classify", s.AxleHandler.Classify)
+	ax.Put("/captures/:id/class", s.AxleHandler.AssignClass)
+	ax.Post("/captures/:id/golongan", s.AxleHandler.AssignGolongan)
+
+	// Vehicle class & resolution rule routes (protected - requires JWT)
+	vc := api.Group("/vehicle-classes")
+	vc.Use(JWTMiddleware(s.AuthService))
+	vc.Get("/", s.ClassHandler.ListClasses)
+	vc.Get("/rules", s.ClassHandler.ListRules)
+	vc.Post("/rules", s.ClassHandler.CreateRule)
+	vc.Get("/rules/:id", s.ClassHandler.GetRule)
+	vc.Put("/rules/:id", s.ClassHandler.UpdateRule)
+	vc.Delete("/rules/:id", s.ClassHandler.DeleteRule)
+
+	// Toll Golongan mapping & reporting routes (protected - requires JWT)
+	gl := api.Group("/golongan")
+	gl.Use(JWTMiddleware(s.AuthService))
+	gl.Get("/counts", s.GolonganHandler.Counts)
+	gl.Get("/rules", s.GolonganHandler.ListRules)
+	gl.Post("/rules", s.GolonganHandler.CreateRule)
+	gl.Get("/rules/:id", s.GolonganHandler.GetRule)
+	gl.Put("/rules/:id", s.GolonganHandler.UpdateRule)
+	gl.Delete("/rules/:id", s.GolonganHandler.DeleteRule)
+
+	// Correlated vehicle routes (protected - requires JWT)
+	vh := api.Group("/vehicles")
+	vh.Use(JWTMiddleware(s.AuthService))
+	vh.Get("/", s.VehicleHandler.ListVehicles)
+	vh.Get("/late-matches", s.VehicleHandler.ListLateMatches)
+	vh.Get("/late-matches/counts", s.VehicleHandler.CountLateMatches)
+	vh.Post("/link", s.VehicleHandler.Link)
+	vh.Get("/clock-drift", s.VehicleHandler.ListClockDrift)
+	vh.Delete("/clock-drift/:id", s.VehicleHandler.ResetClockDrift)
+	vh.Get("/:id", s.VehicleHandler.GetVehicle)
+	vh.Get("/:id/overrides", s.VehicleHandler.ListOverrides)
+	vh.Post("/:id/unlink", s.VehicleHandler.Unlink)
+	vh.Post("/:id/no-partner", s.VehicleHandler.MarkNoPartner)
+
+	// Violation case routes (protected - every change is audited)
+	vl := api.Group("/violations")
+	vl.Use(JWTMiddleware(s.AuthService))
+	vl.Get("/", s.ViolationHandler.ListViolations)
+	vl.Get("/:id", s.ViolationHandler.GetViolation)
+	vl.Post("/:id/transition", s.ViolationHandler.Transition)
+	vl.Put("/:id/assign", s.ViolationHandler.Assign)
+	vl.Get("/:id/notes", s.ViolationHandler.ListNotes)
+	vl.Post("/:id/notes", s.ViolationHandler.AddNote)
+	vl.Get("/:id/audit", s.ViolationHandler.ListAudit)
+
+	// Regulation rule routes (protected - rule versions are immutable)
+	vr := api.Group("/violation-rules")
+	vr.Use(JWTMiddleware(s.AuthService))
+	vr.Get("/", s.RegulationHandler.ListRules)
+	vr.Get("/in-force", s.RegulationHandler.InForce)
+	vr.Post("/", s.RegulationHandler.CreateRule)
+	vr.Get("/:id", s.RegulationHandler.GetRule)
+	vr.Delete("/:id", s.RegulationHandler.DeactivateRule)
+
+	// Dispensation permit routes (protected - requires JWT)
+	pm := api.Group("/permits")
+	pm.Use(JWTMiddleware(s.AuthService))
+	pm.Get("/", s.PermitHandler.ListPermits)
+	pm.Post("/", s.PermitHandler.CreatePermit)
+	pm.Get("/:id", s.PermitHandler.GetPermit)
+	pm.Put("/:id", s.PermitHandler.UpdatePermit)
+	pm.Delete("/:id", s.PermitHandler.DeletePermit)
+
+	// Data quality routes (protected - requires JWT)
+	dq := api.Group("/quality")
+	dq.Use(JWTMiddleware(s.AuthService))
+	dq.Get("/device-stats", s.QualityHandler.ListDeviceStats)
+
+	// Camera calibration profile routes (protected - profile versions are immutable)
+	cc := api.Group("/calibration-profiles")
+	cc.Use(JWTMiddleware(s.AuthService))
+	cc.Get("/", s.CalibrationHandler.ListProfiles)
+	cc.Get("/in-force", s.CalibrationHandler.InForce)
+	cc.Post("/", s.CalibrationHandler.CreateProfile)
+	cc.Post("/calibrate", s.CalibrationHandler.Calibrate)
+	cc.Get("/:id", s.CalibrationHandler.GetProfile)
+	cc.Delete("/:id", s.CalibrationHandler.DeactivateProfile)
 }
 
 func (s *Server) Start(port string) error {
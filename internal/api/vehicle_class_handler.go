@@ -0,0 +1,136 @@
+package api
+
+import (
+	"errors"
+	"log"
+
+	"wim-service/internal/vehicleclass"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type VehicleClassHandler struct {
+	ClassService *vehicleclass.Service
+}
+
+func NewVehicleClassHandler(classService *vehicleclass.Service) *VehicleClassHandler {
+	return &VehicleClassHandler{
+		ClassService: classService,
+	}
+}
+
+func (h *VehicleClassHandler) ListClasses(c *fiber.Ctx) error {
+	classes, err := h.ClassService.ListActive(c.Context())
+	if err != nil {
+		return classError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    classes,
+	})
+}
+
+func (h *VehicleClassHandler) ListRules(c *fiber.Ctx) error {
+	rules, err := h.ClassService.ListRules(c.Context(), c.QueryBool("active", false))
+	if err != nil {
+		return classError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rules,
+	})
+}
+
+func (h *VehicleClassHandler) GetRule(c *fiber.Ctx) error {
+	rule, err := h.ClassService.GetRule(c.Context(), c.Params("id"))
+	if err != nil {
+		return classError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    rule,
+	})
+}
+
+func (h *VehicleClassHandler) CreateRule(c *fiber.Ctx) error {
+	var req vehicleclass.RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := h.ClassService.CreateRule(c.Context(), req)
+	if err != nil {
+		return classError(c, err)
+	}
+
+	log.Printf("[CLASS] Rule %q for class %s created by %v", rule.RuleName, rule.VehicleClassCode, c.Locals("username"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule created",
+		"data":    rule,
+	})
+}
+
+func (h *VehicleClassHandler) UpdateRule(c *fiber.Ctx) error {
+	var req vehicleclass.RuleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	rule, err := h.ClassService.UpdateRule(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return classError(c, err)
+	}
+
+	log.Printf("[CLASS] Rule %s updated by %v", rule.ID, c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule updated",
+		"data":    rule,
+	})
+}
+
+func (h *VehicleClassHandler) DeleteRule(c *fiber.Ctx) error {
+	if err := h.ClassService.DeleteRule(c.Context(), c.Params("id")); err != nil {
+		return classError(c, err)
+	}
+
+	log.Printf("[CLASS] Rule %s deleted by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Rule deleted",
+	})
+}
+
+func classError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, vehicleclass.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Vehicle class, rule or capture not found",
+		})
+	}
+	if errors.Is(err, vehicleclass.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[CLASS] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
@@ -0,0 +1,262 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+
+	"wim-service/internal/correlation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type VehicleHandler struct {
+	CorrelationService *correlation.Service
+}
+
+func NewVehicleHandler(correlationService *correlation.Service) *VehicleHandler {
+	return &VehicleHandler{
+		CorrelationService: correlationService,
+	}
+}
+
+func (h *VehicleHandler) ListVehicles(c *fiber.Ctx) error {
+	filter := correlation.Filter{
+		SiteID:  c.Query("site_id"),
+		Status:  strings.ToUpper(c.Query("status")),
+		PlateNo: c.Query("plate_no"),
+		Limit:   c.QueryInt("limit", 100),
+		Offset:  c.QueryInt("offset", 0),
+	}
+
+	if v := c.Query("review_required"); v != "" {
+		review, err := strconv.ParseBool(v)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"message": "Invalid review_required",
+			})
+		}
+		filter.Review = &review
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return badTimeQuery(c, "from")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return badTimeQuery(c, "to")
+	}
+
+	vehicles, err := h.CorrelationService.List(c.Context(), filter)
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    vehicles,
+	})
+}
+
+func (h *VehicleHandler) GetVehicle(c *fiber.Ctx) error {
+	vehicle, err := h.CorrelationService.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    vehicle,
+	})
+}
+
+// ListLateMatches returns the pairs merged late by the reconciliation job
+func (h *VehicleHandler) ListLateMatches(c *fiber.Ctx) error {
+	filter := correlation.LateMatchFilter{
+		SiteID: c.Query("site_id"),
+		Limit:  c.QueryInt("limit", 100),
+		Offset: c.QueryInt("offset", 0),
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return badTimeQuery(c, "from")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return badTimeQuery(c, "to")
+	}
+
+	matches, err := h.CorrelationService.ListLateMatches(c.Context(), filter)
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    matches,
+	})
+}
+
+// CountLateMatches returns the number of late recoveries per site and day
+func (h *VehicleHandler) CountLateMatches(c *fiber.Ctx) error {
+	filter := correlation.LateMatchFilter{SiteID: c.Query("site_id")}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return badTimeQuery(c, "from")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return badTimeQuery(c, "to")
+	}
+
+	counts, err := h.CorrelationService.CountLateMatches(c.Context(), filter)
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    counts,
+	})
+}
+
+// ListClockDrift returns the clock offset estimate of every ANPR/axle device pair
+func (h *VehicleHandler) ListClockDrift(c *fiber.Ctx) error {
+	estimates, err := h.CorrelationService.ListDrift(c.Context(), c.Query("site_id"))
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    estimates,
+	})
+}
+
+// ResetClockDrift discards the estimate of a device pair after its clocks were re-synchronized
+func (h *VehicleHandler) ResetClockDrift(c *fiber.Ctx) error {
+	if err := h.CorrelationService.ResetDrift(c.Context(), c.Params("id")); err != nil {
+		return vehicleError(c, err)
+	}
+
+	log.Printf("[VEHICLE] Clock drift estimate %s reset by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Clock drift estimate reset",
+	})
+}
+
+type overrideRequest struct {
+	ANPRID string `json:"anpr_id"`
+	AxleID string `json:"axle_id"`
+	Reason string `json:"reason"`
+}
+
+// Unlink splits a wrongly matched vehicle into its ANPR and axle capture
+func (h *VehicleHandler) Unlink(c *fiber.Ctx) error {
+	var req overrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	result, err := h.CorrelationService.Unlink(c.Context(), c.Params("id"), req.Reason, username)
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	log.Printf("[VEHICLE] Vehicle %s unlinked by %s: %s", c.Params("id"), username, req.Reason)
+	return overrideResponse(c, result)
+}
+
+// Link matches a specific ANPR capture with a specific axle capture
+func (h *VehicleHandler) Link(c *fiber.Ctx) error {
+	var req overrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	result, err := h.CorrelationService.Link(c.Context(), req.ANPRID, req.AxleID, req.Reason, username)
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	log.Printf("[VEHICLE] ANPR %s linked to axle %s by %s: %s", req.ANPRID, req.AxleID, username, req.Reason)
+	return overrideResponse(c, result)
+}
+
+// MarkNoPartner flags an unmatched vehicle as having no partner
+func (h *VehicleHandler) MarkNoPartner(c *fiber.Ctx) error {
+	var req overrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	result, err := h.CorrelationService.MarkNoPartner(c.Context(), c.Params("id"), req.Reason, username)
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	log.Printf("[VEHICLE] Vehicle %s marked no partner by %s: %s", c.Params("id"), username, req.Reason)
+	return overrideResponse(c, result)
+}
+
+// ListOverrides returns the manual override audit trail of a vehicle
+func (h *VehicleHandler) ListOverrides(c *fiber.Ctx) error {
+	overrides, err := h.CorrelationService.ListOverrides(c.Context(), c.Params("id"))
+	if err != nil {
+		return vehicleError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    overrides,
+	})
+}
+
+// overrideResponse returns the changed vehicles and the recomputed downstream
+// results (class, Golongan, overload, over-dimension, violations) of their axle captures
+func overrideResponse(c *fiber.Ctx, result *correlation.OverrideResult) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"vehicles":   result.Vehicles,
+			"recomputed": result.Recomputed,
+		},
+	})
+}
+
+func vehicleError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, correlation.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Vehicle or capture not found",
+		})
+	}
+	if errors.Is(err, correlation.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[VEHICLE] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
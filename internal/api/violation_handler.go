@@ -0,0 +1,205 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"strings"
+
+	"wim-service/internal/violation"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ViolationHandler struct {
+	ViolationService *violation.Service
+}
+
+func NewViolationHandler(violationService *violation.Service) *ViolationHandler {
+	return &ViolationHandler{
+		ViolationService: violationService,
+	}
+}
+
+func (h *ViolationHandler) ListViolations(c *fiber.Ctx) error {
+	filter := violation.Filter{
+		Status:        strings.ToUpper(c.Query("status")),
+		Type:          strings.ToUpper(c.Query("violation_type")),
+		SiteID:        c.Query("site_id"),
+		PlateNo:       c.Query("plate_no"),
+		AssignedTo:    c.Query("assigned_to"),
+		Unassigned:    c.QueryBool("unassigned", false),
+		AxleCaptureID: c.Query("axle_capture_id"),
+		Limit:         c.QueryInt("limit", 100),
+		Offset:        c.QueryInt("offset", 0),
+	}
+
+	var err error
+	if filter.From, err = parseTimeQuery(c, "from"); err != nil {
+		return badTimeQuery(c, "from")
+	}
+	if filter.To, err = parseTimeQuery(c, "to"); err != nil {
+		return badTimeQuery(c, "to")
+	}
+
+	violations, err := h.ViolationService.List(c.Context(), filter)
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    violations,
+	})
+}
+
+func (h *ViolationHandler) GetViolation(c *fiber.Ctx) error {
+	v, err := h.ViolationService.Get(c.Context(), c.Params("id"))
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    v,
+	})
+}
+
+type transitionRequest struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+// Transition memindahkan status kasus (OPEN -> UNDER_REVIEW -> CONFIRMED -> ISSUED -> CLOSED, atau DISMISSED)
+func (h *ViolationHandler) Transition(c *fiber.Ctx) error {
+	var req transitionRequest
+	if err := c.BodyParser(&req); err != nil || req.Status == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "status is required",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	v, err := h.ViolationService.Transition(c.Context(), c.Params("id"), req.Status, req.Reason, username)
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	log.Printf("[VIOLATION] Violation %s moved to %s by %s", v.ID, v.Status, username)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    v,
+	})
+}
+
+type assignRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// Assign menugaskan kasus ke user (master_user); user_id kosong = lepas penugasan
+func (h *ViolationHandler) Assign(c *fiber.Ctx) error {
+	var req assignRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	v, err := h.ViolationService.Assign(c.Context(), c.Params("id"), req.UserID, username)
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	log.Printf("[VIOLATION] Violation %s assigned to %q by %s", v.ID, v.AssignedTo, username)
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    v,
+	})
+}
+
+type noteRequest struct {
+	Note string `json:"note"`
+}
+
+func (h *ViolationHandler) AddNote(c *fiber.Ctx) error {
+	var req noteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	username, _ := c.Locals("username").(string)
+	note, err := h.ViolationService.AddNote(c.Context(), c.Params("id"), req.Note, username)
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Note added",
+		"data":    note,
+	})
+}
+
+func (h *ViolationHandler) ListNotes(c *fiber.Ctx) error {
+	if _, err := h.ViolationService.Get(c.Context(), c.Params("id")); err != nil {
+		return violationError(c, err)
+	}
+	notes, err := h.ViolationService.ListNotes(c.Context(), c.Params("id"))
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    notes,
+	})
+}
+
+func (h *ViolationHandler) ListAudit(c *fiber.Ctx) error {
+	if _, err := h.ViolationService.Get(c.Context(), c.Params("id")); err != nil {
+		return violationError(c, err)
+	}
+	audit, err := h.ViolationService.ListAudit(c.Context(), c.Params("id"))
+	if err != nil {
+		return violationError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    audit,
+	})
+}
+
+func violationError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, violation.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Violation not found",
+		})
+	}
+	if errors.Is(err, violation.ErrInvalid) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+	if errors.Is(err, violation.ErrInvalidTransition) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"message": err.Error(),
+		})
+	}
+
+	log.Printf("[VIOLATION] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
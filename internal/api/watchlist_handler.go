@@ -0,0 +1,213 @@
+package api
+
+import (
+	"errors"
+	"log"
+	"wim-service/internal/watchlist"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type WatchlistHandler struct {
+	WatchlistService *watchlist.Service
+}
+
+func NewWatchlistHandler(watchlistService *watchlist.Service) *WatchlistHandler {
+	return &WatchlistHandler{
+		WatchlistService: watchlistService,
+	}
+}
+
+func (h *WatchlistHandler) ListWatchlists(c *fiber.Ctx) error {
+	lists, err := h.WatchlistService.ListWatchlists(c.Context())
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    lists,
+	})
+}
+
+func (h *WatchlistHandler) GetWatchlist(c *fiber.Ctx) error {
+	list, err := h.WatchlistService.GetWatchlist(c.Context(), c.Params("id"))
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    list,
+	})
+}
+
+func (h *WatchlistHandler) CreateWatchlist(c *fiber.Ctx) error {
+	var req watchlist.WatchlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.Code == "" || req.ListName == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Code and list_name are required",
+		})
+	}
+
+	list, err := h.WatchlistService.CreateWatchlist(c.Context(), req)
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	log.Printf("[WATCHLIST] Watchlist %s created by %v", list.Code, c.Locals("username"))
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Watchlist created",
+		"data":    list,
+	})
+}
+
+func (h *WatchlistHandler) UpdateWatchlist(c *fiber.Ctx) error {
+	var req watchlist.WatchlistRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	list, err := h.WatchlistService.UpdateWatchlist(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Watchlist updated",
+		"data":    list,
+	})
+}
+
+func (h *WatchlistHandler) DeleteWatchlist(c *fiber.Ctx) error {
+	if err := h.WatchlistService.DeleteWatchlist(c.Context(), c.Params("id")); err != nil {
+		return watchlistError(c, err)
+	}
+
+	log.Printf("[WATCHLIST] Watchlist %s deleted by %v", c.Params("id"), c.Locals("username"))
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Watchlist deleted",
+	})
+}
+
+func (h *WatchlistHandler) ListEntries(c *fiber.Ctx) error {
+	entries, err := h.WatchlistService.ListEntries(c.Context(), c.Params("id"))
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+func (h *WatchlistHandler) CreateEntry(c *fiber.Ctx) error {
+	var req watchlist.EntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	if req.PlateNo == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Plate_no is required",
+		})
+	}
+
+	entry, err := h.WatchlistService.CreateEntry(c.Context(), c.Params("id"), req)
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"message": "Entry created",
+		"data":    entry,
+	})
+}
+
+func (h *WatchlistHandler) UpdateEntry(c *fiber.Ctx) error {
+	var req watchlist.EntryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"message": "Invalid request body",
+		})
+	}
+
+	entry, err := h.WatchlistService.UpdateEntry(c.Context(), c.Params("entryId"), req)
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Entry updated",
+		"data":    entry,
+	})
+}
+
+func (h *WatchlistHandler) DeleteEntry(c *fiber.Ctx) error {
+	if err := h.WatchlistService.DeleteEntry(c.Context(), c.Params("entryId")); err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"message": "Entry deleted",
+	})
+}
+
+func (h *WatchlistHandler) ListHits(c *fiber.Ctx) error {
+	filter := watchlist.HitFilter{
+		WatchlistID: c.Query("watchlist_id"),
+		PlateNo:     c.Query("plate_no"),
+		AlertStatus: c.Query("alert_status"),
+		Limit:       c.QueryInt("limit", 100),
+	}
+
+	hits, err := h.WatchlistService.ListHits(c.Context(), filter)
+	if err != nil {
+		return watchlistError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"success": true,
+		"data":    hits,
+	})
+}
+
+func watchlistError(c *fiber.Ctx, err error) error {
+	if errors.Is(err, watchlist.ErrNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"success": false,
+			"message": "Watchlist or entry not found",
+		})
+	}
+
+	log.Printf("[WATCHLIST] Request failed: %v", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success": false,
+		"message": err.Error(),
+	})
+}
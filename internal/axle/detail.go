@@ -0,0 +1,103 @@
+package axle
+
+import "strings"
+
+// DefaultGroupSpacingMM is the largest axle spacing still treated as one group
+// (tandem/tridem) when the sensor does not report groups itself
+const DefaultGroupSpacingMM = 2000
+
+// TyreFromWheels derives the tyre configuration from the wheel count of one axle
+func TyreFromWheels(wheels int) string {
+	switch {
+	case wheels >= 4:
+		return TyreDual
+	case wheels > 0:
+		return TyreSingle
+	default:
+		return ""
+	}
+}
+
+// NormalizeTyre maps sensor tyre values ("single", "dual", "S", "D", "twin") to
+// TyreSingle/TyreDual; unknown values return ""
+func NormalizeTyre(v string) string {
+	switch strings.ToUpper(strings.TrimSpace(v)) {
+	case "SINGLE", "S", "1":
+		return TyreSingle
+	case "DUAL", "D", "TWIN", "2":
+		return TyreDual
+	default:
+		return ""
+	}
+}
+
+// AssignGroups numbers axles 1..n and fills in missing tyre configurations and
+// axle groups. Axles that already carry a group number from the sensor keep it;
+// otherwise consecutive axles closer than maxSpacingMM form one group.
+func AssignGroups(details []Detail, maxSpacingMM int) {
+	if maxSpacingMM <= 0 {
+		maxSpacingMM = DefaultGroupSpacingMM
+	}
+
+	fromSensor := len(details) > 0
+	for i := range details {
+		details[i].AxleNo = i + 1
+		if details[i].TyreConfig == "" {
+			details[i].TyreConfig = TyreFromWheels(details[i].WheelCount)
+		}
+		if details[i].GroupNo <= 0 {
+			fromSensor = false
+		}
+	}
+
+	if !fromSensor {
+		group := 0
+		for i := range details {
+			if i == 0 || details[i].SpacingMM <= 0 || details[i].SpacingMM > maxSpacingMM {
+				group++
+			}
+			details[i].GroupNo = group
+		}
+	}
+
+	for _, g := range Groups(details) {
+		for i := range details {
+			if details[i].GroupNo == g.GroupNo {
+				details[i].GroupType = g.GroupType
+			}
+		}
+	}
+}
+
+// Groups summarizes axle groups (count and combined load) in axle order
+func Groups(details []Detail) []Group {
+	var groups []Group
+	index := make(map[int]int)
+	for _, d := range details {
+		i, ok := index[d.GroupNo]
+		if !ok {
+			i = len(groups)
+			index[d.GroupNo] = i
+			groups = append(groups, Group{GroupNo: d.GroupNo})
+		}
+		groups[i].AxleCount++
+		groups[i].WeightKg += d.WeightKg
+	}
+	for i := range groups {
+		groups[i].GroupType = groupType(groups[i].AxleCount)
+	}
+	return groups
+}
+
+func groupType(axles int) string {
+	switch axles {
+	case 1:
+		return GroupSingle
+	case 2:
+		return GroupTandem
+	case 3:
+		return GroupTridem
+	default:
+		return GroupMulti
+	}
+}
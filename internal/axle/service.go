@@ -0,0 +1,192 @@
+package axle
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when an axle capture does not exist
+var ErrNotFound = errors.New("axle: not found")
+
+// Service handles axle capture queries
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new axle service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+const captureColumns = `
+	c.id, c.external_id, c.vehicle_index, c.vehicle_count, COALESCE(c.site_id::text, ''),
+	COALESCE(c.plate_no, ''), c.captured_at, COALESCE(c.camera_id, ''),
+	COALESCE(c.length_mm, 0), COALESCE(c.total_wheels, 0), COALESCE(c.total_axles, 0),
+	COALESCE(c.vehicle_category, ''), COALESCE(c.vehicle_body_type, ''), COALESCE(c.gross_weight_kg, 0),
+	COALESCE(c.vehicle_class_id::text, ''), COALESCE(vc.code, ''), COALESCE(c.road_class, 0),
+	COALESCE(c.permitted_weight_kg, 0), COALESCE(c.overload_kg, 0), COALESCE(c.overload_pct, 0),
+	COALESCE(c.overload_status, ''), c.overload_evaluated_at,
+	COALESCE(c.class_rule_id::text, ''), COALESCE(c.class_confidence, 0), COALESCE(c.class_status, ''),
+	c.class_review_required, COALESCE(c.class_resolved_by, ''),
+	COALESCE(c.toll_golongan, 0), COALESCE(c.golongan_rule_id::text, ''), c.golongan_assigned_at,
+	c.over_dimension, c.dimension_evaluated_at, c.created_date`
+
+const captureFrom = `
+	FROM public.transact_axle_capture c
+	LEFT JOIN public.master_vehicle_class vc ON vc.id = c.vehicle_class_id`
+
+func scanCapture(row interface{ Scan(...any) error }) (*Capture, error) {
+	var c Capture
+	var capturedAt, evaluatedAt, dimensionAt, golonganAt sql.NullTime
+	var overDimension sql.NullBool
+	var issues []byte
+	err := row.Scan(&c.ID, &c.ExternalID, &c.VehicleIndex, &c.VehicleCount, &c.SiteID,
+		&c.PlateNo, &capturedAt, &c.CameraID,
+		&c.LengthMM, &c.TotalWheels, &c.TotalAxles,
+		&c.VehicleCategory, &c.VehicleBodyType, &c.GrossWeightKg,
+		&c.VehicleClassID, &c.VehicleClassCode, &c.RoadClass,
+		&c.PermittedWeightKg, &c.OverloadKg, &c.OverloadPct,
+		&c.OverloadStatus, &evaluatedAt,
+		&c.ClassRuleID, &c.ClassConfidence, &c.ClassStatus,
+		&c.ClassReviewRequired, &c.ClassResolvedBy,
+		&c.TollGolongan, &c.GolonganRuleID, &golonganAt,
+		&overDimension, &dimensionAt,
+		&c.QualityStatus, &issues, &c.CreatedDate)
+	if err != nil {
+		return nil, err
+	}
+	if capturedAt.Valid {
+		c.CapturedAt = &capturedAt.Time
+	}
+	if evaluatedAt.Valid {
+		c.OverloadEvaluated = &evaluatedAt.Time
+	}
+	if golonganAt.Valid {
+		c.GolonganAssignedAt = &golonganAt.Time
+	}
+	if overDimension.Valid {
+		c.OverDimension = &overDimension.Bool
+	}
+	if dimensionAt.Valid {
+		c.DimensionEvaluated = &dimensionAt.Time
+	}
+	c.QualityIssues = json.RawMessage(issues)
+	return &c, nil
+}
+
+// ListCaptures returns axle captures, newest first
+func (s *Service) ListCaptures(ctx context.Context, filter CaptureFilter) ([]Capture, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := `SELECT` + captureColumns + captureFrom + `
+		WHERE c.is_deleted = false
+		  AND ($1 = '' OR c.site_id::text = $1)
+		  AND ($2 = '' OR c.plate_no ILIKE '%' || $2 || '%')
+		  AND ($3::timestamptz IS NULL OR c.captured_at >= $3)
+		  AND ($4::timestamptz IS NULL OR c.captured_at < $4)
+		  AND ($5 = '' OR c.overload_status = $5)
+		  AND ($6::numeric IS NULL OR c.overload_pct >= $6)
+		  AND ($7 = '' OR c.class_status = $7)
+		  AND ($8::bool IS NULL OR c.class_review_required = $8)
+		  AND ($9::bool IS NULL OR c.over_dimension = $9)
+		  AND ($10 = '' OR c.quality_status = $10)
+		  AND ($11 = 0 OR c.toll_golongan = $11)
+		ORDER BY c.captured_at DESC NULLS LAST, c.vehicle_index
+		LIMIT $12 OFFSET $13`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.SiteID, filter.PlateNo,
+		nullTime(filter.From), nullTime(filter.To), filter.OverloadStatus, nullFloat(filter.MinOverloadPct),
+		filter.ClassStatus, nullBool(filter.ReviewRequired), nullBool(filter.OverDimension),
+		filter.QualityStatus, filter.TollGolongan, filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query captures: %w", err)
+	}
+	defer rows.Close()
+
+	captures := []Capture{}
+	for rows.Next() {
+		c, err := scanCapture(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan capture: %w", err)
+		}
+		captures = append(captures, *c)
+	}
+	return captures, rows.Err()
+}
+
+// GetCapture returns one axle capture with its per-axle detail and axle groups
+func (s *Service) GetCapture(ctx context.Context, id string) (*Capture, error) {
+	query := `SELECT` + captureColumns + captureFrom + `
+		WHERE c.id::text = $1 AND c.is_deleted = false`
+
+	c, err := scanCapture(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get capture: %w", err)
+	}
+
+	if c.Axles, err = s.ListDetails(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	c.Groups = Groups(c.Axles)
+	return c, nil
+}
+
+// ListDetails returns the per-axle detail of a capture, front to rear
+func (s *Service) ListDetails(ctx context.Context, captureID string) ([]Detail, error) {
+	query := `
+		SELECT axle_no, COALESCE(weight_kg, 0), COALESCE(spacing_mm, 0), COALESCE(wheel_count, 0),
+		       COALESCE(tyre_config, ''), group_no, group_type
+		FROM public.transact_axle_detail
+		WHERE axle_capture_id::text = $1
+		ORDER BY axle_no`
+
+	rows, err := s.DB.QueryContext(ctx, query, captureID)
+	if err != nil {
+		return nil, fmt.Errorf("query axle detail: %w", err)
+	}
+	defer rows.Close()
+
+	details := []Detail{}
+	for rows.Next() {
+		var d Detail
+		if err := rows.Scan(&d.AxleNo, &d.WeightKg, &d.SpacingMM, &d.WheelCount,
+			&d.TyreConfig, &d.GroupNo, &d.GroupType); err != nil {
+			return nil, fmt.Errorf("scan axle detail: %w", err)
+		}
+		details = append(details, d)
+	}
+	return details, rows.Err()
+}
+
+// SaveDetails replaces the per-axle detail of a capture inside the ingestion transaction
+func SaveDetails(ctx context.Context, tx *sql.Tx, captureID string, details []Detail) error {
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM public.transact_axle_detail WHERE axle_capture_id = $1`, captureID); err != nil {
+		return fmt.Errorf("delete axle detail: %w", err)
+	}
+
+	query := `
+		INSERT INTO public.transact_axle_detail
+			(axle_capture_id, axle_no, weight_kg, spacing_mm, wheel_count, tyre_config, group_no, group_type)
+		VALUES ($1, $2, NULLIF($3, 0), NULLIF($4, 0), NULLIF($5, 0), NULLIF($6, ''), $7, $8)`
+
+	for _, d := range details {
+		_, err := tx.ExecContext(ctx, query, captureID, d.AxleNo, d.WeightKg, d.SpacingMM,
+			d.WheelCount, d.TyreConfig, d.GroupNo, d.GroupType)
+		if err != nil {
+			return fmt.Errorf("insert axle %d: %w", d.AxleNo, err)
+		}
+	}
+	return nil
+}
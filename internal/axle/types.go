@@ -0,0 +1,112 @@
+package axle
+
+import (
+	"encoding/json"
+	"time"
+
+	"wim-service/internal/odol"
+)
+
+// Tyre configuration per axle (transact_axle_detail.tyre_config)
+const (
+	TyreSingle = "SINGLE"
+	TyreDual   = "DUAL"
+)
+
+// Axle group types (transact_axle_detail.group_type)
+const (
+	GroupSingle = "SINGLE"
+	GroupTandem = "TANDEM"
+	GroupTridem = "TRIDEM"
+	GroupMulti  = "MULTI" // Four or more closely spaced axles
+)
+
+// Detail is one measured axle of an axle capture. Zero numeric values mean the
+// sensor did not report the field.
+type Detail struct {
+	AxleNo     int    `json:"axle_no"`     // 1-based, front to rear
+	WeightKg   int    `json:"weight_kg"`   // Axle load
+	SpacingMM  int    `json:"spacing_mm"`  // Distance to the previous axle (0 for the first axle)
+	WheelCount int    `json:"wheel_count"` // Wheels on this axle
+	TyreConfig string `json:"tyre_config"` // SINGLE / DUAL
+	GroupNo    int    `json:"group_no"`    // 1-based axle group
+	GroupType  string `json:"group_type"`  // SINGLE / TANDEM / TRIDEM / MULTI
+}
+
+// Group is a set of consecutive axles treated as one load unit
+type Group struct {
+	GroupNo   int    `json:"group_no"`
+	GroupType string `json:"group_type"`
+	AxleCount int    `json:"axle_count"`
+	WeightKg  int    `json:"weight_kg"`
+}
+
+// Capture is an axle capture row with its per-axle detail
+type Capture struct {
+	ID              string     `json:"id"`
+	ExternalID      string     `json:"external_id"`
+	VehicleIndex    int        `json:"vehicle_index"`
+	VehicleCount    int        `json:"vehicle_count"`
+	SiteID          string     `json:"site_id"`
+	PlateNo         string     `json:"plate_no"`
+	CapturedAt      *time.Time `json:"captured_at"`
+	CameraID        string     `json:"camera_id"`
+	LengthMM        int        `json:"length_mm"`
+	TotalWheels     int        `json:"total_wheels"`
+	TotalAxles      int        `json:"total_axles"`
+	VehicleCategory string     `json:"vehicle_category"`
+	VehicleBodyType string     `json:"vehicle_body_type"`
+	GrossWeightKg   int        `json:"gross_weight_kg"`
+
+	// Overload evaluation (see package overload)
+	VehicleClassID    string     `json:"vehicle_class_id"`
+	VehicleClassCode  string     `json:"vehicle_class_code"`
+	RoadClass         int        `json:"road_class"`
+	PermittedWeightKg float64    `json:"permitted_weight_kg"`
+	OverloadKg        float64    `json:"overload_kg"`
+	OverloadPct       float64    `json:"overload_pct"`
+	OverloadStatus    string     `json:"overload_status"`
+	OverloadEvaluated *time.Time `json:"overload_evaluated_at"`
+
+	// Vehicle class resolution (see package vehicleclass)
+	ClassRuleID         string  `json:"class_rule_id"`
+	ClassConfidence     float64 `json:"class_confidence"`
+	ClassStatus         string  `json:"class_status"`
+	ClassReviewRequired bool    `json:"class_review_required"`
+	ClassResolvedBy     string  `json:"class_resolved_by"`
+
+	// Toll Golongan (see package golongan); 0 when unassigned
+	TollGolongan       int        `json:"toll_golongan"`
+	GolonganRuleID     string     `json:"golongan_rule_id"`
+	GolonganAssignedAt *time.Time `json:"golongan_assigned_at"`
+
+	// Over-dimension evaluation (see package odol); nil when not evaluated
+	OverDimension      *bool      `json:"over_dimension"`
+	DimensionEvaluated *time.Time `json:"dimension_evaluated_at"`
+
+	// Field validation (see package quality); rejected values are stored as NULL
+	QualityStatus string          `json:"quality_status"`
+	QualityIssues json.RawMessage `json:"quality_issues"`
+
+	CreatedDate     time.Time    `json:"created_date"`
+	Axles           []Detail     `json:"axles,omitempty"`
+	Groups          []Group      `json:"groups,omitempty"`
+	DimensionChecks []odol.Check `json:"dimension_checks,omitempty"`
+}
+
+// CaptureFilter narrows ListCaptures
+type CaptureFilter struct {
+	SiteID         string
+	PlateNo        string
+	OverloadStatus string
+	MinOverloadPct *float64
+	ClassStatus    string
+	ReviewRequired *bool
+	OverDimension  *bool
+	QualityStatus  string
+	TollGolongan   int
+	From           *time.Time
+	To             *time.Time
+	Limit          int
+	Offset         int
+}
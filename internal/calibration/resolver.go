@@ -0,0 +1,98 @@
+package calibration
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"wim-service/internal/vision"
+)
+
+// DefaultRefresh is how long the watcher uses its loaded profiles before reloading
+const DefaultRefresh = time.Minute
+
+// Resolver picks the calibration of each capture from the profiles of one site.
+// Profiles are cached and reloaded every Refresh, so a new profile is picked up
+// without restarting the watcher; when a reload fails the cached profiles stay in use.
+type Resolver struct {
+	Service *Service
+	SiteID  string
+	Refresh time.Duration
+
+	mu           sync.Mutex
+	profiles     []Profile
+	loadedAt     time.Time
+	calibrations map[string]*vision.CameraCalibration // By profile ID
+}
+
+// NewResolver creates a profile resolver for a site
+func NewResolver(service *Service, siteID string, refresh time.Duration) *Resolver {
+	if refresh <= 0 {
+		refresh = DefaultRefresh
+	}
+	return &Resolver{
+		Service:      service,
+		SiteID:       siteID,
+		Refresh:      refresh,
+		calibrations: make(map[string]*vision.CameraCalibration),
+	}
+}
+
+// CalibrationFor implements vision.CalibrationSource
+func (r *Resolver) CalibrationFor(camera string, at time.Time) (*vision.CameraCalibration, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	p := Select(r.profiles, camera, at)
+	if p == nil {
+		return nil, nil
+	}
+	if cal, ok := r.calibrations[p.ID]; ok {
+		return cal, nil
+	}
+	cal, err := p.Calibration()
+	if err != nil {
+		return nil, fmt.Errorf("calibration profile %s: %w", p.Ref(), err)
+	}
+	r.calibrations[p.ID] = cal
+	return cal, nil
+}
+
+// Load reloads the profiles now, e.g. to report them at start-up
+func (r *Resolver) Load(ctx context.Context) ([]Profile, error) {
+	profiles, err := r.Service.List(ctx, Filter{SiteID: r.SiteID, ActiveOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	r.profiles = profiles
+	r.loadedAt = time.Now()
+	r.mu.Unlock()
+	return profiles, nil
+}
+
+// load reloads stale profiles; the caller holds mu
+func (r *Resolver) load() error {
+	if !r.loadedAt.IsZero() && time.Since(r.loadedAt) < r.Refresh {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	profiles, err := r.Service.List(ctx, Filter{SiteID: r.SiteID, ActiveOnly: true})
+	if err != nil {
+		if r.loadedAt.IsZero() {
+			return fmt.Errorf("load calibration profiles: %w", err)
+		}
+		log.Printf("[DIMENSION] Warning: Failed to reload calibration profiles, keeping %d cached: %v", len(r.profiles), err)
+		r.loadedAt = time.Now()
+		return nil
+	}
+	r.profiles = profiles
+	r.loadedAt = time.Now()
+	return nil
+}
@@ -0,0 +1,19 @@
+package calibration
+
+import (
+	"time"
+
+	"wim-service/internal/versioned"
+)
+
+// Select returns the profile used for a capture of camera at t: a profile of the
+// camera itself before the site default (see versioned.Select for ties). nil when
+// none is in force.
+func Select(profiles []Profile, camera string, at time.Time) *Profile {
+	return versioned.Select(profiles, at, func(p *Profile) (versioned.Validity, int, bool) {
+		if p.CameraID == "" {
+			return p.Validity, 0, true
+		}
+		return p.Validity, 1, p.CameraID == camera
+	})
+}
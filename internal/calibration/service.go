@@ -0,0 +1,263 @@
+package calibration
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wim-service/internal/versioned"
+	"wim-service/internal/vision"
+)
+
+var (
+	// ErrNotFound is returned when the profile does not exist
+	ErrNotFound = errors.New("calibration: not found")
+	// ErrInvalid is returned when a profile request fails validation
+	ErrInvalid = errors.New("calibration: invalid request")
+)
+
+// Service stores versioned camera calibration profiles (see package versioned). A
+// recalibration is a new version for the same site and camera.
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new calibration profile service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+const profileColumns = `
+	p.id, p.site_id::text, COALESCE(ms.code, ''), p.camera_id, p."version", COALESCE(p.description, ''),
+	p.image_width, p.image_height, p.focal_length_px, p.camera_height_m, p.tilt_angle_deg,
+	COALESCE(p.ref_pixel_length, 0), COALESCE(p.ref_real_length_m, 0), COALESCE(p.ref_distance_m, 0),
+	p.control_points, p.residual,
+	p.effective_from, p.effective_to, COALESCE(p.is_active, true), COALESCE(p.created_by, ''), p.created_date`
+
+const profileFrom = `
+	FROM public.master_camera_calibration p
+	LEFT JOIN public.master_site ms ON ms.id = p.site_id`
+
+func scanProfile(row interface{ Scan(...any) error }) (*Profile, error) {
+	var p Profile
+	var effectiveTo sql.NullTime
+	var points, residual []byte
+	err := row.Scan(&p.ID, &p.SiteID, &p.SiteCode, &p.CameraID, &p.Version, &p.Description,
+		&p.ImageWidth, &p.ImageHeight, &p.FocalLengthPixels, &p.CameraHeightMeters, &p.TiltAngleDegrees,
+		&p.ReferencePixelLength, &p.ReferenceRealLength, &p.ReferenceDistanceM,
+		&points, &residual,
+		&p.EffectiveFrom, &effectiveTo, &p.IsActive, &p.CreatedBy, &p.CreatedDate)
+	if err != nil {
+		return nil, err
+	}
+	if effectiveTo.Valid {
+		p.EffectiveTo = &effectiveTo.Time
+	}
+	if len(points) > 0 {
+		if err := json.Unmarshal(points, &p.ControlPoints); err != nil {
+			return nil, fmt.Errorf("profile %s control points: %w", p.ID, err)
+		}
+	}
+	if len(residual) > 0 {
+		p.Residual = &vision.CalibrationResidual{}
+		if err := json.Unmarshal(residual, p.Residual); err != nil {
+			return nil, fmt.Errorf("profile %s residual: %w", p.ID, err)
+		}
+	}
+	return &p, nil
+}
+
+// List returns profile versions, newest first within each camera
+func (s *Service) List(ctx context.Context, filter Filter) ([]Profile, error) {
+	query := `SELECT` + profileColumns + profileFrom + `
+		WHERE ($1 = '' OR p.site_id::text = $1)
+		  AND (NOT $2 OR p.camera_id = $3)
+		  AND (NOT $4 OR COALESCE(p.is_active, true))
+		  AND ($5::timestamptz IS NULL OR (p.effective_from <= $5 AND (p.effective_to IS NULL OR p.effective_to > $5)))
+		ORDER BY ms.code, p.camera_id, p."version" DESC`
+
+	var camera string
+	if filter.CameraID != nil {
+		camera = *filter.CameraID
+	}
+	var at sql.NullTime
+	if filter.At != nil {
+		at = sql.NullTime{Time: *filter.At, Valid: true}
+	}
+	rows, err := s.DB.QueryContext(ctx, query, filter.SiteID, filter.CameraID != nil, camera, filter.ActiveOnly, at)
+	if err != nil {
+		return nil, fmt.Errorf("query profiles: %w", err)
+	}
+	defer rows.Close()
+
+	profiles := []Profile{}
+	for rows.Next() {
+		p, err := scanProfile(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan profile: %w", err)
+		}
+		profiles = append(profiles, *p)
+	}
+	return profiles, rows.Err()
+}
+
+// Get returns one profile version (also when deactivated)
+func (s *Service) Get(ctx context.Context, id string) (*Profile, error) {
+	query := `SELECT` + profileColumns + profileFrom + `
+		WHERE p.id::text = $1`
+
+	p, err := scanProfile(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get profile: %w", err)
+	}
+	return p, nil
+}
+
+// InForce returns the profile used for a capture of camera at a site at t, or nil
+// when the config calibration applies
+func (s *Service) InForce(ctx context.Context, siteID, camera string, at time.Time) (*Profile, error) {
+	profiles, err := s.List(ctx, Filter{SiteID: siteID, ActiveOnly: true, At: &at})
+	if err != nil {
+		return nil, err
+	}
+	return Select(profiles, camera, at), nil
+}
+
+// Solve validates a profile request and fills in what the calibration derives
+// from it: with control points the recovered focal length, height and tilt, and
+// the residual error
+func Solve(req *ProfileRequest) (*vision.CalibrationResidual, error) {
+	if req.ImageWidth <= 0 || req.ImageHeight <= 0 {
+		return nil, fmt.Errorf("%w: image_width and image_height are required", ErrInvalid)
+	}
+	if req.EffectiveFrom != nil && req.EffectiveTo != nil && !req.EffectiveTo.After(*req.EffectiveFrom) {
+		return nil, fmt.Errorf("%w: effective_to must be after effective_from", ErrInvalid)
+	}
+
+	cal := vision.NewCameraCalibration()
+	cal.LoadFromConfig(
+		req.FocalLengthPixels,
+		req.ImageWidth,
+		req.ImageHeight,
+		req.CameraHeightMeters,
+		req.TiltAngleDegrees,
+		req.ReferencePixelLength,
+		req.ReferenceRealLength,
+		req.ReferenceDistanceM,
+	)
+	var residual *vision.CalibrationResidual
+	if len(req.ControlPoints) > 0 {
+		if err := cal.SetControlPoints(req.ControlPoints); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		residual = cal.Ground.Residual
+		req.FocalLengthPixels = cal.FocalLengthPixels
+		req.CameraHeightMeters = cal.CameraHeightMeters
+		req.TiltAngleDegrees = cal.TiltAngleDegrees
+	}
+	if err := cal.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+	return residual, nil
+}
+
+// Create stores a new profile version. The version is one above the latest
+// version of the same site and camera.
+func (s *Service) Create(ctx context.Context, req ProfileRequest, username string) (*Profile, error) {
+	req.SiteID = strings.TrimSpace(req.SiteID)
+	req.CameraID = strings.TrimSpace(req.CameraID)
+	if req.SiteID == "" {
+		return nil, fmt.Errorf("%w: site_id is required", ErrInvalid)
+	}
+	residual, err := Solve(&req)
+	if err != nil {
+		return nil, err
+	}
+	from, err := versioned.Start(req.EffectiveFrom, req.EffectiveTo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	var points, residualJSON []byte
+	if len(req.ControlPoints) > 0 {
+		if points, err = json.Marshal(req.ControlPoints); err != nil {
+			return nil, fmt.Errorf("marshal control points: %w", err)
+		}
+		if residualJSON, err = json.Marshal(residual); err != nil {
+			return nil, fmt.Errorf("marshal residual: %w", err)
+		}
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM public.master_site WHERE id::text = $1)`, req.SiteID).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("check site: %w", err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: site not found", ErrInvalid)
+	}
+
+	version, err := versioned.NextVersion(ctx, tx, "camera_calibration:"+req.SiteID+":"+req.CameraID, `
+		SELECT COALESCE(MAX("version"), 0) FROM public.master_camera_calibration
+		WHERE site_id::text = $1 AND camera_id = $2`, req.SiteID, req.CameraID)
+	if err != nil {
+		return nil, err
+	}
+
+	var effectiveTo sql.NullTime
+	if req.EffectiveTo != nil {
+		effectiveTo = sql.NullTime{Time: *req.EffectiveTo, Valid: true}
+	}
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO public.master_camera_calibration
+			(site_id, camera_id, "version", description, image_width, image_height,
+			 focal_length_px, camera_height_m, tilt_angle_deg,
+			 ref_pixel_length, ref_real_length_m, ref_distance_m, control_points, residual,
+			 effective_from, effective_to, created_by)
+		VALUES ($1::uuid, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $9,
+		        NULLIF($10, 0), NULLIF($11::numeric, 0), NULLIF($12::numeric, 0), $13::jsonb, $14::jsonb,
+		        $15, $16, NULLIF($17, ''))
+		RETURNING id`,
+		req.SiteID, req.CameraID, version, strings.TrimSpace(req.Description), req.ImageWidth, req.ImageHeight,
+		req.FocalLengthPixels, req.CameraHeightMeters, req.TiltAngleDegrees,
+		req.ReferencePixelLength, req.ReferenceRealLength, req.ReferenceDistanceM, nullJSON(points), nullJSON(residualJSON),
+		from, effectiveTo, username).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert profile: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Deactivate takes a profile version out of use
+func (s *Service) Deactivate(ctx context.Context, id string) error {
+	found, err := versioned.Deactivate(ctx, s.DB, "public.master_camera_calibration", id)
+	if err != nil {
+		return fmt.Errorf("deactivate profile: %w", err)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func nullJSON(b []byte) sql.NullString {
+	return sql.NullString{String: string(b), Valid: len(b) > 0}
+}
@@ -0,0 +1,128 @@
+package calibration
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"math"
+	"strconv"
+
+	"wim-service/internal/vision"
+)
+
+// Distance errors above this share of the measured length are reported
+const maxDistanceErrorPct = 5.0
+
+// CalibrateRequest is a calibration survey of one camera: road markings picked in a
+// reference frame with tape-measured distances between them, or control points
+// whose road coordinates are already known. The image size comes from the frame.
+type CalibrateRequest struct {
+	ProfileRequest
+	Markings  []vision.Marking          `json:"markings"`
+	Distances []vision.MeasuredDistance `json:"distances"`
+	DryRun    bool                      `json:"dry_run"` // Only solve and preview, do not save a profile
+}
+
+// CalibrateResult is the solved calibration with its error estimates
+type CalibrateResult struct {
+	ControlPoints     []vision.ControlPoint       `json:"control_points"` // Markings placed on the road
+	Residual          *vision.CalibrationResidual `json:"residual"`
+	Pose              *vision.CameraPose          `json:"pose"` // nil when it cannot be recovered
+	Distances         []vision.DistanceError      `json:"distances"`
+	DistanceRMSMeters float64                     `json:"distance_rms_m"`
+	DistanceMaxPct    float64                     `json:"distance_max_pct"`
+	Warnings          []string                    `json:"warnings"`
+	Profile           *Profile                    `json:"profile"` // nil for a dry run
+	Preview           []byte                      `json:"-"`       // Annotated frame, JPEG
+}
+
+// Calibrate solves the calibration of a camera from a reference frame and the
+// survey, draws the preview and, unless req.DryRun, saves it as a new profile version
+func (s *Service) Calibrate(ctx context.Context, frame image.Image, req CalibrateRequest, username string) (*CalibrateResult, error) {
+	b := frame.Bounds()
+	req.ImageWidth, req.ImageHeight = b.Dx(), b.Dy()
+
+	markings := req.Markings
+	switch {
+	case len(markings) > 0:
+		points, err := vision.SurveyControlPoints(markings, req.Distances)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+		}
+		req.ControlPoints = points
+	case len(req.ControlPoints) > 0:
+		for i, p := range req.ControlPoints {
+			markings = append(markings, vision.Marking{Name: strconv.Itoa(i + 1), PixelX: p.PixelX, PixelY: p.PixelY})
+		}
+	default:
+		return nil, fmt.Errorf("%w: markings with distances or control_points are required", ErrInvalid)
+	}
+	for _, m := range markings {
+		if m.PixelX < 0 || m.PixelY < 0 || m.PixelX >= float64(b.Dx()) || m.PixelY >= float64(b.Dy()) {
+			return nil, fmt.Errorf("%w: marking %s is outside the %dx%d frame", ErrInvalid, m.Name, b.Dx(), b.Dy())
+		}
+	}
+
+	residual, err := Solve(&req.ProfileRequest)
+	if err != nil {
+		return nil, err
+	}
+	cal, err := Profile{
+		ImageWidth:         req.ImageWidth,
+		ImageHeight:        req.ImageHeight,
+		FocalLengthPixels:  req.FocalLengthPixels,
+		CameraHeightMeters: req.CameraHeightMeters,
+		TiltAngleDegrees:   req.TiltAngleDegrees,
+		ControlPoints:      req.ControlPoints,
+	}.Calibration()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	result := &CalibrateResult{
+		ControlPoints: req.ControlPoints,
+		Residual:      residual,
+		Pose:          cal.Ground.Pose,
+		Distances:     cal.Ground.MeasureDistances(markings, req.Distances),
+		Warnings:      []string{},
+	}
+	if residual.Warning != "" {
+		result.Warnings = append(result.Warnings, residual.Warning)
+	}
+	if result.Pose == nil {
+		result.Warnings = append(result.Warnings, "camera pose could not be recovered; focal length, height and tilt are taken from the request")
+	}
+	var sum float64
+	for _, d := range result.Distances {
+		sum += d.ErrorMeters * d.ErrorMeters
+		result.DistanceMaxPct = math.Max(result.DistanceMaxPct, math.Abs(d.ErrorPct))
+		if math.Abs(d.ErrorPct) > maxDistanceErrorPct {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("distance %s-%s is off by %.1f%%; check the marking positions and the tape measurement", d.From, d.To, d.ErrorPct))
+		}
+	}
+	if len(result.Distances) > 0 {
+		result.DistanceRMSMeters = math.Round(math.Sqrt(sum/float64(len(result.Distances)))*1000) / 1000
+	}
+	if len(req.Distances) == 2*len(markings)-3 {
+		result.Warnings = append(result.Warnings, "no spare distances: measure both diagonals to get an error estimate")
+	}
+
+	var buf bytes.Buffer
+	preview := vision.DrawCalibration(frame, cal.Ground, markings, result.Distances)
+	if err := jpeg.Encode(&buf, preview, &jpeg.Options{Quality: 85}); err != nil {
+		return nil, fmt.Errorf("encode preview: %w", err)
+	}
+	result.Preview = buf.Bytes()
+
+	if req.DryRun {
+		return result, nil
+	}
+	profile, err := s.Create(ctx, req.ProfileRequest, username)
+	if err != nil {
+		return nil, err
+	}
+	result.Profile = profile
+	return result, nil
+}
@@ -0,0 +1,94 @@
+package calibration
+
+import (
+	"fmt"
+	"time"
+
+	"wim-service/internal/versioned"
+	"wim-service/internal/vision"
+)
+
+// Profile is one version of the calibration of an ANPR camera at a site
+type Profile struct {
+	ID                   string                      `json:"id"`
+	SiteID               string                      `json:"site_id"`
+	SiteCode             string                      `json:"site_code"`
+	CameraID             string                      `json:"camera_id"` // "" = default for all cameras of the site
+	Description          string                      `json:"description"`
+	ImageWidth           int                         `json:"image_width"`
+	ImageHeight          int                         `json:"image_height"`
+	FocalLengthPixels    float64                     `json:"focal_length_px"`
+	CameraHeightMeters   float64                     `json:"camera_height_m"`
+	TiltAngleDegrees     float64                     `json:"tilt_angle_deg"`
+	ReferencePixelLength int                         `json:"ref_pixel_length"`
+	ReferenceRealLength  float64                     `json:"ref_real_length_m"`
+	ReferenceDistanceM   float64                     `json:"ref_distance_m"`
+	ControlPoints        []vision.ControlPoint       `json:"control_points"` // Empty = reference object calibration
+	Residual             *vision.CalibrationResidual `json:"residual"`
+	CreatedBy            string                      `json:"created_by"`
+	CreatedDate          time.Time                   `json:"created_date"`
+
+	versioned.Validity // Version, effective range, active
+}
+
+// Ref identifies the profile version, e.g. "gantry-1@v2" ("*" for the site default)
+func (p Profile) Ref() string {
+	camera := p.CameraID
+	if camera == "" {
+		camera = "*"
+	}
+	return fmt.Sprintf("%s@v%d", camera, p.Version)
+}
+
+// Calibration builds the camera calibration of the profile
+func (p Profile) Calibration() (*vision.CameraCalibration, error) {
+	cal := vision.NewCameraCalibration()
+	cal.LoadFromConfig(
+		p.FocalLengthPixels,
+		p.ImageWidth,
+		p.ImageHeight,
+		p.CameraHeightMeters,
+		p.TiltAngleDegrees,
+		p.ReferencePixelLength,
+		p.ReferenceRealLength,
+		p.ReferenceDistanceM,
+	)
+	if len(p.ControlPoints) > 0 {
+		if err := cal.SetControlPoints(p.ControlPoints); err != nil {
+			return nil, err
+		}
+	}
+	if err := cal.Validate(); err != nil {
+		return nil, err
+	}
+	cal.ProfileID = p.ID
+	return cal, nil
+}
+
+// ProfileRequest creates a profile; an existing site + camera gets a new version.
+// With control points the focal length, height and tilt are recovered from them
+// when possible and the reference object fields are not needed.
+type ProfileRequest struct {
+	SiteID               string                `json:"site_id"`
+	CameraID             string                `json:"camera_id"`
+	Description          string                `json:"description"`
+	ImageWidth           int                   `json:"image_width"`
+	ImageHeight          int                   `json:"image_height"`
+	FocalLengthPixels    float64               `json:"focal_length_px"`
+	CameraHeightMeters   float64               `json:"camera_height_m"`
+	TiltAngleDegrees     float64               `json:"tilt_angle_deg"`
+	ReferencePixelLength int                   `json:"ref_pixel_length"`
+	ReferenceRealLength  float64               `json:"ref_real_length_m"`
+	ReferenceDistanceM   float64               `json:"ref_distance_m"`
+	ControlPoints        []vision.ControlPoint `json:"control_points"`
+	EffectiveFrom        *time.Time            `json:"effective_from"` // Default: now
+	EffectiveTo          *time.Time            `json:"effective_to"`
+}
+
+// Filter narrows List
+type Filter struct {
+	SiteID     string
+	CameraID   *string // nil = all cameras; "" = site defaults only
+	ActiveOnly bool
+	At         *time.Time // Only profiles in force at this time
+}
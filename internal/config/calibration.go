@@ -1,9 +1,14 @@
 package config
 
-import "wim-service/internal/vision"
+import (
+	"fmt"
 
-// GetCameraCalibration returns a CameraCalibration object from config
-func (c *Config) GetCameraCalibration() *vision.CameraCalibration {
+	"wim-service/internal/vision"
+)
+
+// GetCameraCalibration returns a CameraCalibration object from config. With
+// CAMERA_CONTROL_POINTS the ground plane is calibrated from the control points.
+func (c *Config) GetCameraCalibration() (*vision.CameraCalibration, error) {
 	calibration := vision.NewCameraCalibration()
 
 	calibration.LoadFromConfig(
@@ -17,5 +22,31 @@ func (c *Config) GetCameraCalibration() *vision.CameraCalibration {
 		c.CameraRefDistance,
 	)
 
-	return calibration
+	if c.CameraControlPoints != "" {
+		points, err := vision.ParseControlPoints(c.CameraControlPoints)
+		if err != nil {
+			return nil, fmt.Errorf("CAMERA_CONTROL_POINTS: %w", err)
+		}
+		if err := calibration.SetControlPoints(points); err != nil {
+			return nil, fmt.Errorf("CAMERA_CONTROL_POINTS: %w", err)
+		}
+	}
+
+	return calibration, nil
+}
+
+// GetDetectorConfig returns the vehicle detector backend settings from config
+func (c *Config) GetDetectorConfig() vision.DetectorConfig {
+	return vision.DetectorConfig{
+		Backend:          c.DimensionDetector,
+		ModelPath:        c.DimensionModelPath,
+		Threshold:        c.DimensionThreshold,
+		Endpoint:         c.InferenceURL,
+		Timeout:          c.InferenceTimeout,
+		BatchSize:        c.InferenceBatchSize,
+		BatchWait:        c.InferenceBatchWait,
+		Classes:          c.InferenceClasses,
+		Fallback:         c.InferenceFallback,
+		FallbackCooldown: c.InferenceFallbackCooldown,
+	}
 }
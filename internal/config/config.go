@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
@@ -68,11 +69,31 @@ type Config struct {
 	AttachmentMinIOBucket   string
 	AttachmentMinIOUseSSL   bool
 
+	// MinIO Config for EVIDENCE bundles
+	EvidenceMinIOEndpoint string
+	EvidenceMinIOAccess   string
+	EvidenceMinIOSecret   string
+	EvidenceMinIOBucket   string
+	EvidenceMinIOUseSSL   bool
+
+	// Evidence Signing
+	EvidenceSigningKeyPath string // PKCS#8 PEM Ed25519 private key (generated on first start if missing)
+
 	// Vehicle Dimension Detection Config
 	DimensionEnabled   bool    // Enable dimension detection
-	DimensionModelPath string  // Path to detection model (if using ML model)
+	DimensionModelPath string  // Directory for persisted per-camera background models ("" = memory only)
 	DimensionThreshold float64 // Detection confidence threshold
 
+	// Vehicle Detector Backend
+	DimensionDetector         string        // "segmentation" (built-in) or "http" (inference server)
+	InferenceURL              string        // Inference server endpoint
+	InferenceTimeout          time.Duration // Per request
+	InferenceBatchSize        int           // Frames per request
+	InferenceBatchWait        time.Duration // Max wait for a batch to fill
+	InferenceClasses          []string      // Accepted class labels (empty = all)
+	InferenceFallback         bool          // Use the built-in detector when the server fails
+	InferenceFallbackCooldown time.Duration // Skip the server this long after a failure
+
 	// Camera Calibration Parameters
 	CameraFocalLength    float64 // Focal length in pixels
 	CameraImageWidth     int     // Image width in pixels
@@ -82,6 +103,65 @@ type Config struct {
 	CameraRefPixelLength int     // Reference object length in pixels
 	CameraRefRealLength  float64 // Reference object length in meters
 	CameraRefDistance    float64 // Distance to reference object in meters
+	CameraControlPoints  string  // "px,py,gx,gy;..." image↔road control points (replaces the reference object)
+
+	// Per-camera calibration profiles (master_camera_calibration); CAMERA_* is the fallback
+	CalibrationProfilesEnabled bool          // Pick the calibration per camera_id from the database
+	CalibrationProfileRefresh  time.Duration // Reload interval of the profiles in the watcher
+
+	// Plate Watchlist Config
+	WatchlistEnabled        bool          // Enable watchlist matching in the ANPR pipeline
+	WatchlistWebhookURLs    []string      // Webhooks notified on every watchlist hit
+	WatchlistWebhookTimeout time.Duration // Timeout per webhook request
+
+	// Ingest Reconciliation Config
+	ReconcileEnabled       bool          // Run the MinIO/DB reconciliation job in the watchers
+	ReconcileInterval      time.Duration // Interval between reconciliation runs
+	ReconcileLookbackDays  int           // Date folders checked per run
+	ReconcileGrace         time.Duration // Objects younger than this are skipped (may be mid-ingestion)
+	ReconcileRemoveOrphans bool          // Delete objects without a DB row instead of only recording them
+
+	// Overload Config
+	OverloadEnabled      bool    // Evaluate gross weight against master_vehicle_class in the axle pipeline
+	OverloadTolerancePct float64 // Overload (percent of permitted weight) still tolerated
+
+	// Vehicle Class Resolution Config
+	ClassResolutionEnabled bool    // Resolve axle captures to master_vehicle_class in the axle pipeline
+	ClassMinConfidence     float64 // Matches below this confidence are flagged for review
+
+	// Over-Dimension (ODOL) Config
+	ODOLEnabled           bool          // Evaluate measured dimensions against master_vehicle_class
+	ODOLLengthToleranceMM int           // Excess length still tolerated
+	ODOLWidthToleranceMM  int           // Excess width still tolerated
+	ODOLHeightToleranceMM int           // Excess height still tolerated
+	ODOLVisionWindow      time.Duration // Max time between axle and ANPR capture for vision dimensions
+
+	// Violation Config
+	ViolationEnabled bool // Raise violation cases from overload / over-dimension results in the watchers
+
+	// Toll Golongan Config
+	GolonganEnabled bool // Assign toll Golongan I-V to axle captures (master_toll_golongan_rule)
+
+	// Vehicle Correlation Config
+	CorrelationEnabled     bool          // Correlate ANPR and axle captures into transact_vehicle in the watchers
+	CorrelationWindow      time.Duration // Max capture time difference of an ANPR/axle match
+	CorrelationMinScore    float64       // Matches scoring below this are flagged for manual review
+	CorrelationTimeWeight  float64       // Weight of the time difference score
+	CorrelationPlateWeight float64       // Weight of the axle camera plate vs ANPR plate similarity
+	CorrelationLaneWeight  float64       // Weight of the lane agreement
+
+	CorrelationReconcileEnabled  bool          // Run the late-arrival correlation reconciliation job (AXLE watcher)
+	CorrelationReconcileInterval time.Duration // Interval between reconciliation runs
+	CorrelationReconcileHorizon  time.Duration // Only captures newer than this are re-evaluated
+	CorrelationReconcileGrace    time.Duration // Captures younger than this are left to the live pipeline
+
+	// Clock Drift Config
+	ClockDriftEnabled    bool          // Estimate the ANPR/axle clock offset per device pair from matched vehicles
+	ClockDriftMinScore   float64       // Only matches scoring at least this are used as samples
+	ClockDriftAlpha      float64       // EWMA weight of a new offset sample
+	ClockDriftThreshold  time.Duration // Estimated |offset| beyond this raises an alert
+	ClockDriftMinSamples int           // Samples needed before alerting or auto-applying
+	ClockDriftAutoApply  bool          // Correct the correlation window with the estimated offset
 }
 
 func Load() (*Config, error) {
@@ -145,11 +225,31 @@ func Load() (*Config, error) {
 		AttachmentMinIOBucket:   getEnv("ATTACHMENT_MINIO_BUCKET", "attachment"),
 		AttachmentMinIOUseSSL:   getEnvBool("ATTACHMENT_MINIO_USE_SSL", true),
 
+		// EVIDENCE MinIO
+		EvidenceMinIOEndpoint: getEnv("EVIDENCE_MINIO_ENDPOINT", "s3minio.activa.id"),
+		EvidenceMinIOAccess:   getEnv("EVIDENCE_MINIO_ACCESS_KEY", "admin"),
+		EvidenceMinIOSecret:   getEnv("EVIDENCE_MINIO_SECRET_KEY", "admin12345"),
+		EvidenceMinIOBucket:   getEnv("EVIDENCE_MINIO_BUCKET", "evidence"),
+		EvidenceMinIOUseSSL:   getEnvBool("EVIDENCE_MINIO_USE_SSL", true),
+
+		// Evidence Signing
+		EvidenceSigningKeyPath: getEnv("EVIDENCE_SIGNING_KEY_PATH", "./keys/evidence_ed25519.pem"),
+
 		// Vehicle Dimension Detection
 		DimensionEnabled:   getEnvBool("DIMENSION_ENABLED", false),
 		DimensionModelPath: getEnv("DIMENSION_MODEL_PATH", ""),
 		DimensionThreshold: getEnvFloat("DIMENSION_THRESHOLD", 0.5),
 
+		// Vehicle Detector Backend
+		DimensionDetector:         getEnv("DIMENSION_DETECTOR", "segmentation"),
+		InferenceURL:              getEnv("INFERENCE_URL", ""),
+		InferenceTimeout:          time.Duration(getEnvInt("INFERENCE_TIMEOUT_MS", 2000)) * time.Millisecond,
+		InferenceBatchSize:        getEnvInt("INFERENCE_BATCH_SIZE", 4),
+		InferenceBatchWait:        time.Duration(getEnvInt("INFERENCE_BATCH_WAIT_MS", 20)) * time.Millisecond,
+		InferenceClasses:          getEnvList("INFERENCE_CLASSES"),
+		InferenceFallback:         getEnvBool("INFERENCE_FALLBACK", true),
+		InferenceFallbackCooldown: time.Duration(getEnvInt("INFERENCE_FALLBACK_COOLDOWN_SEC", 30)) * time.Second,
+
 		// Camera Calibration (default values - should be calibrated)
 		CameraFocalLength:    getEnvFloat("CAMERA_FOCAL_LENGTH", 1000.0),
 		CameraImageWidth:     getEnvInt("CAMERA_IMAGE_WIDTH", 1920),
@@ -159,6 +259,65 @@ func Load() (*Config, error) {
 		CameraRefPixelLength: getEnvInt("CAMERA_REF_PIXEL_LENGTH", 200),
 		CameraRefRealLength:  getEnvFloat("CAMERA_REF_REAL_LENGTH", 5.0),
 		CameraRefDistance:    getEnvFloat("CAMERA_REF_DISTANCE", 10.0),
+		CameraControlPoints:  getEnv("CAMERA_CONTROL_POINTS", ""),
+
+		// Camera Calibration Profiles
+		CalibrationProfilesEnabled: getEnvBool("CALIBRATION_PROFILES_ENABLED", true),
+		CalibrationProfileRefresh:  time.Duration(getEnvInt("CALIBRATION_PROFILE_REFRESH_SEC", 60)) * time.Second,
+
+		// Plate Watchlist
+		WatchlistEnabled:        getEnvBool("WATCHLIST_ENABLED", true),
+		WatchlistWebhookURLs:    getEnvList("WATCHLIST_WEBHOOK_URLS"),
+		WatchlistWebhookTimeout: time.Duration(getEnvInt("WATCHLIST_WEBHOOK_TIMEOUT_SEC", 5)) * time.Second,
+
+		// Ingest Reconciliation
+		ReconcileEnabled:       getEnvBool("RECONCILE_ENABLED", true),
+		ReconcileInterval:      time.Duration(getEnvInt("RECONCILE_INTERVAL_MIN", 60)) * time.Minute,
+		ReconcileLookbackDays:  getEnvInt("RECONCILE_LOOKBACK_DAYS", 7),
+		ReconcileGrace:         time.Duration(getEnvInt("RECONCILE_GRACE_MIN", 10)) * time.Minute,
+		ReconcileRemoveOrphans: getEnvBool("RECONCILE_REMOVE_ORPHANS", false),
+
+		// Overload
+		OverloadEnabled:      getEnvBool("OVERLOAD_ENABLED", true),
+		OverloadTolerancePct: getEnvFloat("OVERLOAD_TOLERANCE_PCT", 5.0),
+
+		// Vehicle Class Resolution
+		ClassResolutionEnabled: getEnvBool("CLASS_RESOLUTION_ENABLED", true),
+		ClassMinConfidence:     getEnvFloat("CLASS_MIN_CONFIDENCE", 0.6),
+
+		// Over-Dimension (ODOL)
+		ODOLEnabled:           getEnvBool("ODOL_ENABLED", true),
+		ODOLLengthToleranceMM: getEnvInt("ODOL_LENGTH_TOLERANCE_MM", 300),
+		ODOLWidthToleranceMM:  getEnvInt("ODOL_WIDTH_TOLERANCE_MM", 100),
+		ODOLHeightToleranceMM: getEnvInt("ODOL_HEIGHT_TOLERANCE_MM", 100),
+		ODOLVisionWindow:      time.Duration(getEnvInt("ODOL_VISION_WINDOW_SEC", 10)) * time.Second,
+
+		// Violation
+		ViolationEnabled: getEnvBool("VIOLATION_ENABLED", true),
+
+		// Toll Golongan
+		GolonganEnabled: getEnvBool("GOLONGAN_ENABLED", true),
+
+		// Vehicle Correlation
+		CorrelationEnabled:     getEnvBool("CORRELATION_ENABLED", true),
+		CorrelationWindow:      time.Duration(getEnvInt("CORRELATION_WINDOW_SEC", 5)) * time.Second,
+		CorrelationMinScore:    getEnvFloat("CORRELATION_MIN_SCORE", 0.6),
+		CorrelationTimeWeight:  getEnvFloat("CORRELATION_TIME_WEIGHT", 0.5),
+		CorrelationPlateWeight: getEnvFloat("CORRELATION_PLATE_WEIGHT", 0.35),
+		CorrelationLaneWeight:  getEnvFloat("CORRELATION_LANE_WEIGHT", 0.15),
+
+		CorrelationReconcileEnabled:  getEnvBool("CORRELATION_RECONCILE_ENABLED", true),
+		CorrelationReconcileInterval: time.Duration(getEnvInt("CORRELATION_RECONCILE_INTERVAL_MIN", 5)) * time.Minute,
+		CorrelationReconcileHorizon:  time.Duration(getEnvInt("CORRELATION_RECONCILE_HORIZON_MIN", 120)) * time.Minute,
+		CorrelationReconcileGrace:    time.Duration(getEnvInt("CORRELATION_RECONCILE_GRACE_SEC", 60)) * time.Second,
+
+		// Clock Drift
+		ClockDriftEnabled:    getEnvBool("CLOCK_DRIFT_ENABLED", true),
+		ClockDriftMinScore:   getEnvFloat("CLOCK_DRIFT_MIN_SCORE", 0.8),
+		ClockDriftAlpha:      getEnvFloat("CLOCK_DRIFT_ALPHA", 0.05),
+		ClockDriftThreshold:  time.Duration(getEnvInt("CLOCK_DRIFT_THRESHOLD_MS", 1000)) * time.Millisecond,
+		ClockDriftMinSamples: getEnvInt("CLOCK_DRIFT_MIN_SAMPLES", 20),
+		ClockDriftAutoApply:  getEnvBool("CLOCK_DRIFT_AUTO_APPLY", false),
 	}
 
 	if cfg.DatabaseURL == "" {
@@ -274,3 +433,13 @@ func getEnvFloat(key string, def float64) float64 {
 	}
 	return def
 }
+
+func getEnvList(key string) []string {
+	var list []string
+	for _, v := range strings.Split(os.Getenv(key), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
@@ -0,0 +1,25 @@
+package config
+
+import "wim-service/internal/correlation"
+
+// GetCorrelationScoring returns the correlation scoring weights and review threshold from config
+func (c *Config) GetCorrelationScoring() correlation.Scoring {
+	return correlation.Scoring{
+		TimeWeight:  c.CorrelationTimeWeight,
+		PlateWeight: c.CorrelationPlateWeight,
+		LaneWeight:  c.CorrelationLaneWeight,
+		MinScore:    c.CorrelationMinScore,
+	}
+}
+
+// GetClockDrift returns the device clock drift estimation settings from config
+func (c *Config) GetClockDrift() correlation.Drift {
+	return correlation.Drift{
+		Enabled:    c.ClockDriftEnabled,
+		MinScore:   c.ClockDriftMinScore,
+		Alpha:      c.ClockDriftAlpha,
+		Threshold:  c.ClockDriftThreshold,
+		MinSamples: c.ClockDriftMinSamples,
+		AutoApply:  c.ClockDriftAutoApply,
+	}
+}
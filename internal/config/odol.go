@@ -0,0 +1,12 @@
+package config
+
+import "wim-service/internal/odol"
+
+// GetODOLTolerances returns the over-dimension tolerances from config
+func (c *Config) GetODOLTolerances() odol.Tolerances {
+	return odol.Tolerances{
+		LengthMM: c.ODOLLengthToleranceMM,
+		WidthMM:  c.ODOLWidthToleranceMM,
+		HeightMM: c.ODOLHeightToleranceMM,
+	}
+}
@@ -0,0 +1,223 @@
+package correlation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"time"
+)
+
+// Drift configures the estimation of device clock offsets from matched pairs
+type Drift struct {
+	Enabled    bool          // Update the running offset of each device pair
+	MinScore   float64       // Only matches scoring at least this are used as samples
+	Alpha      float64       // EWMA weight of a new sample (0..1)
+	Threshold  time.Duration // |offset| beyond this raises an alert
+	MinSamples int           // Samples needed before alerting or auto-applying
+	AutoApply  bool          // Correct candidate times with the estimated offset
+}
+
+// Clock drift status (transact_clock_drift.drift_status)
+const (
+	DriftOK    = "OK"
+	DriftAlert = "DRIFT"
+)
+
+// DriftEstimate is the running clock offset of one ANPR/axle device pair
+type DriftEstimate struct {
+	ID            string     `json:"id"`
+	SiteID        string     `json:"site_id"`
+	SiteCode      string     `json:"site_code"`
+	ANPRCameraID  string     `json:"anpr_camera_id"`
+	AxleCameraID  string     `json:"axle_camera_id"`
+	OffsetMS      float64    `json:"offset_ms"` // axle - anpr
+	LastOffsetMS  int        `json:"last_offset_ms"`
+	Samples       int        `json:"samples"`
+	Status        string     `json:"drift_status"`
+	AlertRaisedAt *time.Time `json:"alert_raised_at"` // Open alert, if any
+	FirstSampleAt *time.Time `json:"first_sample_at"`
+	UpdatedDate   time.Time  `json:"updated_date"`
+}
+
+// pairOffsets are the applied offsets (axle - anpr) of a site, keyed by device pair
+type pairOffsets map[[2]string]time.Duration
+
+// appliedOffsets loads the offsets that are corrected when AutoApply is on
+func (s *Service) appliedOffsets(ctx context.Context, tx *sql.Tx, siteID string) (pairOffsets, error) {
+	if !s.Drift.AutoApply {
+		return nil, nil
+	}
+	rows, err := tx.QueryContext(ctx, `
+		SELECT anpr_camera_id, axle_camera_id, offset_ms::float8
+		FROM public.transact_clock_drift
+		WHERE site_id::text = $1 AND samples >= $2`, siteID, s.Drift.MinSamples)
+	if err != nil {
+		return nil, fmt.Errorf("query clock offsets: %w", err)
+	}
+	defer rows.Close()
+
+	offsets := pairOffsets{}
+	for rows.Next() {
+		var anprCam, axleCam string
+		var ms float64
+		if err := rows.Scan(&anprCam, &axleCam, &ms); err != nil {
+			return nil, fmt.Errorf("scan clock offset: %w", err)
+		}
+		offsets[[2]string{anprCam, axleCam}] = time.Duration(math.Round(ms)) * time.Millisecond
+	}
+	return offsets, rows.Err()
+}
+
+// widest is the largest absolute offset; the candidate query widens its window by it
+func (o pairOffsets) widest() time.Duration {
+	var m time.Duration
+	for _, d := range o {
+		m = max(m, absDuration(d))
+	}
+	return m
+}
+
+// correct removes the estimated offset of each candidate's device pair from its
+// capture time, so candidates are scored as if both clocks agreed
+func (o pairOffsets) correct(sd side, subject Candidate, candidates []Candidate) {
+	if len(o) == 0 {
+		return
+	}
+	for i := range candidates {
+		c := &candidates[i]
+		var offset time.Duration
+		if sd == anprSide {
+			offset = o[[2]string{subject.Camera, c.Camera}] // Kandidat axle: axle - offset
+		} else {
+			offset = -o[[2]string{c.Camera, subject.Camera}] // Kandidat ANPR: anpr + offset
+		}
+		c.ClockOffset = offset
+		c.CapturedAt = c.CapturedAt.Add(-offset)
+	}
+}
+
+// updateDrift adds the raw offset of a matched vehicle to the running estimate of its
+// device pair and raises or clears the drift alert
+func (s *Service) updateDrift(ctx context.Context, tx *sql.Tx, vehicleID string) error {
+	var id, siteID, anprCam, axleCam, status string
+	var offset float64
+	var samples int
+	// Rata-rata biasa sampai 1/alpha sampel, setelah itu EWMA
+	err := tx.QueryRowContext(ctx, `
+		INSERT INTO public.transact_clock_drift AS d
+			(site_id, anpr_camera_id, axle_camera_id, offset_ms, last_offset_ms, samples)
+		SELECT v.site_id, COALESCE(an.camera_id, ''), COALESCE(ax.camera_id, ''), v.offset_ms, v.offset_ms, 1
+		FROM public.transact_vehicle v
+		JOIN public.transact_anpr_capture an ON an.id = v.anpr_id
+		JOIN public.transact_axle_capture ax ON ax.id = v.axle_id
+		WHERE v.id::text = $1 AND v.offset_ms IS NOT NULL
+		ON CONFLICT (site_id, anpr_camera_id, axle_camera_id) DO UPDATE SET
+			offset_ms = d.offset_ms + GREATEST($2::numeric, 1.0 / (d.samples + 1)) * (EXCLUDED.last_offset_ms - d.offset_ms),
+			last_offset_ms = EXCLUDED.last_offset_ms,
+			samples = d.samples + 1,
+			updated_date = now()
+		RETURNING d.id::text, d.site_id::text, d.anpr_camera_id, d.axle_camera_id, d.offset_ms::float8, d.samples, d.drift_status`,
+		vehicleID, s.Drift.Alpha).Scan(&id, &siteID, &anprCam, &axleCam, &offset, &samples, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("update clock drift: %w", err)
+	}
+
+	next := DriftOK
+	if s.Drift.Threshold > 0 && samples >= s.Drift.MinSamples &&
+		math.Abs(offset) > float64(s.Drift.Threshold.Milliseconds()) {
+		next = DriftAlert
+	}
+	if next == status {
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_clock_drift SET drift_status = $2 WHERE id::text = $1`, id, next); err != nil {
+		return fmt.Errorf("update drift status: %w", err)
+	}
+	if next == DriftAlert {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO public.transact_clock_drift_alert (drift_id, offset_ms, threshold_ms)
+			VALUES ($1::uuid, $2, $3)`, id, offset, s.Drift.Threshold.Milliseconds()); err != nil {
+			return fmt.Errorf("raise drift alert: %w", err)
+		}
+		log.Printf("[CORRELATION] ALERT: Clock drift site=%s anpr_camera=%q axle_camera=%q offset=%.0fms exceeds %v (%d samples)",
+			siteID, anprCam, axleCam, offset, s.Drift.Threshold, samples)
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_clock_drift_alert SET cleared_at = now()
+		WHERE drift_id::text = $1 AND cleared_at IS NULL`, id); err != nil {
+		return fmt.Errorf("clear drift alert: %w", err)
+	}
+	log.Printf("[CORRELATION] Clock drift cleared site=%s anpr_camera=%q axle_camera=%q offset=%.0fms",
+		siteID, anprCam, axleCam, offset)
+	return nil
+}
+
+// ListDrift returns the clock offset estimates, drifting pairs first
+func (s *Service) ListDrift(ctx context.Context, siteID string) ([]DriftEstimate, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT d.id, d.site_id::text, COALESCE(ms.code, ''), d.anpr_camera_id, d.axle_camera_id,
+		       d.offset_ms::float8, d.last_offset_ms, d.samples, d.drift_status,
+		       (SELECT max(a.raised_at) FROM public.transact_clock_drift_alert a
+		        WHERE a.drift_id = d.id AND a.cleared_at IS NULL),
+		       d.first_sample_at, d.updated_date
+		FROM public.transact_clock_drift d
+		LEFT JOIN public.master_site ms ON ms.id = d.site_id
+		WHERE ($1 = '' OR d.site_id::text = $1)
+		ORDER BY d.drift_status = 'OK', abs(d.offset_ms) DESC`, siteID)
+	if err != nil {
+		return nil, fmt.Errorf("query clock drift: %w", err)
+	}
+	defer rows.Close()
+
+	estimates := []DriftEstimate{}
+	for rows.Next() {
+		var e DriftEstimate
+		var raisedAt, firstAt sql.NullTime
+		if err := rows.Scan(&e.ID, &e.SiteID, &e.SiteCode, &e.ANPRCameraID, &e.AxleCameraID,
+			&e.OffsetMS, &e.LastOffsetMS, &e.Samples, &e.Status, &raisedAt, &firstAt, &e.UpdatedDate); err != nil {
+			return nil, fmt.Errorf("scan clock drift: %w", err)
+		}
+		e.AlertRaisedAt = timePtr(raisedAt)
+		e.FirstSampleAt = timePtr(firstAt)
+		estimates = append(estimates, e)
+	}
+	return estimates, rows.Err()
+}
+
+// ResetDrift discards the estimate of a device pair (e.g. after its clocks were
+// re-synchronized); open alerts are cleared
+func (s *Service) ResetDrift(ctx context.Context, id string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_clock_drift_alert SET cleared_at = now()
+		WHERE drift_id::text = $1 AND cleared_at IS NULL`, id); err != nil {
+		return fmt.Errorf("clear drift alert: %w", err)
+	}
+	res, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_clock_drift SET
+			offset_ms = 0, last_offset_ms = 0, samples = 0, drift_status = $2,
+			first_sample_at = now(), updated_date = now()
+		WHERE id::text = $1`, id, DriftOK)
+	if err != nil {
+		return fmt.Errorf("reset clock drift: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return tx.Commit()
+}
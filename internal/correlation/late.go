@@ -0,0 +1,239 @@
+package correlation
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Reconciler periodically re-evaluates unmatched captures of one site. The ANPR and
+// axle watchers run independently, so after an outage one side may arrive minutes
+// late; its captures are then merged with the partner rows already recorded as
+// ANPR_ONLY / AXLE_ONLY.
+type Reconciler struct {
+	Service *Service
+	SiteID  string
+	Horizon time.Duration // Only captures newer than this are re-evaluated
+	Grace   time.Duration // Captures younger than this are left to the live pipeline
+}
+
+// ReconcileReport summarizes one reconciliation run
+type ReconcileReport struct {
+	Checked  int // Unmatched captures re-evaluated
+	Merged   int // Pairs merged (logged in transact_vehicle_late_match)
+	Recorded int // Captures without a vehicle row that were recorded unmatched
+	Failed   int
+}
+
+// NewReconciler creates a late-arrival reconciler for one site
+func NewReconciler(svc *Service, siteID string) *Reconciler {
+	return &Reconciler{
+		Service: svc,
+		SiteID:  siteID,
+		Horizon: time.Hour,
+		Grace:   time.Minute,
+	}
+}
+
+// Run reconciles immediately and then on every interval until ctx is cancelled
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	log.Printf("[CORRELATION] Late-arrival reconciliation started (every %v, horizon %v)", interval, r.Horizon)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := r.Reconcile(ctx)
+		if err != nil {
+			log.Printf("[CORRELATION] Late-arrival reconciliation error: %v", err)
+		} else {
+			log.Printf("[CORRELATION] Late-arrival reconciliation done: checked=%d merged=%d recorded=%d failed=%d",
+				report.Checked, report.Merged, report.Recorded, report.Failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile re-evaluates the unmatched captures of both devices inside the horizon
+func (r *Reconciler) Reconcile(ctx context.Context) (*ReconcileReport, error) {
+	report := &ReconcileReport{}
+	now := time.Now()
+
+	// Sisi axle dievaluasi setelah ANPR sehingga capture yang sudah tergabung tidak diambil lagi
+	for _, sd := range []side{anprSide, axleSide} {
+		ids, err := r.unmatched(ctx, sd, now.Add(-r.Horizon), now.Add(-r.Grace))
+		if err != nil {
+			return report, err
+		}
+		for _, id := range ids {
+			if ctx.Err() != nil {
+				return report, ctx.Err()
+			}
+			report.Checked++
+
+			v, merged, err := r.Service.correlate(ctx, sd, id.captureID, true, true)
+			if err != nil {
+				report.Failed++
+				log.Printf("[CORRELATION] Warning: Late reconciliation failed for %s %s: %v", sd.column, id.captureID, err)
+				continue
+			}
+			switch {
+			case merged:
+				report.Merged++
+				log.Printf("[CORRELATION] Late match: vehicle=%s anpr=%s axle=%s plate=%s score=%v",
+					v.ID, v.ANPRID, v.AxleID, v.PlateNo, scoreValue(v.MatchScore))
+			case !id.recorded:
+				report.Recorded++
+			}
+		}
+	}
+	return report, nil
+}
+
+type unmatchedCapture struct {
+	captureID string
+	recorded  bool // Already has an unmatched transact_vehicle row
+}
+
+// unmatched returns the captures of one device captured in [since, until] that have no
+// vehicle row (correlation failed or was disabled) or are still unmatched and not
+// marked "no partner"
+func (r *Reconciler) unmatched(ctx context.Context, sd side, since, until time.Time) ([]unmatchedCapture, error) {
+	query := `
+		SELECT c.id::text, v.id IS NOT NULL
+		FROM ` + sd.table + ` c
+		LEFT JOIN public.transact_vehicle v ON v.` + sd.column + ` = c.id
+		WHERE c.site_id::text = $1
+		  AND c.is_deleted = false
+		  AND c.captured_at BETWEEN $2 AND $3
+		  AND (v.id IS NULL OR (v.correlation_status = $4 AND v.no_partner = false))
+		ORDER BY c.captured_at`
+
+	rows, err := r.Service.DB.QueryContext(ctx, query, r.SiteID, since, until, sd.only)
+	if err != nil {
+		return nil, fmt.Errorf("query unmatched %s: %w", sd.column, err)
+	}
+	defer rows.Close()
+
+	var captures []unmatchedCapture
+	for rows.Next() {
+		var c unmatchedCapture
+		if err := rows.Scan(&c.captureID, &c.recorded); err != nil {
+			return nil, fmt.Errorf("scan unmatched %s: %w", sd.column, err)
+		}
+		captures = append(captures, c)
+	}
+	return captures, rows.Err()
+}
+
+// logLateMatch records a merge made by the reconciliation job
+func logLateMatch(ctx context.Context, tx *sql.Tx, vehicleID string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO public.transact_vehicle_late_match
+			(vehicle_id, site_id, anpr_id, axle_id, match_score, time_diff_seconds, recovery_delay_seconds)
+		SELECT v.id, v.site_id, v.anpr_id, v.axle_id, v.match_score, v.time_diff_seconds,
+			EXTRACT(EPOCH FROM (now() - v.first_detected_at))::numeric(12, 3)
+		FROM public.transact_vehicle v
+		WHERE v.id::text = $1`, vehicleID)
+	if err != nil {
+		return fmt.Errorf("log late match: %w", err)
+	}
+	return nil
+}
+
+func scoreValue(score *float64) any {
+	if score == nil {
+		return "-"
+	}
+	return *score
+}
+
+// ===== Late match reports =====
+
+// ListLateMatches returns the pairs merged by the reconciliation job, newest first
+func (s *Service) ListLateMatches(ctx context.Context, filter LateMatchFilter) ([]LateMatch, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := `
+		SELECT l.id, COALESCE(l.vehicle_id::text, ''), l.site_id::text, COALESCE(ms.code, ''),
+			l.anpr_id::text, l.axle_id::text, COALESCE(v.plate_no, ''),
+			l.match_score, l.time_diff_seconds, l.recovery_delay_seconds, l.merged_at
+		FROM public.transact_vehicle_late_match l
+		LEFT JOIN public.transact_vehicle v ON v.id = l.vehicle_id
+		LEFT JOIN public.master_site ms ON ms.id = l.site_id
+		WHERE ($1 = '' OR l.site_id::text = $1)
+		  AND ($2::timestamptz IS NULL OR l.merged_at >= $2)
+		  AND ($3::timestamptz IS NULL OR l.merged_at < $3)
+		ORDER BY l.merged_at DESC
+		LIMIT $4 OFFSET $5`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.SiteID, nullTime(filter.From), nullTime(filter.To), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query late matches: %w", err)
+	}
+	defer rows.Close()
+
+	matches := []LateMatch{}
+	for rows.Next() {
+		var m LateMatch
+		var score, diff, delay sql.NullFloat64
+		if err := rows.Scan(&m.ID, &m.VehicleID, &m.SiteID, &m.SiteCode, &m.ANPRID, &m.AxleID, &m.PlateNo,
+			&score, &diff, &delay, &m.MergedAt); err != nil {
+			return nil, fmt.Errorf("scan late match: %w", err)
+		}
+		m.MatchScore = floatPtr(score)
+		m.TimeDiffSeconds = floatPtr(diff)
+		m.RecoveryDelaySeconds = floatPtr(delay)
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// CountLateMatches returns the number of late recoveries per site and day
+func (s *Service) CountLateMatches(ctx context.Context, filter LateMatchFilter) ([]LateMatchCount, error) {
+	query := `
+		SELECT l.site_id::text, COALESCE(ms.code, ''), date_trunc('day', l.merged_at), COUNT(*),
+			COALESCE(AVG(l.recovery_delay_seconds), 0)::float8, COALESCE(MAX(l.recovery_delay_seconds), 0)::float8
+		FROM public.transact_vehicle_late_match l
+		LEFT JOIN public.master_site ms ON ms.id = l.site_id
+		WHERE ($1 = '' OR l.site_id::text = $1)
+		  AND ($2::timestamptz IS NULL OR l.merged_at >= $2)
+		  AND ($3::timestamptz IS NULL OR l.merged_at < $3)
+		GROUP BY 1, 2, 3
+		ORDER BY 3 DESC, 2`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.SiteID, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return nil, fmt.Errorf("query late match counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []LateMatchCount{}
+	for rows.Next() {
+		var c LateMatchCount
+		if err := rows.Scan(&c.SiteID, &c.SiteCode, &c.Date, &c.Recovered, &c.AvgDelaySeconds, &c.MaxDelaySeconds); err != nil {
+			return nil, fmt.Errorf("scan late match count: %w", err)
+		}
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+func floatPtr(f sql.NullFloat64) *float64 {
+	if !f.Valid {
+		return nil
+	}
+	return &f.Float64
+}
@@ -0,0 +1,327 @@
+package correlation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// vehicleRow is the part of a transact_vehicle row an override needs
+type vehicleRow struct {
+	ID     string
+	SiteID string
+	ANPRID string
+	AxleID string
+	Status string
+}
+
+func loadVehicleRow(ctx context.Context, tx *sql.Tx, where string, arg string) (*vehicleRow, error) {
+	var v vehicleRow
+	err := tx.QueryRowContext(ctx, `
+		SELECT id::text, site_id::text, COALESCE(anpr_id::text, ''), COALESCE(axle_id::text, ''), correlation_status
+		FROM public.transact_vehicle
+		WHERE `+where+`::text = $1
+		FOR UPDATE`, arg).Scan(&v.ID, &v.SiteID, &v.ANPRID, &v.AxleID, &v.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load vehicle: %w", err)
+	}
+	return &v, nil
+}
+
+// Unlink splits a MATCHED vehicle into an ANPR_ONLY row (same id) and a new AXLE_ONLY
+// row. The pair is not matched automatically again.
+func (s *Service) Unlink(ctx context.Context, vehicleID, reason, actor string) (*OverrideResult, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalid)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	v, err := s.lockVehicle(ctx, tx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if v.Status != StatusMatched {
+		return nil, fmt.Errorf("%w: vehicle is %s, only MATCHED vehicles can be unlinked", ErrInvalid, v.Status)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_vehicle SET
+			axle_id = NULL,
+			correlation_status = $2,
+			match_score = NULL,
+			score_breakdown = NULL,
+			review_required = false,
+			override_action = $3, override_by = $4, override_reason = $5, override_at = now(),
+			updated_date = now()
+		WHERE id::text = $1`, v.ID, StatusANPROnly, OverrideUnlink, actor, reason); err != nil {
+		return nil, fmt.Errorf("unlink vehicle: %w", err)
+	}
+	var axleVehicleID string
+	if err := tx.QueryRowContext(ctx, `
+		INSERT INTO public.transact_vehicle
+			(site_id, axle_id, correlation_status, override_action, override_by, override_reason, override_at)
+		VALUES ($1::uuid, $2::uuid, $3, $4, $5, $6, now())
+		RETURNING id::text`, v.SiteID, v.AxleID, StatusAxleOnly, OverrideUnlink, actor, reason).Scan(&axleVehicleID); err != nil {
+		return nil, fmt.Errorf("insert axle vehicle: %w", err)
+	}
+
+	if err := writeOverride(ctx, tx, v.ID, v.SiteID, OverrideUnlink, v.ANPRID, v.AxleID, reason, actor); err != nil {
+		return nil, err
+	}
+	return s.finishOverride(ctx, tx, []string{v.ID, axleVehicleID}, []string{v.AxleID}, actor)
+}
+
+// Link matches an ANPR capture with an axle capture of the same site. Partners the
+// two captures had are released as unmatched rows.
+func (s *Service) Link(ctx context.Context, anprID, axleID, reason, actor string) (*OverrideResult, error) {
+	if anprID == "" || axleID == "" {
+		return nil, fmt.Errorf("%w: anpr_id and axle_id are required", ErrInvalid)
+	}
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalid)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var anprSite, axleSite string
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(an.site_id::text, ''), COALESCE(ax.site_id::text, '')
+		FROM public.transact_anpr_capture an, public.transact_axle_capture ax
+		WHERE an.id::text = $1 AND an.is_deleted = false
+		  AND ax.id::text = $2 AND ax.is_deleted = false`, anprID, axleID).Scan(&anprSite, &axleSite)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load captures: %w", err)
+	}
+	if anprSite == "" || anprSite != axleSite {
+		return nil, fmt.Errorf("%w: ANPR and axle capture must belong to the same site", ErrInvalid)
+	}
+	if err := lockSite(ctx, tx, anprSite); err != nil {
+		return nil, err
+	}
+
+	anprRow, err := loadVehicleRow(ctx, tx, "anpr_id", anprID)
+	if err != nil {
+		return nil, err
+	}
+	axleRow, err := loadVehicleRow(ctx, tx, "axle_id", axleID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Pasangan lama kedua capture dilepas menjadi row unmatched baru
+	type release struct {
+		sd        side
+		captureID string
+	}
+	var released []release
+	axleIDs := []string{axleID}
+	if anprRow != nil && anprRow.AxleID != "" && anprRow.AxleID != axleID {
+		released = append(released, release{axleSide, anprRow.AxleID})
+		axleIDs = append(axleIDs, anprRow.AxleID)
+	}
+	if axleRow != nil && axleRow.ANPRID != "" && axleRow.ANPRID != anprID {
+		released = append(released, release{anprSide, axleRow.ANPRID})
+	}
+
+	// Row ANPR dipertahankan (id tetap); row axle dihapus jika berbeda
+	if axleRow != nil && (anprRow == nil || axleRow.ID != anprRow.ID) {
+		if _, err := tx.ExecContext(ctx, `DELETE FROM public.transact_vehicle WHERE id::text = $1`, axleRow.ID); err != nil {
+			return nil, fmt.Errorf("remove axle vehicle: %w", err)
+		}
+	}
+
+	var vehicleID string
+	if anprRow != nil {
+		vehicleID = anprRow.ID
+		_, err = tx.ExecContext(ctx, `
+			UPDATE public.transact_vehicle SET
+				axle_id = $2::uuid,
+				correlation_status = $3,
+				match_score = NULL,
+				score_breakdown = NULL,
+				review_required = false,
+				no_partner = false,
+				override_action = $4, override_by = $5, override_reason = $6, override_at = now(),
+				updated_date = now()
+			WHERE id::text = $1`, vehicleID, axleID, StatusMatched, OverrideLink, actor, reason)
+	} else {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO public.transact_vehicle
+				(site_id, anpr_id, axle_id, correlation_status, override_action, override_by, override_reason, override_at)
+			VALUES ($1::uuid, $2::uuid, $3::uuid, $4, $5, $6, $7, now())
+			RETURNING id::text`, anprSite, anprID, axleID, StatusMatched, OverrideLink, actor, reason).Scan(&vehicleID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("link vehicle: %w", err)
+	}
+
+	vehicleIDs := []string{vehicleID}
+	for _, r := range released {
+		var id string
+		if err := tx.QueryRowContext(ctx, `
+			INSERT INTO public.transact_vehicle (site_id, `+r.sd.column+`, correlation_status)
+			VALUES ($1::uuid, $2::uuid, $3)
+			RETURNING id::text`, anprSite, r.captureID, r.sd.only).Scan(&id); err != nil {
+			return nil, fmt.Errorf("release %s: %w", r.sd.column, err)
+		}
+		vehicleIDs = append(vehicleIDs, id)
+	}
+
+	if err := writeOverride(ctx, tx, vehicleID, anprSite, OverrideLink, anprID, axleID, reason, actor); err != nil {
+		return nil, err
+	}
+	return s.finishOverride(ctx, tx, vehicleIDs, axleIDs, actor)
+}
+
+// MarkNoPartner flags an unmatched vehicle as having no partner, so automatic
+// correlation and the reconciliation job leave it alone
+func (s *Service) MarkNoPartner(ctx context.Context, vehicleID, reason, actor string) (*OverrideResult, error) {
+	if strings.TrimSpace(reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", ErrInvalid)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	v, err := s.lockVehicle(ctx, tx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if v.Status == StatusMatched {
+		return nil, fmt.Errorf("%w: vehicle is MATCHED, unlink it first", ErrInvalid)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_vehicle SET
+			no_partner = true,
+			override_action = $2, override_by = $3, override_reason = $4, override_at = now(),
+			updated_date = now()
+		WHERE id::text = $1`, v.ID, OverrideNoPartner, actor, reason); err != nil {
+		return nil, fmt.Errorf("mark no partner: %w", err)
+	}
+	if err := writeOverride(ctx, tx, v.ID, v.SiteID, OverrideNoPartner, v.ANPRID, v.AxleID, reason, actor); err != nil {
+		return nil, err
+	}
+
+	var axleIDs []string
+	if v.AxleID != "" {
+		axleIDs = append(axleIDs, v.AxleID)
+	}
+	return s.finishOverride(ctx, tx, []string{v.ID}, axleIDs, actor)
+}
+
+// lockVehicle takes the site lock and loads the vehicle row for an override
+func (s *Service) lockVehicle(ctx context.Context, tx *sql.Tx, vehicleID string) (*vehicleRow, error) {
+	var siteID string
+	err := tx.QueryRowContext(ctx, `
+		SELECT site_id::text FROM public.transact_vehicle WHERE id::text = $1`, vehicleID).Scan(&siteID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load vehicle: %w", err)
+	}
+	if err := lockSite(ctx, tx, siteID); err != nil {
+		return nil, err
+	}
+
+	v, err := loadVehicleRow(ctx, tx, "id", vehicleID)
+	if err != nil {
+		return nil, err
+	}
+	if v == nil {
+		return nil, ErrNotFound
+	}
+	return v, nil
+}
+
+// finishOverride refreshes the changed rows, commits, recomputes the results of the
+// touched axle captures and returns them
+func (s *Service) finishOverride(ctx context.Context, tx *sql.Tx, vehicleIDs, axleIDs []string, actor string) (*OverrideResult, error) {
+	for _, id := range vehicleIDs {
+		if err := refresh(ctx, tx, id); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+
+	result := &OverrideResult{Vehicles: []Vehicle{}, AxleIDs: axleIDs, Recomputed: map[string]*Recomputed{}}
+	if s.Recompute != nil {
+		for _, id := range axleIDs {
+			result.Recomputed[id] = s.Recompute.Axle(ctx, id, actor)
+		}
+	}
+	for _, id := range vehicleIDs {
+		v, err := s.Get(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		result.Vehicles = append(result.Vehicles, *v)
+	}
+	return result, nil
+}
+
+func writeOverride(ctx context.Context, tx *sql.Tx, vehicleID, siteID, action, anprID, axleID, reason, actor string) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO public.transact_vehicle_override (vehicle_id, site_id, action, anpr_id, axle_id, reason, actor)
+		VALUES ($1::uuid, $2::uuid, $3, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, $6, $7)`,
+		vehicleID, siteID, action, anprID, axleID, reason, actor)
+	if err != nil {
+		return fmt.Errorf("insert override: %w", err)
+	}
+	return nil
+}
+
+// ListOverrides returns the override audit trail of a vehicle and of its captures, oldest first
+func (s *Service) ListOverrides(ctx context.Context, vehicleID string) ([]Override, error) {
+	v, err := s.Get(ctx, vehicleID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, COALESCE(vehicle_id::text, ''), site_id::text, action,
+		       COALESCE(anpr_id::text, ''), COALESCE(axle_id::text, ''), reason, actor, created_date
+		FROM public.transact_vehicle_override
+		WHERE vehicle_id::text = $1
+		   OR ($2 <> '' AND anpr_id::text = $2)
+		   OR ($3 <> '' AND axle_id::text = $3)
+		ORDER BY created_date`, v.ID, v.ANPRID, v.AxleID)
+	if err != nil {
+		return nil, fmt.Errorf("query overrides: %w", err)
+	}
+	defer rows.Close()
+
+	overrides := []Override{}
+	for rows.Next() {
+		var o Override
+		if err := rows.Scan(&o.ID, &o.VehicleID, &o.SiteID, &o.Action,
+			&o.ANPRID, &o.AxleID, &o.Reason, &o.Actor, &o.CreatedDate); err != nil {
+			return nil, fmt.Errorf("scan override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+	return overrides, rows.Err()
+}
@@ -0,0 +1,65 @@
+package correlation
+
+import (
+	"context"
+	"log"
+
+	"wim-service/internal/golongan"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
+)
+
+// Recomputer re-runs everything that depends on which ANPR capture an axle capture
+// is paired with: class (a manual class is kept), Golongan, overload, over-dimension
+// and violations. Overrides run it for every axle capture they touch, automatic
+// correlation for every merged pair. A nil service skips its step (disabled).
+type Recomputer struct {
+	Classes    *vehicleclass.Resolver
+	Golongan   *golongan.Service
+	Overload   *overload.Service
+	ODOL       *odol.Service
+	Violations *violation.Service
+}
+
+// Recomputed holds the results of one axle capture; a step that failed or was skipped is nil
+type Recomputed struct {
+	Class      *vehicleclass.Resolution `json:"class,omitempty"`
+	Golongan   *golongan.Assignment     `json:"golongan,omitempty"`
+	Overload   *overload.Result         `json:"overload,omitempty"`
+	Dimensions *odol.Result             `json:"dimensions,omitempty"`
+	Violations []violation.Violation    `json:"violations,omitempty"`
+}
+
+// Axle recomputes one axle capture. Failures are logged so the remaining steps still run.
+func (r *Recomputer) Axle(ctx context.Context, captureID, actor string) *Recomputed {
+	out := &Recomputed{}
+	var err error
+	if r.Classes != nil {
+		if out.Class, err = r.Classes.ResolveCapture(ctx, captureID, false); err != nil {
+			log.Printf("[CORRELATION] Warning: Class resolution failed for capture %s: %v", captureID, err)
+		}
+	}
+	if r.Golongan != nil {
+		if out.Golongan, err = r.Golongan.AssignCapture(ctx, captureID); err != nil {
+			log.Printf("[CORRELATION] Warning: Golongan re-assignment failed for capture %s: %v", captureID, err)
+		}
+	}
+	if r.Overload != nil {
+		if out.Overload, err = r.Overload.EvaluateCapture(ctx, captureID); err != nil {
+			log.Printf("[CORRELATION] Warning: Overload re-evaluation failed for capture %s: %v", captureID, err)
+		}
+	}
+	if r.ODOL != nil {
+		if out.Dimensions, err = r.ODOL.EvaluateAxleCapture(ctx, captureID); err != nil {
+			log.Printf("[CORRELATION] Warning: Over-dimension re-evaluation failed for capture %s: %v", captureID, err)
+		}
+	}
+	if r.Violations != nil {
+		if out.Violations, err = r.Violations.SyncCapture(ctx, captureID, actor); err != nil {
+			log.Printf("[CORRELATION] Warning: Violation sync failed for capture %s: %v", captureID, err)
+		}
+	}
+	return out
+}
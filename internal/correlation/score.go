@@ -0,0 +1,145 @@
+package correlation
+
+import (
+	"math"
+	"time"
+
+	"wim-service/internal/watchlist"
+)
+
+// Scoring configures how candidates are ranked
+type Scoring struct {
+	TimeWeight  float64
+	PlateWeight float64
+	LaneWeight  float64
+	MinScore    float64 // Matches scoring below this are flagged for manual review
+}
+
+// Breakdown is the combined score of a pair and its components. Plate and lane
+// scores are nil when either side did not report the value; the remaining
+// weights are then renormalized.
+type Breakdown struct {
+	Score         float64  `json:"score"`
+	TimeDiffMS    int64    `json:"time_diff_ms"`              // Signed: other - subject, after clock offset correction
+	ClockOffsetMS int64    `json:"clock_offset_ms,omitempty"` // Estimated device clock offset removed from the other capture
+	TimeScore     float64  `json:"time_score"`
+	PlateScore    *float64 `json:"plate_score"`
+	LaneScore     *float64 `json:"lane_score"`
+	TimeWeight    float64  `json:"time_weight"`
+	PlateWeight   float64  `json:"plate_weight"`
+	LaneWeight    float64  `json:"lane_weight"`
+}
+
+// Score compares two captures. The time score falls linearly from 1 (same instant)
+// to 0 (window edge); the plate score is the edit similarity of the normalized plates;
+// the lane score is 1 for the same lane and 0 otherwise.
+func (sc Scoring) Score(subject, other Candidate, window time.Duration) Breakdown {
+	diff := other.CapturedAt.Sub(subject.CapturedAt)
+	b := Breakdown{
+		TimeDiffMS:    diff.Milliseconds(),
+		ClockOffsetMS: other.ClockOffset.Milliseconds(),
+		TimeWeight:    sc.TimeWeight,
+		PlateWeight:   sc.PlateWeight,
+		LaneWeight:    sc.LaneWeight,
+	}
+	if window > 0 {
+		b.TimeScore = math.Max(0, 1-float64(absDuration(diff))/float64(window))
+	}
+
+	total, weights := sc.TimeWeight*b.TimeScore, sc.TimeWeight
+	if p1, p2 := watchlist.NormalizePlate(subject.Plate), watchlist.NormalizePlate(other.Plate); p1 != "" && p2 != "" {
+		s := PlateSimilarity(p1, p2)
+		b.PlateScore = &s
+		total += sc.PlateWeight * s
+		weights += sc.PlateWeight
+	}
+	if subject.Lane > 0 && other.Lane > 0 {
+		s := 0.0
+		if subject.Lane == other.Lane {
+			s = 1
+		}
+		b.LaneScore = &s
+		total += sc.LaneWeight * s
+		weights += sc.LaneWeight
+	}
+	if weights > 0 {
+		b.Score = round3(total / weights)
+	}
+	return b
+}
+
+// Best returns the candidate of the subject's site inside ±window with the highest
+// score, or nil. Equal scores go to the smaller time difference, then to the lower
+// id, so the result does not depend on the candidate order.
+func (sc Scoring) Best(subject Candidate, candidates []Candidate, window time.Duration) (*Candidate, Breakdown) {
+	var best *Candidate
+	var bestScore Breakdown
+	for i := range candidates {
+		c := &candidates[i]
+		diff := absDuration(c.CapturedAt.Sub(subject.CapturedAt))
+		if c.Site != subject.Site || diff > window {
+			continue
+		}
+		b := sc.Score(subject, *c, window)
+		bestDiff := time.Duration(absInt64(bestScore.TimeDiffMS)) * time.Millisecond
+		if best == nil || b.Score > bestScore.Score ||
+			(b.Score == bestScore.Score && diff < bestDiff) ||
+			(b.Score == bestScore.Score && diff == bestDiff && c.ID < best.ID) {
+			best = c
+			bestScore = b
+		}
+	}
+	return best, bestScore
+}
+
+// PlateSimilarity is 1 - edit distance / longer length of two normalized plates
+func PlateSimilarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	n := len(ra)
+	if len(rb) > n {
+		n = len(rb)
+	}
+	if n == 0 {
+		return 1
+	}
+	return round3(1 - float64(editDistance(ra, rb))/float64(n))
+}
+
+// editDistance is the Levenshtein distance of a and b
+func editDistance(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(b)]
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+func absInt64(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
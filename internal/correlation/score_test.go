@@ -0,0 +1,283 @@
+package correlation
+
+import (
+	"testing"
+	"time"
+)
+
+var (
+	t0      = time.Date(2025, 12, 1, 14, 6, 27, 0, time.UTC)
+	window  = 5 * time.Second
+	scoring = Scoring{TimeWeight: 0.5, PlateWeight: 0.3, LaneWeight: 0.2}
+)
+
+func at(offset time.Duration) time.Time {
+	return t0.Add(offset)
+}
+
+func TestBest(t *testing.T) {
+	subject := Candidate{ID: "subject", Site: "site-a", CapturedAt: t0}
+
+	tests := []struct {
+		name       string
+		subject    Candidate
+		candidates []Candidate
+		want       string // "" = no match
+	}{
+		{
+			name:    "nearest inside window",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(4 * time.Second)},
+				{ID: "c2", Site: "site-a", CapturedAt: at(-1 * time.Second)},
+				{ID: "c3", Site: "site-a", CapturedAt: at(2 * time.Second)},
+			},
+			want: "c2",
+		},
+		{
+			name:    "window edge is inside",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(window)},
+			},
+			want: "c1",
+		},
+		{
+			name:    "just outside window",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(window + time.Millisecond)},
+				{ID: "c2", Site: "site-a", CapturedAt: at(-window - time.Millisecond)},
+			},
+			want: "",
+		},
+		{
+			name:       "no candidates",
+			subject:    subject,
+			candidates: nil,
+			want:       "",
+		},
+		{
+			name:    "tie goes to lower id",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c2", Site: "site-a", CapturedAt: at(-2 * time.Second)},
+				{ID: "c1", Site: "site-a", CapturedAt: at(2 * time.Second)},
+			},
+			want: "c1",
+		},
+		{
+			name:    "tie goes to lower id in any order",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(2 * time.Second)},
+				{ID: "c2", Site: "site-a", CapturedAt: at(-2 * time.Second)},
+			},
+			want: "c1",
+		},
+		{
+			// c1: (0.5*0.6 + 0.2*1) / 0.7, c2: (0.5*1 + 0.2*0) / 0.7, both 0.714
+			name:    "equal score goes to smaller time difference",
+			subject: Candidate{ID: "subject", Site: "site-a", CapturedAt: t0, Lane: 1},
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(2 * time.Second), Lane: 1},
+				{ID: "c2", Site: "site-a", CapturedAt: t0, Lane: 2},
+			},
+			want: "c2",
+		},
+		{
+			name:    "other site is skipped even when nearer",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-b", CapturedAt: t0},
+				{ID: "c2", Site: "site-a", CapturedAt: at(3 * time.Second)},
+			},
+			want: "c2",
+		},
+		{
+			name:    "only other site",
+			subject: subject,
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-b", CapturedAt: t0},
+			},
+			want: "",
+		},
+		{
+			name:    "matching plate beats nearer time",
+			subject: Candidate{ID: "subject", Site: "site-a", CapturedAt: t0, Plate: "B 1234 CD"},
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(500 * time.Millisecond), Plate: "D 9876 XY"},
+				{ID: "c2", Site: "site-a", CapturedAt: at(2 * time.Second), Plate: "B1234CD"},
+			},
+			want: "c2",
+		},
+		{
+			name:    "same lane beats other lane at equal time",
+			subject: Candidate{ID: "subject", Site: "site-a", CapturedAt: t0, Lane: 2},
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(time.Second), Lane: 1},
+				{ID: "c2", Site: "site-a", CapturedAt: at(-time.Second), Lane: 2},
+			},
+			want: "c2",
+		},
+		{
+			name:    "lane not reported does not penalize",
+			subject: Candidate{ID: "subject", Site: "site-a", CapturedAt: t0, Lane: 2},
+			candidates: []Candidate{
+				{ID: "c1", Site: "site-a", CapturedAt: at(time.Second), Lane: 1},
+				{ID: "c2", Site: "site-a", CapturedAt: at(2 * time.Second)},
+			},
+			want: "c2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, score := scoring.Best(tt.subject, tt.candidates, window)
+			switch {
+			case tt.want == "" && got != nil:
+				t.Fatalf("Best() = %s (score %.3f), want no match", got.ID, score.Score)
+			case tt.want != "" && got == nil:
+				t.Fatalf("Best() = nil, want %s", tt.want)
+			case tt.want != "" && got.ID != tt.want:
+				t.Fatalf("Best() = %s (score %.3f), want %s", got.ID, score.Score, tt.want)
+			}
+			if got != nil {
+				want := scoring.Score(tt.subject, *got, window)
+				if score.Score != want.Score || score.TimeDiffMS != want.TimeDiffMS {
+					t.Errorf("Best() score = %v (%d ms), want %v (%d ms)", score.Score, score.TimeDiffMS, want.Score, want.TimeDiffMS)
+				}
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	f := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		subject Candidate
+		other   Candidate
+		want    float64
+		time    float64
+		plate   *float64
+		lane    *float64
+	}{
+		{
+			name:    "time only",
+			subject: Candidate{CapturedAt: t0},
+			other:   Candidate{CapturedAt: at(2500 * time.Millisecond)},
+			want:    0.5,
+			time:    0.5,
+		},
+		{
+			name:    "all components agree",
+			subject: Candidate{CapturedAt: t0, Plate: "B 1234 CD", Lane: 1},
+			other:   Candidate{CapturedAt: t0, Plate: "b1234cd", Lane: 1},
+			want:    1,
+			time:    1,
+			plate:   f(1),
+			lane:    f(1),
+		},
+		{
+			name:    "plate adds its weight",
+			subject: Candidate{CapturedAt: t0, Plate: "B1234CD"},
+			other:   Candidate{CapturedAt: at(time.Second), Plate: "B1234CD"},
+			want:    0.875, // (0.5*0.8 + 0.3*1) / 0.8
+			time:    0.8,
+			plate:   f(1),
+		},
+		{
+			name:    "one misread plate character",
+			subject: Candidate{CapturedAt: t0, Plate: "B1234CD"},
+			other:   Candidate{CapturedAt: t0, Plate: "B1234CO"},
+			want:    0.946, // (0.5 + 0.3*0.857) / 0.8
+			time:    1,
+			plate:   f(0.857),
+		},
+		{
+			name:    "plate missing on one side is left out",
+			subject: Candidate{CapturedAt: t0, Plate: "B1234CD", Lane: 1},
+			other:   Candidate{CapturedAt: at(-time.Second), Lane: 1},
+			want:    0.857, // (0.5*0.8 + 0.2) / 0.7
+			time:    0.8,
+			lane:    f(1),
+		},
+		{
+			name:    "other lane scores zero",
+			subject: Candidate{CapturedAt: t0, Plate: "B1234CD", Lane: 1},
+			other:   Candidate{CapturedAt: t0, Plate: "B1234CD", Lane: 2},
+			want:    0.8, // (0.5 + 0.3) / 1
+			time:    1,
+			plate:   f(1),
+			lane:    f(0),
+		},
+		{
+			name:    "outside window",
+			subject: Candidate{CapturedAt: t0},
+			other:   Candidate{CapturedAt: at(2 * window)},
+			want:    0,
+			time:    0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := scoring.Score(tt.subject, tt.other, window)
+			if b.Score != tt.want {
+				t.Errorf("Score = %v, want %v", b.Score, tt.want)
+			}
+			if b.TimeScore != tt.time {
+				t.Errorf("TimeScore = %v, want %v", b.TimeScore, tt.time)
+			}
+			if !equalScore(b.PlateScore, tt.plate) {
+				t.Errorf("PlateScore = %v, want %v", deref(b.PlateScore), deref(tt.plate))
+			}
+			if !equalScore(b.LaneScore, tt.lane) {
+				t.Errorf("LaneScore = %v, want %v", deref(b.LaneScore), deref(tt.lane))
+			}
+			if want := tt.other.CapturedAt.Sub(tt.subject.CapturedAt).Milliseconds(); b.TimeDiffMS != want {
+				t.Errorf("TimeDiffMS = %d, want %d", b.TimeDiffMS, want)
+			}
+		})
+	}
+}
+
+func TestPlateSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"B1234CD", "B1234CD", 1},
+		{"", "", 1},
+		{"B1234CD", "B1234CO", 0.857},
+		{"B1234CD", "B123CD", 0.857},
+		{"B1234CD", "B1234XY", 0.714},
+		{"B1234CD", "D9876XY", 0},
+		{"B1234CD", "", 0},
+	}
+
+	for _, tt := range tests {
+		if got := PlateSimilarity(tt.a, tt.b); got != tt.want {
+			t.Errorf("PlateSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if got := PlateSimilarity(tt.b, tt.a); got != tt.want {
+			t.Errorf("PlateSimilarity(%q, %q) = %v, want %v", tt.b, tt.a, got, tt.want)
+		}
+	}
+}
+
+func equalScore(a, b *float64) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+func deref(v *float64) any {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
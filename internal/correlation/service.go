@@ -0,0 +1,401 @@
+package correlation
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"wim-service/internal/violation"
+)
+
+var (
+	// ErrNotFound is returned when a capture or vehicle does not exist
+	ErrNotFound = errors.New("correlation: not found")
+	// ErrInvalid is returned when an override request fails validation
+	ErrInvalid = errors.New("correlation: invalid request")
+)
+
+// Service correlates ANPR and axle captures into transact_vehicle
+type Service struct {
+	DB        *sql.DB
+	Window    time.Duration // Maximum capture time difference of a match
+	Scoring   Scoring       // Candidate ranking and review threshold
+	Drift     Drift         // Device clock offset estimation and correction
+	Recompute *Recomputer   // Optional; results of axle captures whose pairing changed
+}
+
+// NewService creates a new correlation service
+func NewService(db *sql.DB, window time.Duration, scoring Scoring, drift Drift) *Service {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Service{DB: db, Window: window, Scoring: scoring, Drift: drift}
+}
+
+// side describes one device of the pair
+type side struct {
+	table      string // Capture table of this side
+	column     string // transact_vehicle column referencing it
+	only       string // Status of an unmatched row of this side
+	otherTable string
+	otherCol   string
+	otherOnly  string
+}
+
+var (
+	anprSide = side{
+		table: "public.transact_anpr_capture", column: "anpr_id", only: StatusANPROnly,
+		otherTable: "public.transact_axle_capture", otherCol: "axle_id", otherOnly: StatusAxleOnly,
+	}
+	axleSide = side{
+		table: "public.transact_axle_capture", column: "axle_id", only: StatusAxleOnly,
+		otherTable: "public.transact_anpr_capture", otherCol: "anpr_id", otherOnly: StatusANPROnly,
+	}
+)
+
+// CorrelateANPR matches a stored ANPR capture to the nearest unmatched axle capture
+// of the same site, or records it as ANPR_ONLY. The results of a merged axle capture
+// are recomputed.
+func (s *Service) CorrelateANPR(ctx context.Context, anprID string) (*Vehicle, error) {
+	v, _, err := s.correlate(ctx, anprSide, anprID, false, true)
+	return v, err
+}
+
+// CorrelateAxle matches a stored axle capture to the nearest unmatched ANPR capture
+// of the same site, or records it as AXLE_ONLY. The caller evaluates the axle capture
+// afterwards (axle pipeline), so nothing is recomputed here.
+func (s *Service) CorrelateAxle(ctx context.Context, axleID string) (*Vehicle, error) {
+	v, _, err := s.correlate(ctx, axleSide, axleID, false, false)
+	return v, err
+}
+
+// correlate matches one capture and reports whether it was merged with a partner;
+// late merges (reconciliation job) are logged in transact_vehicle_late_match. With
+// recompute, the axle capture of a merged pair is recomputed (see Recomputer).
+func (s *Service) correlate(ctx context.Context, sd side, captureID string, late, recompute bool) (*Vehicle, bool, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var siteID string
+	var capturedAt sql.NullTime
+	subject := Candidate{ID: captureID}
+	err = tx.QueryRowContext(ctx, `
+		SELECT COALESCE(site_id::text, ''), captured_at, COALESCE(plate_no, ''), COALESCE(lane_no, 0), COALESCE(camera_id, '')
+		FROM `+sd.table+`
+		WHERE id::text = $1 AND is_deleted = false`, captureID).Scan(&siteID, &capturedAt, &subject.Plate, &subject.Lane, &subject.Camera)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, false, ErrNotFound
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("load capture: %w", err)
+	}
+	if siteID == "" {
+		return nil, false, fmt.Errorf("capture %s has no site", captureID)
+	}
+	subject.Site = siteID
+
+	if err := lockSite(ctx, tx, siteID); err != nil {
+		return nil, false, err
+	}
+
+	var vehicleID, status string
+	var noPartner bool
+	err = tx.QueryRowContext(ctx, `
+		SELECT id::text, correlation_status, no_partner FROM public.transact_vehicle WHERE `+sd.column+`::text = $1`,
+		captureID).Scan(&vehicleID, &status, &noPartner)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, false, fmt.Errorf("load vehicle: %w", err)
+	}
+
+	// Capture yang sudah MATCHED (mis. XML diproses ulang) atau ditandai tanpa pasangan hanya di-refresh
+	var partner *Candidate
+	var score Breakdown
+	if status != StatusMatched && !noPartner && capturedAt.Valid {
+		subject.CapturedAt = capturedAt.Time
+		offsets, err := s.appliedOffsets(ctx, tx, siteID)
+		if err != nil {
+			return nil, false, err
+		}
+		candidates, err := s.candidates(ctx, tx, sd, siteID, captureID, capturedAt.Time, s.Window+offsets.widest())
+		if err != nil {
+			return nil, false, err
+		}
+		offsets.correct(sd, subject, candidates)
+		partner, score = s.Scoring.Best(subject, candidates, s.Window)
+	}
+
+	switch {
+	case partner != nil:
+		vehicleID, err = s.link(ctx, tx, sd, siteID, captureID, partner.ID, score)
+	case vehicleID == "":
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO public.transact_vehicle (site_id, `+sd.column+`, correlation_status)
+			VALUES ($1::uuid, $2::uuid, $3)
+			RETURNING id::text`, siteID, captureID, sd.only).Scan(&vehicleID)
+		if err != nil {
+			err = fmt.Errorf("insert vehicle: %w", err)
+		}
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := refresh(ctx, tx, vehicleID); err != nil {
+		return nil, false, err
+	}
+	if partner != nil && s.Drift.Enabled && score.Score >= s.Drift.MinScore {
+		if err := s.updateDrift(ctx, tx, vehicleID); err != nil {
+			return nil, false, err
+		}
+	}
+	if late && partner != nil {
+		if err := logLateMatch(ctx, tx, vehicleID); err != nil {
+			return nil, false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("commit: %w", err)
+	}
+	v, err := s.Get(ctx, vehicleID)
+	if err == nil && partner != nil && recompute && s.Recompute != nil {
+		s.Recompute.Axle(ctx, v.AxleID, violation.SystemActor)
+	}
+	return v, partner != nil, err
+}
+
+// candidates returns the captures of the other device at the same site inside the
+// window that are not matched yet, skipping "no partner" captures and pairs a user unlinked
+func (s *Service) candidates(ctx context.Context, tx *sql.Tx, sd side, siteID, subjectID string, at time.Time, window time.Duration) ([]Candidate, error) {
+	query := `
+		SELECT o.id::text, o.site_id::text, o.captured_at, COALESCE(o.plate_no, ''), COALESCE(o.lane_no, 0), COALESCE(o.camera_id, '')
+		FROM ` + sd.otherTable + ` o
+		LEFT JOIN public.transact_vehicle v ON v.` + sd.otherCol + ` = o.id
+		WHERE o.site_id::text = $1
+		  AND o.is_deleted = false
+		  AND o.captured_at BETWEEN $2::timestamptz - make_interval(secs => $3) AND $2::timestamptz + make_interval(secs => $3)
+		  AND (v.id IS NULL OR (v.correlation_status = $4 AND v.no_partner = false))
+		  AND NOT EXISTS (
+			SELECT 1 FROM public.transact_vehicle_override r
+			WHERE r.action = 'UNLINK' AND r.` + sd.column + `::text = $5 AND r.` + sd.otherCol + ` = o.id)`
+
+	rows, err := tx.QueryContext(ctx, query, siteID, at, window.Seconds(), sd.otherOnly, subjectID)
+	if err != nil {
+		return nil, fmt.Errorf("query candidates: %w", err)
+	}
+	defer rows.Close()
+
+	var candidates []Candidate
+	for rows.Next() {
+		var c Candidate
+		if err := rows.Scan(&c.ID, &c.Site, &c.CapturedAt, &c.Plate, &c.Lane, &c.Camera); err != nil {
+			return nil, fmt.Errorf("scan candidate: %w", err)
+		}
+		candidates = append(candidates, c)
+	}
+	return candidates, rows.Err()
+}
+
+// link merges the capture with its partner into one MATCHED row with its score. The
+// partner's unmatched row is kept (its id stays stable); the capture's own unmatched row is removed.
+func (s *Service) link(ctx context.Context, tx *sql.Tx, sd side, siteID, captureID, partnerID string, score Breakdown) (string, error) {
+	breakdown, err := json.Marshal(score)
+	if err != nil {
+		return "", fmt.Errorf("marshal score: %w", err)
+	}
+	review := score.Score < s.Scoring.MinScore
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM public.transact_vehicle WHERE `+sd.column+`::text = $1`, captureID); err != nil {
+		return "", fmt.Errorf("remove unmatched row: %w", err)
+	}
+
+	var vehicleID string
+	err = tx.QueryRowContext(ctx, `
+		UPDATE public.transact_vehicle SET
+			`+sd.column+` = $2::uuid,
+			correlation_status = $3,
+			match_score = $4,
+			score_breakdown = $5::jsonb,
+			review_required = $6,
+			updated_date = now()
+		WHERE `+sd.otherCol+`::text = $1
+		RETURNING id::text`, partnerID, captureID, StatusMatched, score.Score, string(breakdown), review).Scan(&vehicleID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = tx.QueryRowContext(ctx, `
+			INSERT INTO public.transact_vehicle
+				(site_id, `+sd.column+`, `+sd.otherCol+`, correlation_status, match_score, score_breakdown, review_required)
+			VALUES ($1::uuid, $2::uuid, $3::uuid, $4, $5, $6::jsonb, $7)
+			RETURNING id::text`, siteID, captureID, partnerID, StatusMatched, score.Score, string(breakdown), review).Scan(&vehicleID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("link vehicle: %w", err)
+	}
+	return vehicleID, nil
+}
+
+// lockSite serializes correlation per site: the ANPR and AXLE watchers, the
+// reconciliation job and manual overrides run concurrently
+func lockSite(ctx context.Context, tx *sql.Tx, siteID string) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext('correlation:' || $1))`, siteID); err != nil {
+		return fmt.Errorf("lock site: %w", err)
+	}
+	return nil
+}
+
+// refresh recomputes the values copied from the captures into the vehicle row
+func refresh(ctx context.Context, tx *sql.Tx, vehicleID string) error {
+	_, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_vehicle v SET
+			plate_no = COALESCE(NULLIF(s.plate_no, ''), $2),
+			anpr_captured_at = s.anpr_at,
+			axle_captured_at = s.axle_at,
+			first_detected_at = LEAST(s.anpr_at, s.axle_at),
+			time_diff_seconds = ABS(EXTRACT(EPOCH FROM (s.axle_at - s.anpr_at)))::numeric(10, 3),
+			offset_ms = ROUND(EXTRACT(EPOCH FROM (s.axle_at - s.anpr_at)) * 1000)::int4,
+			toll_golongan = s.toll_golongan,
+			correlated_at = now(),
+			updated_date = now()
+		FROM (
+			SELECT vv.id, an.plate_no, an.captured_at AS anpr_at, ax.captured_at AS axle_at, ax.toll_golongan
+			FROM public.transact_vehicle vv
+			LEFT JOIN public.transact_anpr_capture an ON an.id = vv.anpr_id
+			LEFT JOIN public.transact_axle_capture ax ON ax.id = vv.axle_id
+			WHERE vv.id::text = $1
+		) s
+		WHERE v.id = s.id`, vehicleID, UnknownPlate)
+	if err != nil {
+		return fmt.Errorf("refresh vehicle: %w", err)
+	}
+	return nil
+}
+
+// ===== Queries =====
+
+const vehicleColumns = `
+	v.id, v.site_id::text, COALESCE(ms.code, ''), COALESCE(v.anpr_id::text, ''), COALESCE(v.axle_id::text, ''),
+	v.plate_no, v.correlation_status, v.anpr_captured_at, v.axle_captured_at, v.first_detected_at,
+	v.time_diff_seconds, v.offset_ms, COALESCE(v.toll_golongan, 0),
+	v.match_score, v.score_breakdown, v.review_required, v.no_partner,
+	COALESCE(v.override_action, ''), COALESCE(v.override_by, ''), COALESCE(v.override_reason, ''), v.override_at,
+	v.correlated_at,
+	v.created_date, v.updated_date`
+
+const vehicleFrom = `
+	FROM public.transact_vehicle v
+	LEFT JOIN public.master_site ms ON ms.id = v.site_id`
+
+func scanVehicle(row interface{ Scan(...any) error }) (*Vehicle, error) {
+	var v Vehicle
+	var anprAt, axleAt, firstAt, overrideAt, correlatedAt sql.NullTime
+	var diff, score sql.NullFloat64
+	var offset sql.NullInt64
+	var breakdown []byte
+	err := row.Scan(&v.ID, &v.SiteID, &v.SiteCode, &v.ANPRID, &v.AxleID,
+		&v.PlateNo, &v.Status, &anprAt, &axleAt, &firstAt,
+		&diff, &offset, &v.TollGolongan,
+		&score, &breakdown, &v.ReviewRequired, &v.NoPartner,
+		&v.OverrideAction, &v.OverrideBy, &v.OverrideReason, &overrideAt,
+		&correlatedAt,
+		&v.CreatedDate, &v.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	v.ANPRCapturedAt = timePtr(anprAt)
+	v.AxleCapturedAt = timePtr(axleAt)
+	v.FirstDetectedAt = timePtr(firstAt)
+	v.OverrideAt = timePtr(overrideAt)
+	v.CorrelatedAt = timePtr(correlatedAt)
+	if diff.Valid {
+		v.TimeDiffSeconds = &diff.Float64
+	}
+	if offset.Valid {
+		ms := int(offset.Int64)
+		v.OffsetMS = &ms
+	}
+	if score.Valid {
+		v.MatchScore = &score.Float64
+	}
+	if breakdown != nil {
+		v.ScoreBreakdown = json.RawMessage(breakdown)
+	}
+	return &v, nil
+}
+
+// List returns correlated vehicles, newest first
+func (s *Service) List(ctx context.Context, filter Filter) ([]Vehicle, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := `SELECT` + vehicleColumns + vehicleFrom + `
+		WHERE ($1 = '' OR v.site_id::text = $1)
+		  AND ($2 = '' OR v.correlation_status = $2)
+		  AND ($3 = '' OR v.plate_no ILIKE '%' || $3 || '%')
+		  AND ($4::timestamptz IS NULL OR v.first_detected_at >= $4)
+		  AND ($5::timestamptz IS NULL OR v.first_detected_at < $5)
+		  AND ($6::bool IS NULL OR v.review_required = $6)
+		ORDER BY v.first_detected_at DESC NULLS LAST
+		LIMIT $7 OFFSET $8`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.SiteID, filter.Status, filter.PlateNo,
+		nullTime(filter.From), nullTime(filter.To), nullBool(filter.Review), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query vehicles: %w", err)
+	}
+	defer rows.Close()
+
+	vehicles := []Vehicle{}
+	for rows.Next() {
+		v, err := scanVehicle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan vehicle: %w", err)
+		}
+		vehicles = append(vehicles, *v)
+	}
+	return vehicles, rows.Err()
+}
+
+// Get returns one correlated vehicle
+func (s *Service) Get(ctx context.Context, id string) (*Vehicle, error) {
+	query := `SELECT` + vehicleColumns + vehicleFrom + `
+		WHERE v.id::text = $1`
+
+	v, err := scanVehicle(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get vehicle: %w", err)
+	}
+	return v, nil
+}
+
+func timePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
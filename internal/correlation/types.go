@@ -0,0 +1,139 @@
+package correlation
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Correlation status (transact_vehicle.correlation_status)
+const (
+	StatusMatched  = "MATCHED"   // ANPR and axle capture of the same vehicle
+	StatusANPROnly = "ANPR_ONLY" // ANPR capture without axle partner (yet)
+	StatusAxleOnly = "AXLE_ONLY" // Axle capture without ANPR partner (yet)
+)
+
+// UnknownPlate is the plate_no of vehicles without an ANPR read
+const UnknownPlate = "UNKNOWN"
+
+// DefaultWindow is the maximum capture time difference of a match
+const DefaultWindow = 5 * time.Second
+
+// Vehicle is one row of transact_vehicle
+type Vehicle struct {
+	ID              string     `json:"id"`
+	SiteID          string     `json:"site_id"`
+	SiteCode        string     `json:"site_code"`
+	ANPRID          string     `json:"anpr_id"`
+	AxleID          string     `json:"axle_id"`
+	PlateNo         string     `json:"plate_no"`
+	Status          string     `json:"correlation_status"`
+	ANPRCapturedAt  *time.Time `json:"anpr_captured_at"`
+	AxleCapturedAt  *time.Time `json:"axle_captured_at"`
+	FirstDetectedAt *time.Time `json:"first_detected_at"`
+	TimeDiffSeconds *float64   `json:"time_diff_seconds"`
+	OffsetMS        *int       `json:"offset_ms"` // axle - anpr
+	TollGolongan    int        `json:"toll_golongan"`
+
+	// Match scoring (MATCHED only); low scores need manual review
+	MatchScore     *float64        `json:"match_score"`
+	ScoreBreakdown json.RawMessage `json:"score_breakdown"`
+	ReviewRequired bool            `json:"review_required"`
+
+	// Manual override (supervisor); no_partner rows are skipped by automatic correlation
+	NoPartner      bool       `json:"no_partner"`
+	OverrideAction string     `json:"override_action,omitempty"`
+	OverrideBy     string     `json:"override_by,omitempty"`
+	OverrideReason string     `json:"override_reason,omitempty"`
+	OverrideAt     *time.Time `json:"override_at"`
+
+	CorrelatedAt *time.Time `json:"correlated_at"`
+	CreatedDate  time.Time  `json:"created_date"`
+	UpdatedDate  time.Time  `json:"updated_date"`
+}
+
+// Manual override actions (transact_vehicle_override.action)
+const (
+	OverrideLink      = "LINK"       // ANPR and axle capture linked by a user
+	OverrideUnlink    = "UNLINK"     // Wrong match split; the pair is not matched automatically again
+	OverrideNoPartner = "NO_PARTNER" // Unmatched capture has no partner; skipped by automatic correlation
+)
+
+// Override is one entry of the manual override audit trail
+type Override struct {
+	ID          string    `json:"id"`
+	VehicleID   string    `json:"vehicle_id"`
+	SiteID      string    `json:"site_id"`
+	Action      string    `json:"action"`
+	ANPRID      string    `json:"anpr_id"`
+	AxleID      string    `json:"axle_id"`
+	Reason      string    `json:"reason"`
+	Actor       string    `json:"actor"`
+	CreatedDate time.Time `json:"created_date"`
+}
+
+// OverrideResult is the outcome of a manual override: the vehicle rows after the
+// change and the axle captures whose downstream results must be recomputed
+type OverrideResult struct {
+	Vehicles   []Vehicle              `json:"vehicles"`
+	AxleIDs    []string               `json:"axle_ids"`
+	Recomputed map[string]*Recomputed `json:"recomputed"` // By axle capture id
+}
+
+// Candidate is a capture that may belong to the same vehicle as the subject capture
+type Candidate struct {
+	ID         string
+	Site       string // site_id; only captures of the same site are paired
+	CapturedAt time.Time
+	Plate      string // ANPR read, or the axle camera's own read (anpr/text)
+	Lane       int    // 0 = not reported
+	Camera     string // camera_id of the capture
+
+	// Estimated clock offset already removed from CapturedAt (auto-applied drift)
+	ClockOffset time.Duration
+}
+
+// Filter narrows List
+type Filter struct {
+	SiteID  string
+	Status  string
+	PlateNo string
+	Review  *bool
+	From    *time.Time
+	To      *time.Time
+	Limit   int
+	Offset  int
+}
+
+// LateMatch is a pair merged by the late-arrival reconciliation job
+type LateMatch struct {
+	ID                   string    `json:"id"`
+	VehicleID            string    `json:"vehicle_id"`
+	SiteID               string    `json:"site_id"`
+	SiteCode             string    `json:"site_code"`
+	ANPRID               string    `json:"anpr_id"`
+	AxleID               string    `json:"axle_id"`
+	PlateNo              string    `json:"plate_no"`
+	MatchScore           *float64  `json:"match_score"`
+	TimeDiffSeconds      *float64  `json:"time_diff_seconds"`
+	RecoveryDelaySeconds *float64  `json:"recovery_delay_seconds"` // merged_at - first_detected_at
+	MergedAt             time.Time `json:"merged_at"`
+}
+
+// LateMatchCount is the number of late recoveries of one site on one day
+type LateMatchCount struct {
+	SiteID          string    `json:"site_id"`
+	SiteCode        string    `json:"site_code"`
+	Date            time.Time `json:"date"`
+	Recovered       int64     `json:"recovered"`
+	AvgDelaySeconds float64   `json:"avg_delay_seconds"`
+	MaxDelaySeconds float64   `json:"max_delay_seconds"`
+}
+
+// LateMatchFilter narrows ListLateMatches and CountLateMatches (by merged_at)
+type LateMatchFilter struct {
+	SiteID string
+	From   *time.Time
+	To     *time.Time
+	Limit  int
+	Offset int
+}
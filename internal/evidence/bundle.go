@@ -0,0 +1,597 @@
+package evidence
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// Bundle file layout
+const (
+	BundleManifestPath  = "manifest.json"
+	BundleSignaturePath = "manifest.sig"
+	BundleRecordPath    = "record.json"
+	BundlePublicKeyPath = "public_key.pem"
+	bundleFilesDir      = "files/"
+	bundleVersion       = 1
+)
+
+// Limits on a bundle submitted for verification, so a crafted zip cannot exhaust memory
+const (
+	MaxBundleSize         = 64 << 20 // Zip file
+	maxBundleEntries      = 64
+	maxBundleEntrySize    = 64 << 20 // Uncompressed, per entry
+	maxBundleUncompressed = 256 << 20
+)
+
+// ErrIntegrity is returned when an object no longer matches the digest recorded at ingestion
+var ErrIntegrity = errors.New("evidence: object does not match recorded digest")
+
+// Manifest describes every file in a bundle; its exact bytes are what gets signed
+type Manifest struct {
+	Version     int            `json:"version"`
+	BundleID    string         `json:"bundle_id"`
+	SiteID      string         `json:"site_id"`
+	SiteCode    string         `json:"site_code"`
+	SubjectType string         `json:"subject_type"` // ANPR / AXLE
+	SubjectID   string         `json:"subject_id"`
+	GeneratedAt time.Time      `json:"generated_at"`
+	GeneratedBy string         `json:"generated_by"`
+	Algorithm   string         `json:"algorithm"`
+	KeyID       string         `json:"key_id"`
+	Files       []ManifestFile `json:"files"`
+}
+
+// ManifestFile is one entry of the manifest
+type ManifestFile struct {
+	Path           string `json:"path"`
+	Kind           string `json:"kind"`
+	SHA256         string `json:"sha256"`
+	SizeBytes      int64  `json:"size_bytes"`
+	SourceObject   string `json:"source_object,omitempty"`   // bucket/object in MinIO
+	RecordedSHA256 string `json:"recorded_sha256,omitempty"` // digest stored at ingestion
+}
+
+// Bundle is a generated, signed bundle stored in MinIO
+type Bundle struct {
+	ID          string    `json:"id"`
+	SubjectType string    `json:"subject_type"`
+	SubjectID   string    `json:"subject_id"`
+	SiteID      string    `json:"site_id"`
+	Bucket      string    `json:"bucket"`
+	Object      string    `json:"object"`
+	SHA256      string    `json:"sha256"`
+	SizeBytes   int64     `json:"size_bytes"`
+	KeyID       string    `json:"key_id"`
+	Signature   string    `json:"signature"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedDate time.Time `json:"created_date"`
+}
+
+// FileCheck is the verification outcome of one bundled file
+type FileCheck struct {
+	Path           string `json:"path"`
+	ExpectedSHA256 string `json:"expected_sha256"`
+	ActualSHA256   string `json:"actual_sha256"`
+	OK             bool   `json:"ok"`
+}
+
+// BundleVerification is the outcome of verifying a bundle
+type BundleVerification struct {
+	Valid          bool        `json:"valid"`
+	SignatureValid bool        `json:"signature_valid"`
+	KeyID          string      `json:"key_id"`
+	KeyTrusted     bool        `json:"key_trusted"` // false when only the embedded key was used
+	Manifest       *Manifest   `json:"manifest,omitempty"`
+	Files          []FileCheck `json:"files"`
+	Errors         []string    `json:"errors,omitempty"`
+}
+
+// SigningKey is a site public key published for offline verification
+type SigningKey struct {
+	KeyID        string    `json:"key_id"`
+	SiteID       string    `json:"site_id"`
+	Algorithm    string    `json:"algorithm"`
+	PublicKeyPEM string    `json:"public_key_pem"`
+	IsActive     bool      `json:"is_active"`
+	CreatedDate  time.Time `json:"created_date"`
+}
+
+// bundleSource is an object copied into a bundle
+type bundleSource struct {
+	Kind   string
+	Store  string // capture type whose MinIO client holds the object
+	Object Object
+}
+
+// bundleSubject is everything that goes into one bundle
+type bundleSubject struct {
+	Type    string
+	ID      string
+	SiteID  string
+	Record  json.RawMessage
+	Sources []bundleSource
+}
+
+// ConfigureBundles sets the site identity, signing key and the MinIO bucket for bundles
+func (s *Service) ConfigureBundles(siteID, siteCode string, signer *Signer, endpoint, accessKey, secretKey, bucket string, useSSL bool) error {
+	mc, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create minio client: %w", err)
+	}
+
+	s.SiteID = siteID
+	s.SiteCode = siteCode
+	s.Signer = signer
+	s.BundleStore = mc
+	s.BundleBucket = bucket
+	return nil
+}
+
+// PublishSigningKey records the site's public key so bundles can be verified offline
+func (s *Service) PublishSigningKey(ctx context.Context) error {
+	if s.Signer == nil {
+		return fmt.Errorf("no signing key configured")
+	}
+
+	query := `
+		INSERT INTO public.master_site_signing_key (site_id, key_id, algorithm, public_key_pem)
+		VALUES (NULLIF($1, '')::uuid, $2, 'Ed25519', $3)
+		ON CONFLICT (key_id) DO UPDATE SET is_active = true, updated_date = now()`
+
+	if _, err := s.DB.ExecContext(ctx, query, s.SiteID, s.Signer.KeyID, string(s.Signer.PublicKeyPEM())); err != nil {
+		return fmt.Errorf("publish signing key: %w", err)
+	}
+	return nil
+}
+
+// ListSigningKeys returns all published site public keys
+func (s *Service) ListSigningKeys(ctx context.Context) ([]SigningKey, error) {
+	query := `
+		SELECT key_id, COALESCE(site_id::text, ''), algorithm, public_key_pem,
+		       COALESCE(is_active, true), created_date
+		FROM public.master_site_signing_key
+		WHERE is_deleted = false
+		ORDER BY created_date DESC`
+
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query signing keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []SigningKey{}
+	for rows.Next() {
+		var k SigningKey
+		if err := rows.Scan(&k.KeyID, &k.SiteID, &k.Algorithm, &k.PublicKeyPEM, &k.IsActive, &k.CreatedDate); err != nil {
+			return nil, fmt.Errorf("scan signing key: %w", err)
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// publishedKey is a row of master_site_signing_key
+type publishedKey struct {
+	SiteID   string
+	IsActive bool
+	Key      ed25519.PublicKey
+}
+
+// trustedKey looks up a published public key by id; nil when it is not published
+func (s *Service) trustedKey(ctx context.Context, keyID string) (*publishedKey, error) {
+	var k publishedKey
+	var pemText string
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(site_id::text, ''), COALESCE(is_active, true), public_key_pem
+		FROM public.master_site_signing_key
+		WHERE key_id = $1 AND is_deleted = false`,
+		keyID).Scan(&k.SiteID, &k.IsActive, &pemText)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("query signing key: %w", err)
+	}
+	if k.Key, err = ParsePublicKeyPEM([]byte(pemText)); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// BuildCaptureBundle packages a capture's objects, its DB record and a signed manifest
+// into a zip stored in MinIO
+func (s *Service) BuildCaptureBundle(ctx context.Context, captureType, id, generatedBy string) (*Bundle, error) {
+	capture, err := s.GetCapture(ctx, captureType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	record, err := s.captureRecordJSON(ctx, captureType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	subject := bundleSubject{
+		Type:   captureType,
+		ID:     id,
+		SiteID: capture.SiteID,
+		Record: record,
+	}
+	for _, obj := range capture.Objects {
+		subject.Sources = append(subject.Sources, bundleSource{Kind: obj.Kind, Store: captureType, Object: obj})
+	}
+
+	return s.buildBundle(ctx, subject, generatedBy)
+}
+
+func (s *Service) captureRecordJSON(ctx context.Context, captureType, id string) (json.RawMessage, error) {
+	table := "transact_anpr_capture"
+	if captureType == CaptureAXLE {
+		table = "transact_axle_capture"
+	}
+
+	var record []byte
+	query := fmt.Sprintf(`SELECT row_to_json(t) FROM public.%s t WHERE t.id::text = $1`, table)
+	if err := s.DB.QueryRowContext(ctx, query, id).Scan(&record); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query %s record: %w", table, err)
+	}
+	return record, nil
+}
+
+func (s *Service) buildBundle(ctx context.Context, subject bundleSubject, generatedBy string) (*Bundle, error) {
+	if s.Signer == nil || s.BundleStore == nil {
+		return nil, fmt.Errorf("evidence bundles are not configured")
+	}
+
+	siteID := subject.SiteID
+	if siteID == "" {
+		siteID = s.SiteID
+	}
+
+	manifest := Manifest{
+		Version:     bundleVersion,
+		BundleID:    uuid.New().String(),
+		SiteID:      siteID,
+		SiteCode:    s.SiteCode,
+		SubjectType: subject.Type,
+		SubjectID:   subject.ID,
+		GeneratedAt: time.Now().UTC(),
+		GeneratedBy: generatedBy,
+		Algorithm:   "Ed25519",
+		KeyID:       s.Signer.KeyID,
+	}
+
+	// Collect file contents first; the manifest must list every digest before signing
+	files := make(map[string][]byte)
+	for i, src := range subject.Sources {
+		mc, ok := s.Stores[src.Store]
+		if !ok {
+			return nil, fmt.Errorf("no object store configured for %s", src.Store)
+		}
+
+		data, err := readObject(ctx, mc, src.Object)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", src.Object.Name, err)
+		}
+
+		sum := sha256.Sum256(data)
+		digest := hex.EncodeToString(sum[:])
+		if src.Object.SHA256 != "" && !strings.EqualFold(strings.TrimSpace(src.Object.SHA256), digest) {
+			return nil, fmt.Errorf("%w: %s/%s", ErrIntegrity, src.Object.Bucket, src.Object.Name)
+		}
+
+		p := fmt.Sprintf("%s%02d-%s-%s", bundleFilesDir, i+1, src.Kind, path.Base(src.Object.Name))
+		files[p] = data
+		manifest.Files = append(manifest.Files, ManifestFile{
+			Path:           p,
+			Kind:           src.Kind,
+			SHA256:         digest,
+			SizeBytes:      int64(len(data)),
+			SourceObject:   src.Object.Bucket + "/" + src.Object.Name,
+			RecordedSHA256: strings.TrimSpace(src.Object.SHA256),
+		})
+	}
+
+	recordSum := sha256.Sum256(subject.Record)
+	manifest.Files = append(manifest.Files, ManifestFile{
+		Path:      BundleRecordPath,
+		Kind:      "record",
+		SHA256:    hex.EncodeToString(recordSum[:]),
+		SizeBytes: int64(len(subject.Record)),
+	})
+
+	manifestBytes, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshal manifest: %w", err)
+	}
+	signature := base64.StdEncoding.EncodeToString(s.Signer.Sign(manifestBytes))
+
+	// Write the zip
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	entries := []struct {
+		name string
+		data []byte
+	}{
+		{BundleManifestPath, manifestBytes},
+		{BundleSignaturePath, []byte(signature)},
+		{BundlePublicKeyPath, s.Signer.PublicKeyPEM()},
+		{BundleRecordPath, subject.Record},
+	}
+	for _, f := range manifest.Files {
+		if data, ok := files[f.Path]; ok {
+			entries = append(entries, struct {
+				name string
+				data []byte
+			}{f.Path, data})
+		}
+	}
+	for _, e := range entries {
+		w, err := zw.CreateHeader(&zip.FileHeader{Name: e.name, Method: zip.Deflate, Modified: manifest.GeneratedAt})
+		if err != nil {
+			return nil, fmt.Errorf("zip %s: %w", e.name, err)
+		}
+		if _, err := w.Write(e.data); err != nil {
+			return nil, fmt.Errorf("zip %s: %w", e.name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("close zip: %w", err)
+	}
+
+	zipSum := sha256.Sum256(buf.Bytes())
+	bundle := &Bundle{
+		ID:          manifest.BundleID,
+		SubjectType: subject.Type,
+		SubjectID:   subject.ID,
+		SiteID:      siteID,
+		Bucket:      s.BundleBucket,
+		Object: fmt.Sprintf("bundles/%s/%s/%s-%s.zip",
+			strings.ToLower(subject.Type), manifest.GeneratedAt.Format("02012006"), subject.ID, manifest.BundleID),
+		SHA256:      hex.EncodeToString(zipSum[:]),
+		SizeBytes:   int64(buf.Len()),
+		KeyID:       manifest.KeyID,
+		Signature:   signature,
+		CreatedBy:   generatedBy,
+		CreatedDate: manifest.GeneratedAt,
+	}
+
+	_, err = s.BundleStore.PutObject(ctx, bundle.Bucket, bundle.Object, bytes.NewReader(buf.Bytes()), bundle.SizeBytes,
+		minio.PutObjectOptions{ContentType: "application/zip"})
+	if err != nil {
+		return nil, fmt.Errorf("minio put bundle: %w", err)
+	}
+
+	query := `
+		INSERT INTO public.transact_evidence_bundle
+			(id, subject_type, subject_id, site_id, minio_bucket, minio_object,
+			 bundle_sha256, size_bytes, key_id, signature, created_by_username, created_date)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, $8, $9, $10, $11, $12)`
+
+	_, err = s.DB.ExecContext(ctx, query,
+		bundle.ID, bundle.SubjectType, bundle.SubjectID, bundle.SiteID, bundle.Bucket, bundle.Object,
+		bundle.SHA256, bundle.SizeBytes, bundle.KeyID, bundle.Signature, bundle.CreatedBy, bundle.CreatedDate)
+	if err != nil {
+		// Bundle object without a row is useless; don't leave it behind
+		if rmErr := s.BundleStore.RemoveObject(ctx, bundle.Bucket, bundle.Object, minio.RemoveObjectOptions{}); rmErr != nil {
+			log.Printf("[EVIDENCE] Failed to remove orphaned bundle %s: %v", bundle.Object, rmErr)
+		}
+		return nil, fmt.Errorf("insert bundle: %w", err)
+	}
+
+	log.Printf("[EVIDENCE] Bundle %s generated for %s %s (%d files, key %s)",
+		bundle.ID, subject.Type, subject.ID, len(manifest.Files), bundle.KeyID)
+	return bundle, nil
+}
+
+// LatestBundle returns the most recent bundle generated for a subject
+func (s *Service) LatestBundle(ctx context.Context, subjectType, subjectID string) (*Bundle, error) {
+	query := `
+		SELECT id, subject_type, subject_id, COALESCE(site_id::text, ''), minio_bucket, minio_object,
+		       bundle_sha256, size_bytes, key_id, signature, COALESCE(created_by_username, ''), created_date
+		FROM public.transact_evidence_bundle
+		WHERE subject_type = $1 AND subject_id::text = $2
+		ORDER BY created_date DESC
+		LIMIT 1`
+
+	b := &Bundle{}
+	err := s.DB.QueryRowContext(ctx, query, subjectType, subjectID).Scan(
+		&b.ID, &b.SubjectType, &b.SubjectID, &b.SiteID, &b.Bucket, &b.Object,
+		&b.SHA256, &b.SizeBytes, &b.KeyID, &b.Signature, &b.CreatedBy, &b.CreatedDate)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query bundle: %w", err)
+	}
+	return b, nil
+}
+
+// OpenBundle returns a reader over a stored bundle zip
+func (s *Service) OpenBundle(ctx context.Context, b *Bundle) (*minio.Object, error) {
+	if s.BundleStore == nil {
+		return nil, fmt.Errorf("evidence bundles are not configured")
+	}
+	return s.BundleStore.GetObject(ctx, b.Bucket, b.Object, minio.GetObjectOptions{})
+}
+
+// VerifyBundleWithRegistry verifies a bundle using the published key named in its
+// manifest. The key is only trusted when it is active and belongs to the manifest's site.
+func (s *Service) VerifyBundleWithRegistry(ctx context.Context, data []byte) (*BundleVerification, error) {
+	contents, err := readBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	m, err := parseManifest(contents)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.trustedKey(ctx, m.KeyID)
+	if err != nil {
+		return nil, err
+	}
+	if key == nil {
+		return verifyContents(contents, nil)
+	}
+	v, err := verifyContents(contents, key.Key)
+	if err != nil {
+		return nil, err
+	}
+	if key.SiteID != m.SiteID {
+		v.KeyTrusted, v.Valid = false, false
+		v.Errors = append(v.Errors, fmt.Sprintf("key %s is published for site %q, manifest is for site %q", m.KeyID, key.SiteID, m.SiteID))
+	}
+	if !key.IsActive {
+		v.KeyTrusted, v.Valid = false, false
+		v.Errors = append(v.Errors, fmt.Sprintf("key %s is deactivated", m.KeyID))
+	}
+	return v, nil
+}
+
+// VerifyBundle checks a bundle's manifest signature and every file digest. It needs no
+// database or network access. If trusted is nil the embedded public key is used and the
+// result is reported as not trusted.
+func VerifyBundle(data []byte, trusted ed25519.PublicKey) (*BundleVerification, error) {
+	contents, err := readBundle(data)
+	if err != nil {
+		return nil, err
+	}
+	return verifyContents(contents, trusted)
+}
+
+// readBundle unpacks a bundle zip within the size and entry limits
+func readBundle(data []byte) (map[string][]byte, error) {
+	if len(data) > MaxBundleSize {
+		return nil, fmt.Errorf("bundle is larger than %d MB", MaxBundleSize>>20)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("open bundle: %w", err)
+	}
+	if len(zr.File) > maxBundleEntries {
+		return nil, fmt.Errorf("bundle has %d entries, at most %d allowed", len(zr.File), maxBundleEntries)
+	}
+
+	contents := make(map[string][]byte, len(zr.File))
+	var total int64
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", f.Name, err)
+		}
+		// Header sizes can lie; the limit applies to the bytes actually inflated
+		b, err := io.ReadAll(io.LimitReader(rc, maxBundleEntrySize+1))
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f.Name, err)
+		}
+		if len(b) > maxBundleEntrySize {
+			return nil, fmt.Errorf("%s is larger than %d MB uncompressed", f.Name, maxBundleEntrySize>>20)
+		}
+		if total += int64(len(b)); total > maxBundleUncompressed {
+			return nil, fmt.Errorf("bundle is larger than %d MB uncompressed", maxBundleUncompressed>>20)
+		}
+		contents[f.Name] = b
+	}
+	return contents, nil
+}
+
+func parseManifest(contents map[string][]byte) (*Manifest, error) {
+	b, ok := contents[BundleManifestPath]
+	if !ok {
+		return nil, fmt.Errorf("bundle has no %s", BundleManifestPath)
+	}
+	var m Manifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("parse manifest: %w", err)
+	}
+	return &m, nil
+}
+
+func verifyContents(contents map[string][]byte, trusted ed25519.PublicKey) (*BundleVerification, error) {
+	v := &BundleVerification{Files: []FileCheck{}}
+
+	m, err := parseManifest(contents)
+	if err != nil {
+		return nil, err
+	}
+	manifestBytes := contents[BundleManifestPath]
+	v.Manifest = m
+	v.KeyID = m.KeyID
+
+	pub := trusted
+	if pub != nil {
+		v.KeyTrusted = true
+		if KeyID(pub) != m.KeyID {
+			v.Errors = append(v.Errors, fmt.Sprintf("manifest key_id %s does not match trusted key %s", m.KeyID, KeyID(pub)))
+		}
+	} else if embedded, ok := contents[BundlePublicKeyPath]; ok {
+		if pub, err = ParsePublicKeyPEM(embedded); err != nil {
+			v.Errors = append(v.Errors, "embedded public key: "+err.Error())
+		} else {
+			v.Errors = append(v.Errors, "verified with the key embedded in the bundle; supply the site's published key to establish trust")
+		}
+	}
+
+	sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(contents[BundleSignaturePath])))
+	if err != nil {
+		v.Errors = append(v.Errors, "signature is not valid base64")
+	} else if pub != nil {
+		v.SignatureValid = ed25519.Verify(pub, manifestBytes, sig)
+		if !v.SignatureValid {
+			v.Errors = append(v.Errors, "manifest signature is invalid")
+		}
+	}
+
+	allFilesOK := true
+	for _, f := range m.Files {
+		check := FileCheck{Path: f.Path, ExpectedSHA256: f.SHA256}
+		if b, ok := contents[f.Path]; ok {
+			sum := sha256.Sum256(b)
+			check.ActualSHA256 = hex.EncodeToString(sum[:])
+			check.OK = strings.EqualFold(check.ActualSHA256, f.SHA256)
+		}
+		if !check.OK {
+			allFilesOK = false
+			v.Errors = append(v.Errors, fmt.Sprintf("%s: digest mismatch or missing", f.Path))
+		}
+		v.Files = append(v.Files, check)
+	}
+
+	v.Valid = v.SignatureValid && v.KeyTrusted && allFilesOK && KeyID(pub) == m.KeyID
+	return v, nil
+}
+
+func readObject(ctx context.Context, mc *minio.Client, obj Object) ([]byte, error) {
+	r, err := mc.GetObject(ctx, obj.Bucket, obj.Name, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(r)
+}
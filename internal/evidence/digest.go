@@ -0,0 +1,49 @@
+package evidence
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+)
+
+// DigestReader wraps a reader and computes the SHA-256 of everything read through it,
+// so an object can be hashed while it is streamed to MinIO without buffering it
+type DigestReader struct {
+	r io.Reader
+	h hash.Hash
+	n int64
+}
+
+// NewDigestReader creates a hashing reader around r
+func NewDigestReader(r io.Reader) *DigestReader {
+	return &DigestReader{r: r, h: sha256.New()}
+}
+
+func (d *DigestReader) Read(p []byte) (int, error) {
+	n, err := d.r.Read(p)
+	if n > 0 {
+		d.h.Write(p[:n])
+		d.n += int64(n)
+	}
+	return n, err
+}
+
+// Sum returns the hex SHA-256 of the bytes read so far
+func (d *DigestReader) Sum() string {
+	return hex.EncodeToString(d.h.Sum(nil))
+}
+
+// Size returns the number of bytes read so far
+func (d *DigestReader) Size() int64 {
+	return d.n
+}
+
+// HashReader consumes r and returns its hex SHA-256 and size
+func HashReader(r io.Reader) (string, int64, error) {
+	d := NewDigestReader(r)
+	if _, err := io.Copy(io.Discard, d); err != nil {
+		return "", d.Size(), err
+	}
+	return d.Sum(), d.Size(), nil
+}
@@ -0,0 +1,263 @@
+package evidence
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// ErrNotFound is returned when a capture or evidence object does not exist
+var ErrNotFound = errors.New("evidence: not found")
+
+// Service verifies stored evidence and keeps the chain-of-custody log
+type Service struct {
+	DB     *sql.DB
+	Stores map[string]*minio.Client // MinIO client per capture type
+
+	// Signed bundles (optional, see ConfigureBundles)
+	SiteID       string
+	SiteCode     string
+	Signer       *Signer
+	BundleStore  *minio.Client
+	BundleBucket string
+}
+
+// NewService creates a new evidence service
+func NewService(db *sql.DB) *Service {
+	return &Service{
+		DB:     db,
+		Stores: make(map[string]*minio.Client),
+	}
+}
+
+// RegisterStore sets the MinIO endpoint holding the objects of a capture type
+func (s *Service) RegisterStore(captureType, endpoint, accessKey, secretKey string, useSSL bool) error {
+	mc, err := minio.New(endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
+		Secure: useSSL,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create minio client: %w", err)
+	}
+	s.Stores[captureType] = mc
+	return nil
+}
+
+// ParseCaptureType normalizes "anpr"/"axle" from a URL into a capture type
+func ParseCaptureType(v string) (string, error) {
+	switch strings.ToUpper(v) {
+	case CaptureANPR:
+		return CaptureANPR, nil
+	case CaptureAXLE:
+		return CaptureAXLE, nil
+	}
+	return "", fmt.Errorf("unknown capture type %q (use anpr or axle)", v)
+}
+
+// GetCapture loads a capture and the digests recorded at ingestion
+func (s *Service) GetCapture(ctx context.Context, captureType, id string) (*Capture, error) {
+	c := &Capture{Type: captureType, ID: id}
+	var capturedAt, hashedAt sql.NullTime
+	var bucket string
+
+	switch captureType {
+	case CaptureANPR:
+		var xmlObj, fullObj, plateObj, xmlSHA, fullSHA, plateSHA string
+		query := `
+			SELECT external_id, COALESCE(site_id::text, ''), plate_no, captured_at, evidence_hashed_at,
+			       minio_bucket, minio_xml_object, minio_full_image_object, minio_plate_image_object,
+			       COALESCE(xml_sha256, ''), COALESCE(full_image_sha256, ''), COALESCE(plate_image_sha256, '')
+			FROM public.transact_anpr_capture
+			WHERE id::text = $1 AND is_deleted = false`
+		err := s.DB.QueryRowContext(ctx, query, id).Scan(
+			&c.ExternalID, &c.SiteID, &c.PlateNo, &capturedAt, &hashedAt,
+			&bucket, &xmlObj, &fullObj, &plateObj, &xmlSHA, &fullSHA, &plateSHA)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("query anpr capture: %w", err)
+		}
+		c.Objects = []Object{
+			{Kind: "xml", Bucket: bucket, Name: xmlObj, SHA256: xmlSHA},
+			{Kind: "full_image", Bucket: bucket, Name: fullObj, SHA256: fullSHA},
+			{Kind: "plate_image", Bucket: bucket, Name: plateObj, SHA256: plateSHA},
+		}
+
+	case CaptureAXLE:
+		var xmlObj, imgObj, xmlSHA, imgSHA string
+		query := `
+			SELECT external_id, COALESCE(site_id::text, ''), COALESCE(plate_no, ''), captured_at, evidence_hashed_at,
+			       minio_bucket, minio_xml_object, minio_image_object,
+			       COALESCE(xml_sha256, ''), COALESCE(image_sha256, '')
+			FROM public.transact_axle_capture
+			WHERE id::text = $1 AND is_deleted = false`
+		err := s.DB.QueryRowContext(ctx, query, id).Scan(
+			&c.ExternalID, &c.SiteID, &c.PlateNo, &capturedAt, &hashedAt,
+			&bucket, &xmlObj, &imgObj, &xmlSHA, &imgSHA)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrNotFound
+			}
+			return nil, fmt.Errorf("query axle capture: %w", err)
+		}
+		c.Objects = []Object{
+			{Kind: "xml", Bucket: bucket, Name: xmlObj, SHA256: xmlSHA},
+			{Kind: "image", Bucket: bucket, Name: imgObj, SHA256: imgSHA},
+		}
+
+	default:
+		return nil, fmt.Errorf("unknown capture type %q", captureType)
+	}
+
+	if capturedAt.Valid {
+		c.CapturedAt = &capturedAt.Time
+	}
+	if hashedAt.Valid {
+		c.HashedAt = &hashedAt.Time
+	}
+	return c, nil
+}
+
+// Verify re-downloads every object of a capture and compares it with the stored digest
+func (s *Service) Verify(ctx context.Context, captureType, id string) (*Verification, error) {
+	capture, err := s.GetCapture(ctx, captureType, id)
+	if err != nil {
+		return nil, err
+	}
+
+	mc, ok := s.Stores[captureType]
+	if !ok {
+		return nil, fmt.Errorf("no object store configured for %s", captureType)
+	}
+
+	v := &Verification{
+		CaptureType: captureType,
+		CaptureID:   id,
+		Result:      ResultVerified,
+		VerifiedAt:  time.Now(),
+	}
+
+	for _, obj := range capture.Objects {
+		ov := ObjectVerification{Object: obj}
+
+		if obj.SHA256 == "" {
+			ov.Result = ResultNoDigest
+		} else if actual, size, err := hashObject(ctx, mc, obj); err != nil {
+			ov.Result = ResultMissing
+			ov.Error = err.Error()
+		} else {
+			ov.ActualSHA256 = actual
+			ov.SizeBytes = size
+			if strings.EqualFold(actual, strings.TrimSpace(obj.SHA256)) {
+				ov.Result = ResultVerified
+			} else {
+				ov.Result = ResultMismatch
+			}
+		}
+
+		v.Result = worseResult(v.Result, ov.Result)
+		v.Objects = append(v.Objects, ov)
+	}
+
+	v.Verified = v.Result == ResultVerified
+	if !v.Verified {
+		log.Printf("[EVIDENCE] Verification of %s %s: %s", captureType, id, v.Result)
+	}
+	return v, nil
+}
+
+// OpenObject returns a reader over one evidence object of a capture
+func (s *Service) OpenObject(ctx context.Context, captureType, id, kind string) (*minio.Object, *Object, error) {
+	capture, err := s.GetCapture(ctx, captureType, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	mc, ok := s.Stores[captureType]
+	if !ok {
+		return nil, nil, fmt.Errorf("no object store configured for %s", captureType)
+	}
+
+	for i := range capture.Objects {
+		obj := &capture.Objects[i]
+		if obj.Kind != kind {
+			continue
+		}
+		r, err := mc.GetObject(ctx, obj.Bucket, obj.Name, minio.GetObjectOptions{})
+		if err != nil {
+			return nil, nil, fmt.Errorf("get object: %w", err)
+		}
+		return r, obj, nil
+	}
+	return nil, nil, ErrNotFound
+}
+
+// LogAccess appends a row to the chain-of-custody log
+func (s *Service) LogAccess(ctx context.Context, captureType, id, kind, action, result string, who Accessor) error {
+	query := `
+		INSERT INTO public.transact_evidence_access
+			(capture_type, capture_id, object_kind, action, result, user_id, username, ip_address, user_agent)
+		VALUES ($1, $2, NULLIF($3, ''), $4, NULLIF($5, ''), $6, $7, $8, $9)`
+
+	_, err := s.DB.ExecContext(ctx, query,
+		captureType, id, kind, action, result, who.UserID, who.Username, who.IPAddress, who.UserAgent)
+	if err != nil {
+		return fmt.Errorf("insert evidence access: %w", err)
+	}
+	return nil
+}
+
+// ListAccess returns the chain-of-custody log of a capture, oldest first
+func (s *Service) ListAccess(ctx context.Context, captureType, id string) ([]AccessRecord, error) {
+	query := `
+		SELECT id, COALESCE(object_kind, ''), action, COALESCE(result, ''),
+		       COALESCE(user_id, 0), COALESCE(username, ''), COALESCE(ip_address, ''),
+		       COALESCE(user_agent, ''), accessed_at
+		FROM public.transact_evidence_access
+		WHERE capture_type = $1 AND capture_id::text = $2
+		ORDER BY accessed_at`
+
+	rows, err := s.DB.QueryContext(ctx, query, captureType, id)
+	if err != nil {
+		return nil, fmt.Errorf("query evidence access: %w", err)
+	}
+	defer rows.Close()
+
+	records := []AccessRecord{}
+	for rows.Next() {
+		var r AccessRecord
+		if err := rows.Scan(&r.ID, &r.ObjectKind, &r.Action, &r.Result,
+			&r.UserID, &r.Username, &r.IPAddress, &r.UserAgent, &r.AccessedAt); err != nil {
+			return nil, fmt.Errorf("scan evidence access: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func hashObject(ctx context.Context, mc *minio.Client, obj Object) (string, int64, error) {
+	r, err := mc.GetObject(ctx, obj.Bucket, obj.Name, minio.GetObjectOptions{})
+	if err != nil {
+		return "", 0, err
+	}
+	defer r.Close()
+
+	return HashReader(r)
+}
+
+// worseResult keeps the most severe of two verification results
+func worseResult(a, b string) string {
+	rank := map[string]int{ResultVerified: 0, ResultNoDigest: 1, ResultMissing: 2, ResultMismatch: 3}
+	if rank[b] > rank[a] {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,122 @@
+package evidence
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Signer signs bundle manifests with the site's Ed25519 key
+type Signer struct {
+	PrivateKey ed25519.PrivateKey
+	PublicKey  ed25519.PublicKey
+	KeyID      string // First 16 hex chars of SHA-256(public key)
+}
+
+// LoadOrCreateSigner loads a PKCS#8 PEM Ed25519 private key from path.
+// If the file does not exist a new key is generated and written with 0600 permissions,
+// so every site gets its own key on first start.
+func LoadOrCreateSigner(path string) (*Signer, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return createSigner(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read signing key: %w", err)
+	}
+
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("signing key %s is not PEM encoded", path)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+	priv, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+
+	return newSigner(priv), nil
+}
+
+func createSigner(path string) (*Signer, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, fmt.Errorf("generate signing key: %w", err)
+	}
+
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		return nil, fmt.Errorf("marshal signing key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("create key dir: %w", err)
+	}
+	pemBytes := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	if err := os.WriteFile(path, pemBytes, 0o600); err != nil {
+		return nil, fmt.Errorf("write signing key: %w", err)
+	}
+
+	s := newSigner(priv)
+	log.Printf("[EVIDENCE] Generated new site signing key %s at %s", s.KeyID, path)
+	return s, nil
+}
+
+func newSigner(priv ed25519.PrivateKey) *Signer {
+	pub := priv.Public().(ed25519.PublicKey)
+	return &Signer{
+		PrivateKey: priv,
+		PublicKey:  pub,
+		KeyID:      KeyID(pub),
+	}
+}
+
+// Sign returns the Ed25519 signature of msg
+func (s *Signer) Sign(msg []byte) []byte {
+	return ed25519.Sign(s.PrivateKey, msg)
+}
+
+// PublicKeyPEM returns the public key as a PKIX PEM block
+func (s *Signer) PublicKeyPEM() []byte {
+	return EncodePublicKeyPEM(s.PublicKey)
+}
+
+// KeyID derives a short, stable identifier for a public key
+func KeyID(pub ed25519.PublicKey) string {
+	sum := sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// EncodePublicKeyPEM encodes an Ed25519 public key as a PKIX PEM block
+func EncodePublicKeyPEM(pub ed25519.PublicKey) []byte {
+	der, _ := x509.MarshalPKIXPublicKey(pub)
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})
+}
+
+// ParsePublicKeyPEM decodes a PKIX PEM Ed25519 public key
+func ParsePublicKeyPEM(b []byte) (ed25519.PublicKey, error) {
+	block, _ := pem.Decode(b)
+	if block == nil {
+		return nil, fmt.Errorf("public key is not PEM encoded")
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("parse public key: %w", err)
+	}
+	pub, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key is not Ed25519")
+	}
+	return pub, nil
+}
@@ -0,0 +1,85 @@
+package evidence
+
+import "time"
+
+// Capture types (transact_evidence_access.capture_type)
+const (
+	CaptureANPR = "ANPR"
+	CaptureAXLE = "AXLE"
+)
+
+// Access actions recorded in the chain-of-custody log
+const (
+	ActionView     = "VIEW"
+	ActionDownload = "DOWNLOAD"
+	ActionVerify   = "VERIFY"
+	ActionGenerate = "GENERATE" // Evidence bundle built from the capture
+)
+
+// Verification results
+const (
+	ResultVerified = "VERIFIED"
+	ResultMismatch = "MISMATCH"
+	ResultMissing  = "MISSING"
+	ResultNoDigest = "NO_DIGEST"
+)
+
+// Object is a single evidence file stored in MinIO
+type Object struct {
+	Kind   string `json:"kind"` // xml / full_image / plate_image / image
+	Bucket string `json:"bucket"`
+	Name   string `json:"object"`
+	SHA256 string `json:"sha256"`
+}
+
+// Capture is a capture row together with its evidence objects and stored digests
+type Capture struct {
+	Type       string     `json:"capture_type"`
+	ID         string     `json:"id"`
+	ExternalID string     `json:"external_id"`
+	SiteID     string     `json:"site_id"`
+	PlateNo    string     `json:"plate_no"`
+	CapturedAt *time.Time `json:"captured_at"`
+	HashedAt   *time.Time `json:"evidence_hashed_at"`
+	Objects    []Object   `json:"objects"`
+}
+
+// ObjectVerification is the re-verification outcome of one object
+type ObjectVerification struct {
+	Object
+	ActualSHA256 string `json:"actual_sha256"`
+	SizeBytes    int64  `json:"size_bytes"`
+	Result       string `json:"result"`
+	Error        string `json:"error,omitempty"`
+}
+
+// Verification is the re-verification outcome of a whole capture
+type Verification struct {
+	CaptureType string               `json:"capture_type"`
+	CaptureID   string               `json:"capture_id"`
+	Result      string               `json:"result"`
+	Verified    bool                 `json:"verified"`
+	VerifiedAt  time.Time            `json:"verified_at"`
+	Objects     []ObjectVerification `json:"objects"`
+}
+
+// Accessor identifies who touched a piece of evidence
+type Accessor struct {
+	UserID    int
+	Username  string
+	IPAddress string
+	UserAgent string
+}
+
+// AccessRecord is one row of the chain-of-custody log
+type AccessRecord struct {
+	ID         string    `json:"id"`
+	ObjectKind string    `json:"object_kind,omitempty"`
+	Action     string    `json:"action"`
+	Result     string    `json:"result,omitempty"`
+	UserID     int       `json:"user_id"`
+	Username   string    `json:"username"`
+	IPAddress  string    `json:"ip_address"`
+	UserAgent  string    `json:"user_agent"`
+	AccessedAt time.Time `json:"accessed_at"`
+}
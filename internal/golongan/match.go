@@ -0,0 +1,84 @@
+package golongan
+
+import "strings"
+
+// MatchRules returns the matching rule with the lowest priority number, or nil.
+// Ties go to the rule with more criteria.
+func MatchRules(rules []Rule, s Subject) *Rule {
+	var best *Rule
+	bestCriteria := 0
+	for i := range rules {
+		r := &rules[i]
+		criteria, ok := r.matches(s)
+		if !ok {
+			continue
+		}
+		if best == nil || r.Priority < best.Priority ||
+			(r.Priority == best.Priority && criteria > bestCriteria) {
+			best = r
+			bestCriteria = criteria
+		}
+	}
+	return best
+}
+
+// matches reports whether every criterion of the rule holds for s, and how many
+// criteria the rule specifies. A criterion the sensor did not report never matches.
+func (r Rule) matches(s Subject) (int, bool) {
+	criteria := 0
+	if r.MinAxles > 0 || r.MaxAxles > 0 {
+		criteria++
+		if s.Axles <= 0 ||
+			(r.MinAxles > 0 && s.Axles < r.MinAxles) ||
+			(r.MaxAxles > 0 && s.Axles > r.MaxAxles) {
+			return 0, false
+		}
+	}
+	if r.MinLengthMM > 0 || r.MaxLengthMM > 0 {
+		criteria++
+		if s.LengthMM <= 0 ||
+			(r.MinLengthMM > 0 && s.LengthMM < r.MinLengthMM) ||
+			(r.MaxLengthMM > 0 && s.LengthMM > r.MaxLengthMM) {
+			return 0, false
+		}
+	}
+	if list := splitList(r.BodyTypes); len(list) > 0 {
+		criteria++
+		if !inList(list, s.BodyType) {
+			return 0, false
+		}
+	}
+	if list := splitList(r.Categories); len(list) > 0 {
+		criteria++
+		if !inList(list, s.Category) {
+			return 0, false
+		}
+	}
+	if r.VehicleClassID != "" {
+		criteria++
+		if r.VehicleClassID != s.VehicleClassID {
+			return 0, false
+		}
+	}
+	return criteria, true
+}
+
+func inList(list []string, value string) bool {
+	value = strings.TrimSpace(value)
+	for _, v := range list {
+		if value != "" && strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
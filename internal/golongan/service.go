@@ -0,0 +1,319 @@
+package golongan
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotFound is returned when a rule or capture does not exist
+	ErrNotFound = errors.New("golongan: not found")
+	// ErrInvalid is returned when a rule request fails validation
+	ErrInvalid = errors.New("golongan: invalid request")
+)
+
+// Service maps captures to toll Golongan using master_toll_golongan_rule
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new Golongan service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+// ===== Capture assignment =====
+
+// AssignCapture computes the Golongan of an axle capture from its axle count, length,
+// body type, category and resolved vehicle class, and stores it on the capture
+func (s *Service) AssignCapture(ctx context.Context, captureID string) (*Assignment, error) {
+	var subject Subject
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(total_axles, 0), COALESCE(length_mm, 0),
+		       COALESCE(vehicle_body_type, ''), COALESCE(vehicle_category, ''),
+		       COALESCE(vehicle_class_id::text, '')
+		FROM public.transact_axle_capture
+		WHERE id::text = $1 AND is_deleted = false`,
+		captureID).Scan(&subject.Axles, &subject.LengthMM, &subject.BodyType, &subject.Category, &subject.VehicleClassID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load capture: %w", err)
+	}
+
+	rules, err := s.ListRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Assignment{CaptureID: captureID, AssignedAt: time.Now()}
+	if r := MatchRules(rules, subject); r != nil {
+		a.Golongan = r.Golongan
+		a.GolonganLabel = Label(r.Golongan)
+		a.RuleID = r.ID
+		a.RuleName = r.RuleName
+	}
+
+	_, err = s.DB.ExecContext(ctx, `
+		UPDATE public.transact_axle_capture SET
+			toll_golongan = NULLIF($2, 0),
+			golongan_rule_id = NULLIF($3, '')::uuid,
+			golongan_assigned_at = $4,
+			updated_date = now()
+		WHERE id::text = $1`,
+		captureID, a.Golongan, a.RuleID, a.AssignedAt)
+	if err != nil {
+		return nil, fmt.Errorf("save golongan: %w", err)
+	}
+
+	// Kendaraan hasil korelasi ikut Golongan axle capture-nya
+	_, err = s.DB.ExecContext(ctx, `
+		UPDATE public.transact_vehicle SET toll_golongan = NULLIF($2, 0), updated_date = now()
+		WHERE axle_id::text = $1`, captureID, a.Golongan)
+	if err != nil {
+		return nil, fmt.Errorf("save vehicle golongan: %w", err)
+	}
+	return a, nil
+}
+
+// Counts returns the number of axle captures per site and Golongan
+func (s *Service) Counts(ctx context.Context, filter CountFilter) ([]Count, error) {
+	query := `
+		SELECT COALESCE(c.site_id::text, ''), COALESCE(ms.code, ''), COALESCE(c.toll_golongan, 0), COUNT(*)
+		FROM public.transact_axle_capture c
+		LEFT JOIN public.master_site ms ON ms.id = c.site_id
+		WHERE c.is_deleted = false
+		  AND ($1 = '' OR c.site_id::text = $1)
+		  AND ($2::timestamptz IS NULL OR c.captured_at >= $2)
+		  AND ($3::timestamptz IS NULL OR c.captured_at < $3)
+		GROUP BY 1, 2, 3
+		ORDER BY 2, 3`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.SiteID, nullTime(filter.From), nullTime(filter.To))
+	if err != nil {
+		return nil, fmt.Errorf("query golongan counts: %w", err)
+	}
+	defer rows.Close()
+
+	counts := []Count{}
+	for rows.Next() {
+		var c Count
+		if err := rows.Scan(&c.SiteID, &c.SiteCode, &c.Golongan, &c.Vehicles); err != nil {
+			return nil, fmt.Errorf("scan golongan count: %w", err)
+		}
+		c.GolonganLabel = Label(c.Golongan)
+		counts = append(counts, c)
+	}
+	return counts, rows.Err()
+}
+
+// ===== Rules =====
+
+const ruleColumns = `
+	r.id, r.golongan, r.rule_name, r.priority,
+	COALESCE(r.min_axles, 0), COALESCE(r.max_axles, 0),
+	COALESCE(r.min_length_mm, 0), COALESCE(r.max_length_mm, 0),
+	COALESCE(r.body_types, ''), COALESCE(r.categories, ''),
+	COALESCE(r.vehicle_class_id::text, ''), COALESCE(vc.code, ''),
+	COALESCE(r.is_active, true), r.created_date, r.updated_date`
+
+const ruleFrom = `
+	FROM public.master_toll_golongan_rule r
+	LEFT JOIN public.master_vehicle_class vc ON vc.id = r.vehicle_class_id`
+
+func scanRule(row interface{ Scan(...any) error }) (*Rule, error) {
+	var r Rule
+	err := row.Scan(&r.ID, &r.Golongan, &r.RuleName, &r.Priority,
+		&r.MinAxles, &r.MaxAxles, &r.MinLengthMM, &r.MaxLengthMM,
+		&r.BodyTypes, &r.Categories, &r.VehicleClassID, &r.VehicleClassCode,
+		&r.IsActive, &r.CreatedDate, &r.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	r.GolonganLabel = Label(r.Golongan)
+	return &r, nil
+}
+
+// ListRules returns all rules; activeOnly limits to rules used for assignment
+func (s *Service) ListRules(ctx context.Context, activeOnly bool) ([]Rule, error) {
+	query := `SELECT` + ruleColumns + ruleFrom + `
+		WHERE r.is_deleted = false
+		  AND (NOT $1 OR COALESCE(r.is_active, true))
+		ORDER BY r.priority, r.golongan`
+
+	rows, err := s.DB.QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("query rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rule: %w", err)
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// GetRule returns one rule
+func (s *Service) GetRule(ctx context.Context, id string) (*Rule, error) {
+	query := `SELECT` + ruleColumns + ruleFrom + `
+		WHERE r.id::text = $1 AND r.is_deleted = false`
+
+	r, err := scanRule(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get rule: %w", err)
+	}
+	return r, nil
+}
+
+func (s *Service) validateRule(ctx context.Context, req RuleRequest, create bool) error {
+	if (create || req.Golongan != 0) && Label(req.Golongan) == "" {
+		return fmt.Errorf("%w: golongan must be 1..5", ErrInvalid)
+	}
+	if req.MinAxles < 0 || req.MaxAxles < 0 || req.MinLengthMM < 0 || req.MaxLengthMM < 0 {
+		return fmt.Errorf("%w: bounds must not be negative", ErrInvalid)
+	}
+	if req.MaxAxles > 0 && req.MinAxles > req.MaxAxles {
+		return fmt.Errorf("%w: min_axles must not exceed max_axles", ErrInvalid)
+	}
+	if req.MaxLengthMM > 0 && req.MinLengthMM > req.MaxLengthMM {
+		return fmt.Errorf("%w: min_length_mm must not exceed max_length_mm", ErrInvalid)
+	}
+	if req.VehicleClassID != "" {
+		var exists bool
+		err := s.DB.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM public.master_vehicle_class WHERE id::text = $1 AND is_deleted = false)`,
+			req.VehicleClassID).Scan(&exists)
+		if err != nil {
+			return fmt.Errorf("check vehicle class: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: vehicle_class_id not found", ErrInvalid)
+		}
+	}
+	return nil
+}
+
+// CreateRule adds a mapping rule
+func (s *Service) CreateRule(ctx context.Context, req RuleRequest) (*Rule, error) {
+	if strings.TrimSpace(req.RuleName) == "" {
+		return nil, fmt.Errorf("%w: rule_name is required", ErrInvalid)
+	}
+	if err := s.validateRule(ctx, req, true); err != nil {
+		return nil, err
+	}
+
+	priority := 100
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	var id string
+	query := `
+		INSERT INTO public.master_toll_golongan_rule
+			(golongan, rule_name, priority, min_axles, max_axles, min_length_mm, max_length_mm,
+			 body_types, categories, vehicle_class_id, is_active)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0), NULLIF($6, 0), NULLIF($7, 0),
+		        NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, '')::uuid, $11)
+		RETURNING id`
+
+	err := s.DB.QueryRowContext(ctx, query,
+		req.Golongan, strings.TrimSpace(req.RuleName), priority,
+		req.MinAxles, req.MaxAxles, req.MinLengthMM, req.MaxLengthMM,
+		strings.TrimSpace(req.BodyTypes), strings.TrimSpace(req.Categories), req.VehicleClassID, isActive,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert rule: %w", err)
+	}
+
+	return s.GetRule(ctx, id)
+}
+
+// UpdateRule replaces the criteria of a rule; golongan, name, priority and
+// is_active are left unchanged when omitted
+func (s *Service) UpdateRule(ctx context.Context, id string, req RuleRequest) (*Rule, error) {
+	if err := s.validateRule(ctx, req, false); err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE public.master_toll_golongan_rule SET
+			golongan = COALESCE(NULLIF($2, 0)::int2, golongan),
+			rule_name = COALESCE(NULLIF($3, ''), rule_name),
+			priority = COALESCE($4, priority),
+			min_axles = NULLIF($5, 0),
+			max_axles = NULLIF($6, 0),
+			min_length_mm = NULLIF($7, 0),
+			max_length_mm = NULLIF($8, 0),
+			body_types = NULLIF($9, ''),
+			categories = NULLIF($10, ''),
+			vehicle_class_id = NULLIF($11, '')::uuid,
+			is_active = COALESCE($12, is_active),
+			updated_date = now()
+		WHERE id::text = $1 AND is_deleted = false`
+
+	res, err := s.DB.ExecContext(ctx, query, id,
+		req.Golongan, strings.TrimSpace(req.RuleName), nullInt(req.Priority),
+		req.MinAxles, req.MaxAxles, req.MinLengthMM, req.MaxLengthMM,
+		strings.TrimSpace(req.BodyTypes), strings.TrimSpace(req.Categories),
+		req.VehicleClassID, nullBool(req.IsActive),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("update rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetRule(ctx, id)
+}
+
+// DeleteRule soft-deletes a rule
+func (s *Service) DeleteRule(ctx context.Context, id string) error {
+	res, err := s.DB.ExecContext(ctx,
+		`UPDATE public.master_toll_golongan_rule SET is_deleted = true, updated_date = now() WHERE id::text = $1 AND is_deleted = false`, id)
+	if err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func nullInt(i *int) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
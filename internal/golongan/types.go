@@ -0,0 +1,85 @@
+package golongan
+
+import "time"
+
+// Labels of toll Golongan 1..5
+var labels = [...]string{"", "I", "II", "III", "IV", "V"}
+
+// Label returns the Roman numeral of a Golongan, or "" when unassigned
+func Label(g int) string {
+	if g < 1 || g >= len(labels) {
+		return ""
+	}
+	return labels[g]
+}
+
+// Rule is one row of master_toll_golongan_rule. Zero bounds and empty lists mean
+// "not constrained"; every specified criterion must match.
+type Rule struct {
+	ID               string    `json:"id"`
+	Golongan         int       `json:"golongan"`
+	GolonganLabel    string    `json:"golongan_label"`
+	RuleName         string    `json:"rule_name"`
+	Priority         int       `json:"priority"`
+	MinAxles         int       `json:"min_axles"`
+	MaxAxles         int       `json:"max_axles"`
+	MinLengthMM      int       `json:"min_length_mm"`
+	MaxLengthMM      int       `json:"max_length_mm"`
+	BodyTypes        string    `json:"body_types"`
+	Categories       string    `json:"categories"`
+	VehicleClassID   string    `json:"vehicle_class_id"`
+	VehicleClassCode string    `json:"vehicle_class_code"`
+	IsActive         bool      `json:"is_active"`
+	CreatedDate      time.Time `json:"created_date"`
+	UpdatedDate      time.Time `json:"updated_date"`
+}
+
+// RuleRequest is the create/update payload of a rule
+type RuleRequest struct {
+	Golongan       int    `json:"golongan"`
+	RuleName       string `json:"rule_name"`
+	Priority       *int   `json:"priority"`
+	MinAxles       int    `json:"min_axles"`
+	MaxAxles       int    `json:"max_axles"`
+	MinLengthMM    int    `json:"min_length_mm"`
+	MaxLengthMM    int    `json:"max_length_mm"`
+	BodyTypes      string `json:"body_types"`
+	Categories     string `json:"categories"`
+	VehicleClassID string `json:"vehicle_class_id"`
+	IsActive       *bool  `json:"is_active"`
+}
+
+// Subject is what is known about one vehicle
+type Subject struct {
+	Axles          int
+	LengthMM       int
+	BodyType       string
+	Category       string
+	VehicleClassID string
+}
+
+// Assignment is the Golongan stored on one capture
+type Assignment struct {
+	CaptureID     string    `json:"capture_id"`
+	Golongan      int       `json:"golongan"` // 0 = no rule matched
+	GolonganLabel string    `json:"golongan_label"`
+	RuleID        string    `json:"rule_id,omitempty"`
+	RuleName      string    `json:"rule_name,omitempty"`
+	AssignedAt    time.Time `json:"assigned_at"`
+}
+
+// Count is the number of vehicles of one Golongan at one site
+type Count struct {
+	SiteID        string `json:"site_id"`
+	SiteCode      string `json:"site_code"`
+	Golongan      int    `json:"golongan"` // 0 = unassigned
+	GolonganLabel string `json:"golongan_label"`
+	Vehicles      int64  `json:"vehicles"`
+}
+
+// CountFilter narrows Counts
+type CountFilter struct {
+	SiteID string
+	From   *time.Time
+	To     *time.Time
+}
@@ -3,16 +3,24 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"log"
-	"os"
 	"path"
 	"strconv"
 	"strings"
 	"time"
 
+	"wim-service/internal/correlation"
+	"wim-service/internal/odol"
+	"wim-service/internal/quality"
+	"wim-service/internal/reconcile"
+	"wim-service/internal/violation"
+	"wim-service/internal/vision"
+	"wim-service/internal/watchlist"
+
 	"github.com/jlaffaye/ftp"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -25,6 +33,19 @@ type ANPRMetadata struct {
 	CameraID   string
 	Confidence string
 	ID         string
+	Lane       string
+
+	LaneNo          int            // lajur yang lolos validasi (0 = tidak dilaporkan)
+	CapturedAt      *time.Time     // frametime yang lolos validasi
+	ConfidenceValue *float64       // confidence yang lolos validasi
+	Quality         quality.Result // field yang ditolak validasi (nilainya disimpan NULL)
+}
+
+// anprDigests holds the SHA-256 (hex) of each object copied from FTP to MinIO
+type anprDigests struct {
+	XML        string
+	FullImage  string
+	PlateImage string
 }
 
 // Sesuaikan dengan struktur XML dari kamera
@@ -41,6 +62,10 @@ type xmlResult struct {
 		Value string `xml:"value,attr"`
 	} `xml:"ID"`
 
+	Lane struct {
+		Value string `xml:"value,attr"`
+	} `xml:"lane"` // opsional
+
 	Capture struct {
 		FrameTime struct {
 			Value string `xml:"value,attr"`
@@ -63,7 +88,12 @@ type FileProcessor struct {
 	RemoteDir        string
 	Minio            *minio.Client
 	Bucket           string
-	DimensionHandler *DimensionHandler // Optional: for vehicle dimension detection
+	DimensionHandler *DimensionHandler    // Optional: for vehicle dimension detection
+	Watchlist        *watchlist.Matcher   // Optional: for plate watchlist alerts
+	OverDimension    *odol.Service        // Optional: re-evaluate over-dimension once vision dimensions are stored
+	Violations       *violation.Service   // Optional: sync violations of re-evaluated axle captures
+	Correlation      *correlation.Service // Optional: correlate each capture with axle captures
+	Quality          *quality.Service     // Per-device validation failure counters
 }
 
 // SetDimensionHandler sets the dimension handler for processing vehicle dimensions
@@ -71,6 +101,28 @@ func (p *FileProcessor) SetDimensionHandler(handler *DimensionHandler) {
 	p.DimensionHandler = handler
 }
 
+// SetOverDimensionEvaluator sets the service that re-evaluates linked axle captures
+// after vision dimensions are stored on an ANPR capture
+func (p *FileProcessor) SetOverDimensionEvaluator(s *odol.Service) {
+	p.OverDimension = s
+}
+
+// SetViolationService sets the service that raises or updates violations after
+// axle captures are re-evaluated for over-dimension
+func (p *FileProcessor) SetViolationService(s *violation.Service) {
+	p.Violations = s
+}
+
+// SetCorrelationService sets the service that correlates each stored capture with axle captures
+func (p *FileProcessor) SetCorrelationService(s *correlation.Service) {
+	p.Correlation = s
+}
+
+// SetWatchlistMatcher sets the matcher used to check captured plates against watchlists
+func (p *FileProcessor) SetWatchlistMatcher(matcher *watchlist.Matcher) {
+	p.Watchlist = matcher
+}
+
 func NewFileProcessor(db *sql.DB, siteUUID, remoteDir, endpoint, accessKey, secretKey, bucket string, useSSL bool) (*FileProcessor, error) {
 	mc, err := minio.New(endpoint, &minio.Options{
 		Creds:  credentials.NewStaticV4(accessKey, secretKey, ""),
@@ -86,6 +138,7 @@ func NewFileProcessor(db *sql.DB, siteUUID, remoteDir, endpoint, accessKey, secr
 		RemoteDir: remoteDir,
 		Minio:     mc,
 		Bucket:    bucket,
+		Quality:   quality.NewService(db),
 	}, nil
 }
 
@@ -108,6 +161,15 @@ func (p *FileProcessor) HandleNewFile(ctx context.Context, c *ftp.ServerConn, na
 
 	log.Printf("[ANPR] plate=%s time=%s cam=%s conf=%s id=%s\n",
 		meta.Plate, meta.FrameTime, meta.CameraID, meta.Confidence, meta.ID)
+	for _, is := range meta.Quality.Issues {
+		log.Printf("[ANPR] Warning: id=%s %s %s=%q: %s", meta.ID, is.Flag, is.Field, is.Value, is.Message)
+	}
+
+	// id adalah kunci upsert; tanpa id yang valid record ditolak agar tidak menimpa capture lain
+	if meta.Quality.Rejected != "" {
+		p.reject(ctx, c, name, meta)
+		return true
+	}
 
 	// Format tanggal hari ini -> 03122025 (ddMMyyyy)
 	datePrefix := time.Now().Format("02012006")
@@ -125,37 +187,68 @@ func (p *FileProcessor) HandleNewFile(ctx context.Context, c *ftp.ServerConn, na
 	fullObj := fmt.Sprintf("%s/%s", datePrefix, fullImg)
 	plateObj := fmt.Sprintf("%s/%s", datePrefix, plateImg)
 
-	// upload XML
-	if err := p.uploadXML(ctx, c, name, xmlObj); err != nil {
+	// Semua object yang ter-upload dilacak; kalau gagal sebelum commit, object dihapus lagi
+	tx := newIngestTx(p.DB, p.Minio, p.Bucket, reconcile.ANPRSpec, meta.ID)
+	defer tx.rollback()
+
+	// upload XML (digest dihitung sambil streaming)
+	var digests anprDigests
+	if digests.XML, err = p.uploadXML(ctx, c, tx, name, xmlObj); err != nil {
 		log.Println("[ANPR] upload xml error:", err)
 		return false
 	}
 
-	// upload 2 image
-	if err := p.uploadImage(ctx, c, fullImg, fullObj); err != nil {
+	// upload 2 image; full image sekaligus di-decode untuk dimension detection
+	var decoder *vision.StreamDecoder
+	var fullTap io.Writer
+	if p.DimensionHandler != nil {
+		decoder = vision.NewStreamDecoder()
+		fullTap = decoder
+	}
+	digests.FullImage, err = p.uploadImage(ctx, c, tx, fullImg, fullObj, fullTap)
+	if decoder != nil {
+		decoder.Close(err)
+	}
+	if err != nil {
 		log.Println("[ANPR] upload full img error:", err)
 		return false
 	}
-	if err := p.uploadImage(ctx, c, plateImg, plateObj); err != nil {
+	if digests.PlateImage, err = p.uploadImage(ctx, c, tx, plateImg, plateObj, nil); err != nil {
 		log.Println("[ANPR] upload plate img error:", err)
 		return false
 	}
 
 	// insert ke database
-	if err := p.insertANPRRecord(ctx, meta, datePrefix, xmlObj, fullObj, plateObj); err != nil {
+	captureID, err := p.insertANPRRecord(ctx, meta, datePrefix, xmlObj, fullObj, plateObj, digests)
+	if err != nil {
 		log.Println("[ANPR] insert DB error:", err)
 		// gagal insert -> jangan hapus dari FTP supaya bisa diproses ulang
 		return false
 	}
+	tx.commit()
+
+	if err := p.Quality.RecordFailures(ctx, p.SiteUUID, quality.DeviceANPR, meta.CameraID, meta.Quality.Issues); err != nil {
+		log.Printf("[ANPR] Warning: Validation counter update failed for %s: %v", meta.ID, err)
+	}
+
+	// Correlate with axle captures (ANPR_ONLY until the axle side arrives)
+	if p.Correlation != nil {
+		p.correlate(ctx, captureID)
+	}
+
+	// Check plate against watchlists if matcher is set
+	if p.Watchlist != nil {
+		p.checkWatchlist(ctx, meta, captureID)
+	}
 
 	// Process vehicle dimensions if handler is set
-	if p.DimensionHandler != nil {
+	if decoder != nil {
 		log.Printf("[ANPR] Processing vehicle dimensions for plate: %s", meta.Plate)
-		// Download full image temporarily for dimension processing
-		// In production, you might want to download from MinIO or keep FTP file temporarily
-		if err := p.processDimensions(ctx, meta, fullObj); err != nil {
+		if err := p.processDimensions(meta, captureID, fullObj, decoder); err != nil {
 			log.Printf("[ANPR] Warning: Failed to process dimensions: %v", err)
 			// Don't fail the whole process if dimension detection fails
+		} else if p.OverDimension != nil {
+			p.evaluateOverDimension(ctx, captureID)
 		}
 	}
 
@@ -187,14 +280,7 @@ func (p *FileProcessor) parseXML(ctx context.Context, c *ftp.ServerConn, name st
 		return nil, fmt.Errorf("unmarshal xml: %w", err)
 	}
 
-	return &ANPRMetadata{
-		Plate:      x.ANPR.Text.Value,
-		FrameTime:  x.Capture.FrameTime.Value,
-		Location:   x.Location.Value,
-		CameraID:   x.CameraID.Value,
-		Confidence: x.ANPR.Confidence.Value,
-		ID:         x.ID.Value,
-	}, nil
+	return validateANPR(&x), nil
 }
 
 // Cari 2 file JPG yang prefix-nya sama dengan nama XML
@@ -236,39 +322,41 @@ func (p *FileProcessor) findImagesForXML(c *ftp.ServerConn, xmlName string) (ful
 	return fullImg, plateImg, nil
 }
 
-func (p *FileProcessor) uploadXML(ctx context.Context, c *ftp.ServerConn, xmlName, objectName string) error {
-	r, err := c.Retr(path.Join(p.RemoteDir, xmlName))
-	if err != nil {
-		return fmt.Errorf("ftp retr xml: %w", err)
-	}
-	defer r.Close()
-
-	_, err = p.Minio.PutObject(ctx, p.Bucket, objectName, r, -1, minio.PutObjectOptions{
-		ContentType: "application/xml",
-	})
+// uploadXML streams the XML from FTP to MinIO and returns its SHA-256 (hex)
+func (p *FileProcessor) uploadXML(ctx context.Context, c *ftp.ServerConn, tx *ingestTx, xmlName, objectName string) (string, error) {
+	digest, err := tx.upload(ctx, c, path.Join(p.RemoteDir, xmlName), objectName, "application/xml", nil)
 	if err != nil {
-		return fmt.Errorf("minio put xml: %w", err)
+		return "", fmt.Errorf("upload xml: %w", err)
 	}
 
-	log.Println("[ANPR] uploaded xml to minio:", objectName)
-	return nil
+	log.Printf("[ANPR] uploaded xml to minio: %s (sha256=%s)", objectName, digest)
+	return digest, nil
 }
 
-func (p *FileProcessor) uploadImage(ctx context.Context, c *ftp.ServerConn, ftpName, objectName string) error {
-	r, err := c.Retr(path.Join(p.RemoteDir, ftpName))
+// uploadImage streams an image from FTP to MinIO and returns its SHA-256 (hex)
+func (p *FileProcessor) uploadImage(ctx context.Context, c *ftp.ServerConn, tx *ingestTx, ftpName, objectName string, tap io.Writer) (string, error) {
+	digest, err := tx.upload(ctx, c, path.Join(p.RemoteDir, ftpName), objectName, "image/jpeg", tap)
 	if err != nil {
-		return fmt.Errorf("ftp retr image: %w", err)
+		return "", fmt.Errorf("upload image: %w", err)
 	}
-	defer r.Close()
+	log.Printf("[ANPR] uploaded image to minio: %s (sha256=%s)", objectName, digest)
+	return digest, nil
+}
 
-	_, err = p.Minio.PutObject(ctx, p.Bucket, objectName, r, -1, minio.PutObjectOptions{
-		ContentType: "image/jpeg",
-	})
-	if err != nil {
-		return fmt.Errorf("minio put image: %w", err)
+// reject menghitung kegagalan validasi lalu memindahkan XML (dan gambar jika sudah ada)
+// ke folder rejected/ tanpa menyimpan ke database
+func (p *FileProcessor) reject(ctx context.Context, c *ftp.ServerConn, name string, meta *ANPRMetadata) {
+	log.Printf("[ANPR] Rejected %s: invalid %s, not stored", name, meta.Quality.Rejected)
+	if err := p.Quality.RecordFailures(ctx, p.SiteUUID, quality.DeviceANPR, meta.CameraID, meta.Quality.Issues); err != nil {
+		log.Printf("[ANPR] Warning: Validation counter update failed for %s: %v", name, err)
+	}
+	names := []string{name}
+	if fullImg, plateImg, err := p.findImagesForXML(c, name); err == nil {
+		names = append(names, fullImg, plateImg)
+	}
+	if err := quarantineFTP(c, p.RemoteDir, names); err != nil {
+		log.Println("[ANPR] quarantine ftp error:", err)
 	}
-	log.Println("[ANPR] uploaded image to minio:", objectName)
-	return nil
 }
 
 func (p *FileProcessor) deleteFTP(c *ftp.ServerConn, names []string) error {
@@ -282,24 +370,24 @@ func (p *FileProcessor) deleteFTP(c *ftp.ServerConn, names []string) error {
 	return nil
 }
 
-func (p *FileProcessor) insertANPRRecord(ctx context.Context, meta *ANPRMetadata, dateFolder, xmlObj, fullObj, plateObj string) error {
+// insertANPRRecord upserts the capture and returns its transact_anpr_capture.id
+func (p *FileProcessor) insertANPRRecord(ctx context.Context, meta *ANPRMetadata, dateFolder, xmlObj, fullObj, plateObj string, digests anprDigests) (string, error) {
 
-	// parse confidence (string -> float)
+	// confidence dan frametime sudah divalidasi; nilai yang ditolak disimpan NULL
 	var conf sql.NullFloat64
-	if meta.Confidence != "" {
-		if f, err := strconv.ParseFloat(meta.Confidence, 64); err == nil {
-			conf.Valid = true
-			conf.Float64 = f
-		}
+	if meta.ConfidenceValue != nil {
+		conf.Valid = true
+		conf.Float64 = *meta.ConfidenceValue
 	}
-
-	// parse frametime ke timestamptz (layout sesuai XML: 2025.12.01 14:06:27.946)
 	var capturedAt sql.NullTime
-	if meta.FrameTime != "" {
-		if t, err := time.Parse("2006.01.02 15:04:05.000", meta.FrameTime); err == nil {
-			capturedAt.Valid = true
-			capturedAt.Time = t
-		}
+	if meta.CapturedAt != nil {
+		capturedAt.Valid = true
+		capturedAt.Time = *meta.CapturedAt
+	}
+
+	issues, err := json.Marshal(meta.Quality.Issues)
+	if err != nil {
+		return "", fmt.Errorf("marshal quality issues: %w", err)
 	}
 
 	query := `
@@ -308,8 +396,9 @@ func (p *FileProcessor) insertANPRRecord(ctx context.Context, meta *ANPRMetadata
 		 location_code, camera_id,
 		 minio_bucket, minio_date_folder,
 		 minio_xml_object, minio_full_image_object, minio_plate_image_object,
-		 synced_to_central)
-	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,false)
+		 xml_sha256, full_image_sha256, plate_image_sha256, evidence_hashed_at,
+		 synced_to_central, quality_status, quality_issues, lane_no)
+	VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,now(),false,$16,$17::jsonb,NULLIF($18, 0))
 	ON CONFLICT (external_id) DO UPDATE SET
 		site_id = EXCLUDED.site_id,
 		plate_no = EXCLUDED.plate_no,
@@ -322,10 +411,19 @@ func (p *FileProcessor) insertANPRRecord(ctx context.Context, meta *ANPRMetadata
 		minio_xml_object = EXCLUDED.minio_xml_object,
 		minio_full_image_object = EXCLUDED.minio_full_image_object,
 		minio_plate_image_object = EXCLUDED.minio_plate_image_object,
-		updated_date = now();
+		xml_sha256 = EXCLUDED.xml_sha256,
+		full_image_sha256 = EXCLUDED.full_image_sha256,
+		plate_image_sha256 = EXCLUDED.plate_image_sha256,
+		evidence_hashed_at = EXCLUDED.evidence_hashed_at,
+		quality_status = EXCLUDED.quality_status,
+		quality_issues = EXCLUDED.quality_issues,
+		lane_no = EXCLUDED.lane_no,
+		updated_date = now()
+	RETURNING id;
 	`
 
-	_, err := p.DB.ExecContext(
+	var captureID string
+	err = p.DB.QueryRowContext(
 		ctx,
 		query,
 		p.SiteUUID, // Site UUID from master_site.id
@@ -340,38 +438,75 @@ func (p *FileProcessor) insertANPRRecord(ctx context.Context, meta *ANPRMetadata
 		xmlObj,
 		fullObj,
 		plateObj,
-	)
+		digests.XML,
+		digests.FullImage,
+		digests.PlateImage,
+		meta.Quality.Status(),
+		string(issues),
+		meta.LaneNo,
+	).Scan(&captureID)
 	if err != nil {
-		return fmt.Errorf("exec insert: %w", err)
+		return "", fmt.Errorf("exec insert: %w", err)
 	}
 
-	return nil
+	return captureID, nil
 }
 
-// processDimensions downloads the image from MinIO and processes vehicle dimensions
-func (p *FileProcessor) processDimensions(ctx context.Context, meta *ANPRMetadata, objectName string) error {
-	// Download image from MinIO to temporary file
-	tmpFile := fmt.Sprintf("/tmp/anpr_%s.jpg", meta.ID)
-
-	obj, err := p.Minio.GetObject(ctx, p.Bucket, objectName, minio.GetObjectOptions{})
+// correlate records the capture in transact_vehicle; failures are logged only
+func (p *FileProcessor) correlate(ctx context.Context, captureID string) {
+	v, err := p.Correlation.CorrelateANPR(ctx, captureID)
 	if err != nil {
-		return fmt.Errorf("get object from minio: %w", err)
+		log.Printf("[ANPR] Warning: Correlation failed for capture %s: %v", captureID, err)
+		return
+	}
+	log.Printf("[ANPR] Correlation capture=%s vehicle=%s status=%s score=%v review=%v",
+		captureID, v.ID, v.Status, scoreText(v.MatchScore), v.ReviewRequired)
+}
+
+// scoreText formats an optional correlation score for logging
+func scoreText(score *float64) string {
+	if score == nil {
+		return "-"
+	}
+	return strconv.FormatFloat(*score, 'f', 3, 64)
+}
+
+// checkWatchlist matches the captured plate against active watchlist entries.
+// Failures are logged only; a watchlist problem must not block ingestion.
+func (p *FileProcessor) checkWatchlist(ctx context.Context, meta *ANPRMetadata, captureID string) {
+	capture := watchlist.Capture{
+		ID:       captureID,
+		SiteID:   p.SiteUUID,
+		PlateNo:  meta.Plate,
+		CameraID: meta.CameraID,
+		Location: meta.Location,
+	}
+	if meta.CapturedAt != nil {
+		capture.CapturedAt = *meta.CapturedAt
 	}
-	defer obj.Close()
 
-	// Save to temporary file
-	outFile, err := os.Create(tmpFile)
+	hits, err := p.Watchlist.CheckCapture(ctx, capture)
 	if err != nil {
-		return fmt.Errorf("create temp file: %w", err)
+		log.Printf("[ANPR] Warning: Watchlist check failed for plate %s: %v", meta.Plate, err)
+		return
 	}
-	defer outFile.Close()
+	if hits > 0 {
+		log.Printf("[ANPR] Plate %s matched %d watchlist entries", meta.Plate, hits)
+	}
+}
 
-	if _, err := io.Copy(outFile, obj); err != nil {
-		return fmt.Errorf("copy to temp file: %w", err)
+// processDimensions processes vehicle dimensions from the full image decoded while it was uploaded
+func (p *FileProcessor) processDimensions(meta *ANPRMetadata, captureID, objectName string, decoder *vision.StreamDecoder) error {
+	img, err := decoder.Image()
+	if err != nil {
+		return fmt.Errorf("decode full image: %w", err)
 	}
 
-	// Process dimensions
-	result, err := p.DimensionHandler.ProcessANPRImage(tmpFile, meta.Plate, meta.ID)
+	capturedAt := time.Now()
+	if meta.CapturedAt != nil {
+		capturedAt = *meta.CapturedAt
+	}
+	result, err := p.DimensionHandler.ProcessANPRDecodedImage(img, objectName, meta.CameraID, capturedAt, meta.Plate, captureID)
 	if err != nil {
 		return fmt.Errorf("process dimensions: %w", err)
 	}
@@ -382,8 +517,25 @@ func (p *FileProcessor) processDimensions(ctx context.Context, meta *ANPRMetadat
 			i+1, dims.LengthMeters, dims.WidthMeters, dims.HeightMeters, dims.Confidence)
 	}
 
-	// Clean up temp file
-	os.Remove(tmpFile)
-
 	return nil
 }
+
+// evaluateOverDimension re-evaluates axle captures matched to this ANPR capture
+func (p *FileProcessor) evaluateOverDimension(ctx context.Context, captureID string) {
+	ids, err := p.OverDimension.EvaluateForANPR(ctx, captureID)
+	if err != nil {
+		log.Printf("[ANPR] Warning: Over-dimension evaluation failed for capture %s: %v", captureID, err)
+		return
+	}
+	if len(ids) > 0 {
+		log.Printf("[ANPR] Over-dimension re-evaluated for %d axle capture(s)", len(ids))
+	}
+	if p.Violations == nil {
+		return
+	}
+	for _, id := range ids {
+		if _, err := p.Violations.SyncCapture(ctx, id, violation.SystemActor); err != nil {
+			log.Printf("[ANPR] Warning: Violation sync failed for axle capture %s: %v", id, err)
+		}
+	}
+}
@@ -3,14 +3,27 @@ package handler
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"encoding/xml"
 	"fmt"
 	"io"
 	"log"
 	"path"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
+	"wim-service/internal/axle"
+	"wim-service/internal/correlation"
+	"wim-service/internal/golongan"
+	"wim-service/internal/odol"
+	"wim-service/internal/overload"
+	"wim-service/internal/quality"
+	"wim-service/internal/reconcile"
+	"wim-service/internal/vehicleclass"
+	"wim-service/internal/violation"
+
 	"github.com/jlaffaye/ftp"
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -18,17 +31,28 @@ import (
 
 // ===== Metadata axle yg kita ambil =====
 
+// Satu AxleMetadata per kendaraan (vac/vehicleN); field event dipakai bersama
 type AxleMetadata struct {
 	Plate     string
 	FrameTime string
 	CameraID  string
 	ID        string
+	Lane      int // lajur (0 = tidak dilaporkan)
+
+	VehicleIndex int // N dari vac/vehicleN
+	VehicleCount int // jumlah kendaraan dalam event
+
+	Length      int // mm
+	GrossWeight int // kg, dari sensor atau jumlah beban sumbu
+	NWheels     int
+	NAxles      int
+	Category    string
+	BodyType    string
 
-	Length   int // mm
-	NWheels  int
-	NAxles   int
-	Category string
-	BodyType string
+	Axles []axle.Detail // per sumbu, depan ke belakang (kosong jika sensor tidak melaporkan)
+
+	CapturedAt *time.Time     // frametime yang lolos validasi
+	Quality    quality.Result // field yang ditolak validasi (nilainya disimpan NULL)
 }
 
 // Struktur XML untuk parsing axle
@@ -41,6 +65,10 @@ type axleXML struct {
 		Value string `xml:"value,attr"`
 	} `xml:"ID"`
 
+	Lane struct {
+		Value string `xml:"value,attr"`
+	} `xml:"lane"` // opsional
+
 	Capture struct {
 		FrameTime struct {
 			Value string `xml:"value,attr"`
@@ -53,35 +81,153 @@ type axleXML struct {
 		} `xml:"text"`
 	} `xml:"anpr"`
 
-	VAC struct {
-		Vehicle0 struct {
-			Length struct {
-				Value string `xml:"value,attr"`
-			} `xml:"length"`
-			NWheels struct {
-				Value string `xml:"value,attr"`
-			} `xml:"nwheels"`
-			NAxles struct {
-				Value string `xml:"value,attr"`
-			} `xml:"naxles"`
-			Category struct {
-				Value string `xml:"value,attr"`
-			} `xml:"category"`
-			BodyType struct {
-				Value string `xml:"value,attr"`
-			} `xml:"body_type"`
-		} `xml:"vehicle0"`
-	} `xml:"vac"`
+	VAC axleVAC `xml:"vac"`
+}
+
+// Satu elemen vac/vehicleN
+type axleVehicleXML struct {
+	Index int `xml:"-"`
+
+	Length struct {
+		Value string `xml:"value,attr"`
+	} `xml:"length"`
+	Weight struct {
+		Value string `xml:"value,attr"`
+	} `xml:"weight"` // berat total kg (opsional)
+	NWheels struct {
+		Value string `xml:"value,attr"`
+	} `xml:"nwheels"`
+	NAxles struct {
+		Value string `xml:"value,attr"`
+	} `xml:"naxles"`
+	Category struct {
+		Value string `xml:"value,attr"`
+	} `xml:"category"`
+	BodyType struct {
+		Value string `xml:"value,attr"`
+	} `xml:"body_type"`
+
+	Axles axleList `xml:"axles"`
+}
+
+// axleVAC menampung semua elemen vehicleN (vehicle0, vehicle1, ...) dalam urutan index
+type axleVAC struct {
+	Vehicles []axleVehicleXML
+}
+
+func (v *axleVAC) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	err := decodeIndexed(d, "vehicle", func(idx int, el xml.StartElement) error {
+		var veh axleVehicleXML
+		if err := d.DecodeElement(&veh, &el); err != nil {
+			return err
+		}
+		veh.Index = idx
+		v.Vehicles = append(v.Vehicles, veh)
+		return nil
+	})
+	sort.SliceStable(v.Vehicles, func(i, j int) bool {
+		return v.Vehicles[i].Index < v.Vehicles[j].Index
+	})
+	return err
+}
+
+// Satu elemen vehicleN/axles/axleN
+type axleItemXML struct {
+	Index int `xml:"-"`
+
+	Weight struct {
+		Value string `xml:"value,attr"`
+	} `xml:"weight"` // kg
+	Spacing struct {
+		Value string `xml:"value,attr"`
+	} `xml:"spacing"` // mm ke sumbu sebelumnya
+	NWheels struct {
+		Value string `xml:"value,attr"`
+	} `xml:"nwheels"`
+	Tyre struct {
+		Value string `xml:"value,attr"`
+	} `xml:"tyre"` // single / dual
+	Group struct {
+		Value string `xml:"value,attr"`
+	} `xml:"group"`
+}
+
+// axleList menampung semua elemen axleN dalam urutan index
+type axleList struct {
+	Items []axleItemXML
+}
+
+func (a *axleList) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	err := decodeIndexed(d, "axle", func(idx int, el xml.StartElement) error {
+		var item axleItemXML
+		if err := d.DecodeElement(&item, &el); err != nil {
+			return err
+		}
+		item.Index = idx
+		a.Items = append(a.Items, item)
+		return nil
+	})
+	sort.SliceStable(a.Items, func(i, j int) bool {
+		return a.Items[i].Index < a.Items[j].Index
+	})
+	return err
+}
+
+// decodeIndexed memanggil fn untuk setiap child "<prefix>N" sampai end element parent;
+// child lain dilewati
+func decodeIndexed(d *xml.Decoder, prefix string, fn func(idx int, el xml.StartElement) error) error {
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			idx, ok := indexedElement(t.Name.Local, prefix)
+			if !ok {
+				if err := d.Skip(); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fn(idx, t); err != nil {
+				return fmt.Errorf("decode %s: %w", t.Name.Local, err)
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// indexedElement mengambil N dari nama elemen "<prefix>N"
+func indexedElement(name, prefix string) (int, bool) {
+	digits, ok := strings.CutPrefix(name, prefix)
+	if !ok || digits == "" {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(digits)
+	if err != nil || idx < 0 {
+		return 0, false
+	}
+	return idx, true
 }
 
 // ===== Processor untuk folder AXLE =====
 
 type AxleProcessor struct {
-	DB        *sql.DB
-	SiteUUID  string // Site UUID from master_site.id
-	RemoteDir string
-	Minio     *minio.Client
-	Bucket    string
+	DB          *sql.DB
+	SiteUUID    string // Site UUID from master_site.id
+	RemoteDir   string
+	Minio       *minio.Client
+	Bucket      string
+	Classes     *vehicleclass.Resolver // Optional vehicle class resolution
+	Overload    *overload.Service      // Optional overload evaluation
+	Dimension   *odol.Service          // Optional over-dimension evaluation
+	Violations  *violation.Service     // Optional violation cases from overload / over-dimension
+	Golongan    *golongan.Service      // Optional toll Golongan assignment
+	Correlation *correlation.Service   // Optional ANPR/axle correlation into transact_vehicle
+	Quality     *quality.Service       // Per-device validation failure counters
 }
 
 func NewAxleProcessor(db *sql.DB, siteUUID, remoteDir, endpoint, accessKey, secretKey, bucket string, useSSL bool) (*AxleProcessor, error) {
@@ -99,9 +245,40 @@ func NewAxleProcessor(db *sql.DB, siteUUID, remoteDir, endpoint, accessKey, secr
 		RemoteDir: remoteDir,
 		Minio:     mc,
 		Bucket:    bucket,
+		Quality:   quality.NewService(db),
 	}, nil
 }
 
+// SetClassResolver sets the resolver that maps each capture to master_vehicle_class
+func (p *AxleProcessor) SetClassResolver(r *vehicleclass.Resolver) {
+	p.Classes = r
+}
+
+// SetOverloadEvaluator sets the overload service used after each capture is stored
+func (p *AxleProcessor) SetOverloadEvaluator(s *overload.Service) {
+	p.Overload = s
+}
+
+// SetOverDimensionEvaluator sets the over-dimension service used after each capture is stored
+func (p *AxleProcessor) SetOverDimensionEvaluator(s *odol.Service) {
+	p.Dimension = s
+}
+
+// SetGolonganService sets the service that assigns the toll Golongan after class resolution
+func (p *AxleProcessor) SetGolonganService(s *golongan.Service) {
+	p.Golongan = s
+}
+
+// SetCorrelationService sets the service that correlates each evaluated capture with ANPR captures
+func (p *AxleProcessor) SetCorrelationService(s *correlation.Service) {
+	p.Correlation = s
+}
+
+// SetViolationService sets the service that raises violations after each capture is evaluated
+func (p *AxleProcessor) SetViolationService(s *violation.Service) {
+	p.Violations = s
+}
+
 // Dipanggil watcher tiap kali ada file di folder AXLE
 // Kita hanya proses file .xml
 func (p *AxleProcessor) HandleNewFileAXLE(ctx context.Context, c *ftp.ServerConn, name string) bool {
@@ -111,16 +288,28 @@ func (p *AxleProcessor) HandleNewFileAXLE(ctx context.Context, c *ftp.ServerConn
 
 	log.Println("[AXLE] processing xml:", name)
 
-	meta, err := p.parseAxleXML(ctx, c, name)
+	vehicles, err := p.parseAxleXML(ctx, c, name)
 	if err != nil {
 		log.Println("[AXLE] parse xml error:", err)
 		// xml rusak → tandai selesai saja (tidak retry terus)
 		return true
 	}
+	meta := vehicles[0]
 
-	log.Printf("[AXLE] ID=%s Plate=%s Time=%s Cam=%s Length=%dmm Axles=%d Wheels=%d Cat=%s Body=%s\n",
-		meta.ID, meta.Plate, meta.FrameTime, meta.CameraID,
-		meta.Length, meta.NAxles, meta.NWheels, meta.Category, meta.BodyType)
+	for _, v := range vehicles {
+		for _, is := range v.Quality.Issues {
+			log.Printf("[AXLE] Warning: ID=%s %s %s %s=%q: %s", v.ID, is.Location, is.Flag, is.Field, is.Value, is.Message)
+		}
+		log.Printf("[AXLE] ID=%s Vehicle=%d/%d Plate=%s Time=%s Cam=%s Length=%dmm Gross=%dkg Axles=%d Wheels=%d Cat=%s Body=%s\n",
+			v.ID, v.VehicleIndex, v.VehicleCount, v.Plate, v.FrameTime, v.CameraID,
+			v.Length, v.GrossWeight, v.NAxles, v.NWheels, v.Category, v.BodyType)
+	}
+
+	// ID event adalah kunci upsert; tanpa ID yang valid semua kendaraan event ditolak
+	if meta.Quality.Rejected != "" {
+		p.reject(ctx, c, name, vehicles)
+		return true
+	}
 
 	// Folder tanggal hari ini, misal: 03122025
 	datePrefix := time.Now().Format("02012006")
@@ -136,19 +325,31 @@ func (p *AxleProcessor) HandleNewFileAXLE(ctx context.Context, c *ftp.ServerConn
 	xmlObj := fmt.Sprintf("%s/%s", datePrefix, name)
 	imgObj := fmt.Sprintf("%s/%s", datePrefix, imgName)
 
-	if err := p.uploadXML(ctx, c, name, xmlObj); err != nil {
+	// object yang sudah ter-upload dihapus lagi kalau proses gagal sebelum commit
+	tx := newIngestTx(p.DB, p.Minio, p.Bucket, reconcile.AxleSpec, meta.ID)
+	defer tx.rollback()
+
+	xmlSHA, err := p.uploadXML(ctx, c, tx, name, xmlObj)
+	if err != nil {
 		log.Println("[AXLE] upload xml error:", err)
 		return false
 	}
-	if err := p.uploadImage(ctx, c, imgName, imgObj); err != nil {
+	imgSHA, err := p.uploadImage(ctx, c, tx, imgName, imgObj)
+	if err != nil {
 		log.Println("[AXLE] upload image error:", err)
 		return false
 	}
 
-	if err := p.insertAxleRecord(ctx, meta, datePrefix, xmlObj, imgObj); err != nil {
+	captureIDs, err := p.insertAxleRecords(ctx, vehicles, datePrefix, xmlObj, imgObj, xmlSHA, imgSHA)
+	if err != nil {
 		log.Println("[AXLE] insert DB error:", err)
 		return false
 	}
+	tx.commit()
+	p.recordQuality(ctx, vehicles)
+
+	// Class resolution lalu overload; kegagalan hanya di-log, tidak memblokir ingestion
+	p.evaluateCaptures(ctx, captureIDs)
 
 	// semua sudah ke-upload → hapus dari FTP
 	if err := p.deleteFTP(c, []string{name, imgName}); err != nil {
@@ -161,7 +362,8 @@ func (p *AxleProcessor) HandleNewFileAXLE(ctx context.Context, c *ftp.ServerConn
 	return true
 }
 
-func (p *AxleProcessor) parseAxleXML(ctx context.Context, c *ftp.ServerConn, name string) (*AxleMetadata, error) {
+// parseAxleXML mengembalikan satu metadata per kendaraan (minimal satu)
+func (p *AxleProcessor) parseAxleXML(ctx context.Context, c *ftp.ServerConn, name string) ([]*AxleMetadata, error) {
 	r, err := c.Retr(path.Join(p.RemoteDir, name))
 	if err != nil {
 		return nil, fmt.Errorf("ftp retr xml: %w", err)
@@ -178,20 +380,56 @@ func (p *AxleProcessor) parseAxleXML(ctx context.Context, c *ftp.ServerConn, nam
 		return nil, fmt.Errorf("unmarshal xml: %w", err)
 	}
 
-	meta := &AxleMetadata{
-		Plate:     x.ANPR.Text.Value,
-		FrameTime: x.Capture.FrameTime.Value,
-		CameraID:  x.CameraID.Value,
-		ID:        x.ID.Value,
-		Category:  x.VAC.Vehicle0.Category.Value,
-		BodyType:  x.VAC.Vehicle0.BodyType.Value,
+	return axleVehicles(&x), nil
+}
+
+// axleVehicles memecah satu event menjadi metadata per kendaraan.
+// Event tanpa elemen vehicleN tetap menghasilkan satu row (vehicle_index 0).
+func axleVehicles(x *axleXML) []*AxleMetadata {
+	vehicles := x.VAC.Vehicles
+	if len(vehicles) == 0 {
+		vehicles = []axleVehicleXML{{}}
 	}
 
-	fmt.Sscanf(x.VAC.Vehicle0.Length.Value, "%d", &meta.Length)
-	fmt.Sscanf(x.VAC.Vehicle0.NWheels.Value, "%d", &meta.NWheels)
-	fmt.Sscanf(x.VAC.Vehicle0.NAxles.Value, "%d", &meta.NAxles)
+	out := make([]*AxleMetadata, 0, len(vehicles))
+	for _, v := range vehicles {
+		meta := &AxleMetadata{
+			Plate:        x.ANPR.Text.Value,
+			FrameTime:    x.Capture.FrameTime.Value,
+			CameraID:     x.CameraID.Value,
+			ID:           x.ID.Value,
+			VehicleIndex: v.Index,
+			VehicleCount: len(vehicles),
+			Category:     v.Category.Value,
+			BodyType:     v.BodyType.Value,
+		}
+
+		validateAxleVehicle(x, v, meta)
+		if meta.NAxles == 0 {
+			meta.NAxles = len(meta.Axles)
+		}
+
+		// Berat total: pakai nilai sensor jika ada, selain itu jumlah beban sumbu
+		// (hanya jika semua beban sumbu valid, jumlah parsial bukan berat total)
+		if meta.GrossWeight == 0 {
+			meta.GrossWeight = sumAxleWeights(meta.Axles)
+		}
+
+		out = append(out, meta)
+	}
+	return out
+}
 
-	return meta, nil
+// sumAxleWeights returns 0 when any axle weight is missing
+func sumAxleWeights(details []axle.Detail) int {
+	total := 0
+	for _, d := range details {
+		if d.WeightKg == 0 {
+			return 0
+		}
+		total += d.WeightKg
+	}
+	return total
 }
 
 func (p *AxleProcessor) findImageForAxleXML(c *ftp.ServerConn, xmlName string) (string, error) {
@@ -223,38 +461,38 @@ func (p *AxleProcessor) findImageForAxleXML(c *ftp.ServerConn, xmlName string) (
 	return candidate, nil
 }
 
-func (p *AxleProcessor) uploadXML(ctx context.Context, c *ftp.ServerConn, xmlName, objectName string) error {
-	r, err := c.Retr(path.Join(p.RemoteDir, xmlName))
+// uploadXML streams the XML from FTP to MinIO and returns its SHA-256 (hex)
+func (p *AxleProcessor) uploadXML(ctx context.Context, c *ftp.ServerConn, tx *ingestTx, xmlName, objectName string) (string, error) {
+	digest, err := tx.upload(ctx, c, path.Join(p.RemoteDir, xmlName), objectName, "application/xml", nil)
 	if err != nil {
-		return fmt.Errorf("ftp retr xml: %w", err)
+		return "", fmt.Errorf("upload xml: %w", err)
 	}
-	defer r.Close()
+	log.Printf("[AXLE] uploaded xml to minio: %s (sha256=%s)", objectName, digest)
+	return digest, nil
+}
 
-	_, err = p.Minio.PutObject(ctx, p.Bucket, objectName, r, -1, minio.PutObjectOptions{
-		ContentType: "application/xml",
-	})
+// uploadImage streams an image from FTP to MinIO and returns its SHA-256 (hex)
+func (p *AxleProcessor) uploadImage(ctx context.Context, c *ftp.ServerConn, tx *ingestTx, ftpName, objectName string) (string, error) {
+	digest, err := tx.upload(ctx, c, path.Join(p.RemoteDir, ftpName), objectName, "image/jpeg", nil)
 	if err != nil {
-		return fmt.Errorf("minio put xml: %w", err)
+		return "", fmt.Errorf("upload image: %w", err)
 	}
-	log.Println("[AXLE] uploaded xml to minio:", objectName)
-	return nil
+	log.Printf("[AXLE] uploaded image to minio: %s (sha256=%s)", objectName, digest)
+	return digest, nil
 }
 
-func (p *AxleProcessor) uploadImage(ctx context.Context, c *ftp.ServerConn, ftpName, objectName string) error {
-	r, err := c.Retr(path.Join(p.RemoteDir, ftpName))
-	if err != nil {
-		return fmt.Errorf("ftp retr image: %w", err)
+// reject menghitung kegagalan validasi lalu memindahkan XML (dan gambar jika sudah ada)
+// ke folder rejected/ tanpa menyimpan ke database
+func (p *AxleProcessor) reject(ctx context.Context, c *ftp.ServerConn, name string, vehicles []*AxleMetadata) {
+	log.Printf("[AXLE] Rejected %s: invalid %s, not stored", name, vehicles[0].Quality.Rejected)
+	p.recordQuality(ctx, vehicles)
+	names := []string{name}
+	if imgName, err := p.findImageForAxleXML(c, name); err == nil {
+		names = append(names, imgName)
 	}
-	defer r.Close()
-
-	_, err = p.Minio.PutObject(ctx, p.Bucket, objectName, r, -1, minio.PutObjectOptions{
-		ContentType: "image/jpeg",
-	})
-	if err != nil {
-		return fmt.Errorf("minio put image: %w", err)
+	if err := quarantineFTP(c, p.RemoteDir, names); err != nil {
+		log.Println("[AXLE] quarantine ftp error:", err)
 	}
-	log.Println("[AXLE] uploaded image to minio:", objectName)
-	return nil
 }
 
 func (p *AxleProcessor) deleteFTP(c *ftp.ServerConn, names []string) error {
@@ -268,22 +506,217 @@ func (p *AxleProcessor) deleteFTP(c *ftp.ServerConn, names []string) error {
 	return nil
 }
 
-func (p *AxleProcessor) insertAxleRecord(ctx context.Context, meta *AxleMetadata, dateFolder, xmlObj, imgObj string) error {
-	var capturedAt sql.NullTime
-	if meta.FrameTime != "" {
-		if t, err := time.Parse("2006.01.02 15:04:05.000", meta.FrameTime); err == nil {
-			capturedAt.Valid = true
-			capturedAt.Time = t
+// axleDetails mengubah elemen axleN menjadi detail per sumbu lengkap dengan grup sumbu.
+// Nilai yang ditolak validasi tetap 0 (disimpan NULL oleh axle.SaveDetails).
+func axleDetails(items []axleItemXML, vehicleIndex int, q *quality.Result) []axle.Detail {
+	if len(items) == 0 {
+		return nil
+	}
+
+	details := make([]axle.Detail, 0, len(items))
+	for _, it := range items {
+		q.Location = fmt.Sprintf("vehicle%d/axle%d", vehicleIndex, it.Index)
+		var d axle.Detail
+		d.WeightKg, _ = q.Int(it.Weight.Value, axleItemWeight)
+		d.SpacingMM, _ = q.Int(it.Spacing.Value, axleItemSpacing)
+		d.WheelCount, _ = q.Int(it.NWheels.Value, axleItemWheels)
+		d.GroupNo, _ = q.Int(it.Group.Value, axleItemGroup)
+		d.TyreConfig = axle.NormalizeTyre(it.Tyre.Value)
+		details = append(details, d)
+	}
+	axle.AssignGroups(details, axle.DefaultGroupSpacingMM)
+	return details
+}
+
+// insertAxleRecords menyimpan semua kendaraan dalam satu event secara atomik.
+// Row dari proses sebelumnya dengan index yang tidak ada lagi di event ditandai
+// is_deleted (soft delete) agar pelanggaran dan audit trail-nya tetap tersimpan.
+func (p *AxleProcessor) insertAxleRecords(ctx context.Context, vehicles []*AxleMetadata, dateFolder, xmlObj, imgObj, xmlSHA, imgSHA string) ([]string, error) {
+	dbTx, err := p.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer dbTx.Rollback()
+
+	ids := make([]string, 0, len(vehicles))
+	indexes := make([]int64, 0, len(vehicles))
+	for _, meta := range vehicles {
+		id, err := p.insertAxleRecord(ctx, dbTx, meta, dateFolder, xmlObj, imgObj, xmlSHA, imgSHA)
+		if err != nil {
+			return nil, fmt.Errorf("vehicle %d: %w", meta.VehicleIndex, err)
+		}
+		ids = append(ids, id)
+		indexes = append(indexes, int64(meta.VehicleIndex))
+	}
+
+	// Pasangan MATCHED dari kendaraan stale dilepas: ANPR capture kembali ANPR_ONLY agar
+	// bisa dicocokkan lagi. Row AXLE_ONLY dihapus; audit override tetap (SET NULL).
+	_, err = dbTx.ExecContext(ctx, `
+		WITH stale AS (
+			UPDATE public.transact_axle_capture
+			SET is_deleted = true, updated_date = now()
+			WHERE external_id = $1 AND NOT (vehicle_index = ANY($2)) AND is_deleted = false
+			RETURNING id
+		), released AS (
+			UPDATE public.transact_vehicle SET
+				axle_id = NULL,
+				correlation_status = 'ANPR_ONLY',
+				time_diff_seconds = NULL,
+				offset_ms = NULL,
+				match_score = NULL,
+				score_breakdown = NULL,
+				review_required = false,
+				updated_date = now()
+			WHERE axle_id IN (SELECT id FROM stale) AND correlation_status = 'MATCHED'
+		)
+		DELETE FROM public.transact_vehicle
+		WHERE axle_id IN (SELECT id FROM stale) AND correlation_status = 'AXLE_ONLY'`,
+		vehicles[0].ID, indexes)
+	if err != nil {
+		return nil, fmt.Errorf("release stale vehicles: %w", err)
+	}
+
+	if err := dbTx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit: %w", err)
+	}
+	return ids, nil
+}
+
+// recordQuality counts rejected fields per device. Event fields (no location) are
+// shared by every vehicle of the event and counted once.
+func (p *AxleProcessor) recordQuality(ctx context.Context, vehicles []*AxleMetadata) {
+	var issues []quality.Issue
+	for i, v := range vehicles {
+		for _, is := range v.Quality.Issues {
+			if i > 0 && is.Location == "" {
+				continue
+			}
+			issues = append(issues, is)
+		}
+	}
+	if err := p.Quality.RecordFailures(ctx, p.SiteUUID, quality.DeviceAxle, vehicles[0].CameraID, issues); err != nil {
+		log.Printf("[AXLE] Warning: Validation counter update failed for %s: %v", vehicles[0].ID, err)
+	}
+}
+
+// evaluateCaptures first correlates each stored vehicle with ANPR captures, so the
+// matched ANPR (plate, vision dimensions) is known, then resolves the vehicle class
+// and the toll Golongan, overload and over-dimension (all use the resolved class) and
+// finally raises violations from the results
+func (p *AxleProcessor) evaluateCaptures(ctx context.Context, captureIDs []string) {
+	for _, id := range captureIDs {
+		if p.Correlation != nil {
+			p.correlate(ctx, id)
+		}
+		if p.Classes != nil {
+			p.resolveClass(ctx, id)
+		}
+		if p.Golongan != nil {
+			p.assignGolongan(ctx, id)
+		}
+		if p.Overload != nil {
+			p.evaluateOverload(ctx, id)
 		}
+		if p.Dimension != nil {
+			p.evaluateDimension(ctx, id)
+		}
+		if p.Violations != nil {
+			p.syncViolations(ctx, id)
+		}
+	}
+}
+
+func (p *AxleProcessor) resolveClass(ctx context.Context, id string) {
+	res, err := p.Classes.ResolveCapture(ctx, id, false)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Class resolution failed for capture %s: %v", id, err)
+		return
+	}
+	log.Printf("[AXLE] Class capture=%s class=%s rule=%q confidence=%.3f status=%s review=%v",
+		id, res.VehicleClassCode, res.RuleName, res.Confidence, res.Status, res.ReviewRequired)
+}
+
+func (p *AxleProcessor) assignGolongan(ctx context.Context, id string) {
+	a, err := p.Golongan.AssignCapture(ctx, id)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Golongan assignment failed for capture %s: %v", id, err)
+		return
+	}
+	if a.Golongan == 0 {
+		log.Printf("[AXLE] Golongan capture=%s unassigned: no rule matched", id)
+		return
+	}
+	log.Printf("[AXLE] Golongan capture=%s golongan=%s rule=%q", id, a.GolonganLabel, a.RuleName)
+}
+
+func (p *AxleProcessor) evaluateOverload(ctx context.Context, id string) {
+	res, err := p.Overload.EvaluateCapture(ctx, id)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Overload evaluation failed for capture %s: %v", id, err)
+		return
+	}
+	log.Printf("[AXLE] Overload capture=%s gross=%dkg permitted=%.0fkg class=%s road=%d overload=%.2f%% status=%s",
+		id, res.GrossWeightKg, res.PermittedWeightKg, res.VehicleClassCode, res.RoadClass, res.OverloadPct, res.Status)
+}
+
+func (p *AxleProcessor) evaluateDimension(ctx context.Context, id string) {
+	res, err := p.Dimension.EvaluateAxleCapture(ctx, id)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Over-dimension evaluation failed for capture %s: %v", id, err)
+		return
+	}
+	if res.OverDimension == nil {
+		log.Printf("[AXLE] Over-dimension capture=%s skipped: no vehicle class", id)
+		return
+	}
+	log.Printf("[AXLE] Over-dimension capture=%s class=%s checks=%d over=%v",
+		id, res.VehicleClassCode, len(res.Checks), *res.OverDimension)
+}
+
+func (p *AxleProcessor) correlate(ctx context.Context, id string) {
+	v, err := p.Correlation.CorrelateAxle(ctx, id)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Correlation failed for capture %s: %v", id, err)
+		return
+	}
+	log.Printf("[AXLE] Correlation capture=%s vehicle=%s status=%s plate=%s score=%v review=%v",
+		id, v.ID, v.Status, v.PlateNo, scoreText(v.MatchScore), v.ReviewRequired)
+}
+
+func (p *AxleProcessor) syncViolations(ctx context.Context, id string) {
+	violations, err := p.Violations.SyncCapture(ctx, id, violation.SystemActor)
+	if err != nil {
+		log.Printf("[AXLE] Warning: Violation sync failed for capture %s: %v", id, err)
+		return
+	}
+	for _, v := range violations {
+		log.Printf("[AXLE] Violation capture=%s type=%s status=%s detected=%v: %s",
+			id, v.Type, v.Status, v.StillDetected, v.Detail)
+	}
+}
+
+func (p *AxleProcessor) insertAxleRecord(ctx context.Context, dbTx *sql.Tx, meta *AxleMetadata, dateFolder, xmlObj, imgObj, xmlSHA, imgSHA string) (string, error) {
+	var capturedAt sql.NullTime
+	if meta.CapturedAt != nil {
+		capturedAt.Valid = true
+		capturedAt.Time = *meta.CapturedAt
+	}
+
+	issues, err := json.Marshal(meta.Quality.Issues)
+	if err != nil {
+		return "", fmt.Errorf("marshal quality issues: %w", err)
 	}
 
 	query := `
       INSERT INTO public.transact_axle_capture
       (site_id, external_id, plate_no, captured_at, camera_id,
        length_mm, total_wheels, total_axles, vehicle_category, vehicle_body_type,
-       minio_bucket, minio_date_folder, minio_xml_object, minio_image_object)
-      VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14)
-      ON CONFLICT (external_id) DO UPDATE SET
+       minio_bucket, minio_date_folder, minio_xml_object, minio_image_object,
+       xml_sha256, image_sha256, evidence_hashed_at, vehicle_index, vehicle_count, gross_weight_kg,
+       quality_status, quality_issues, lane_no)
+      VALUES ($1,$2,$3,$4,$5,NULLIF($6, 0),NULLIF($7, 0),NULLIF($8, 0),$9,$10,$11,$12,$13,$14,$15,$16,now(),$17,$18,NULLIF($19, 0),
+              $20,$21::jsonb,NULLIF($22, 0))
+      ON CONFLICT (external_id, vehicle_index) DO UPDATE SET
        site_id = EXCLUDED.site_id,
        plate_no = EXCLUDED.plate_no,
        captured_at = EXCLUDED.captured_at,
@@ -297,10 +730,21 @@ func (p *AxleProcessor) insertAxleRecord(ctx context.Context, meta *AxleMetadata
        minio_date_folder = EXCLUDED.minio_date_folder,
        minio_xml_object = EXCLUDED.minio_xml_object,
        minio_image_object = EXCLUDED.minio_image_object,
-       updated_date = now();
+       xml_sha256 = EXCLUDED.xml_sha256,
+       image_sha256 = EXCLUDED.image_sha256,
+       evidence_hashed_at = EXCLUDED.evidence_hashed_at,
+       vehicle_count = EXCLUDED.vehicle_count,
+       gross_weight_kg = EXCLUDED.gross_weight_kg,
+       quality_status = EXCLUDED.quality_status,
+       quality_issues = EXCLUDED.quality_issues,
+       lane_no = EXCLUDED.lane_no,
+       is_deleted = false, -- Kendaraan yang muncul lagi di event dipulihkan
+       updated_date = now()
+      RETURNING id;
       `
 
-	_, err := p.DB.ExecContext(
+	var captureID string
+	err = dbTx.QueryRowContext(
 		ctx,
 		query,
 		p.SiteUUID, // Site UUID from master_site.id
@@ -317,9 +761,21 @@ func (p *AxleProcessor) insertAxleRecord(ctx context.Context, meta *AxleMetadata
 		dateFolder,
 		xmlObj,
 		imgObj,
-	)
+		xmlSHA,
+		imgSHA,
+		meta.VehicleIndex,
+		meta.VehicleCount,
+		meta.GrossWeight,
+		meta.Quality.Status(),
+		string(issues),
+		meta.Lane,
+	).Scan(&captureID)
 	if err != nil {
-		return fmt.Errorf("exec insert: %w", err)
+		return "", fmt.Errorf("exec insert: %w", err)
 	}
-	return nil
+
+	if err := axle.SaveDetails(ctx, dbTx, captureID, meta.Axles); err != nil {
+		return "", err
+	}
+	return captureID, nil
 }
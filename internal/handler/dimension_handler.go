@@ -4,6 +4,8 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"image"
+	"io"
 	"log"
 	"path/filepath"
 	"time"
@@ -30,8 +32,8 @@ type DimensionResult struct {
 }
 
 // NewDimensionHandler creates a new dimension handler
-func NewDimensionHandler(db *sql.DB, siteUUID, modelPath string, threshold float64) (*DimensionHandler, error) {
-	dimensionService := vision.NewDimensionService(modelPath, threshold)
+func NewDimensionHandler(db *sql.DB, siteUUID string, detector vision.VehicleDetector) (*DimensionHandler, error) {
+	dimensionService := vision.NewDimensionService(detector)
 
 	return &DimensionHandler{
 		DB:               db,
@@ -46,18 +48,42 @@ func (dh *DimensionHandler) SetCalibration(calibration *vision.CameraCalibration
 	return dh.DimensionService.SetCalibration(calibration)
 }
 
+// SetCalibrationProfiles sets the per-camera calibration; cameras without a
+// profile keep the calibration from SetCalibration
+func (dh *DimensionHandler) SetCalibrationProfiles(profiles vision.CalibrationSource) {
+	dh.DimensionService.Profiles = profiles
+}
+
 // ProcessImageFile processes a single image file and returns dimensions
 func (dh *DimensionHandler) ProcessImageFile(imagePath string) (*DimensionResult, error) {
 	log.Printf("[DIMENSION_HANDLER] Processing image: %s", imagePath)
+	dimensions, err := dh.DimensionService.ProcessImage(imagePath)
+	return dh.handleResult(imagePath, dimensions, err)
+}
 
+// ProcessImageReader decodes an image from r and returns dimensions.
+// source identifies the image in results (file path or MinIO object name).
+func (dh *DimensionHandler) ProcessImageReader(r io.Reader, source string) (*DimensionResult, error) {
+	log.Printf("[DIMENSION_HANDLER] Processing image: %s", source)
+	dimensions, err := dh.DimensionService.ProcessReader(r, source)
+	return dh.handleResult(source, dimensions, err)
+}
+
+// ProcessImage processes an already decoded image and returns dimensions
+func (dh *DimensionHandler) ProcessImage(img image.Image, source string) (*DimensionResult, error) {
+	log.Printf("[DIMENSION_HANDLER] Processing image: %s", source)
+	dimensions, err := dh.DimensionService.ProcessDecoded(img, source)
+	return dh.handleResult(source, dimensions, err)
+}
+
+// handleResult builds the result and saves it to database if enabled
+func (dh *DimensionHandler) handleResult(source string, dimensions []vision.VehicleDimensions, err error) (*DimensionResult, error) {
 	result := &DimensionResult{
-		ImagePath:   imagePath,
+		ImagePath:   source,
 		ProcessedAt: time.Now(),
 		Success:     false,
 	}
 
-	// Process the image
-	dimensions, err := dh.DimensionService.ProcessImage(imagePath)
 	if err != nil {
 		result.ErrorMessage = err.Error()
 		log.Printf("[DIMENSION_HANDLER] Error processing image: %v", err)
@@ -70,7 +96,7 @@ func (dh *DimensionHandler) ProcessImageFile(imagePath string) (*DimensionResult
 
 	// Save to database if enabled
 	if dh.SaveResults && dh.DB != nil {
-		if err := dh.saveDimensionsToDatabase(imagePath, dimensions); err != nil {
+		if err := dh.saveDimensionsToDatabase(source, dimensions); err != nil {
 			log.Printf("[DIMENSION_HANDLER] Warning: Failed to save to database: %v", err)
 		}
 	}
@@ -80,7 +106,7 @@ func (dh *DimensionHandler) ProcessImageFile(imagePath string) (*DimensionResult
 	return result, nil
 }
 
-// ProcessANPRImage processes an ANPR image with metadata
+// ProcessANPRImage processes an ANPR image file with metadata
 func (dh *DimensionHandler) ProcessANPRImage(imagePath string, plateNumber string, anprID string) (*DimensionResult, error) {
 	log.Printf("[DIMENSION_HANDLER] Processing ANPR image for plate: %s (ANPR ID: %s)", plateNumber, anprID)
 
@@ -88,19 +114,48 @@ func (dh *DimensionHandler) ProcessANPRImage(imagePath string, plateNumber strin
 	if err != nil {
 		return result, err
 	}
+	dh.associateANPR(result, anprID)
+	return result, nil
+}
 
-	// Update database with ANPR association if available
-	if dh.SaveResults && dh.DB != nil && anprID != "" {
-		for i, dims := range result.Dimensions {
-			if err := dh.updateANPRWithDimensions(anprID, dims, i); err != nil {
-				log.Printf("[DIMENSION_HANDLER] Warning: Failed to update ANPR record: %v", err)
-			}
-		}
-	}
+// ProcessANPRDecodedImage processes an ANPR image that was decoded during ingestion,
+// so the image never has to be written to disk or downloaded again. cameraID selects
+// the detector's background model and, with capturedAt, the calibration profile.
+func (dh *DimensionHandler) ProcessANPRDecodedImage(img image.Image, source, cameraID string, capturedAt time.Time, plateNumber, anprID string) (*DimensionResult, error) {
+	log.Printf("[DIMENSION_HANDLER] Processing ANPR image for plate: %s (ANPR ID: %s, camera: %s)", plateNumber, anprID, cameraID)
 
+	dimensions, err := dh.DimensionService.ProcessCamera(img, source, cameraID, capturedAt)
+	result, err := dh.handleResult(source, dimensions, err)
+	if err != nil {
+		return result, err
+	}
+	dh.associateANPR(result, anprID)
 	return result, nil
 }
 
+// associateANPR stores the dimensions of the most confident valid vehicle on the
+// ANPR capture (transact_anpr_capture.vision_*), used by the over-dimension check
+func (dh *DimensionHandler) associateANPR(result *DimensionResult, anprID string) {
+	if !dh.SaveResults || dh.DB == nil || anprID == "" {
+		return
+	}
+	best := -1
+	for i, dims := range result.Dimensions {
+		if !dims.IsValid() {
+			continue
+		}
+		if best < 0 || dims.Confidence > result.Dimensions[best].Confidence {
+			best = i
+		}
+	}
+	if best < 0 {
+		return
+	}
+	if err := dh.updateANPRWithDimensions(anprID, result.Dimensions[best]); err != nil {
+		log.Printf("[DIMENSION_HANDLER] Warning: Failed to update ANPR record: %v", err)
+	}
+}
+
 // saveDimensionsToDatabase saves dimension results to database
 func (dh *DimensionHandler) saveDimensionsToDatabase(imagePath string, dimensions []vision.VehicleDimensions) error {
 	// Create table if not exists
@@ -166,43 +221,34 @@ func (dh *DimensionHandler) saveDimensionsToDatabase(imagePath string, dimension
 	return nil
 }
 
-// updateANPRWithDimensions updates ANPR record with dimension data
-func (dh *DimensionHandler) updateANPRWithDimensions(anprID string, dims vision.VehicleDimensions, vehicleIndex int) error {
-	// Check if dimension columns exist in anpr_data table
-	alterTableSQL := `
-		ALTER TABLE anpr_data 
-		ADD COLUMN IF NOT EXISTS vehicle_length DECIMAL(10, 3),
-		ADD COLUMN IF NOT EXISTS vehicle_width DECIMAL(10, 3),
-		ADD COLUMN IF NOT EXISTS vehicle_height DECIMAL(10, 3),
-		ADD COLUMN IF NOT EXISTS vehicle_class VARCHAR(50),
-		ADD COLUMN IF NOT EXISTS dimension_confidence DECIMAL(5, 4);
+// updateANPRWithDimensions updates the ANPR capture with vision dimension data
+func (dh *DimensionHandler) updateANPRWithDimensions(anprID string, dims vision.VehicleDimensions) error {
+	updateSQL := `
+		UPDATE public.transact_anpr_capture
+		SET vision_length_m = $2,
+		    vision_width_m = $3,
+		    vision_height_m = $4,
+		    vision_confidence = $5,
+		    vision_measured_at = $6,
+		    vision_calibration_id = NULLIF($7, '')::uuid,
+		    updated_date = now()
+		WHERE id::text = $1
 	`
 
-	if _, err := dh.DB.Exec(alterTableSQL); err != nil {
-		return fmt.Errorf("failed to alter table: %w", err)
+	measuredAt := dims.Timestamp
+	if measuredAt.IsZero() {
+		measuredAt = time.Now()
 	}
 
-	vehicleClass := dh.DimensionService.ClassifyVehicle(dims)
-
-	// Update ANPR record with dimension data
-	updateSQL := `
-		UPDATE anpr_data 
-		SET vehicle_length = $1,
-		    vehicle_width = $2,
-		    vehicle_height = $3,
-		    vehicle_class = $4,
-		    dimension_confidence = $5
-		WHERE id = $6
-	`
-
 	_, err := dh.DB.Exec(
 		updateSQL,
+		anprID,
 		dims.LengthMeters,
 		dims.WidthMeters,
 		dims.HeightMeters,
-		vehicleClass.Class,
 		dims.Confidence,
-		anprID,
+		measuredAt,
+		dims.CalibrationID,
 	)
 
 	return err
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"path"
+	"time"
+
+	"wim-service/internal/evidence"
+	"wim-service/internal/reconcile"
+
+	"github.com/jlaffaye/ftp"
+	"github.com/minio/minio-go/v7"
+)
+
+// ingestTx tracks the objects uploaded while ingesting one capture. Until commit is
+// called, rollback removes them again so a failed insert or a mid-way upload error
+// never leaves objects in MinIO without a database row.
+type ingestTx struct {
+	db         *sql.DB
+	mc         *minio.Client
+	bucket     string
+	spec       reconcile.Spec
+	externalID string
+	objects    []string
+	committed  bool
+}
+
+func newIngestTx(db *sql.DB, mc *minio.Client, bucket string, spec reconcile.Spec, externalID string) *ingestTx {
+	return &ingestTx{
+		db:         db,
+		mc:         mc,
+		bucket:     bucket,
+		spec:       spec,
+		externalID: externalID,
+	}
+}
+
+// upload streams a file from FTP to MinIO, tracks it and returns its SHA-256 (hex).
+// If tap is not nil it receives the same bytes while they are uploaded.
+func (t *ingestTx) upload(ctx context.Context, c *ftp.ServerConn, remotePath, objectName, contentType string, tap io.Writer) (string, error) {
+	r, err := c.Retr(remotePath)
+	if err != nil {
+		return "", fmt.Errorf("ftp retr %s: %w", path.Base(remotePath), err)
+	}
+	defer r.Close()
+
+	// Track before the put: a failed multipart upload may still leave a partial object
+	t.objects = append(t.objects, objectName)
+
+	dr := evidence.NewDigestReader(r)
+	var body io.Reader = dr
+	if tap != nil {
+		body = io.TeeReader(dr, tap)
+	}
+	_, err = t.mc.PutObject(ctx, t.bucket, objectName, body, -1, minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("minio put: %w", err)
+	}
+	return dr.Sum(), nil
+}
+
+// commit marks the ingestion as successful; the uploaded objects are kept
+func (t *ingestTx) commit() {
+	t.committed = true
+}
+
+// rollback removes every uploaded object unless the ingestion was committed.
+// Objects that cannot be removed are recorded as orphaned for the reconciliation job.
+func (t *ingestTx) rollback() {
+	if t.committed || len(t.objects) == 0 {
+		return
+	}
+
+	// The caller's context may already be cancelled (shutdown); cleanup gets its own
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	tag := "[" + t.spec.Source + "]"
+	for i := len(t.objects) - 1; i >= 0; i-- {
+		obj := t.objects[i]
+
+		// A retry of an already-ingested file overwrites the same objects; never delete
+		// objects an existing row points at
+		referenced, err := reconcile.ObjectReferenced(ctx, t.db, t.spec, t.bucket, obj)
+		if err != nil {
+			log.Printf("%s rollback: %v", tag, err)
+		}
+		if referenced {
+			log.Printf("%s rollback: keeping %s (referenced by existing capture)", tag, obj)
+			continue
+		}
+
+		if err := t.mc.RemoveObject(ctx, t.bucket, obj, minio.RemoveObjectOptions{}); err != nil {
+			log.Printf("%s rollback: failed to remove %s: %v", tag, obj, err)
+			orphan := reconcile.Orphan{
+				Source:     t.spec.Source,
+				Bucket:     t.bucket,
+				Object:     obj,
+				ExternalID: t.externalID,
+				Reason:     reconcile.ReasonIngestRollback,
+				LastError:  err.Error(),
+			}
+			if err := reconcile.RecordOrphan(ctx, t.db, orphan); err != nil {
+				log.Printf("%s rollback: failed to record orphan %s: %v", tag, obj, err)
+			}
+			continue
+		}
+		log.Printf("%s rollback: removed %s", tag, obj)
+	}
+	t.objects = nil
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"fmt"
+	"path"
+	"time"
+
+	"wim-service/internal/quality"
+
+	"github.com/jlaffaye/ftp"
+)
+
+// Layout frametime dari kamera ANPR/axle, contoh: 2025.12.01 14:06:27.946
+const frameTimeLayout = "2006.01.02 15:04:05.000"
+
+// Frametime lebih dari ini di depan jam server dianggap jam device salah
+const maxFrameTimeFuture = 24 * time.Hour
+
+// Aturan field ANPR
+var (
+	anprIDSpec         = quality.TextSpec{Field: "id", MaxLen: 64, Required: true, Key: true}
+	anprPlateSpec      = quality.TextSpec{Field: "plate", MaxLen: 32, Required: true}
+	anprFrameTimeSpec  = quality.TimeSpec{Field: "frametime", Layout: frameTimeLayout, MaxFuture: maxFrameTimeFuture, Required: true}
+	anprConfidenceSpec = quality.FloatSpec{Field: "confidence", Unit: "%", Min: 0, Max: 100}
+	laneSpec           = quality.IntSpec{Field: "lane", Min: 1, Max: 20}
+)
+
+// Aturan field axle (per event, per kendaraan dan per sumbu)
+var (
+	axleIDSpec        = quality.TextSpec{Field: "id", MaxLen: 64, Required: true, Key: true}
+	axlePlateSpec     = quality.TextSpec{Field: "plate", MaxLen: 32}
+	axleFrameTimeSpec = quality.TimeSpec{Field: "frametime", Layout: frameTimeLayout, MaxFuture: maxFrameTimeFuture, Required: true}
+
+	axleLengthSpec  = quality.IntSpec{Field: "length", Unit: "mm", Min: 1000, Max: 30000, Required: true}
+	axleWeightSpec  = quality.IntSpec{Field: "weight", Unit: "kg", Min: 100, Max: 200000}
+	axleWheelsSpec  = quality.IntSpec{Field: "nwheels", Min: 2, Max: 40}
+	axleNAxlesSpec  = quality.IntSpec{Field: "naxles", Min: 1, Max: 12}
+	axleItemWeight  = quality.IntSpec{Field: "axle.weight", Unit: "kg", Min: 50, Max: 40000}
+	axleItemSpacing = quality.IntSpec{Field: "axle.spacing", Unit: "mm", Min: 0, Max: 20000}
+	axleItemWheels  = quality.IntSpec{Field: "axle.nwheels", Min: 1, Max: 8}
+	axleItemGroup   = quality.IntSpec{Field: "axle.group", Min: 0, Max: 20}
+)
+
+// validateANPR memeriksa field ANPR; nilai yang ditolak disimpan sebagai NULL.
+// Record tanpa id yang valid ditolak (Quality.Rejected) karena id adalah kunci upsert.
+func validateANPR(x *xmlResult) *ANPRMetadata {
+	meta := &ANPRMetadata{
+		Plate:      x.ANPR.Text.Value,
+		FrameTime:  x.Capture.FrameTime.Value,
+		Location:   x.Location.Value,
+		CameraID:   x.CameraID.Value,
+		Confidence: x.ANPR.Confidence.Value,
+		ID:         x.ID.Value,
+		Lane:       x.Lane.Value,
+	}
+
+	q := &meta.Quality
+	meta.ID, _ = q.Text(meta.ID, anprIDSpec)
+	meta.Plate, _ = q.Text(meta.Plate, anprPlateSpec)
+	if t, ok := q.Time(meta.FrameTime, anprFrameTimeSpec); ok {
+		meta.CapturedAt = &t
+	}
+	if f, ok := q.Float(meta.Confidence, anprConfidenceSpec); ok {
+		meta.ConfidenceValue = &f
+	}
+	meta.LaneNo, _ = q.Int(meta.Lane, laneSpec)
+	return meta
+}
+
+// validateAxleVehicle memeriksa field event dan field satu kendaraan
+func validateAxleVehicle(x *axleXML, v axleVehicleXML, meta *AxleMetadata) {
+	q := &meta.Quality
+	meta.ID, _ = q.Text(meta.ID, axleIDSpec)
+	meta.Plate, _ = q.Text(meta.Plate, axlePlateSpec)
+	if t, ok := q.Time(x.Capture.FrameTime.Value, axleFrameTimeSpec); ok {
+		meta.CapturedAt = &t
+	}
+	meta.Lane, _ = q.Int(x.Lane.Value, laneSpec)
+
+	q.Location = fmt.Sprintf("vehicle%d", v.Index)
+	meta.Length, _ = q.Int(v.Length.Value, axleLengthSpec)
+	meta.NWheels, _ = q.Int(v.NWheels.Value, axleWheelsSpec)
+	meta.NAxles, _ = q.Int(v.NAxles.Value, axleNAxlesSpec)
+	meta.GrossWeight, _ = q.Int(v.Weight.Value, axleWeightSpec)
+
+	meta.Axles = axleDetails(v.Axles.Items, v.Index, q)
+	q.Location = ""
+}
+
+// Subfolder FTP untuk file yang ditolak validasi (tidak disimpan ke database)
+const rejectedDir = "rejected"
+
+// quarantineFTP memindahkan file yang ditolak ke subfolder rejected/ agar tidak
+// diproses (dan dihitung) ulang di setiap polling
+func quarantineFTP(c *ftp.ServerConn, remoteDir string, names []string) error {
+	dir := path.Join(remoteDir, rejectedDir)
+	_ = c.MakeDir(dir) // Sudah ada -> error diabaikan
+	for _, n := range names {
+		if err := c.Rename(path.Join(remoteDir, n), path.Join(dir, n)); err != nil {
+			return fmt.Errorf("move %s to %s: %w", n, dir, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package odol
+
+import "math"
+
+// Evaluate compares each measured dimension with its class limit. Dimensions that
+// were not measured, or whose limit is unknown, produce no check. Height is not
+// checked: vision only estimates it from the length, which is no evidence.
+func Evaluate(m Measured, limits Limits, tol Tolerances) []Check {
+	var checks []Check
+	add := func(dim, source string, measured, limit, tolerance int) {
+		if measured <= 0 || limit <= 0 {
+			return
+		}
+		c := Check{
+			Dimension:   dim,
+			Source:      source,
+			MeasuredMM:  measured,
+			LimitMM:     limit,
+			ToleranceMM: tolerance,
+			ExcessMM:    measured - limit,
+		}
+		c.ExcessPct = math.Round(float64(c.ExcessMM)/float64(limit)*10000) / 100
+		c.IsViolation = c.ExcessMM > tolerance
+		if source == SourceVision {
+			c.AnprID = m.AnprID
+		}
+		checks = append(checks, c)
+	}
+
+	add(DimLength, m.LengthSource, m.LengthMM, limits.LengthMM, tol.LengthMM)
+	add(DimWidth, SourceVision, m.WidthMM, limits.WidthMM, tol.WidthMM)
+	return checks
+}
+
+// AnyViolation reports whether any dimension exceeds its limit beyond tolerance
+func AnyViolation(checks []Check) bool {
+	for _, c := range checks {
+		if c.IsViolation {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,324 @@
+package odol
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"wim-service/internal/regulation"
+	"wim-service/internal/vehicleclass"
+)
+
+// ErrNotFound is returned when the capture does not exist
+var ErrNotFound = errors.New("odol: not found")
+
+// Service evaluates axle captures for over-dimension and stores the checks
+type Service struct {
+	DB           *sql.DB
+	Classes      *vehicleclass.Service
+	Rules        *regulation.Service
+	Tolerances   Tolerances    // Used when no regulation rule is in force
+	VisionWindow time.Duration // Max time between axle and ANPR capture for a vision measurement
+}
+
+// NewService creates a new over-dimension service
+func NewService(db *sql.DB, tol Tolerances, visionWindow time.Duration) *Service {
+	return &Service{
+		DB:           db,
+		Classes:      vehicleclass.NewService(db),
+		Rules:        regulation.NewService(db),
+		Tolerances:   tol,
+		VisionWindow: visionWindow,
+	}
+}
+
+// plateExpr normalizes a plate column like watchlist.NormalizePlate
+const plateExpr = `regexp_replace(upper(COALESCE(%s, '')), '[^A-Z0-9]', '', 'g')`
+
+// EvaluateAxleCapture compares the measured size of an axle capture with the limits
+// of its vehicle class. Length comes from the axle sensor; width (and length when
+// the sensor has none) from the vision dimensions of the ANPR capture
+// it is matched with (automatically or by an override), or, before correlation, of
+// the nearest ANPR capture at the same site. Tolerances come from the OVER_DIMENSION
+// rule in force at the capture time.
+func (s *Service) EvaluateAxleCapture(ctx context.Context, captureID string) (*Result, error) {
+	var siteID, classID string
+	var lengthMM, roadClass int
+	var capturedAt sql.NullTime
+	var plateNorm string
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(c.site_id::text, ''), COALESCE(c.vehicle_class_id::text, ''),
+		       COALESCE(c.length_mm, 0), c.captured_at, `+fmt.Sprintf(plateExpr, "c.plate_no")+`,
+		       COALESCE(ms.road_class, 2)
+		FROM public.transact_axle_capture c
+		LEFT JOIN public.master_site ms ON ms.id = c.site_id
+		WHERE c.id::text = $1 AND c.is_deleted = false`,
+		captureID).Scan(&siteID, &classID, &lengthMM, &capturedAt, &plateNorm, &roadClass)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load capture: %w", err)
+	}
+
+	result := &Result{CaptureID: captureID, Checks: []Check{}, EvaluatedAt: time.Now()}
+
+	if classID != "" {
+		class, err := s.Classes.Get(ctx, classID)
+		if err != nil && !errors.Is(err, vehicleclass.ErrNotFound) {
+			return nil, err
+		}
+		if class != nil {
+			result.VehicleClassID = class.ID
+			result.VehicleClassCode = class.Code
+
+			m := Measured{LengthMM: lengthMM, LengthSource: SourceAxle}
+			if capturedAt.Valid {
+				if err := s.applyVision(ctx, &m, captureID, siteID, plateNorm, capturedAt.Time); err != nil {
+					return nil, err
+				}
+			}
+			limits := Limits{
+				LengthMM: metersToMM(class.Length),
+				WidthMM:  metersToMM(class.Width),
+				HeightMM: metersToMM(class.Height),
+			}
+			tol, err := s.tolerances(ctx, result, roadClass, capturedAt)
+			if err != nil {
+				return nil, err
+			}
+			result.Checks = Evaluate(m, limits, tol)
+			over := AnyViolation(result.Checks)
+			result.OverDimension = &over
+		}
+	}
+
+	if err := s.save(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// tolerances returns the tolerances of the OVER_DIMENSION rule in force for the
+// capture (recorded on the result), or the configured defaults
+func (s *Service) tolerances(ctx context.Context, r *Result, roadClass int, capturedAt sql.NullTime) (Tolerances, error) {
+	at := r.EvaluatedAt
+	if capturedAt.Valid {
+		at = capturedAt.Time
+	}
+	rule, err := s.Rules.InForce(ctx, regulation.Subject{
+		RuleType:       regulation.TypeOverDimension,
+		At:             at,
+		RoadClass:      roadClass,
+		VehicleClassID: r.VehicleClassID,
+	})
+	if err != nil {
+		return Tolerances{}, fmt.Errorf("select rule: %w", err)
+	}
+	if rule == nil {
+		return s.Tolerances, nil
+	}
+	r.RuleID = rule.ID
+	r.RuleVersion = rule.Ref()
+	return Tolerances{
+		LengthMM: rule.LengthToleranceMM,
+		WidthMM:  rule.WidthToleranceMM,
+		HeightMM: rule.HeightToleranceMM,
+	}, nil
+}
+
+// applyVision fills width (and missing length) from the vision dimensions of
+// the ANPR capture paired with the axle capture in transact_vehicle. Proximity (nearest
+// unmatched ANPR capture, plate must match when the axle capture has one) is only used
+// while the capture has no correlation row; an unmatched row means no vision data.
+func (s *Service) applyVision(ctx context.Context, m *Measured, captureID, siteID, plateNorm string, at time.Time) error {
+	var status, anprID string
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT correlation_status, COALESCE(anpr_id::text, '')
+		FROM public.transact_vehicle
+		WHERE axle_id::text = $1`, captureID).Scan(&status, &anprID)
+	switch {
+	case err == nil && status != "MATCHED":
+		return nil // Not matched, unlinked or marked no partner
+	case err == nil:
+		return s.applyVisionOf(ctx, m, anprID)
+	case !errors.Is(err, sql.ErrNoRows):
+		return fmt.Errorf("find correlated anpr capture: %w", err)
+	}
+
+	query := `
+		SELECT an.id, COALESCE(an.vision_length_m, 0), COALESCE(an.vision_width_m, 0)
+		FROM public.transact_anpr_capture an
+		WHERE an.vision_measured_at IS NOT NULL AND an.is_deleted = false
+		  AND ($1 = '' OR an.site_id::text = $1)
+		  AND an.captured_at BETWEEN $2::timestamptz - make_interval(secs => $3) AND $2::timestamptz + make_interval(secs => $3)
+		  AND ($4 = '' OR ` + fmt.Sprintf(plateExpr, "an.plate_no") + ` = $4)
+		  AND NOT EXISTS (
+			SELECT 1 FROM public.transact_vehicle v
+			WHERE v.anpr_id = an.id AND v.correlation_status = 'MATCHED'
+		  )
+		ORDER BY abs(extract(epoch FROM an.captured_at - $2::timestamptz))
+		LIMIT 1`
+
+	var id string
+	var length, width float64
+	err = s.DB.QueryRowContext(ctx, query, siteID, at, s.VisionWindow.Seconds(), plateNorm).
+		Scan(&id, &length, &width)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("find vision measurement: %w", err)
+	}
+	m.setVision(id, length, width)
+	return nil
+}
+
+// applyVisionOf fills the measurement from one ANPR capture, if it has vision dimensions
+func (s *Service) applyVisionOf(ctx context.Context, m *Measured, anprID string) error {
+	var length, width float64
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(vision_length_m, 0), COALESCE(vision_width_m, 0)
+		FROM public.transact_anpr_capture
+		WHERE id::text = $1 AND vision_measured_at IS NOT NULL AND is_deleted = false`,
+		anprID).Scan(&length, &width)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("load vision measurement: %w", err)
+	}
+	m.setVision(anprID, length, width)
+	return nil
+}
+
+// setVision records vision dimensions (meters) of an ANPR capture. vision_height_m is
+// an estimate (a ratio of the length) and is not used.
+func (m *Measured) setVision(anprID string, length, width float64) {
+	m.AnprID = anprID
+	m.WidthMM = metersToMM(width)
+	if m.LengthMM <= 0 {
+		m.LengthMM = metersToMM(length)
+		m.LengthSource = SourceVision
+	}
+}
+
+// EvaluateForANPR re-evaluates the axle captures that an ANPR capture's vision
+// measurement applies to (called when vision dimensions arrive after the axle data)
+// and returns their IDs
+func (s *Service) EvaluateForANPR(ctx context.Context, anprCaptureID string) ([]string, error) {
+	query := `
+		SELECT ax.id
+		FROM public.transact_axle_capture ax
+		JOIN public.transact_vehicle v ON v.axle_id = ax.id
+		WHERE v.anpr_id::text = $1 AND v.correlation_status = 'MATCHED'
+		  AND ax.is_deleted = false AND ax.vehicle_class_id IS NOT NULL
+		UNION
+		SELECT ax.id
+		FROM public.transact_anpr_capture an
+		JOIN public.transact_axle_capture ax
+		  ON (an.site_id IS NULL OR ax.site_id = an.site_id)
+		 AND ax.captured_at BETWEEN an.captured_at - make_interval(secs => $2) AND an.captured_at + make_interval(secs => $2)
+		 AND (` + fmt.Sprintf(plateExpr, "ax.plate_no") + ` = '' OR ` + fmt.Sprintf(plateExpr, "ax.plate_no") + ` = ` + fmt.Sprintf(plateExpr, "an.plate_no") + `)
+		WHERE an.id::text = $1 AND ax.is_deleted = false AND ax.vehicle_class_id IS NOT NULL
+		  AND NOT EXISTS (SELECT 1 FROM public.transact_vehicle v WHERE v.axle_id = ax.id)`
+
+	rows, err := s.DB.QueryContext(ctx, query, anprCaptureID, s.VisionWindow.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("find axle captures: %w", err)
+	}
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("scan axle capture: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, id := range ids {
+		if _, err := s.EvaluateAxleCapture(ctx, id); err != nil {
+			return nil, fmt.Errorf("evaluate %s: %w", id, err)
+		}
+	}
+	return ids, nil
+}
+
+// ListChecks returns the stored dimension checks of an axle capture
+func (s *Service) ListChecks(ctx context.Context, captureID string) ([]Check, error) {
+	query := `
+		SELECT dimension, source, COALESCE(anpr_capture_id::text, ''), measured_mm, limit_mm,
+		       tolerance_mm, excess_mm, excess_pct, is_violation
+		FROM public.transact_dimension_check
+		WHERE axle_capture_id::text = $1
+		ORDER BY array_position(ARRAY['LENGTH', 'WIDTH', 'HEIGHT'], dimension::text)`
+
+	rows, err := s.DB.QueryContext(ctx, query, captureID)
+	if err != nil {
+		return nil, fmt.Errorf("query dimension checks: %w", err)
+	}
+	defer rows.Close()
+
+	checks := []Check{}
+	for rows.Next() {
+		var c Check
+		if err := rows.Scan(&c.Dimension, &c.Source, &c.AnprID, &c.MeasuredMM, &c.LimitMM,
+			&c.ToleranceMM, &c.ExcessMM, &c.ExcessPct, &c.IsViolation); err != nil {
+			return nil, fmt.Errorf("scan dimension check: %w", err)
+		}
+		checks = append(checks, c)
+	}
+	return checks, rows.Err()
+}
+
+func (s *Service) save(ctx context.Context, r *Result) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM public.transact_dimension_check WHERE axle_capture_id::text = $1`, r.CaptureID); err != nil {
+		return fmt.Errorf("delete dimension checks: %w", err)
+	}
+
+	insert := `
+		INSERT INTO public.transact_dimension_check
+			(axle_capture_id, dimension, source, anpr_capture_id, vehicle_class_id,
+			 measured_mm, limit_mm, tolerance_mm, excess_mm, excess_pct, is_violation, evaluated_at)
+		VALUES ($1::uuid, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, '')::uuid, $6, $7, $8, $9, $10, $11, $12)`
+	for _, c := range r.Checks {
+		_, err := tx.ExecContext(ctx, insert, r.CaptureID, c.Dimension, c.Source, c.AnprID, r.VehicleClassID,
+			c.MeasuredMM, c.LimitMM, c.ToleranceMM, c.ExcessMM, c.ExcessPct, c.IsViolation, r.EvaluatedAt)
+		if err != nil {
+			return fmt.Errorf("insert %s check: %w", c.Dimension, err)
+		}
+	}
+
+	var over sql.NullBool
+	if r.OverDimension != nil {
+		over = sql.NullBool{Bool: *r.OverDimension, Valid: true}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_axle_capture
+		SET over_dimension = $2, dimension_evaluated_at = $3, dimension_rule_id = NULLIF($4, '')::uuid,
+		    updated_date = now()
+		WHERE id::text = $1`, r.CaptureID, over, r.EvaluatedAt, r.RuleID); err != nil {
+		return fmt.Errorf("update capture: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+func metersToMM(m float64) int {
+	return int(math.Round(m * 1000))
+}
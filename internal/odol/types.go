@@ -0,0 +1,64 @@
+package odol
+
+import "time"
+
+// Dimensions checked (transact_dimension_check.dimension)
+const (
+	DimLength = "LENGTH"
+	DimWidth  = "WIDTH"
+	DimHeight = "HEIGHT"
+)
+
+// Measurement sources (transact_dimension_check.source)
+const (
+	SourceAxle   = "AXLE"   // length_mm reported by the axle sensor
+	SourceVision = "VISION" // vision.VehicleDimensions stored on the ANPR capture
+)
+
+// Tolerances is the excess (mm) over the class limit still tolerated, per dimension.
+// HeightMM is kept with the rule but not checked until height is measured.
+type Tolerances struct {
+	LengthMM int
+	WidthMM  int
+	HeightMM int
+}
+
+// Measured holds the measured size of one vehicle in mm; zero means not measured
+type Measured struct {
+	LengthMM     int
+	LengthSource string
+	WidthMM      int
+	AnprID       string // ANPR capture the vision measurement came from
+}
+
+// Limits holds the legal size of a vehicle class in mm
+type Limits struct {
+	LengthMM int
+	WidthMM  int
+	HeightMM int
+}
+
+// Check is the comparison of one dimension against its class limit
+type Check struct {
+	Dimension   string  `json:"dimension"`
+	Source      string  `json:"source"`
+	AnprID      string  `json:"anpr_capture_id,omitempty"`
+	MeasuredMM  int     `json:"measured_mm"`
+	LimitMM     int     `json:"limit_mm"`
+	ToleranceMM int     `json:"tolerance_mm"`
+	ExcessMM    int     `json:"excess_mm"`
+	ExcessPct   float64 `json:"excess_pct"`
+	IsViolation bool    `json:"is_violation"`
+}
+
+// Result is the over-dimension evaluation of one axle capture
+type Result struct {
+	CaptureID        string    `json:"capture_id"`
+	VehicleClassID   string    `json:"vehicle_class_id,omitempty"`
+	VehicleClassCode string    `json:"vehicle_class_code,omitempty"`
+	OverDimension    *bool     `json:"over_dimension"`         // nil when the capture has no class
+	RuleID           string    `json:"rule_id,omitempty"`      // Regulation rule applied (empty = configured default)
+	RuleVersion      string    `json:"rule_version,omitempty"` // code@vN
+	Checks           []Check   `json:"checks"`
+	EvaluatedAt      time.Time `json:"evaluated_at"`
+}
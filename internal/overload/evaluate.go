@@ -0,0 +1,33 @@
+package overload
+
+import "math"
+
+// DefaultTolerancePct is the overload (percent of permitted weight) still tolerated
+const DefaultTolerancePct = 5.0
+
+// Evaluate compares a gross weight with the permitted weight. Overload up to and
+// including tolerancePct is WITHIN_TOLERANCE; anything above is OVERLOADED.
+// The returned values are rounded to two decimals as stored in the database.
+func Evaluate(grossKg int, permittedKg, tolerancePct float64) (overloadKg, overloadPct float64, status string) {
+	if grossKg <= 0 || permittedKg <= 0 {
+		return 0, 0, StatusUnknown
+	}
+
+	overloadKg = float64(grossKg) - permittedKg
+	overloadPct = round2(overloadKg / permittedKg * 100)
+	overloadKg = round2(overloadKg)
+
+	switch {
+	case overloadKg <= 0:
+		status = StatusLegal
+	case overloadPct <= tolerancePct:
+		status = StatusWithinTolerance
+	default:
+		status = StatusOverloaded
+	}
+	return overloadKg, overloadPct, status
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,139 @@
+package overload
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"wim-service/internal/regulation"
+	"wim-service/internal/vehicleclass"
+)
+
+// ErrNotFound is returned when the axle capture does not exist
+var ErrNotFound = errors.New("overload: not found")
+
+// Service evaluates axle captures against master_vehicle_class and stores the result
+type Service struct {
+	DB           *sql.DB
+	Classes      *vehicleclass.Service
+	Rules        *regulation.Service
+	TolerancePct float64 // Used when no regulation rule is in force
+}
+
+// NewService creates a new overload service
+func NewService(db *sql.DB, tolerancePct float64) *Service {
+	return &Service{
+		DB:           db,
+		Classes:      vehicleclass.NewService(db),
+		Rules:        regulation.NewService(db),
+		TolerancePct: tolerancePct,
+	}
+}
+
+// EvaluateCapture computes and stores the overload result of one axle capture.
+// The class resolved by vehicleclass.Resolver is used when resolution has run;
+// otherwise the class is looked up by axle count. Tolerance and permitted weight
+// come from the OVERLOAD rule in force at the capture time.
+func (s *Service) EvaluateCapture(ctx context.Context, captureID string) (*Result, error) {
+	var gross, axles sql.NullInt64
+	var roadClass int
+	var classID, classStatus string
+	var capturedAt sql.NullTime
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT c.gross_weight_kg, c.total_axles, COALESCE(ms.road_class, 2),
+		       COALESCE(c.vehicle_class_id::text, ''), COALESCE(c.class_status, ''), c.captured_at
+		FROM public.transact_axle_capture c
+		LEFT JOIN public.master_site ms ON ms.id = c.site_id
+		WHERE c.id::text = $1 AND c.is_deleted = false`,
+		captureID).Scan(&gross, &axles, &roadClass, &classID, &classStatus, &capturedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load capture: %w", err)
+	}
+
+	result := &Result{
+		CaptureID:     captureID,
+		GrossWeightKg: int(gross.Int64),
+		RoadClass:     roadClass,
+		Status:        StatusUnknown,
+		EvaluatedAt:   time.Now(),
+	}
+
+	var class *vehicleclass.Class
+	switch {
+	case classStatus == "":
+		class, err = s.Classes.FindByAxles(ctx, int(axles.Int64), roadClass)
+	case classID != "":
+		class, err = s.Classes.Get(ctx, classID)
+	default:
+		err = vehicleclass.ErrNotFound // Resolution ran but matched no class
+	}
+
+	switch {
+	case errors.Is(err, vehicleclass.ErrNotFound):
+		// No class for this axle count: stored as UNKNOWN
+	case err != nil:
+		return nil, err
+	default:
+		result.VehicleClassID = class.ID
+		result.VehicleClassCode = class.Code
+		result.PermittedWeightKg = class.PermittedWeight(roadClass)
+		result.TolerancePct = s.TolerancePct
+
+		at := result.EvaluatedAt
+		if capturedAt.Valid {
+			at = capturedAt.Time
+		}
+		rule, err := s.Rules.InForce(ctx, regulation.Subject{
+			RuleType:       regulation.TypeOverload,
+			At:             at,
+			RoadClass:      roadClass,
+			VehicleClassID: class.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("select rule: %w", err)
+		}
+		if rule != nil {
+			result.RuleID = rule.ID
+			result.RuleVersion = rule.Ref()
+			result.TolerancePct = rule.TolerancePct
+			if rule.PermittedWeightKg > 0 {
+				result.PermittedWeightKg = rule.PermittedWeightKg
+			}
+		}
+
+		result.OverloadKg, result.OverloadPct, result.Status =
+			Evaluate(result.GrossWeightKg, result.PermittedWeightKg, result.TolerancePct)
+	}
+
+	if err := s.save(ctx, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *Service) save(ctx context.Context, r *Result) error {
+	query := `
+		UPDATE public.transact_axle_capture SET
+			vehicle_class_id = CASE WHEN class_status IS NULL THEN NULLIF($2, '')::uuid ELSE vehicle_class_id END,
+			road_class = $3,
+			permitted_weight_kg = NULLIF($4::numeric, 0),
+			overload_kg = CASE WHEN $7::text = 'UNKNOWN' THEN NULL ELSE $5::numeric END,
+			overload_pct = CASE WHEN $7::text = 'UNKNOWN' THEN NULL ELSE $6::numeric END,
+			overload_status = $7,
+			overload_evaluated_at = $8,
+			overload_rule_id = NULLIF($9, '')::uuid,
+			updated_date = now()
+		WHERE id::text = $1`
+
+	_, err := s.DB.ExecContext(ctx, query, r.CaptureID, r.VehicleClassID, r.RoadClass,
+		r.PermittedWeightKg, r.OverloadKg, r.OverloadPct, r.Status, r.EvaluatedAt, r.RuleID)
+	if err != nil {
+		return fmt.Errorf("save overload result: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package overload
+
+import "time"
+
+// Overload status stored in transact_axle_capture.overload_status
+const (
+	StatusLegal           = "LEGAL"
+	StatusWithinTolerance = "WITHIN_TOLERANCE"
+	StatusOverloaded      = "OVERLOADED"
+	StatusUnknown         = "UNKNOWN" // No matched class, no permitted weight or no measured weight
+)
+
+// Result is the overload evaluation of one axle capture
+type Result struct {
+	CaptureID         string    `json:"capture_id"`
+	GrossWeightKg     int       `json:"gross_weight_kg"`
+	VehicleClassID    string    `json:"vehicle_class_id,omitempty"`
+	VehicleClassCode  string    `json:"vehicle_class_code,omitempty"`
+	RoadClass         int       `json:"road_class"`
+	PermittedWeightKg float64   `json:"permitted_weight_kg"`
+	TolerancePct      float64   `json:"tolerance_pct"`
+	RuleID            string    `json:"rule_id,omitempty"`      // Regulation rule applied (empty = configured default)
+	RuleVersion       string    `json:"rule_version,omitempty"` // code@vN
+	OverloadKg        float64   `json:"overload_kg"`
+	OverloadPct       float64   `json:"overload_pct"`
+	Status            string    `json:"status"`
+	EvaluatedAt       time.Time `json:"evaluated_at"`
+}
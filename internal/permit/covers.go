@@ -0,0 +1,41 @@
+package permit
+
+import "time"
+
+// ValidAt reports whether the permit is active and valid at t for the site
+func (p Permit) ValidAt(t time.Time, siteID string) bool {
+	if !p.IsActive || t.Before(p.ValidFrom) || !t.Before(p.ValidTo) {
+		return false
+	}
+	if len(p.SiteIDs) == 0 {
+		return true
+	}
+	for _, id := range p.SiteIDs {
+		if id == siteID {
+			return true
+		}
+	}
+	return false
+}
+
+// Covers reports whether every exceeded value is within the permit's limit.
+// A value the permit does not cover (limit 0) is never permitted.
+func (p Permit) Covers(e Exceedance) bool {
+	checks := []struct{ measured, max int }{
+		{e.GrossWeightKg, p.MaxGrossWeightKg},
+		{e.LengthMM, p.MaxLengthMM},
+		{e.WidthMM, p.MaxWidthMM},
+		{e.HeightMM, p.MaxHeightMM},
+	}
+	exceeded := false
+	for _, c := range checks {
+		if c.measured <= 0 {
+			continue
+		}
+		exceeded = true
+		if c.max <= 0 || c.measured > c.max {
+			return false
+		}
+	}
+	return exceeded
+}
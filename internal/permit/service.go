@@ -0,0 +1,309 @@
+package permit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wim-service/internal/watchlist"
+)
+
+var (
+	// ErrNotFound is returned when the permit does not exist
+	ErrNotFound = errors.New("permit: not found")
+	// ErrInvalid is returned when a permit request fails validation
+	ErrInvalid = errors.New("permit: invalid request")
+)
+
+// Service manages the dispensation permit registry
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new permit service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+const permitColumns = `
+	p.id, p.permit_no, p.plate_no, p.plate_normalized, COALESCE(p.holder, ''), COALESCE(p.route, ''),
+	COALESCE(p.max_gross_weight_kg, 0), COALESCE(p.max_length_mm, 0), COALESCE(p.max_width_mm, 0),
+	COALESCE(p.max_height_mm, 0), p.valid_from, p.valid_to,
+	COALESCE((SELECT string_agg(ps.site_id::text, ',' ORDER BY ps.site_id)
+	          FROM public.master_dispensation_permit_site ps WHERE ps.permit_id = p.id), ''),
+	COALESCE(p.notes, ''), COALESCE(p.is_active, true), COALESCE(p.created_by, ''),
+	p.created_date, p.updated_date`
+
+func scanPermit(row interface{ Scan(...any) error }) (*Permit, error) {
+	var p Permit
+	var sites string
+	err := row.Scan(&p.ID, &p.PermitNo, &p.PlateNo, &p.PlateNormalized, &p.Holder, &p.Route,
+		&p.MaxGrossWeightKg, &p.MaxLengthMM, &p.MaxWidthMM,
+		&p.MaxHeightMM, &p.ValidFrom, &p.ValidTo,
+		&sites,
+		&p.Notes, &p.IsActive, &p.CreatedBy,
+		&p.CreatedDate, &p.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	p.SiteIDs = []string{}
+	if sites != "" {
+		p.SiteIDs = strings.Split(sites, ",")
+	}
+	return &p, nil
+}
+
+// List returns permits, latest validity first
+func (s *Service) List(ctx context.Context, filter Filter) ([]Permit, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := `SELECT` + permitColumns + `
+		FROM public.master_dispensation_permit p
+		WHERE p.is_deleted = false
+		  AND ($1 = '' OR p.plate_normalized LIKE '%' || $1 || '%')
+		  AND ($2 = '' OR NOT EXISTS (SELECT 1 FROM public.master_dispensation_permit_site ps WHERE ps.permit_id = p.id)
+		               OR EXISTS (SELECT 1 FROM public.master_dispensation_permit_site ps WHERE ps.permit_id = p.id AND ps.site_id::text = $2))
+		  AND (NOT $3 OR COALESCE(p.is_active, true))
+		  AND ($4::timestamptz IS NULL OR (p.valid_from <= $4 AND p.valid_to > $4))
+		ORDER BY p.valid_to DESC, p.permit_no
+		LIMIT $5 OFFSET $6`
+
+	rows, err := s.DB.QueryContext(ctx, query, watchlist.NormalizePlate(filter.PlateNo), filter.SiteID,
+		filter.ActiveOnly, nullTime(filter.At), filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query permits: %w", err)
+	}
+	defer rows.Close()
+
+	permits := []Permit{}
+	for rows.Next() {
+		p, err := scanPermit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan permit: %w", err)
+		}
+		permits = append(permits, *p)
+	}
+	return permits, rows.Err()
+}
+
+// Get returns one permit
+func (s *Service) Get(ctx context.Context, id string) (*Permit, error) {
+	query := `SELECT` + permitColumns + `
+		FROM public.master_dispensation_permit p
+		WHERE p.id::text = $1 AND p.is_deleted = false`
+
+	p, err := scanPermit(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get permit: %w", err)
+	}
+	return p, nil
+}
+
+// FindCovering returns a permit of the plate valid at the site and time that covers
+// the exceedance, or nil when there is none
+func (s *Service) FindCovering(ctx context.Context, plateNo, siteID string, at time.Time, e Exceedance) (*Permit, error) {
+	normalized := watchlist.NormalizePlate(plateNo)
+	if normalized == "" {
+		return nil, nil
+	}
+
+	query := `SELECT` + permitColumns + `
+		FROM public.master_dispensation_permit p
+		WHERE p.is_deleted = false AND COALESCE(p.is_active, true)
+		  AND p.plate_normalized = $1
+		  AND p.valid_from <= $2 AND p.valid_to > $2
+		ORDER BY p.valid_to DESC`
+
+	rows, err := s.DB.QueryContext(ctx, query, normalized, at)
+	if err != nil {
+		return nil, fmt.Errorf("query permits: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		p, err := scanPermit(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan permit: %w", err)
+		}
+		if p.ValidAt(at, siteID) && p.Covers(e) {
+			return p, nil
+		}
+	}
+	return nil, rows.Err()
+}
+
+func validateLimits(req PermitRequest) error {
+	if req.MaxGrossWeightKg < 0 || req.MaxLengthMM < 0 || req.MaxWidthMM < 0 || req.MaxHeightMM < 0 {
+		return fmt.Errorf("%w: limits must not be negative", ErrInvalid)
+	}
+	if req.ValidFrom != nil && req.ValidTo != nil && !req.ValidTo.After(*req.ValidFrom) {
+		return fmt.Errorf("%w: valid_to must be after valid_from", ErrInvalid)
+	}
+	return nil
+}
+
+// Create registers a permit
+func (s *Service) Create(ctx context.Context, req PermitRequest, username string) (*Permit, error) {
+	normalized := watchlist.NormalizePlate(req.PlateNo)
+	if strings.TrimSpace(req.PermitNo) == "" || normalized == "" {
+		return nil, fmt.Errorf("%w: permit_no and plate_no are required", ErrInvalid)
+	}
+	if req.ValidFrom == nil || req.ValidTo == nil {
+		return nil, fmt.Errorf("%w: valid_from and valid_to are required", ErrInvalid)
+	}
+	if req.MaxGrossWeightKg == 0 && req.MaxLengthMM == 0 && req.MaxWidthMM == 0 && req.MaxHeightMM == 0 {
+		return nil, fmt.Errorf("%w: at least one limit is required", ErrInvalid)
+	}
+	if err := validateLimits(req); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO public.master_dispensation_permit
+			(permit_no, plate_no, plate_normalized, holder, route,
+			 max_gross_weight_kg, max_length_mm, max_width_mm, max_height_mm,
+			 valid_from, valid_to, notes, is_active, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''),
+		        NULLIF($6, 0), NULLIF($7, 0), NULLIF($8, 0), NULLIF($9, 0),
+		        $10, $11, NULLIF($12, ''), $13, NULLIF($14, ''))
+		RETURNING id`,
+		strings.TrimSpace(req.PermitNo), strings.TrimSpace(req.PlateNo), normalized, req.Holder, req.Route,
+		req.MaxGrossWeightKg, req.MaxLengthMM, req.MaxWidthMM, req.MaxHeightMM,
+		*req.ValidFrom, *req.ValidTo, req.Notes, isActive, username).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert permit: %w", err)
+	}
+	if err := replaceSites(ctx, tx, id, req.SiteIDs); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Update updates a permit; empty fields are left unchanged
+func (s *Service) Update(ctx context.Context, id string, req PermitRequest) (*Permit, error) {
+	if err := validateLimits(req); err != nil {
+		return nil, err
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE public.master_dispensation_permit SET
+			permit_no = COALESCE(NULLIF($2, ''), permit_no),
+			plate_no = COALESCE(NULLIF($3, ''), plate_no),
+			plate_normalized = COALESCE(NULLIF($4, ''), plate_normalized),
+			holder = COALESCE(NULLIF($5, ''), holder),
+			route = COALESCE(NULLIF($6, ''), route),
+			max_gross_weight_kg = COALESCE(NULLIF($7, 0), max_gross_weight_kg),
+			max_length_mm = COALESCE(NULLIF($8, 0), max_length_mm),
+			max_width_mm = COALESCE(NULLIF($9, 0), max_width_mm),
+			max_height_mm = COALESCE(NULLIF($10, 0), max_height_mm),
+			valid_from = COALESCE($11, valid_from),
+			valid_to = COALESCE($12, valid_to),
+			notes = COALESCE(NULLIF($13, ''), notes),
+			is_active = COALESCE($14, is_active),
+			updated_date = now()
+		WHERE id::text = $1 AND is_deleted = false`, id,
+		strings.TrimSpace(req.PermitNo), strings.TrimSpace(req.PlateNo), watchlist.NormalizePlate(req.PlateNo),
+		req.Holder, req.Route,
+		req.MaxGrossWeightKg, req.MaxLengthMM, req.MaxWidthMM, req.MaxHeightMM,
+		nullTime(req.ValidFrom), nullTime(req.ValidTo), req.Notes, nullBool(req.IsActive))
+	if err != nil {
+		return nil, fmt.Errorf("update permit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+	if req.SiteIDs != nil {
+		if err := replaceSites(ctx, tx, id, req.SiteIDs); err != nil {
+			return nil, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.Get(ctx, id)
+}
+
+// Delete soft-deletes a permit
+func (s *Service) Delete(ctx context.Context, id string) error {
+	res, err := s.DB.ExecContext(ctx,
+		`UPDATE public.master_dispensation_permit SET is_deleted = true, updated_date = now() WHERE id::text = $1 AND is_deleted = false`, id)
+	if err != nil {
+		return fmt.Errorf("delete permit: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func replaceSites(ctx context.Context, tx *sql.Tx, permitID string, siteIDs []string) error {
+	if _, err := tx.ExecContext(ctx,
+		`DELETE FROM public.master_dispensation_permit_site WHERE permit_id::text = $1`, permitID); err != nil {
+		return fmt.Errorf("delete permit sites: %w", err)
+	}
+	for _, siteID := range siteIDs {
+		var exists bool
+		if err := tx.QueryRowContext(ctx,
+			`SELECT EXISTS (SELECT 1 FROM public.master_site WHERE id::text = $1)`, siteID).Scan(&exists); err != nil {
+			return fmt.Errorf("check site: %w", err)
+		}
+		if !exists {
+			return fmt.Errorf("%w: site %s not found", ErrInvalid, siteID)
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO public.master_dispensation_permit_site (permit_id, site_id)
+			VALUES ($1::uuid, $2::uuid)
+			ON CONFLICT DO NOTHING`, permitID, siteID); err != nil {
+			return fmt.Errorf("insert permit site: %w", err)
+		}
+	}
+	return nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
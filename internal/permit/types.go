@@ -0,0 +1,62 @@
+package permit
+
+import "time"
+
+// Permit is an oversize/overweight dispensation permit
+type Permit struct {
+	ID               string    `json:"id"`
+	PermitNo         string    `json:"permit_no"`
+	PlateNo          string    `json:"plate_no"`
+	PlateNormalized  string    `json:"plate_normalized"`
+	Holder           string    `json:"holder"`
+	Route            string    `json:"route"`
+	MaxGrossWeightKg int       `json:"max_gross_weight_kg"` // 0 = weight not covered
+	MaxLengthMM      int       `json:"max_length_mm"`       // 0 = length not covered
+	MaxWidthMM       int       `json:"max_width_mm"`
+	MaxHeightMM      int       `json:"max_height_mm"`
+	ValidFrom        time.Time `json:"valid_from"`
+	ValidTo          time.Time `json:"valid_to"`
+	SiteIDs          []string  `json:"site_ids"` // Empty = all sites
+	Notes            string    `json:"notes"`
+	IsActive         bool      `json:"is_active"`
+	CreatedBy        string    `json:"created_by"`
+	CreatedDate      time.Time `json:"created_date"`
+	UpdatedDate      time.Time `json:"updated_date"`
+}
+
+// PermitRequest creates or updates a permit; on update empty fields are left unchanged
+// and SiteIDs replaces the allowed sites when not nil
+type PermitRequest struct {
+	PermitNo         string     `json:"permit_no"`
+	PlateNo          string     `json:"plate_no"`
+	Holder           string     `json:"holder"`
+	Route            string     `json:"route"`
+	MaxGrossWeightKg int        `json:"max_gross_weight_kg"`
+	MaxLengthMM      int        `json:"max_length_mm"`
+	MaxWidthMM       int        `json:"max_width_mm"`
+	MaxHeightMM      int        `json:"max_height_mm"`
+	ValidFrom        *time.Time `json:"valid_from"`
+	ValidTo          *time.Time `json:"valid_to"`
+	SiteIDs          []string   `json:"site_ids"`
+	Notes            string     `json:"notes"`
+	IsActive         *bool      `json:"is_active"`
+}
+
+// Filter narrows List
+type Filter struct {
+	PlateNo    string
+	SiteID     string
+	ActiveOnly bool
+	At         *time.Time // Only permits valid at this time
+	Limit      int
+	Offset     int
+}
+
+// Exceedance is what a vehicle measured beyond its regular limits; zero fields
+// were not exceeded
+type Exceedance struct {
+	GrossWeightKg int
+	LengthMM      int
+	WidthMM       int
+	HeightMM      int
+}
@@ -0,0 +1,112 @@
+package quality
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Int parses an integer field. Rejected values return ok=false and add an issue;
+// they must be stored as NULL, never as 0.
+func (r *Result) Int(raw string, s IntSpec) (v int, ok bool) {
+	num, ok := r.number(raw, s.Field, s.Unit, s.Required)
+	if !ok {
+		return 0, false
+	}
+	if num != math.Trunc(num) {
+		r.add(s.Field, FlagMalformed, raw, "not an integer", s.Required)
+		return 0, false
+	}
+	if num < float64(s.Min) || num > float64(s.Max) {
+		r.add(s.Field, FlagOutOfRange, raw, fmt.Sprintf("outside [%d, %d]", s.Min, s.Max), s.Required)
+		return 0, false
+	}
+	return int(num), true
+}
+
+// Float parses a decimal field
+func (r *Result) Float(raw string, s FloatSpec) (v float64, ok bool) {
+	num, ok := r.number(raw, s.Field, s.Unit, s.Required)
+	if !ok {
+		return 0, false
+	}
+	if num < s.Min || num > s.Max {
+		r.add(s.Field, FlagOutOfRange, raw, fmt.Sprintf("outside [%g, %g]", s.Min, s.Max), s.Required)
+		return 0, false
+	}
+	return num, true
+}
+
+// Time parses a timestamp field in the local time zone
+func (r *Result) Time(raw string, s TimeSpec) (t time.Time, ok bool) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		if s.Required {
+			r.add(s.Field, FlagMissing, raw, "required", true)
+		}
+		return time.Time{}, false
+	}
+	t, err := time.Parse(s.Layout, value)
+	if err != nil {
+		r.add(s.Field, FlagMalformed, raw, "expected layout "+s.Layout, s.Required)
+		return time.Time{}, false
+	}
+	if s.MaxFuture > 0 && t.After(time.Now().Add(s.MaxFuture)) {
+		r.add(s.Field, FlagOutOfRange, raw, "in the future", s.Required)
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// Text trims a text field and checks presence and length. A failed key field
+// marks the whole record as rejected.
+func (r *Result) Text(raw string, s TextSpec) (v string, ok bool) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		if s.Required {
+			r.add(s.Field, FlagMissing, raw, "required", true)
+			r.reject(s)
+		}
+		return "", !s.Required
+	}
+	if s.MaxLen > 0 && utf8.RuneCountInString(value) > s.MaxLen {
+		r.add(s.Field, FlagOutOfRange, raw, fmt.Sprintf("longer than %d characters", s.MaxLen), s.Required)
+		r.reject(s)
+		return "", false
+	}
+	return value, true
+}
+
+func (r *Result) reject(s TextSpec) {
+	if s.Key && r.Rejected == "" {
+		r.Rejected = s.Field
+	}
+}
+
+// number parses a decimal with an optional unit suffix; empty optional fields
+// return ok=false without an issue
+func (r *Result) number(raw, field, unit string, required bool) (float64, bool) {
+	value := strings.TrimSpace(raw)
+	if value == "" {
+		if required {
+			r.add(field, FlagMissing, raw, "required", true)
+		}
+		return 0, false
+	}
+	if unit != "" && len(value) > len(unit) && strings.EqualFold(value[len(value)-len(unit):], unit) {
+		value = strings.TrimSpace(value[:len(value)-len(unit)])
+	}
+	num, err := strconv.ParseFloat(value, 64)
+	if err != nil || math.IsNaN(num) || math.IsInf(num, 0) {
+		msg := "not a number"
+		if unit != "" {
+			msg += " (unit " + unit + ")"
+		}
+		r.add(field, FlagMalformed, raw, msg, required)
+		return 0, false
+	}
+	return num, true
+}
@@ -0,0 +1,76 @@
+package quality
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Device types (transact_device_validation_stat.device_type)
+const (
+	DeviceANPR = "ANPR"
+	DeviceAxle = "AXLE"
+)
+
+// Service keeps the per-device validation failure counters
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new quality service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+// RecordFailures increments the counter of every (field, flag) rejected in one record
+func (s *Service) RecordFailures(ctx context.Context, siteID, deviceType, deviceID string, issues []Issue) error {
+	if len(issues) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO public.transact_device_validation_stat
+			(site_id, device_type, device_id, field, flag, failure_count, last_value)
+		VALUES ($1::uuid, $2, $3, $4, $5, 1, $6)
+		ON CONFLICT (site_id, device_type, device_id, field, flag) DO UPDATE SET
+			failure_count = transact_device_validation_stat.failure_count + 1,
+			last_value = EXCLUDED.last_value,
+			last_seen_at = now()`
+
+	for _, is := range issues {
+		if _, err := s.DB.ExecContext(ctx, query, siteID, deviceType, deviceID,
+			is.Field, is.Flag, is.Value); err != nil {
+			return fmt.Errorf("record %s/%s: %w", is.Field, is.Flag, err)
+		}
+	}
+	return nil
+}
+
+// ListDeviceStats returns the failure counters, highest first
+func (s *Service) ListDeviceStats(ctx context.Context, filter StatFilter) ([]DeviceStat, error) {
+	query := `
+		SELECT device_type, site_id::text, device_id, field, flag, failure_count,
+		       COALESCE(last_value, ''), first_seen_at, last_seen_at
+		FROM public.transact_device_validation_stat
+		WHERE ($1 = '' OR device_type = $1)
+		  AND ($2 = '' OR site_id::text = $2)
+		  AND ($3 = '' OR device_id = $3)
+		ORDER BY failure_count DESC, last_seen_at DESC`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.DeviceType, filter.SiteID, filter.DeviceID)
+	if err != nil {
+		return nil, fmt.Errorf("query validation stats: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []DeviceStat{}
+	for rows.Next() {
+		var st DeviceStat
+		if err := rows.Scan(&st.DeviceType, &st.SiteID, &st.DeviceID, &st.Field, &st.Flag,
+			&st.FailureCount, &st.LastValue, &st.FirstSeenAt, &st.LastSeenAt); err != nil {
+			return nil, fmt.Errorf("scan validation stat: %w", err)
+		}
+		stats = append(stats, st)
+	}
+	return stats, rows.Err()
+}
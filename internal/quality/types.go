@@ -0,0 +1,114 @@
+package quality
+
+import "time"
+
+// Issue flags (quality_issues[].flag, transact_device_validation_stat.flag)
+const (
+	FlagMissing    = "MISSING"      // Required field absent or empty
+	FlagMalformed  = "MALFORMED"    // Not a number / wrong unit / bad layout
+	FlagOutOfRange = "OUT_OF_RANGE" // Parsed but outside the plausible range
+)
+
+// Record quality status (quality_status column)
+const (
+	StatusValid   = "VALID"   // No issues
+	StatusWarning = "WARNING" // Optional fields rejected (stored as NULL)
+	StatusInvalid = "INVALID" // Required fields missing or rejected
+)
+
+// Issue is one rejected field value
+type Issue struct {
+	Field    string `json:"field"`              // Field name, e.g. "length" or "axle.weight"
+	Location string `json:"location,omitempty"` // Element the field belongs to, e.g. "vehicle1/axle2"
+	Flag     string `json:"flag"`
+	Value    string `json:"value,omitempty"` // Raw value as received
+	Message  string `json:"message"`
+	Required bool   `json:"required"`
+}
+
+// Result collects the issues found while parsing one record
+type Result struct {
+	Issues   []Issue `json:"issues"`
+	Location string  `json:"-"` // Stamped on issues added while set
+	Rejected string  `json:"-"` // Key field that failed; the record must not be stored
+}
+
+// Valid reports whether no field was rejected
+func (r *Result) Valid() bool {
+	return len(r.Issues) == 0
+}
+
+// Status summarizes the result as VALID / WARNING / INVALID
+func (r *Result) Status() string {
+	status := StatusValid
+	for _, is := range r.Issues {
+		if is.Required {
+			return StatusInvalid
+		}
+		status = StatusWarning
+	}
+	return status
+}
+
+func (r *Result) add(field, flag, value, message string, required bool) {
+	r.Issues = append(r.Issues, Issue{
+		Field:    field,
+		Location: r.Location,
+		Flag:     flag,
+		Value:    value,
+		Message:  message,
+		Required: required,
+	})
+}
+
+// IntSpec describes an integer field
+type IntSpec struct {
+	Field    string
+	Unit     string // Accepted (optional) suffix, e.g. "mm" or "kg"
+	Min, Max int
+	Required bool
+}
+
+// FloatSpec describes a decimal field
+type FloatSpec struct {
+	Field    string
+	Unit     string
+	Min, Max float64
+	Required bool
+}
+
+// TimeSpec describes a timestamp field
+type TimeSpec struct {
+	Field     string
+	Layout    string
+	MaxFuture time.Duration // Values later than now + MaxFuture are out of range (0 = unchecked)
+	Required  bool
+}
+
+// TextSpec describes a free text field
+type TextSpec struct {
+	Field    string
+	MaxLen   int
+	Required bool
+	Key      bool // Identifies the record (upsert key); a failure rejects the whole record
+}
+
+// DeviceStat is the validation failure counter of one device, field and flag
+type DeviceStat struct {
+	DeviceType   string    `json:"device_type"`
+	SiteID       string    `json:"site_id"`
+	DeviceID     string    `json:"device_id"`
+	Field        string    `json:"field"`
+	Flag         string    `json:"flag"`
+	FailureCount int64     `json:"failure_count"`
+	LastValue    string    `json:"last_value"`
+	FirstSeenAt  time.Time `json:"first_seen_at"`
+	LastSeenAt   time.Time `json:"last_seen_at"`
+}
+
+// StatFilter narrows ListDeviceStats
+type StatFilter struct {
+	DeviceType string
+	SiteID     string
+	DeviceID   string
+}
@@ -0,0 +1,191 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Reconciler periodically compares a bucket with its capture table: objects that no
+// row references are removed or marked orphaned, and rows whose objects are gone are
+// recorded as MISSING_OBJECT
+type Reconciler struct {
+	DB            *sql.DB
+	Minio         *minio.Client
+	Bucket        string
+	Spec          Spec
+	LookbackDays  int           // Date folders (ddMMyyyy) checked per run
+	Grace         time.Duration // Objects younger than this may still be mid-ingestion
+	RemoveOrphans bool          // Delete NO_DB_ROW objects instead of only recording them
+}
+
+// Report summarizes one reconciliation run
+type Report struct {
+	Folders        int
+	ObjectsChecked int
+	RowsChecked    int
+	Orphans        int
+	Removed        int
+	MissingObjects int
+}
+
+// NewReconciler creates a reconciler for one capture type
+func NewReconciler(db *sql.DB, mc *minio.Client, bucket string, spec Spec) *Reconciler {
+	return &Reconciler{
+		DB:           db,
+		Minio:        mc,
+		Bucket:       bucket,
+		Spec:         spec,
+		LookbackDays: 7,
+		Grace:        10 * time.Minute,
+	}
+}
+
+// Run reconciles immediately and then on every interval until ctx is cancelled
+func (r *Reconciler) Run(ctx context.Context, interval time.Duration) {
+	tag := "[" + r.Spec.Source + "]"
+	log.Printf("%s Reconciliation job started (every %v, lookback %d days)", tag, interval, r.LookbackDays)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		report, err := r.Reconcile(ctx)
+		if err != nil {
+			log.Printf("%s Reconciliation error: %v", tag, err)
+		} else {
+			log.Printf("%s Reconciliation done: folders=%d objects=%d rows=%d orphans=%d removed=%d missing=%d",
+				tag, report.Folders, report.ObjectsChecked, report.RowsChecked,
+				report.Orphans, report.Removed, report.MissingObjects)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Reconcile checks every date folder inside the look-back window
+func (r *Reconciler) Reconcile(ctx context.Context) (*Report, error) {
+	report := &Report{}
+	now := time.Now()
+
+	for d := 0; d < r.LookbackDays; d++ {
+		if ctx.Err() != nil {
+			return report, ctx.Err()
+		}
+		folder := now.AddDate(0, 0, -d).Format("02012006")
+		if err := r.reconcileFolder(ctx, folder, now, report); err != nil {
+			return report, fmt.Errorf("folder %s: %w", folder, err)
+		}
+		report.Folders++
+	}
+	return report, nil
+}
+
+func (r *Reconciler) reconcileFolder(ctx context.Context, folder string, now time.Time, report *Report) error {
+	prefix := folder + "/"
+
+	// Objects in MinIO
+	objects := make(map[string]time.Time)
+	for obj := range r.Minio.ListObjects(ctx, r.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			return fmt.Errorf("list objects: %w", obj.Err)
+		}
+		objects[obj.Key] = obj.LastModified
+	}
+	report.ObjectsChecked += len(objects)
+
+	// Objects referenced by capture rows
+	query := fmt.Sprintf(`SELECT id, external_id, %s FROM %s WHERE minio_bucket = $1 AND minio_date_folder = $2`,
+		strings.Join(r.Spec.ObjectColumns, ", "), r.Spec.Table)
+	rows, err := r.DB.QueryContext(ctx, query, r.Bucket, folder)
+	if err != nil {
+		return fmt.Errorf("query captures: %w", err)
+	}
+	defer rows.Close()
+
+	referenced := make(map[string]bool)
+	var missing []Orphan
+	for rows.Next() {
+		var id, externalID string
+		names := make([]sql.NullString, len(r.Spec.ObjectColumns))
+		dest := []any{&id, &externalID}
+		for i := range names {
+			dest = append(dest, &names[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return fmt.Errorf("scan capture: %w", err)
+		}
+		report.RowsChecked++
+
+		for _, n := range names {
+			if !n.Valid || n.String == "" {
+				continue
+			}
+			referenced[n.String] = true
+			if _, ok := objects[n.String]; !ok {
+				missing = append(missing, Orphan{
+					Source:     r.Spec.Source,
+					Bucket:     r.Bucket,
+					Object:     n.String,
+					ExternalID: externalID,
+					CaptureID:  id,
+					Reason:     ReasonMissingObject,
+				})
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	// Rows whose objects are gone
+	missingNames := []string{}
+	for _, o := range missing {
+		if err := RecordOrphan(ctx, r.DB, o); err != nil {
+			return err
+		}
+		missingNames = append(missingNames, o.Object)
+		log.Printf("[%s] Capture %s references missing object %s", r.Spec.Source, o.ExternalID, o.Object)
+	}
+	report.MissingObjects += len(missing)
+
+	// Objects no row references
+	orphanNames := []string{}
+	for key, modified := range objects {
+		if referenced[key] || now.Sub(modified) < r.Grace {
+			continue
+		}
+		report.Orphans++
+
+		o := Orphan{Source: r.Spec.Source, Bucket: r.Bucket, Object: key, Reason: ReasonNoDBRow}
+		if r.RemoveOrphans {
+			if err := r.Minio.RemoveObject(ctx, r.Bucket, key, minio.RemoveObjectOptions{}); err != nil {
+				o.LastError = err.Error()
+			} else {
+				o.Status = StatusRemoved
+				report.Removed++
+			}
+		}
+		if o.Status != StatusRemoved {
+			orphanNames = append(orphanNames, key)
+		}
+		if err := RecordOrphan(ctx, r.DB, o); err != nil {
+			return err
+		}
+	}
+
+	// Anything recorded earlier that is no longer the case is resolved
+	if err := resolveStale(ctx, r.DB, r.Spec.Source, r.Bucket, ReasonMissingObject, prefix, missingNames); err != nil {
+		return err
+	}
+	return resolveStale(ctx, r.DB, r.Spec.Source, r.Bucket, ReasonNoDBRow, prefix, orphanNames)
+}
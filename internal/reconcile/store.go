@@ -0,0 +1,110 @@
+package reconcile
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// Orphan reasons (transact_orphan_object.reason)
+const (
+	ReasonIngestRollback = "INGEST_ROLLBACK" // Rollback could not remove an uploaded object
+	ReasonNoDBRow        = "NO_DB_ROW"       // Object in MinIO that no capture row references
+	ReasonMissingObject  = "MISSING_OBJECT"  // Capture row references an object that is gone
+)
+
+// Orphan statuses
+const (
+	StatusOrphaned = "ORPHANED"
+	StatusRemoved  = "REMOVED"
+	StatusResolved = "RESOLVED"
+)
+
+// Spec describes where a capture type keeps its MinIO object references
+type Spec struct {
+	Source        string   // ANPR / AXLE
+	Table         string   // Capture table
+	ObjectColumns []string // Columns holding object names
+}
+
+var (
+	ANPRSpec = Spec{
+		Source:        "ANPR",
+		Table:         "public.transact_anpr_capture",
+		ObjectColumns: []string{"minio_xml_object", "minio_full_image_object", "minio_plate_image_object"},
+	}
+	AxleSpec = Spec{
+		Source:        "AXLE",
+		Table:         "public.transact_axle_capture",
+		ObjectColumns: []string{"minio_xml_object", "minio_image_object"},
+	}
+)
+
+// ObjectReferenced reports whether any capture row still points at the object.
+// Rollback uses it so a retry never deletes objects of an earlier successful ingestion.
+func ObjectReferenced(ctx context.Context, db *sql.DB, spec Spec, bucket, object string) (bool, error) {
+	conds := make([]string, len(spec.ObjectColumns))
+	for i, col := range spec.ObjectColumns {
+		conds[i] = col + " = $2"
+	}
+	query := fmt.Sprintf(`SELECT EXISTS (SELECT 1 FROM %s WHERE minio_bucket = $1 AND (%s))`,
+		spec.Table, strings.Join(conds, " OR "))
+
+	var exists bool
+	if err := db.QueryRowContext(ctx, query, bucket, object).Scan(&exists); err != nil {
+		return false, fmt.Errorf("check object reference: %w", err)
+	}
+	return exists, nil
+}
+
+// Orphan is one row of transact_orphan_object
+type Orphan struct {
+	Source     string
+	Bucket     string
+	Object     string
+	ExternalID string
+	CaptureID  string
+	Reason     string
+	Status     string
+	LastError  string
+}
+
+// RecordOrphan upserts an orphan; repeated detections only refresh last_seen_at/status
+func RecordOrphan(ctx context.Context, db *sql.DB, o Orphan) error {
+	if o.Status == "" {
+		o.Status = StatusOrphaned
+	}
+
+	query := `
+		INSERT INTO public.transact_orphan_object
+			(source, minio_bucket, minio_object, external_id, capture_id, reason, status, last_error, resolved_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, '')::uuid, $6, $7, NULLIF($8, ''),
+		        CASE WHEN $7 = 'ORPHANED' THEN NULL ELSE now() END)
+		ON CONFLICT (minio_bucket, minio_object, reason) DO UPDATE SET
+			status = EXCLUDED.status,
+			last_error = EXCLUDED.last_error,
+			last_seen_at = now(),
+			resolved_at = EXCLUDED.resolved_at`
+
+	_, err := db.ExecContext(ctx, query,
+		o.Source, o.Bucket, o.Object, o.ExternalID, o.CaptureID, o.Reason, o.Status, o.LastError)
+	if err != nil {
+		return fmt.Errorf("record orphan: %w", err)
+	}
+	return nil
+}
+
+// resolveStale marks open records under a prefix as resolved once the condition is gone
+func resolveStale(ctx context.Context, db *sql.DB, source, bucket, reason, prefix string, stillPresent []string) error {
+	query := `
+		UPDATE public.transact_orphan_object SET status = 'RESOLVED', resolved_at = now()
+		WHERE source = $1 AND minio_bucket = $2 AND reason = $3 AND status = 'ORPHANED'
+		  AND minio_object LIKE $4 || '%'
+		  AND NOT (minio_object = ANY($5))`
+
+	if _, err := db.ExecContext(ctx, query, source, bucket, reason, prefix, stillPresent); err != nil {
+		return fmt.Errorf("resolve stale orphans: %w", err)
+	}
+	return nil
+}
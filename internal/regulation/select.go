@@ -0,0 +1,38 @@
+package regulation
+
+import "wim-service/internal/versioned"
+
+// Applies reports whether the rule is in force and scoped to the subject
+func (r Rule) Applies(s Subject) bool {
+	if r.RuleType != s.RuleType || !r.InForce(s.At) {
+		return false
+	}
+	if r.RoadClass != 0 && r.RoadClass != s.RoadClass {
+		return false
+	}
+	if r.VehicleClassID != "" && r.VehicleClassID != s.VehicleClassID {
+		return false
+	}
+	return true
+}
+
+// Specificity ranks scoped rules above generic ones: a vehicle class scope
+// outweighs a road class scope
+func (r Rule) Specificity() int {
+	n := 0
+	if r.VehicleClassID != "" {
+		n += 2
+	}
+	if r.RoadClass != 0 {
+		n++
+	}
+	return n
+}
+
+// Select returns the rule applied to the subject: the most specific applicable
+// rule (see versioned.Select for ties). nil when none applies.
+func Select(rules []Rule, s Subject) *Rule {
+	return versioned.Select(rules, s.At, func(r *Rule) (versioned.Validity, int, bool) {
+		return r.Validity, r.Specificity(), r.Applies(s)
+	})
+}
@@ -0,0 +1,228 @@
+package regulation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"wim-service/internal/versioned"
+)
+
+var (
+	// ErrNotFound is returned when the rule does not exist
+	ErrNotFound = errors.New("regulation: not found")
+	// ErrInvalid is returned when a rule request fails validation
+	ErrInvalid = errors.New("regulation: invalid request")
+)
+
+// Service stores versioned violation rules (see package versioned) and selects the
+// rule in force for a capture. A change is a new version with the same code.
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new regulation service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+const ruleColumns = `
+	r.id, r.code, r."version", r.rule_type, COALESCE(r.description, ''),
+	COALESCE(r.road_class, 0), COALESCE(r.vehicle_class_id::text, ''), COALESCE(vc.code, ''),
+	COALESCE(r.tolerance_pct, 0), COALESCE(r.permitted_weight_kg, 0),
+	COALESCE(r.length_tolerance_mm, 0), COALESCE(r.width_tolerance_mm, 0), COALESCE(r.height_tolerance_mm, 0),
+	r.effective_from, r.effective_to, COALESCE(r.is_active, true), COALESCE(r.created_by, ''), r.created_date`
+
+const ruleFrom = `
+	FROM public.master_violation_rule r
+	LEFT JOIN public.master_vehicle_class vc ON vc.id = r.vehicle_class_id`
+
+func scanRule(row interface{ Scan(...any) error }) (*Rule, error) {
+	var r Rule
+	var effectiveTo sql.NullTime
+	err := row.Scan(&r.ID, &r.Code, &r.Version, &r.RuleType, &r.Description,
+		&r.RoadClass, &r.VehicleClassID, &r.VehicleClassCode,
+		&r.TolerancePct, &r.PermittedWeightKg,
+		&r.LengthToleranceMM, &r.WidthToleranceMM, &r.HeightToleranceMM,
+		&r.EffectiveFrom, &effectiveTo, &r.IsActive, &r.CreatedBy, &r.CreatedDate)
+	if err != nil {
+		return nil, err
+	}
+	if effectiveTo.Valid {
+		r.EffectiveTo = &effectiveTo.Time
+	}
+	return &r, nil
+}
+
+// List returns rule versions, newest first within each code
+func (s *Service) List(ctx context.Context, filter Filter) ([]Rule, error) {
+	query := `SELECT` + ruleColumns + ruleFrom + `
+		WHERE ($1 = '' OR r.rule_type = $1)
+		  AND ($2 = '' OR r.code = $2)
+		  AND (NOT $3 OR COALESCE(r.is_active, true))
+		  AND ($4::timestamptz IS NULL OR (r.effective_from <= $4 AND (r.effective_to IS NULL OR r.effective_to > $4)))
+		ORDER BY r.rule_type, r.code, r."version" DESC`
+
+	var at sql.NullTime
+	if filter.At != nil {
+		at = sql.NullTime{Time: *filter.At, Valid: true}
+	}
+	rows, err := s.DB.QueryContext(ctx, query, filter.RuleType, filter.Code, filter.ActiveOnly, at)
+	if err != nil {
+		return nil, fmt.Errorf("query rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rule: %w", err)
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// Get returns one rule version (also when deactivated)
+func (s *Service) Get(ctx context.Context, id string) (*Rule, error) {
+	query := `SELECT` + ruleColumns + ruleFrom + `
+		WHERE r.id::text = $1`
+
+	r, err := scanRule(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get rule: %w", err)
+	}
+	return r, nil
+}
+
+// InForce returns the rule applied to the subject, or nil when no rule applies
+func (s *Service) InForce(ctx context.Context, subj Subject) (*Rule, error) {
+	at := subj.At
+	rules, err := s.List(ctx, Filter{RuleType: subj.RuleType, ActiveOnly: true, At: &at})
+	if err != nil {
+		return nil, err
+	}
+	return Select(rules, subj), nil
+}
+
+// Validate checks a rule request
+func Validate(req RuleRequest) error {
+	if strings.TrimSpace(req.Code) == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalid)
+	}
+	switch req.RuleType {
+	case TypeOverload:
+		if req.TolerancePct < 0 || req.TolerancePct > 100 {
+			return fmt.Errorf("%w: tolerance_pct must be in [0, 100]", ErrInvalid)
+		}
+		if req.PermittedWeightKg < 0 {
+			return fmt.Errorf("%w: permitted_weight_kg must not be negative", ErrInvalid)
+		}
+	case TypeOverDimension:
+		if req.LengthToleranceMM < 0 || req.WidthToleranceMM < 0 || req.HeightToleranceMM < 0 {
+			return fmt.Errorf("%w: tolerances must not be negative", ErrInvalid)
+		}
+	default:
+		return fmt.Errorf("%w: rule_type must be %s or %s", ErrInvalid, TypeOverload, TypeOverDimension)
+	}
+	if req.RoadClass != 0 && req.RoadClass != 2 && req.RoadClass != 3 {
+		return fmt.Errorf("%w: road_class must be 2 or 3", ErrInvalid)
+	}
+	if req.EffectiveFrom != nil && req.EffectiveTo != nil && !req.EffectiveTo.After(*req.EffectiveFrom) {
+		return fmt.Errorf("%w: effective_to must be after effective_from", ErrInvalid)
+	}
+	return nil
+}
+
+// Create stores a new rule version. The version is one above the latest version
+// of the same code; a code keeps its rule_type across versions.
+func (s *Service) Create(ctx context.Context, req RuleRequest, username string) (*Rule, error) {
+	req.Code = strings.ToUpper(strings.TrimSpace(req.Code))
+	req.RuleType = strings.ToUpper(strings.TrimSpace(req.RuleType))
+	if err := Validate(req); err != nil {
+		return nil, err
+	}
+	from, err := versioned.Start(req.EffectiveFrom, req.EffectiveTo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalid, err)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	version, err := versioned.NextVersion(ctx, tx, "violation_rule:"+req.Code, `
+		SELECT COALESCE(MAX("version"), 0) FROM public.master_violation_rule
+		WHERE code = $1`, req.Code)
+	if err != nil {
+		return nil, err
+	}
+
+	var existingType string
+	err = tx.QueryRowContext(ctx, `
+		SELECT rule_type FROM public.master_violation_rule
+		WHERE code = $1 LIMIT 1`, req.Code).Scan(&existingType)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("get rule type: %w", err)
+	}
+	if existingType != "" && existingType != req.RuleType {
+		return nil, fmt.Errorf("%w: code %s is a %s rule", ErrInvalid, req.Code, existingType)
+	}
+
+	if req.VehicleClassID != "" {
+		var exists bool
+		if err := tx.QueryRowContext(ctx, `
+			SELECT EXISTS (SELECT 1 FROM public.master_vehicle_class WHERE id::text = $1 AND is_deleted = false)`,
+			req.VehicleClassID).Scan(&exists); err != nil {
+			return nil, fmt.Errorf("check vehicle class: %w", err)
+		}
+		if !exists {
+			return nil, fmt.Errorf("%w: vehicle class not found", ErrInvalid)
+		}
+	}
+
+	var effectiveTo sql.NullTime
+	if req.EffectiveTo != nil {
+		effectiveTo = sql.NullTime{Time: *req.EffectiveTo, Valid: true}
+	}
+
+	var id string
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO public.master_violation_rule
+			(code, "version", rule_type, description, road_class, vehicle_class_id,
+			 tolerance_pct, permitted_weight_kg, length_tolerance_mm, width_tolerance_mm, height_tolerance_mm,
+			 effective_from, effective_to, created_by)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, 0), NULLIF($6, '')::uuid,
+		        $7, NULLIF($8::numeric, 0), $9, $10, $11, $12, $13, NULLIF($14, ''))
+		RETURNING id`,
+		req.Code, version, req.RuleType, strings.TrimSpace(req.Description), req.RoadClass, req.VehicleClassID,
+		req.TolerancePct, req.PermittedWeightKg, req.LengthToleranceMM, req.WidthToleranceMM, req.HeightToleranceMM,
+		from, effectiveTo, username).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert rule: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Deactivate takes a rule version out of evaluation
+func (s *Service) Deactivate(ctx context.Context, id string) error {
+	found, err := versioned.Deactivate(ctx, s.DB, "public.master_violation_rule", id)
+	if err != nil {
+		return fmt.Errorf("deactivate rule: %w", err)
+	}
+	if !found {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+package regulation
+
+import (
+	"fmt"
+	"time"
+
+	"wim-service/internal/versioned"
+)
+
+// Rule types (master_violation_rule.rule_type)
+const (
+	TypeOverload      = "OVERLOAD"
+	TypeOverDimension = "OVER_DIMENSION"
+)
+
+// Rule is one version of a violation rule
+type Rule struct {
+	ID                string    `json:"id"`
+	Code              string    `json:"code"`
+	RuleType          string    `json:"rule_type"`
+	Description       string    `json:"description"`
+	RoadClass         int       `json:"road_class"`       // 0 = any road class
+	VehicleClassID    string    `json:"vehicle_class_id"` // "" = any vehicle class
+	VehicleClassCode  string    `json:"vehicle_class_code"`
+	TolerancePct      float64   `json:"tolerance_pct"`
+	PermittedWeightKg float64   `json:"permitted_weight_kg"` // 0 = master_vehicle_class limit
+	LengthToleranceMM int       `json:"length_tolerance_mm"`
+	WidthToleranceMM  int       `json:"width_tolerance_mm"`
+	HeightToleranceMM int       `json:"height_tolerance_mm"`
+	CreatedBy         string    `json:"created_by"`
+	CreatedDate       time.Time `json:"created_date"`
+
+	versioned.Validity // Version, effective range, active
+}
+
+// Ref identifies the rule version, e.g. "OVERLOAD-DEFAULT@v2"
+func (r Rule) Ref() string {
+	return fmt.Sprintf("%s@v%d", r.Code, r.Version)
+}
+
+// RuleRequest creates a rule; an existing code gets a new version
+type RuleRequest struct {
+	Code              string     `json:"code"`
+	RuleType          string     `json:"rule_type"`
+	Description       string     `json:"description"`
+	RoadClass         int        `json:"road_class"`
+	VehicleClassID    string     `json:"vehicle_class_id"`
+	TolerancePct      float64    `json:"tolerance_pct"`
+	PermittedWeightKg float64    `json:"permitted_weight_kg"`
+	LengthToleranceMM int        `json:"length_tolerance_mm"`
+	WidthToleranceMM  int        `json:"width_tolerance_mm"`
+	HeightToleranceMM int        `json:"height_tolerance_mm"`
+	EffectiveFrom     *time.Time `json:"effective_from"` // Default: now
+	EffectiveTo       *time.Time `json:"effective_to"`
+}
+
+// Subject is what a rule is selected for: a capture of a vehicle class on a road class
+type Subject struct {
+	RuleType       string
+	At             time.Time
+	RoadClass      int
+	VehicleClassID string
+}
+
+// Filter narrows List
+type Filter struct {
+	RuleType   string
+	Code       string
+	ActiveOnly bool
+	At         *time.Time // Only rules in force at this time
+}
@@ -0,0 +1,167 @@
+package vehicleclass
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DefaultMinConfidence is the confidence below which a match is flagged for review
+const DefaultMinConfidence = 0.6
+
+// Resolution is the resolved vehicle class of one axle capture
+type Resolution struct {
+	CaptureID        string    `json:"capture_id"`
+	VehicleClassID   string    `json:"vehicle_class_id,omitempty"`
+	VehicleClassCode string    `json:"vehicle_class_code,omitempty"`
+	RuleID           string    `json:"rule_id,omitempty"`
+	RuleName         string    `json:"rule_name,omitempty"`
+	Confidence       float64   `json:"confidence"`
+	Status           string    `json:"status"`
+	ReviewRequired   bool      `json:"review_required"`
+	ResolvedBy       string    `json:"resolved_by,omitempty"`
+	ResolvedAt       time.Time `json:"resolved_at"`
+}
+
+// Resolver resolves axle captures to master_vehicle_class using the rules in
+// master_vehicle_class_rule and stores the result on the capture
+type Resolver struct {
+	DB            *sql.DB
+	Classes       *Service
+	MinConfidence float64
+}
+
+// NewResolver creates a new class resolver
+func NewResolver(db *sql.DB, minConfidence float64) *Resolver {
+	return &Resolver{
+		DB:            db,
+		Classes:       NewService(db),
+		MinConfidence: minConfidence,
+	}
+}
+
+// ResolveCapture matches a capture against the active rules. Captures classified
+// manually keep their class unless force is set.
+func (r *Resolver) ResolveCapture(ctx context.Context, captureID string, force bool) (*Resolution, error) {
+	var subject Subject
+	var status string
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(total_axles, 0), COALESCE(length_mm, 0),
+		       COALESCE(vehicle_body_type, ''), COALESCE(vehicle_category, ''), COALESCE(class_status, '')
+		FROM public.transact_axle_capture
+		WHERE id::text = $1 AND is_deleted = false`,
+		captureID).Scan(&subject.Axles, &subject.LengthMM, &subject.BodyType, &subject.Category, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load capture: %w", err)
+	}
+
+	if status == StatusManual && !force {
+		return r.current(ctx, captureID)
+	}
+
+	rules, err := r.Classes.ListRules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Resolution{
+		CaptureID:      captureID,
+		Status:         StatusUnmatched,
+		ReviewRequired: true,
+		ResolvedAt:     time.Now(),
+	}
+	if m := MatchRules(rules, subject); m != nil {
+		res.VehicleClassID = m.Rule.VehicleClassID
+		res.VehicleClassCode = m.Rule.VehicleClassCode
+		res.RuleID = m.Rule.ID
+		res.RuleName = m.Rule.RuleName
+		res.Confidence = m.Confidence
+		if m.Confidence >= r.MinConfidence {
+			res.Status = StatusMatched
+			res.ReviewRequired = false
+		} else {
+			res.Status = StatusLowConfidence
+		}
+	}
+
+	if err := r.save(ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// AssignClass sets the class of a capture manually and clears the review flag
+func (r *Resolver) AssignClass(ctx context.Context, captureID, classID, username string) (*Resolution, error) {
+	class, err := r.Classes.Get(ctx, classID)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Resolution{
+		CaptureID:        captureID,
+		VehicleClassID:   class.ID,
+		VehicleClassCode: class.Code,
+		Confidence:       1,
+		Status:           StatusManual,
+		ResolvedBy:       username,
+		ResolvedAt:       time.Now(),
+	}
+	if err := r.save(ctx, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (r *Resolver) save(ctx context.Context, res *Resolution) error {
+	query := `
+		UPDATE public.transact_axle_capture SET
+			vehicle_class_id = NULLIF($2, '')::uuid,
+			class_rule_id = NULLIF($3, '')::uuid,
+			class_confidence = $4,
+			class_status = $5,
+			class_review_required = $6,
+			class_resolved_at = $7,
+			class_resolved_by = NULLIF($8, ''),
+			updated_date = now()
+		WHERE id::text = $1 AND is_deleted = false`
+
+	result, err := r.DB.ExecContext(ctx, query, res.CaptureID, res.VehicleClassID, res.RuleID,
+		res.Confidence, res.Status, res.ReviewRequired, res.ResolvedAt, res.ResolvedBy)
+	if err != nil {
+		return fmt.Errorf("save class resolution: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// current returns the stored resolution of a capture
+func (r *Resolver) current(ctx context.Context, captureID string) (*Resolution, error) {
+	res := &Resolution{CaptureID: captureID}
+	var resolvedAt sql.NullTime
+	err := r.DB.QueryRowContext(ctx, `
+		SELECT COALESCE(c.vehicle_class_id::text, ''), COALESCE(vc.code, ''),
+		       COALESCE(c.class_rule_id::text, ''), COALESCE(cr.rule_name, ''),
+		       COALESCE(c.class_confidence, 0), COALESCE(c.class_status, ''),
+		       c.class_review_required, COALESCE(c.class_resolved_by, ''), c.class_resolved_at
+		FROM public.transact_axle_capture c
+		LEFT JOIN public.master_vehicle_class vc ON vc.id = c.vehicle_class_id
+		LEFT JOIN public.master_vehicle_class_rule cr ON cr.id = c.class_rule_id
+		WHERE c.id::text = $1`,
+		captureID).Scan(&res.VehicleClassID, &res.VehicleClassCode, &res.RuleID, &res.RuleName,
+		&res.Confidence, &res.Status, &res.ReviewRequired, &res.ResolvedBy, &resolvedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load class resolution: %w", err)
+	}
+	res.ResolvedAt = resolvedAt.Time
+	return res, nil
+}
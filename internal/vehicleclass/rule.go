@@ -0,0 +1,148 @@
+package vehicleclass
+
+import (
+	"strings"
+	"time"
+)
+
+// Resolution status stored in transact_axle_capture.class_status
+const (
+	StatusMatched       = "MATCHED"
+	StatusLowConfidence = "LOW_CONFIDENCE" // Best rule below the minimum confidence; needs review
+	StatusUnmatched     = "UNMATCHED"      // No rule matched; needs review
+	StatusManual        = "MANUAL"         // Assigned by an operator
+)
+
+// Rule is one row of master_vehicle_class_rule. Zero bounds and empty lists mean
+// "not constrained". Axle bounds are hard filters; length, body type and category
+// only lower the confidence when they do not match.
+type Rule struct {
+	ID               string    `json:"id"`
+	VehicleClassID   string    `json:"vehicle_class_id"`
+	VehicleClassCode string    `json:"vehicle_class_code"`
+	RuleName         string    `json:"rule_name"`
+	Priority         int       `json:"priority"`
+	MinAxles         int       `json:"min_axles"`
+	MaxAxles         int       `json:"max_axles"`
+	MinLengthMM      int       `json:"min_length_mm"`
+	MaxLengthMM      int       `json:"max_length_mm"`
+	BodyTypes        string    `json:"body_types"`
+	Categories       string    `json:"categories"`
+	BaseConfidence   float64   `json:"base_confidence"`
+	IsActive         bool      `json:"is_active"`
+	CreatedDate      time.Time `json:"created_date"`
+	UpdatedDate      time.Time `json:"updated_date"`
+}
+
+// RuleRequest is the create/update payload of a rule
+type RuleRequest struct {
+	VehicleClassID string   `json:"vehicle_class_id"`
+	RuleName       string   `json:"rule_name"`
+	Priority       *int     `json:"priority"`
+	MinAxles       int      `json:"min_axles"`
+	MaxAxles       int      `json:"max_axles"`
+	MinLengthMM    int      `json:"min_length_mm"`
+	MaxLengthMM    int      `json:"max_length_mm"`
+	BodyTypes      string   `json:"body_types"`
+	Categories     string   `json:"categories"`
+	BaseConfidence *float64 `json:"base_confidence"`
+	IsActive       *bool    `json:"is_active"`
+}
+
+// Subject is what the sensor reported about one vehicle
+type Subject struct {
+	Axles    int
+	LengthMM int
+	BodyType string
+	Category string
+}
+
+// Match is the best rule for a subject
+type Match struct {
+	Rule       Rule
+	Confidence float64
+}
+
+// MatchRules returns the rule with the highest confidence for the subject, or nil
+// if no rule passes the axle bounds. Ties go to the more specific rule, then to the
+// lower priority number.
+func MatchRules(rules []Rule, s Subject) *Match {
+	var best *Match
+	bestCriteria := 0
+	for _, r := range rules {
+		conf, criteria, ok := r.score(s)
+		if !ok {
+			continue
+		}
+		better := best == nil || conf > best.Confidence ||
+			(conf == best.Confidence && criteria > bestCriteria) ||
+			(conf == best.Confidence && criteria == bestCriteria && r.Priority < best.Rule.Priority)
+		if better {
+			best = &Match{Rule: r, Confidence: conf}
+			bestCriteria = criteria
+		}
+	}
+	return best
+}
+
+// score returns the confidence of the rule for s and the number of criteria the
+// rule specifies. A criterion the sensor did not report counts half.
+func (r Rule) score(s Subject) (float64, int, bool) {
+	if r.MinAxles > 0 || r.MaxAxles > 0 {
+		if s.Axles <= 0 ||
+			(r.MinAxles > 0 && s.Axles < r.MinAxles) ||
+			(r.MaxAxles > 0 && s.Axles > r.MaxAxles) {
+			return 0, 0, false
+		}
+	}
+
+	criteria, total := 1, 1.0 // axle count
+	if r.MinLengthMM > 0 || r.MaxLengthMM > 0 {
+		criteria++
+		switch {
+		case s.LengthMM <= 0:
+			total += 0.5
+		case (r.MinLengthMM == 0 || s.LengthMM >= r.MinLengthMM) &&
+			(r.MaxLengthMM == 0 || s.LengthMM <= r.MaxLengthMM):
+			total++
+		}
+	}
+	if list := splitList(r.BodyTypes); len(list) > 0 {
+		criteria++
+		total += listScore(list, s.BodyType)
+	}
+	if list := splitList(r.Categories); len(list) > 0 {
+		criteria++
+		total += listScore(list, s.Category)
+	}
+
+	base := r.BaseConfidence
+	if base <= 0 || base > 1 {
+		base = 1
+	}
+	conf := base * total / float64(criteria)
+	return float64(int(conf*1000+0.5)) / 1000, criteria, true
+}
+
+func listScore(list []string, value string) float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0.5
+	}
+	for _, v := range list {
+		if strings.EqualFold(v, value) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,214 @@
+package vehicleclass
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalid is returned when a rule request fails validation
+var ErrInvalid = errors.New("vehicleclass: invalid request")
+
+const ruleColumns = `
+	r.id, r.vehicle_class_id, vc.code, r.rule_name, r.priority,
+	COALESCE(r.min_axles, 0), COALESCE(r.max_axles, 0),
+	COALESCE(r.min_length_mm, 0), COALESCE(r.max_length_mm, 0),
+	COALESCE(r.body_types, ''), COALESCE(r.categories, ''), r.base_confidence,
+	COALESCE(r.is_active, true), r.created_date, r.updated_date`
+
+const ruleFrom = `
+	FROM public.master_vehicle_class_rule r
+	JOIN public.master_vehicle_class vc ON vc.id = r.vehicle_class_id`
+
+func scanRule(row interface{ Scan(...any) error }) (*Rule, error) {
+	var r Rule
+	err := row.Scan(&r.ID, &r.VehicleClassID, &r.VehicleClassCode, &r.RuleName, &r.Priority,
+		&r.MinAxles, &r.MaxAxles, &r.MinLengthMM, &r.MaxLengthMM,
+		&r.BodyTypes, &r.Categories, &r.BaseConfidence,
+		&r.IsActive, &r.CreatedDate, &r.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// ListRules returns all rules; activeOnly limits to rules used for resolution
+func (s *Service) ListRules(ctx context.Context, activeOnly bool) ([]Rule, error) {
+	query := `SELECT` + ruleColumns + ruleFrom + `
+		WHERE r.is_deleted = false
+		  AND (NOT $1 OR (COALESCE(r.is_active, true) AND COALESCE(vc.is_active, true) AND vc.is_deleted = false))
+		ORDER BY r.priority, vc.code`
+
+	rows, err := s.DB.QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("query rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []Rule{}
+	for rows.Next() {
+		r, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan rule: %w", err)
+		}
+		rules = append(rules, *r)
+	}
+	return rules, rows.Err()
+}
+
+// GetRule returns one rule
+func (s *Service) GetRule(ctx context.Context, id string) (*Rule, error) {
+	query := `SELECT` + ruleColumns + ruleFrom + `
+		WHERE r.id::text = $1 AND r.is_deleted = false`
+
+	r, err := scanRule(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get rule: %w", err)
+	}
+	return r, nil
+}
+
+func validateRule(req RuleRequest) error {
+	if req.MinAxles < 0 || req.MaxAxles < 0 || req.MinLengthMM < 0 || req.MaxLengthMM < 0 {
+		return fmt.Errorf("%w: bounds must not be negative", ErrInvalid)
+	}
+	if req.MaxAxles > 0 && req.MinAxles > req.MaxAxles {
+		return fmt.Errorf("%w: min_axles must not exceed max_axles", ErrInvalid)
+	}
+	if req.MaxLengthMM > 0 && req.MinLengthMM > req.MaxLengthMM {
+		return fmt.Errorf("%w: min_length_mm must not exceed max_length_mm", ErrInvalid)
+	}
+	if req.BaseConfidence != nil && (*req.BaseConfidence <= 0 || *req.BaseConfidence > 1) {
+		return fmt.Errorf("%w: base_confidence must be in (0, 1]", ErrInvalid)
+	}
+	return nil
+}
+
+// CreateRule adds a rule for a vehicle class
+func (s *Service) CreateRule(ctx context.Context, req RuleRequest) (*Rule, error) {
+	if req.VehicleClassID == "" || strings.TrimSpace(req.RuleName) == "" {
+		return nil, fmt.Errorf("%w: vehicle_class_id and rule_name are required", ErrInvalid)
+	}
+	if err := validateRule(req); err != nil {
+		return nil, err
+	}
+	if _, err := s.Get(ctx, req.VehicleClassID); err != nil {
+		return nil, err
+	}
+
+	priority := 100
+	if req.Priority != nil {
+		priority = *req.Priority
+	}
+	confidence := 1.0
+	if req.BaseConfidence != nil {
+		confidence = *req.BaseConfidence
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	var id string
+	query := `
+		INSERT INTO public.master_vehicle_class_rule
+			(vehicle_class_id, rule_name, priority, min_axles, max_axles, min_length_mm, max_length_mm,
+			 body_types, categories, base_confidence, is_active)
+		VALUES ($1, $2, $3, NULLIF($4, 0), NULLIF($5, 0), NULLIF($6, 0), NULLIF($7, 0),
+		        NULLIF($8, ''), NULLIF($9, ''), $10, $11)
+		RETURNING id`
+
+	err := s.DB.QueryRowContext(ctx, query,
+		req.VehicleClassID, strings.TrimSpace(req.RuleName), priority,
+		req.MinAxles, req.MaxAxles, req.MinLengthMM, req.MaxLengthMM,
+		strings.TrimSpace(req.BodyTypes), strings.TrimSpace(req.Categories), confidence, isActive,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert rule: %w", err)
+	}
+
+	return s.GetRule(ctx, id)
+}
+
+// UpdateRule replaces the criteria of a rule; name, priority, confidence and
+// is_active are left unchanged when omitted
+func (s *Service) UpdateRule(ctx context.Context, id string, req RuleRequest) (*Rule, error) {
+	if err := validateRule(req); err != nil {
+		return nil, err
+	}
+	if req.VehicleClassID != "" {
+		if _, err := s.Get(ctx, req.VehicleClassID); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE public.master_vehicle_class_rule SET
+			vehicle_class_id = COALESCE(NULLIF($2, '')::uuid, vehicle_class_id),
+			rule_name = COALESCE(NULLIF($3, ''), rule_name),
+			priority = COALESCE($4, priority),
+			min_axles = NULLIF($5, 0),
+			max_axles = NULLIF($6, 0),
+			min_length_mm = NULLIF($7, 0),
+			max_length_mm = NULLIF($8, 0),
+			body_types = NULLIF($9, ''),
+			categories = NULLIF($10, ''),
+			base_confidence = COALESCE($11, base_confidence),
+			is_active = COALESCE($12, is_active),
+			updated_date = now()
+		WHERE id::text = $1 AND is_deleted = false`
+
+	res, err := s.DB.ExecContext(ctx, query, id,
+		req.VehicleClassID, strings.TrimSpace(req.RuleName), nullInt(req.Priority),
+		req.MinAxles, req.MaxAxles, req.MinLengthMM, req.MaxLengthMM,
+		strings.TrimSpace(req.BodyTypes), strings.TrimSpace(req.Categories),
+		nullFloat(req.BaseConfidence), nullBool(req.IsActive),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("update rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetRule(ctx, id)
+}
+
+// DeleteRule soft-deletes a rule
+func (s *Service) DeleteRule(ctx context.Context, id string) error {
+	res, err := s.DB.ExecContext(ctx,
+		`UPDATE public.master_vehicle_class_rule SET is_deleted = true, updated_date = now() WHERE id::text = $1 AND is_deleted = false`, id)
+	if err != nil {
+		return fmt.Errorf("delete rule: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+func nullInt(i *int) sql.NullInt64 {
+	if i == nil {
+		return sql.NullInt64{}
+	}
+	return sql.NullInt64{Int64: int64(*i), Valid: true}
+}
+
+func nullFloat(f *float64) sql.NullFloat64 {
+	if f == nil {
+		return sql.NullFloat64{}
+	}
+	return sql.NullFloat64{Float64: *f, Valid: true}
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
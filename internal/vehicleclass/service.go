@@ -0,0 +1,100 @@
+package vehicleclass
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrNotFound is returned when no vehicle class matches
+var ErrNotFound = errors.New("vehicleclass: not found")
+
+// Service reads master_vehicle_class
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new vehicle class service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+const classColumns = `
+	id, code, type, description, total_axle,
+	class_2_weight, class_3_weight, COALESCE(weight_unit, ''), length, width, height`
+
+func scanClass(row interface{ Scan(...any) error }) (*Class, error) {
+	var c Class
+	err := row.Scan(&c.ID, &c.Code, &c.Type, &c.Description, &c.TotalAxle,
+		&c.Class2Weight, &c.Class3Weight, &c.WeightUnit, &c.Length, &c.Width, &c.Height)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// ListActive returns all active vehicle classes ordered by axle count
+func (s *Service) ListActive(ctx context.Context) ([]Class, error) {
+	query := `SELECT` + classColumns + `
+		FROM public.master_vehicle_class
+		WHERE is_active = true AND is_deleted = false
+		ORDER BY total_axle, code`
+
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query vehicle classes: %w", err)
+	}
+	defer rows.Close()
+
+	classes := []Class{}
+	for rows.Next() {
+		c, err := scanClass(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan vehicle class: %w", err)
+		}
+		classes = append(classes, *c)
+	}
+	return classes, rows.Err()
+}
+
+// Get returns one vehicle class by id
+func (s *Service) Get(ctx context.Context, id string) (*Class, error) {
+	query := `SELECT` + classColumns + `
+		FROM public.master_vehicle_class
+		WHERE id::text = $1 AND is_deleted = false`
+
+	c, err := scanClass(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get vehicle class: %w", err)
+	}
+	return c, nil
+}
+
+// FindByAxles returns the active class with the given axle count. When several
+// classes share the axle count, the one with the highest permitted weight on the
+// road class is used so an ambiguous match never overstates the overload.
+func (s *Service) FindByAxles(ctx context.Context, totalAxle, roadClass int) (*Class, error) {
+	classes, err := s.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var best *Class
+	for i := range classes {
+		c := &classes[i]
+		if c.TotalAxle != totalAxle {
+			continue
+		}
+		if best == nil || c.PermittedWeight(roadClass) > best.PermittedWeight(roadClass) {
+			best = c
+		}
+	}
+	if best == nil {
+		return nil, ErrNotFound
+	}
+	return best, nil
+}
@@ -0,0 +1,39 @@
+package vehicleclass
+
+// Class is one row of master_vehicle_class
+type Class struct {
+	ID           string  `json:"id"`
+	Code         string  `json:"code"`
+	Type         string  `json:"type"`
+	Description  string  `json:"description"`
+	TotalAxle    int     `json:"total_axle"`
+	Class2Weight float64 `json:"class_2_weight"` // Permitted gross weight on class II roads, in WeightUnit
+	Class3Weight float64 `json:"class_3_weight"` // Permitted gross weight on class III roads, in WeightUnit
+	WeightUnit   string  `json:"weight_unit"`    // kg / t; empty when not set
+	Length       float64 `json:"length"`         // m
+	Width        float64 `json:"width"`          // m
+	Height       float64 `json:"height"`         // m
+}
+
+// Units of class_2_weight / class_3_weight
+const (
+	UnitKilogram = "kg"
+	UnitTonne    = "t"
+)
+
+// PermittedWeight returns the permitted gross weight in kg for a road class.
+// Road class 3 uses class_3_weight; everything else uses class_2_weight.
+// It returns 0 when the class has no known weight unit.
+func (c Class) PermittedWeight(roadClass int) float64 {
+	w := c.Class2Weight
+	if roadClass == 3 {
+		w = c.Class3Weight
+	}
+	switch c.WeightUnit {
+	case UnitKilogram:
+		return w
+	case UnitTonne:
+		return w * 1000
+	}
+	return 0
+}
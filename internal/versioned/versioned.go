@@ -0,0 +1,100 @@
+// Package versioned holds what the versioned master tables (violation rules,
+// camera calibration profiles) share: immutable numbered versions with an
+// effective date range, where a change is a new version and an old version is
+// deactivated rather than deleted because captures refer to the version that
+// produced their results.
+package versioned
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrWindow is returned when effective_to is not after effective_from
+var ErrWindow = errors.New("effective_to must be after effective_from")
+
+// Validity is the version number and effective date range of one version
+type Validity struct {
+	Version       int        `json:"version"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTo   *time.Time `json:"effective_to"`
+	IsActive      bool       `json:"is_active"`
+}
+
+// InForce reports whether the version is active and in its effective range at t
+func (v Validity) InForce(t time.Time) bool {
+	if !v.IsActive || t.Before(v.EffectiveFrom) {
+		return false
+	}
+	return v.EffectiveTo == nil || t.Before(*v.EffectiveTo)
+}
+
+// Select returns the item used at t among the items in force at t that apply: the
+// highest rank (more specific scope), then the latest effective_from, then the
+// highest version. nil when none applies.
+func Select[T any](items []T, at time.Time, of func(*T) (v Validity, rank int, applies bool)) *T {
+	var best *T
+	var bestV Validity
+	bestRank := 0
+	for i := range items {
+		v, rank, applies := of(&items[i])
+		if !applies || !v.InForce(at) {
+			continue
+		}
+		if best == nil || better(v, rank, bestV, bestRank) {
+			best, bestV, bestRank = &items[i], v, rank
+		}
+	}
+	return best
+}
+
+func better(a Validity, rankA int, b Validity, rankB int) bool {
+	if rankA != rankB {
+		return rankA > rankB
+	}
+	if !a.EffectiveFrom.Equal(b.EffectiveFrom) {
+		return a.EffectiveFrom.After(b.EffectiveFrom)
+	}
+	return a.Version > b.Version
+}
+
+// Start returns the effective_from of a new version (now when from is nil) and
+// checks that to, when set, is after it
+func Start(from, to *time.Time) (time.Time, error) {
+	start := time.Now()
+	if from != nil {
+		start = *from
+	}
+	if to != nil && !to.After(start) {
+		return time.Time{}, ErrWindow
+	}
+	return start, nil
+}
+
+// NextVersion serializes version numbering of one sequence (key, e.g. a rule code)
+// for the rest of tx and returns the next version number. latest selects the
+// highest version so far, e.g. SELECT COALESCE(MAX("version"), 0) ...
+func NextVersion(ctx context.Context, tx *sql.Tx, key, latest string, args ...any) (int, error) {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, key); err != nil {
+		return 0, fmt.Errorf("lock %s: %w", key, err)
+	}
+	var n int
+	if err := tx.QueryRowContext(ctx, latest, args...).Scan(&n); err != nil {
+		return 0, fmt.Errorf("get latest version: %w", err)
+	}
+	return n + 1, nil
+}
+
+// Deactivate takes the version with this id in table out of use; found is false
+// when there is no such row
+func Deactivate(ctx context.Context, db *sql.DB, table, id string) (found bool, err error) {
+	result, err := db.ExecContext(ctx, `UPDATE `+table+` SET is_active = false WHERE id::text = $1`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
@@ -0,0 +1,64 @@
+package versioned
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+type item struct {
+	name  string
+	rank  int
+	valid Validity
+}
+
+func TestSelect(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	until := t0.Add(10 * day)
+	at := t0.Add(5 * day)
+
+	tests := []struct {
+		name  string
+		items []item
+		want  string // "" = none
+	}{
+		{"none", nil, ""},
+		{"inactive", []item{{"a", 0, Validity{1, t0, nil, false}}}, ""},
+		{"not yet effective", []item{{"a", 0, Validity{1, at.Add(time.Second), nil, true}}}, ""},
+		{"expired", []item{{"a", 0, Validity{1, t0, &at, true}}}, ""},
+		{"in force", []item{{"a", 0, Validity{1, t0, &until, true}}}, "a"},
+		{"rank first", []item{{"a", 0, Validity{2, t0.Add(day), nil, true}}, {"b", 1, Validity{1, t0, nil, true}}}, "b"},
+		{"latest effective_from", []item{{"a", 0, Validity{2, t0, nil, true}}, {"b", 0, Validity{1, t0.Add(day), nil, true}}}, "b"},
+		{"highest version", []item{{"a", 0, Validity{1, t0, nil, true}}, {"b", 0, Validity{2, t0, nil, true}}}, "b"},
+		{"not applicable", []item{{"a", -1, Validity{1, t0, nil, true}}, {"b", 0, Validity{1, t0, nil, true}}}, "b"},
+	}
+	for _, tt := range tests {
+		got := Select(tt.items, at, func(i *item) (Validity, int, bool) { return i.valid, i.rank, i.rank >= 0 })
+		var name string
+		if got != nil {
+			name = got.name
+		}
+		if name != tt.want {
+			t.Errorf("%s: selected %q, want %q", tt.name, name, tt.want)
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	from := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	after, before := from.Add(time.Hour), from.Add(-time.Hour)
+
+	if got, err := Start(&from, &after); err != nil || !got.Equal(from) {
+		t.Errorf("Start(from, after) = %v, %v; want %v", got, err, from)
+	}
+	if _, err := Start(&from, &from); !errors.Is(err, ErrWindow) {
+		t.Errorf("Start(from, from) err = %v, want ErrWindow", err)
+	}
+	if _, err := Start(nil, &before); !errors.Is(err, ErrWindow) {
+		t.Errorf("Start(nil, past) err = %v, want ErrWindow", err)
+	}
+	if got, err := Start(nil, nil); err != nil || time.Since(got) > time.Minute {
+		t.Errorf("Start(nil, nil) = %v, %v; want now", got, err)
+	}
+}
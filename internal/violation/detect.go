@@ -0,0 +1,63 @@
+package violation
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DimensionExcess is one violating dimension check (see package odol)
+type DimensionExcess struct {
+	Dimension  string
+	MeasuredMM int
+	ExcessMM   int
+	ExcessPct  float64
+}
+
+// OverloadFinding builds the OVERLOAD finding from a capture's overload result.
+// ok is false when overload has not been evaluated (or could not be).
+func OverloadFinding(status string, grossKg int, permittedKg, overloadPct float64) (f Finding, ok bool) {
+	if status == "" || status == "UNKNOWN" {
+		return Finding{}, false
+	}
+	f = Finding{
+		Type:      TypeOverload,
+		Detected:  status == "OVERLOADED",
+		ExcessPct: overloadPct,
+		Detail: fmt.Sprintf("gross %d kg, permitted %.0f kg, overload %.2f%% (%s)",
+			grossKg, permittedKg, overloadPct, status),
+	}
+	if f.Detected {
+		f.Exceeded.GrossWeightKg = grossKg
+	}
+	return f, true
+}
+
+// OverDimensionFinding builds the OVER_DIMENSION finding from a capture's
+// over-dimension result. ok is false when it has not been evaluated.
+func OverDimensionFinding(overDimension *bool, excesses []DimensionExcess) (f Finding, ok bool) {
+	if overDimension == nil {
+		return Finding{}, false
+	}
+	f = Finding{Type: TypeOverDimension, Detected: *overDimension}
+	parts := make([]string, 0, len(excesses))
+	for _, e := range excesses {
+		parts = append(parts, fmt.Sprintf("%s +%d mm (%.2f%%)", e.Dimension, e.ExcessMM, e.ExcessPct))
+		if e.ExcessPct > f.ExcessPct {
+			f.ExcessPct = e.ExcessPct
+		}
+		switch e.Dimension {
+		case "LENGTH":
+			f.Exceeded.LengthMM = e.MeasuredMM
+		case "WIDTH":
+			f.Exceeded.WidthMM = e.MeasuredMM
+		case "HEIGHT":
+			f.Exceeded.HeightMM = e.MeasuredMM
+		}
+	}
+	if len(parts) == 0 {
+		f.Detail = "within dimension limits"
+	} else {
+		f.Detail = strings.Join(parts, ", ")
+	}
+	return f, true
+}
@@ -0,0 +1,52 @@
+package violation
+
+// Violation statuses (transact_violation.status)
+const (
+	StatusOpen        = "OPEN"         // Raised by the pipeline, not yet picked up
+	StatusUnderReview = "UNDER_REVIEW" // An officer is reviewing the evidence
+	StatusConfirmed   = "CONFIRMED"    // Evidence confirms the violation
+	StatusDismissed   = "DISMISSED"    // Not a violation (sensor fault, permit, ...)
+	StatusIssued      = "ISSUED"       // Ticket / sanction issued to the operator
+	StatusClosed      = "CLOSED"       // Case finished
+	StatusPermitted   = "PERMITTED"    // Exceedance covered by a dispensation permit (set by the pipeline only)
+)
+
+// transitions lists the statuses reachable from each status
+var transitions = map[string][]string{
+	StatusOpen:        {StatusUnderReview, StatusDismissed},
+	StatusUnderReview: {StatusConfirmed, StatusDismissed, StatusOpen},
+	StatusConfirmed:   {StatusIssued, StatusDismissed},
+	StatusDismissed:   {StatusClosed, StatusUnderReview},
+	StatusIssued:      {StatusClosed},
+	StatusClosed:      {},
+	StatusPermitted:   {StatusUnderReview}, // Officer disputes the permit
+}
+
+// ValidStatus reports whether s is a known status
+func ValidStatus(s string) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// NextStatuses returns the statuses a violation in status from may move to
+func NextStatuses(from string) []string {
+	next := transitions[from]
+	out := make([]string, len(next))
+	copy(out, next)
+	return out
+}
+
+// CanTransition reports whether a violation may move from one status to another
+func CanTransition(from, to string) bool {
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ReasonRequired reports whether moving to status to must be justified
+func ReasonRequired(to string) bool {
+	return to == StatusDismissed
+}
@@ -0,0 +1,347 @@
+package violation
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"wim-service/internal/permit"
+)
+
+var (
+	// ErrNotFound is returned when the violation (or capture) does not exist
+	ErrNotFound = errors.New("violation: not found")
+	// ErrInvalid is returned when a request fails validation
+	ErrInvalid = errors.New("violation: invalid request")
+	// ErrInvalidTransition is returned when the status change is not allowed
+	ErrInvalidTransition = errors.New("violation: invalid status transition")
+)
+
+// Service manages violation cases, their notes and audit trail
+type Service struct {
+	DB      *sql.DB
+	Permits *permit.Service
+}
+
+// NewService creates a new violation service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db, Permits: permit.NewService(db)}
+}
+
+const violationColumns = `
+	v.id, v.axle_capture_id, v.violation_type, v.status, COALESCE(v.site_id::text, ''),
+	COALESCE(v.plate_no, ''), v.captured_at, COALESCE(v.excess_pct, 0), COALESCE(v.detail, ''),
+	v.still_detected, COALESCE(v.rule_id::text, ''), COALESCE(v.rule_version, ''), COALESCE(v.permit_id::text, ''), COALESCE(v.assigned_to::text, ''), COALESCE(u.full_name, ''), v.assigned_at,
+	v.closed_at, v.created_date, v.updated_date`
+
+const violationFrom = `
+	FROM public.transact_violation v
+	LEFT JOIN public.master_user u ON u.id = v.assigned_to`
+
+func scanViolation(row interface{ Scan(...any) error }) (*Violation, error) {
+	var v Violation
+	var capturedAt, assignedAt, closedAt sql.NullTime
+	err := row.Scan(&v.ID, &v.AxleCaptureID, &v.Type, &v.Status, &v.SiteID,
+		&v.PlateNo, &capturedAt, &v.ExcessPct, &v.Detail,
+		&v.StillDetected, &v.RuleID, &v.RuleVersion, &v.PermitID, &v.AssignedTo, &v.AssignedToName, &assignedAt,
+		&closedAt, &v.CreatedDate, &v.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	if capturedAt.Valid {
+		v.CapturedAt = &capturedAt.Time
+	}
+	if assignedAt.Valid {
+		v.AssignedAt = &assignedAt.Time
+	}
+	if closedAt.Valid {
+		v.ClosedAt = &closedAt.Time
+	}
+	v.NextStatuses = NextStatuses(v.Status)
+	return &v, nil
+}
+
+// List returns violations, newest capture first
+func (s *Service) List(ctx context.Context, filter Filter) ([]Violation, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	query := `SELECT` + violationColumns + violationFrom + `
+		WHERE v.is_deleted = false
+		  AND ($1 = '' OR v.status = $1)
+		  AND ($2 = '' OR v.violation_type = $2)
+		  AND ($3 = '' OR v.site_id::text = $3)
+		  AND ($4 = '' OR v.plate_no ILIKE '%' || $4 || '%')
+		  AND ($5 = '' OR v.assigned_to::text = $5)
+		  AND ($6 = false OR v.assigned_to IS NULL)
+		  AND ($7 = '' OR v.axle_capture_id::text = $7)
+		  AND ($8::timestamptz IS NULL OR v.captured_at >= $8)
+		  AND ($9::timestamptz IS NULL OR v.captured_at < $9)
+		ORDER BY v.captured_at DESC NULLS LAST, v.violation_type
+		LIMIT $10 OFFSET $11`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.Status, filter.Type, filter.SiteID, filter.PlateNo,
+		filter.AssignedTo, filter.Unassigned, filter.AxleCaptureID, nullTime(filter.From), nullTime(filter.To),
+		filter.Limit, filter.Offset)
+	if err != nil {
+		return nil, fmt.Errorf("query violations: %w", err)
+	}
+	defer rows.Close()
+
+	violations := []Violation{}
+	for rows.Next() {
+		v, err := scanViolation(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan violation: %w", err)
+		}
+		violations = append(violations, *v)
+	}
+	return violations, rows.Err()
+}
+
+// Get returns one violation with its notes and audit trail
+func (s *Service) Get(ctx context.Context, id string) (*Violation, error) {
+	v, err := s.get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if v.Notes, err = s.ListNotes(ctx, id); err != nil {
+		return nil, err
+	}
+	if v.Audit, err = s.ListAudit(ctx, id); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (s *Service) get(ctx context.Context, id string) (*Violation, error) {
+	query := `SELECT` + violationColumns + violationFrom + `
+		WHERE v.id::text = $1 AND v.is_deleted = false`
+
+	v, err := scanViolation(s.DB.QueryRowContext(ctx, query, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get violation: %w", err)
+	}
+	return v, nil
+}
+
+// Transition moves a violation to another status. Dismissals need a reason;
+// the reason is kept in the audit trail.
+func (s *Service) Transition(ctx context.Context, id, to, reason, actor string) (*Violation, error) {
+	to = strings.ToUpper(strings.TrimSpace(to))
+	reason = strings.TrimSpace(reason)
+	if !ValidStatus(to) {
+		return nil, fmt.Errorf("%w: unknown status %q", ErrInvalid, to)
+	}
+	if ReasonRequired(to) && reason == "" {
+		return nil, fmt.Errorf("%w: reason is required for %s", ErrInvalid, to)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	from, err := lockStatus(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if !CanTransition(from, to) {
+		return nil, fmt.Errorf("%w: %s -> %s", ErrInvalidTransition, from, to)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_violation
+		SET status = $2,
+		    closed_at = CASE WHEN $2 = 'CLOSED' THEN now() ELSE NULL END,
+		    auto_dismissed = false, -- Keputusan petugas, tidak dibuka ulang oleh pipeline
+		    updated_date = now()
+		WHERE id::text = $1`, id, to); err != nil {
+		return nil, fmt.Errorf("update status: %w", err)
+	}
+	if err := writeAudit(ctx, tx, id, ActionStatusChanged, from, to, reason, actor); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// Assign assigns a violation to an active master_user; an empty userID unassigns it
+func (s *Service) Assign(ctx context.Context, id, userID, actor string) (*Violation, error) {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	status, err := lockStatus(ctx, tx, id)
+	if err != nil {
+		return nil, err
+	}
+	if status == StatusClosed {
+		return nil, fmt.Errorf("%w: violation is closed", ErrInvalidTransition)
+	}
+
+	detail := "unassigned"
+	if userID != "" {
+		var username string
+		err := tx.QueryRowContext(ctx, `
+			SELECT username FROM public.master_user
+			WHERE id::text = $1 AND is_deleted = false AND is_active = true`, userID).Scan(&username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user not found or inactive", ErrInvalid)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("get user: %w", err)
+		}
+		detail = "assigned to " + username
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_violation
+		SET assigned_to = NULLIF($2, '')::uuid,
+		    assigned_at = CASE WHEN $2 = '' THEN NULL ELSE now() END,
+		    updated_date = now()
+		WHERE id::text = $1`, id, userID); err != nil {
+		return nil, fmt.Errorf("update assignment: %w", err)
+	}
+	if err := writeAudit(ctx, tx, id, ActionAssigned, "", "", detail, actor); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return s.Get(ctx, id)
+}
+
+// AddNote adds an officer note to a violation
+func (s *Service) AddNote(ctx context.Context, id, note, actor string) (*Note, error) {
+	note = strings.TrimSpace(note)
+	if note == "" {
+		return nil, fmt.Errorf("%w: note is required", ErrInvalid)
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := lockStatus(ctx, tx, id); err != nil {
+		return nil, err
+	}
+
+	n := Note{Note: note, CreatedBy: actor}
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO public.transact_violation_note (violation_id, note, created_by)
+		VALUES ($1::uuid, $2, $3)
+		RETURNING id, created_date`, id, note, actor).Scan(&n.ID, &n.CreatedDate)
+	if err != nil {
+		return nil, fmt.Errorf("insert note: %w", err)
+	}
+	if err := writeAudit(ctx, tx, id, ActionNoteAdded, "", "", "note "+n.ID, actor); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// ListNotes returns the notes of a violation, oldest first
+func (s *Service) ListNotes(ctx context.Context, id string) ([]Note, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, note, created_by, created_date
+		FROM public.transact_violation_note
+		WHERE violation_id::text = $1
+		ORDER BY created_date`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query notes: %w", err)
+	}
+	defer rows.Close()
+
+	notes := []Note{}
+	for rows.Next() {
+		var n Note
+		if err := rows.Scan(&n.ID, &n.Note, &n.CreatedBy, &n.CreatedDate); err != nil {
+			return nil, fmt.Errorf("scan note: %w", err)
+		}
+		notes = append(notes, n)
+	}
+	return notes, rows.Err()
+}
+
+// ListAudit returns the audit trail of a violation, oldest first
+func (s *Service) ListAudit(ctx context.Context, id string) ([]Audit, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT id, action, COALESCE(from_status, ''), COALESCE(to_status, ''), COALESCE(detail, ''),
+		       actor, created_date
+		FROM public.transact_violation_audit
+		WHERE violation_id::text = $1
+		ORDER BY created_date, id`, id)
+	if err != nil {
+		return nil, fmt.Errorf("query audit: %w", err)
+	}
+	defer rows.Close()
+
+	audit := []Audit{}
+	for rows.Next() {
+		var a Audit
+		if err := rows.Scan(&a.ID, &a.Action, &a.FromStatus, &a.ToStatus, &a.Detail,
+			&a.Actor, &a.CreatedDate); err != nil {
+			return nil, fmt.Errorf("scan audit: %w", err)
+		}
+		audit = append(audit, a)
+	}
+	return audit, rows.Err()
+}
+
+// lockStatus locks the violation row for the rest of tx and returns its status
+func lockStatus(ctx context.Context, tx *sql.Tx, id string) (string, error) {
+	var status string
+	err := tx.QueryRowContext(ctx, `
+		SELECT status FROM public.transact_violation
+		WHERE id::text = $1 AND is_deleted = false
+		FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("lock violation: %w", err)
+	}
+	return status, nil
+}
+
+func writeAudit(ctx context.Context, tx *sql.Tx, id, action, from, to, detail, actor string) error {
+	if actor == "" {
+		actor = SystemActor
+	}
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO public.transact_violation_audit (violation_id, action, from_status, to_status, detail, actor)
+		VALUES ($1::uuid, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)`,
+		id, action, from, to, detail, actor)
+	if err != nil {
+		return fmt.Errorf("insert audit: %w", err)
+	}
+	return nil
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
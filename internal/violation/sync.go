@@ -0,0 +1,274 @@
+package violation
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// captureInfo is the part of an axle capture copied onto its violations
+type captureInfo struct {
+	ID         string
+	SiteID     string
+	PlateNo    string
+	CapturedAt sql.NullTime
+}
+
+// SyncCapture raises, updates or auto-dismisses the violations of an axle capture
+// from its current overload and over-dimension results. The plate is taken from the
+// correlated ANPR capture when the axle capture is matched (transact_vehicle). Exceedances covered by a
+// dispensation permit are recorded as PERMITTED. Violations already worked by an
+// officer keep their status; only still_detected and the audit trail change.
+// Violations dismissed here because the finding disappeared are reopened when it
+// is detected again; violations dismissed by an officer stay dismissed. A violation
+// whose finding can no longer be evaluated (e.g. overload UNKNOWN after a class
+// change) is marked not detected, keeping its status, for the officer to review.
+func (s *Service) SyncCapture(ctx context.Context, captureID, actor string) ([]Violation, error) {
+	var info captureInfo
+	var overloadStatus string
+	var grossKg int
+	var permittedKg, overloadPct float64
+	var overDimension sql.NullBool
+	var overloadRule, overloadRuleVersion, dimensionRule, dimensionRuleVersion string
+	err := s.DB.QueryRowContext(ctx, `
+		SELECT c.id, COALESCE(c.site_id::text, ''), COALESCE(NULLIF(tv.plate_no, 'UNKNOWN'), c.plate_no, ''), c.captured_at,
+		       COALESCE(c.overload_status, ''), COALESCE(c.gross_weight_kg, 0),
+		       COALESCE(c.permitted_weight_kg, 0), COALESCE(c.overload_pct, 0), c.over_dimension,
+		       COALESCE(ro.id::text, ''), COALESCE(ro.code || '@v' || ro."version", ''),
+		       COALESCE(rd.id::text, ''), COALESCE(rd.code || '@v' || rd."version", '')
+		FROM public.transact_axle_capture c
+		LEFT JOIN public.transact_vehicle tv ON tv.axle_id = c.id AND tv.correlation_status = 'MATCHED'
+		LEFT JOIN public.master_violation_rule ro ON ro.id = c.overload_rule_id
+		LEFT JOIN public.master_violation_rule rd ON rd.id = c.dimension_rule_id
+		WHERE c.id::text = $1 AND c.is_deleted = false`, captureID).
+		Scan(&info.ID, &info.SiteID, &info.PlateNo, &info.CapturedAt,
+			&overloadStatus, &grossKg, &permittedKg, &overloadPct, &overDimension,
+			&overloadRule, &overloadRuleVersion, &dimensionRule, &dimensionRuleVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load capture: %w", err)
+	}
+
+	var findings []Finding
+	unevaluated := map[string]string{} // Violation type -> reason
+	if f, ok := OverloadFinding(overloadStatus, grossKg, permittedKg, overloadPct); ok {
+		f.RuleID, f.RuleVersion = overloadRule, overloadRuleVersion
+		findings = append(findings, f)
+	} else {
+		unevaluated[TypeOverload] = "overload can no longer be evaluated (status " + cmp.Or(overloadStatus, "not evaluated") + ")"
+	}
+	var over *bool
+	if overDimension.Valid {
+		over = &overDimension.Bool
+	}
+	excesses, err := s.dimensionExcesses(ctx, captureID)
+	if err != nil {
+		return nil, err
+	}
+	if f, ok := OverDimensionFinding(over, excesses); ok {
+		f.RuleID, f.RuleVersion = dimensionRule, dimensionRuleVersion
+		findings = append(findings, f)
+	} else {
+		unevaluated[TypeOverDimension] = "over-dimension can no longer be evaluated (no vehicle class)"
+	}
+
+	// Dispensation permits of the plate valid at the capture time
+	at := time.Now()
+	if info.CapturedAt.Valid {
+		at = info.CapturedAt.Time
+	}
+	for i := range findings {
+		f := &findings[i]
+		if !f.Detected {
+			continue
+		}
+		p, err := s.Permits.FindCovering(ctx, info.PlateNo, info.SiteID, at, f.Exceeded)
+		if err != nil {
+			return nil, fmt.Errorf("find permit: %w", err)
+		}
+		if p != nil {
+			f.PermitID, f.PermitNo = p.ID, p.PermitNo
+		}
+	}
+
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, f := range findings {
+		if err := applyFinding(ctx, tx, info, f, actor); err != nil {
+			return nil, fmt.Errorf("apply %s: %w", f.Type, err)
+		}
+	}
+	for _, typ := range []string{TypeOverload, TypeOverDimension} {
+		if reason, ok := unevaluated[typ]; ok {
+			if err := markUnevaluated(ctx, tx, info.ID, typ, reason, actor); err != nil {
+				return nil, fmt.Errorf("mark %s unevaluated: %w", typ, err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.List(ctx, Filter{AxleCaptureID: captureID})
+}
+
+func (s *Service) dimensionExcesses(ctx context.Context, captureID string) ([]DimensionExcess, error) {
+	rows, err := s.DB.QueryContext(ctx, `
+		SELECT dimension, measured_mm, excess_mm, excess_pct
+		FROM public.transact_dimension_check
+		WHERE axle_capture_id::text = $1 AND is_violation = true
+		ORDER BY array_position(ARRAY['LENGTH', 'WIDTH', 'HEIGHT'], dimension::text)`, captureID)
+	if err != nil {
+		return nil, fmt.Errorf("query dimension checks: %w", err)
+	}
+	defer rows.Close()
+
+	var out []DimensionExcess
+	for rows.Next() {
+		var e DimensionExcess
+		if err := rows.Scan(&e.Dimension, &e.MeasuredMM, &e.ExcessMM, &e.ExcessPct); err != nil {
+			return nil, fmt.Errorf("scan dimension check: %w", err)
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+func applyFinding(ctx context.Context, tx *sql.Tx, info captureInfo, f Finding, actor string) error {
+	var id, status, detail, ruleVersion, permitID, plateNo string
+	var stillDetected, autoDismissed bool
+	err := tx.QueryRowContext(ctx, `
+		SELECT id, status, COALESCE(detail, ''), still_detected, auto_dismissed, COALESCE(rule_version, ''),
+		       COALESCE(permit_id::text, ''), COALESCE(plate_no, '')
+		FROM public.transact_violation
+		WHERE axle_capture_id::text = $1 AND violation_type = $2 AND is_deleted = false
+		FOR UPDATE`, info.ID, f.Type).Scan(&id, &status, &detail, &stillDetected, &autoDismissed, &ruleVersion, &permitID, &plateNo)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		if !f.Detected {
+			return nil
+		}
+		// Tercakup dispensasi -> permitted exceedance, bukan kasus baru
+		initial := StatusOpen
+		if f.PermitID != "" {
+			initial = StatusPermitted
+		}
+		err := tx.QueryRowContext(ctx, `
+			INSERT INTO public.transact_violation
+				(axle_capture_id, violation_type, status, site_id, plate_no, captured_at, excess_pct, detail,
+				 rule_id, rule_version, permit_id)
+			VALUES ($1::uuid, $2, $3, NULLIF($4, '')::uuid, NULLIF($5, ''), $6, $7, $8,
+			        NULLIF($9, '')::uuid, NULLIF($10, ''), NULLIF($11, '')::uuid)
+			RETURNING id`,
+			info.ID, f.Type, initial, info.SiteID, info.PlateNo, info.CapturedAt, f.ExcessPct, f.Detail,
+			f.RuleID, f.RuleVersion, f.PermitID).Scan(&id)
+		if err != nil {
+			return fmt.Errorf("insert violation: %w", err)
+		}
+		return writeAudit(ctx, tx, id, ActionCreated, "", initial, describe(f), actor)
+	}
+	if err != nil {
+		return fmt.Errorf("load violation: %w", err)
+	}
+
+	if f.Detected {
+		// Status hanya diubah otomatis selama kasus belum dikerjakan petugas;
+		// dismiss otomatis dibuka kembali (dismiss oleh petugas tetap)
+		next := status
+		switch {
+		case status == StatusOpen && f.PermitID != "":
+			next = StatusPermitted
+		case status == StatusPermitted && f.PermitID == "":
+			next = StatusOpen
+		case status == StatusDismissed && autoDismissed && f.PermitID != "":
+			next = StatusPermitted
+		case status == StatusDismissed && autoDismissed:
+			next = StatusOpen
+		}
+		if next == status && stillDetected && detail == f.Detail && ruleVersion == f.RuleVersion &&
+			permitID == f.PermitID && plateNo == info.PlateNo {
+			return nil
+		}
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE public.transact_violation
+			SET status = $2, excess_pct = $3, detail = $4, still_detected = true,
+			    rule_id = NULLIF($5, '')::uuid, rule_version = NULLIF($6, ''),
+			    permit_id = NULLIF($7, '')::uuid, plate_no = NULLIF($8, ''),
+			    auto_dismissed = (auto_dismissed AND $2 = 'DISMISSED'), updated_date = now()
+			WHERE id = $1`, id, next, f.ExcessPct, f.Detail, f.RuleID, f.RuleVersion, f.PermitID, info.PlateNo); err != nil {
+			return fmt.Errorf("update violation: %w", err)
+		}
+		if status == StatusDismissed && next != status {
+			return writeAudit(ctx, tx, id, ActionStatusChanged, status, next,
+				"detected again after re-evaluation: "+describe(f), actor)
+		}
+		if next != status {
+			return writeAudit(ctx, tx, id, ActionStatusChanged, status, next, describe(f), actor)
+		}
+		return writeAudit(ctx, tx, id, ActionReevaluated, "", "", "detected: "+describe(f), actor)
+	}
+
+	// Belum dikerjakan petugas -> dismiss otomatis (juga kasus yang sebelumnya tidak
+	// bisa dievaluasi); selain itu hanya ditandai
+	open := status == StatusOpen || status == StatusPermitted
+	if !stillDetected && !open {
+		return nil
+	}
+	if open {
+		if _, err := tx.ExecContext(ctx, `
+			UPDATE public.transact_violation
+			SET status = $2, still_detected = false, auto_dismissed = true, detail = $3, updated_date = now()
+			WHERE id = $1`, id, StatusDismissed, f.Detail); err != nil {
+			return fmt.Errorf("dismiss violation: %w", err)
+		}
+		return writeAudit(ctx, tx, id, ActionStatusChanged, status, StatusDismissed,
+			"no longer detected after re-evaluation: "+describe(f), actor)
+	}
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE public.transact_violation
+		SET still_detected = false, updated_date = now()
+		WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("update violation: %w", err)
+	}
+	return writeAudit(ctx, tx, id, ActionReevaluated, "", "", "no longer detected: "+describe(f), actor)
+}
+
+// markUnevaluated marks a still detected violation whose finding can no longer be
+// evaluated as not detected. Its status is kept: the data no longer confirms the
+// violation, but does not clear it either.
+func markUnevaluated(ctx context.Context, tx *sql.Tx, captureID, violationType, reason, actor string) error {
+	var id string
+	err := tx.QueryRowContext(ctx, `
+		UPDATE public.transact_violation
+		SET still_detected = false, updated_date = now()
+		WHERE axle_capture_id::text = $1 AND violation_type = $2 AND is_deleted = false AND still_detected = true
+		RETURNING id`, captureID, violationType).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("update violation: %w", err)
+	}
+	return writeAudit(ctx, tx, id, ActionReevaluated, "", "", reason+"; review required", actor)
+}
+
+// describe is the audit detail of a finding: measurement, rule version and permit
+func describe(f Finding) string {
+	d := f.Detail
+	if f.RuleVersion != "" {
+		d += " [rule " + f.RuleVersion + "]"
+	}
+	if f.PermitNo != "" {
+		d += " [permitted exceedance, permit " + f.PermitNo + "]"
+	}
+	return d
+}
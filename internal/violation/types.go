@@ -0,0 +1,99 @@
+package violation
+
+import (
+	"time"
+
+	"wim-service/internal/permit"
+)
+
+// Violation types (transact_violation.violation_type)
+const (
+	TypeOverload      = "OVERLOAD"
+	TypeOverDimension = "OVER_DIMENSION"
+)
+
+// Audit actions (transact_violation_audit.action)
+const (
+	ActionCreated       = "CREATED"
+	ActionStatusChanged = "STATUS_CHANGED"
+	ActionAssigned      = "ASSIGNED"
+	ActionNoteAdded     = "NOTE_ADDED"
+	ActionReevaluated   = "REEVALUATED"
+)
+
+// SystemActor is recorded for changes made by the pipelines
+const SystemActor = "system"
+
+// Violation is a violation case raised from an axle capture
+type Violation struct {
+	ID             string     `json:"id"`
+	AxleCaptureID  string     `json:"axle_capture_id"`
+	Type           string     `json:"violation_type"`
+	Status         string     `json:"status"`
+	SiteID         string     `json:"site_id"`
+	PlateNo        string     `json:"plate_no"`
+	CapturedAt     *time.Time `json:"captured_at"`
+	ExcessPct      float64    `json:"excess_pct"`
+	Detail         string     `json:"detail"`
+	StillDetected  bool       `json:"still_detected"`
+	RuleID         string     `json:"rule_id"`
+	RuleVersion    string     `json:"rule_version"` // Regulation rule version that produced the violation (code@vN)
+	PermitID       string     `json:"permit_id"`    // Dispensation permit covering the exceedance (status PERMITTED)
+	AssignedTo     string     `json:"assigned_to"`
+	AssignedToName string     `json:"assigned_to_name"`
+	AssignedAt     *time.Time `json:"assigned_at"`
+	ClosedAt       *time.Time `json:"closed_at"`
+	CreatedDate    time.Time  `json:"created_date"`
+	UpdatedDate    time.Time  `json:"updated_date"`
+	NextStatuses   []string   `json:"next_statuses"`
+
+	Notes []Note  `json:"notes,omitempty"`
+	Audit []Audit `json:"audit,omitempty"`
+}
+
+// Note is an officer note on a violation
+type Note struct {
+	ID          string    `json:"id"`
+	Note        string    `json:"note"`
+	CreatedBy   string    `json:"created_by"`
+	CreatedDate time.Time `json:"created_date"`
+}
+
+// Audit is one entry of the violation audit trail
+type Audit struct {
+	ID          string    `json:"id"`
+	Action      string    `json:"action"`
+	FromStatus  string    `json:"from_status"`
+	ToStatus    string    `json:"to_status"`
+	Detail      string    `json:"detail"`
+	Actor       string    `json:"actor"`
+	CreatedDate time.Time `json:"created_date"`
+}
+
+// Filter narrows List
+type Filter struct {
+	Status        string
+	Type          string
+	SiteID        string
+	PlateNo       string
+	AssignedTo    string
+	Unassigned    bool
+	AxleCaptureID string
+	From          *time.Time
+	To            *time.Time
+	Limit         int
+	Offset        int
+}
+
+// Finding is a violation detected (or no longer detected) on a capture
+type Finding struct {
+	Type        string
+	Detected    bool
+	ExcessPct   float64
+	Detail      string
+	RuleID      string // Regulation rule the evaluation used; empty = configured default
+	RuleVersion string
+	Exceeded    permit.Exceedance // What exceeded the regular limits (checked against permits)
+	PermitID    string            // Covering dispensation permit, if any
+	PermitNo    string
+}
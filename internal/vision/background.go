@@ -0,0 +1,146 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// background is the running-average model of the empty road of one camera. Pixels
+// only become "seen" once they were observed outside any detected vehicle, so the
+// vehicle in the first frame does not leave a ghost in the model.
+type background struct {
+	mu     sync.Mutex
+	w, h   int
+	mean   [3][]float32 // RGB
+	seen   []bool
+	frames int
+}
+
+func newBackground(w, h int) *background {
+	bg := &background{w: w, h: h, seen: make([]bool, w*h)}
+	for c := range bg.mean {
+		bg.mean[c] = make([]float32, w*h)
+	}
+	return bg
+}
+
+// ready reports whether any pixel of the model can be used
+func (bg *background) ready() bool {
+	return bg.frames > 0
+}
+
+// update blends the frame into the model. Pixels covered by a vehicle (fg) are
+// blended very slowly so that a parked object is eventually absorbed.
+func (bg *background) update(g *workFrame, fg []bool, rate float64) {
+	slow := float32(rate / 20)
+	fast := float32(rate)
+	for i := range bg.seen {
+		rate := fast
+		switch {
+		case !bg.seen[i] && fg[i]:
+			continue // Masih tertutup kendaraan sejak awal: belum ada nilai latar
+		case !bg.seen[i]:
+			rate = 1
+			bg.seen[i] = true
+		case fg[i]:
+			rate = slow
+		}
+		for c := range bg.mean {
+			bg.mean[c][i] += rate * (g.rgb[c][i] - bg.mean[c][i])
+		}
+	}
+	bg.frames++
+}
+
+// reset forgets the model (e.g. after a camera moved or the scene changed completely)
+func (bg *background) reset() {
+	for i := range bg.seen {
+		bg.seen[i] = false
+	}
+	bg.frames = 0
+}
+
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]`)
+
+// backgroundFile is the persisted model of a camera inside dir
+func backgroundFile(dir, camera string) string {
+	if camera == "" {
+		camera = "default"
+	}
+	return filepath.Join(dir, "background_"+unsafeFileChars.ReplaceAllString(camera, "_")+".png")
+}
+
+// loadBackground reads a persisted model; a missing file or another size is not an error
+func loadBackground(path string, w, h int) (*background, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, err := png.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if img.Bounds().Dx() != w || img.Bounds().Dy() != h {
+		return nil, nil
+	}
+	// PNG tanpa piksel transparan didecode sebagai RGBA
+	rgba := image.NewNRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(rgba, rgba.Rect, img, img.Bounds().Min, draw.Src)
+
+	bg := newBackground(w, h)
+	for i := range bg.seen {
+		px := rgba.Pix[(i/w)*rgba.Stride+(i%w)*4:]
+		if px[3] == 0 {
+			continue // Belum pernah terlihat saat disimpan
+		}
+		for c := range bg.mean {
+			bg.mean[c][i] = float32(px[c])
+		}
+		bg.seen[i] = true
+	}
+	bg.frames = 1
+	return bg, nil
+}
+
+// save writes the model as a PNG; unseen pixels are transparent
+func (bg *background) save(path string) error {
+	img := image.NewNRGBA(image.Rect(0, 0, bg.w, bg.h))
+	for i := range bg.seen {
+		if !bg.seen[i] {
+			continue
+		}
+		px := img.Pix[(i/bg.w)*img.Stride+(i%bg.w)*4:]
+		for c := range bg.mean {
+			px[c] = uint8(min(max(bg.mean[c][i]+0.5, 0), 255))
+		}
+		px[3] = 255
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -5,6 +5,9 @@ import (
 	"math"
 )
 
+// heightToLength is the assumed vehicle height as a fraction of its length
+const heightToLength = 0.4
+
 // CameraCalibration holds camera calibration parameters
 type CameraCalibration struct {
 	// Intrinsic parameters
@@ -25,6 +28,13 @@ type CameraCalibration struct {
 
 	// Computed values
 	PixelToMeterRatio float64 // Conversion ratio at reference distance
+
+	// Ground-plane calibration from control points. When set, pixel-to-ground
+	// projections go through its homography instead of the reference-object ratio.
+	Ground *GroundCalibration
+
+	// ProfileID is the calibration profile this calibration was built from ("" = config)
+	ProfileID string
 }
 
 // NewCameraCalibration creates a new camera calibration with default values
@@ -66,6 +76,22 @@ func (cc *CameraCalibration) LoadFromConfig(
 	cc.ComputePixelToMeterRatio()
 }
 
+// SetControlPoints calibrates the ground plane from four or more image↔road control
+// points. A recovered camera pose replaces the focal length, height and tilt.
+func (cc *CameraCalibration) SetControlPoints(points []ControlPoint) error {
+	ground, err := NewGroundCalibration(points, cc.PrincipalPointX, cc.PrincipalPointY)
+	if err != nil {
+		return err
+	}
+	cc.Ground = ground
+	if p := ground.Pose; p != nil {
+		cc.FocalLengthPixels = p.FocalLengthPixels
+		cc.CameraHeightMeters = p.HeightMeters
+		cc.TiltAngleDegrees = p.TiltDegrees
+	}
+	return nil
+}
+
 // ComputePixelToMeterRatio calculates the pixel to meter conversion ratio
 func (cc *CameraCalibration) ComputePixelToMeterRatio() {
 	if cc.ReferencePixelLength > 0 {
@@ -120,6 +146,10 @@ func (cc *CameraCalibration) EstimateDistance(pixelY int) float64 {
 
 // CalculateGroundDimensions calculates real-world dimensions from bounding box
 func (cc *CameraCalibration) CalculateGroundDimensions(bbox BoundingBox) (*VehicleDimensions, error) {
+	if cc.Ground != nil {
+		return cc.groundDimensions(bbox)
+	}
+
 	// Calculate bottom center of bounding box (closest point to camera)
 	bottomY := bbox.Y + bbox.Height
 	centerX := bbox.X + bbox.Width/2
@@ -145,7 +175,7 @@ func (cc *CameraCalibration) CalculateGroundDimensions(bbox BoundingBox) (*Vehic
 
 	// Height estimation (simplified - assumes vehicle height proportional to length)
 	// For better accuracy, need side-view camera or 3D reconstruction
-	height := length * 0.4 // Rough estimate: vehicles are typically 40% as tall as long
+	height := length * heightToLength // Rough estimate: vehicles are typically 40% as tall as long
 
 	return &VehicleDimensions{
 		LengthMeters:   length,
@@ -158,6 +188,56 @@ func (cc *CameraCalibration) CalculateGroundDimensions(bbox BoundingBox) (*Vehic
 	}, nil
 }
 
+// groundDimensions projects the bounding box onto the road plane. The bottom edge
+// touches the road, so its corners give the width across the road exactly. The
+// length runs along the road from the bottom edge to the top edge; the top edge is
+// projected as if it lay on the road too, which overshoots by the vehicle height,
+// so the overshoot is removed when the camera pose is known.
+func (cc *CameraCalibration) groundDimensions(bbox BoundingBox) (*VehicleDimensions, error) {
+	g := cc.Ground
+	left, right := float64(bbox.X), float64(bbox.X+bbox.Width)
+	top, bottom := float64(bbox.Y), float64(bbox.Y+bbox.Height)
+	centerX := (left + right) / 2
+
+	blX, blY, ok1 := g.Project(left, bottom)
+	brX, brY, ok2 := g.Project(right, bottom)
+	bcX, bcY, ok3 := g.Project(centerX, bottom)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, fmt.Errorf("bounding box bottom is above the horizon")
+	}
+	tcX, tcY, ok4 := g.Project(centerX, top)
+	if !ok4 {
+		return nil, fmt.Errorf("bounding box top is above the horizon")
+	}
+
+	width := math.Hypot(brX-blX, brY-blY)
+
+	var length, distance float64
+	if p := g.Pose; p != nil {
+		distance = math.Hypot(bcX-p.GroundX, bcY-p.GroundY)
+		// Sinar ke tepi atas mengenai atap setinggi h pada (Hc - h) / Hc dari jarak
+		// titik proyeksinya di jalan. Dengan h = 0.4 × panjang (estimasi tinggi di
+		// bawah) panjang = (far - distance) / (1 + 0.4 × far / Hc)
+		far := math.Hypot(tcX-p.GroundX, tcY-p.GroundY)
+		length = math.Max(far-distance, 0) / (1 + heightToLength*far/p.HeightMeters)
+	} else {
+		distance = cc.EstimateDistance(bbox.Y + bbox.Height)
+		length = math.Hypot(tcX-bcX, tcY-bcY)
+	}
+	// Height estimation (simplified - vehicles are typically 40% as tall as long)
+	height := length * heightToLength
+
+	return &VehicleDimensions{
+		LengthMeters:   length,
+		WidthMeters:    width,
+		HeightMeters:   height,
+		DistanceMeters: distance,
+		CenterX:        int(centerX),
+		CenterY:        bbox.Y + bbox.Height,
+		Confidence:     0.7, // Medium confidence without side view
+	}, nil
+}
+
 // Validate checks if calibration parameters are reasonable
 func (cc *CameraCalibration) Validate() error {
 	if cc.FocalLengthPixels <= 0 {
@@ -172,7 +252,7 @@ func (cc *CameraCalibration) Validate() error {
 	if cc.TiltAngleDegrees < 0 || cc.TiltAngleDegrees > 90 {
 		return fmt.Errorf("tilt angle must be between 0 and 90 degrees")
 	}
-	if cc.ReferenceRealLength <= 0 {
+	if cc.Ground == nil && cc.ReferenceRealLength <= 0 {
 		return fmt.Errorf("reference length must be positive")
 	}
 
@@ -195,5 +275,20 @@ func (cc *CameraCalibration) GetCalibrationInfo() string {
 		cc.TiltAngleDegrees,
 		cc.ReferencePixelLength, cc.ReferenceRealLength, cc.ReferenceDistanceM,
 		cc.PixelToMeterRatio,
-	)
+	) + cc.groundInfo()
+}
+
+func (cc *CameraCalibration) groundInfo() string {
+	if cc.Ground == nil {
+		return ""
+	}
+	r := cc.Ground.Residual
+	info := fmt.Sprintf("\n  Ground: %d control points, RMS %.3f m (max %.3f m, %.2f px)", r.Points, r.RMSMeters, r.MaxMeters, r.RMSPixels)
+	if r.LeaveOneOutRMS != nil {
+		info += fmt.Sprintf(", leave-one-out %.3f m", *r.LeaveOneOutRMS)
+	}
+	if r.Warning != "" {
+		info += "\n  Warning: " + r.Warning
+	}
+	return info
 }
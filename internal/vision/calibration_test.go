@@ -0,0 +1,106 @@
+package vision
+
+import (
+	"math"
+	"testing"
+)
+
+// pinholeCamera is a synthetic camera for the calibration tests: square pixels, no
+// roll, standing at (X, Y) on the road at Height metres and looking along +Y (the
+// road) tilted down by Tilt degrees. Ground X runs across the road, Y along it.
+type pinholeCamera struct {
+	Focal, CX, CY float64
+	X, Y, Height  float64
+	Tilt          float64
+}
+
+// axes returns the camera's right, down and forward unit vectors in ground coordinates
+func (c pinholeCamera) axes() (right, down, forward [3]float64) {
+	s, co := math.Sincos(c.Tilt * math.Pi / 180)
+	return [3]float64{1, 0, 0}, [3]float64{0, -s, -co}, [3]float64{0, co, -s}
+}
+
+// project maps a point (x, y, z) with z above the road to a pixel
+func (c pinholeCamera) project(x, y, z float64) (float64, float64) {
+	right, down, forward := c.axes()
+	v := [3]float64{x - c.X, y - c.Y, z - c.Height}
+	zc := dot3(v, forward)
+	return c.CX + c.Focal*dot3(v, right)/zc, c.CY + c.Focal*dot3(v, down)/zc
+}
+
+// groundToImage is the exact road→image homography K·[r1 r2 t], scaled to h33 = 1
+func (c pinholeCamera) groundToImage() Homography {
+	right, down, forward := c.axes()
+	center := [3]float64{c.X, c.Y, c.Height}
+	t := [3]float64{-dot3(right, center), -dot3(down, center), -dot3(forward, center)}
+	rt := Homography{
+		right[0], right[1], t[0],
+		down[0], down[1], t[1],
+		forward[0], forward[1], t[2],
+	}
+	h := Homography{c.Focal, 0, c.CX, 0, c.Focal, c.CY, 0, 0, 1}.mul(rt)
+	for i := range h {
+		h[i] /= rt[8]
+	}
+	return h
+}
+
+// controlPoints projects road points into the image
+func (c pinholeCamera) controlPoints(ground [][2]float64) []ControlPoint {
+	points := make([]ControlPoint, len(ground))
+	for i, g := range ground {
+		px, py := c.project(g[0], g[1], 0)
+		points[i] = ControlPoint{PixelX: px, PixelY: py, GroundX: g[0], GroundY: g[1]}
+	}
+	return points
+}
+
+// laneRectangle is a 3.5 m lane over 20 m of road with its centre point
+var laneRectangle = [][2]float64{{0, 0}, {3.5, 0}, {3.5, 20}, {0, 20}, {1.75, 10}}
+
+func TestGroundDimensions(t *testing.T) {
+	tests := []struct {
+		name                 string
+		camera               pinholeCamera
+		length, width, nearY float64
+	}{
+		{"truck", pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}, 10, 2.5, 2},
+		{"car", pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}, 4.5, 1.8, 5},
+		{"long focal", pinholeCamera{Focal: 2000, CX: 960, CY: 540, X: 1.75, Y: -25, Height: 8, Tilt: 12}, 12, 2.5, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cc := NewCameraCalibration()
+			if err := cc.SetControlPoints(tt.camera.controlPoints(laneRectangle)); err != nil {
+				t.Fatal(err)
+			}
+
+			// Kendaraan di tengah kamera: sudut bawah dekat di jalan memberi tepi bawah,
+			// kiri dan kanan box; tepi atas adalah atap belakang setinggi 0.4 × panjang
+			x0, x1 := tt.camera.X-tt.width/2, tt.camera.X+tt.width/2
+			left, bottom := tt.camera.project(x0, tt.nearY, 0)
+			right, _ := tt.camera.project(x1, tt.nearY, 0)
+			_, top := tt.camera.project(tt.camera.X, tt.nearY+tt.length, tt.length*heightToLength)
+			bbox := BoundingBox{
+				X:      int(math.Round(left)),
+				Y:      int(math.Round(top)),
+				Width:  int(math.Round(right - left)),
+				Height: int(math.Round(bottom - top)),
+			}
+
+			dims, err := cc.CalculateGroundDimensions(bbox)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(dims.WidthMeters-tt.width) > 0.05 {
+				t.Errorf("width = %.3f m, want %.2f", dims.WidthMeters, tt.width)
+			}
+			if math.Abs(dims.LengthMeters-tt.length) > 0.15 {
+				t.Errorf("length = %.3f m, want %.2f", dims.LengthMeters, tt.length)
+			}
+			if want := tt.nearY - tt.camera.Y; math.Abs(dims.DistanceMeters-want) > 0.1 {
+				t.Errorf("distance = %.3f m, want %.2f", dims.DistanceMeters, want)
+			}
+		})
+	}
+}
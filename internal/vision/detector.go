@@ -1,64 +1,136 @@
 package vision
 
 import (
-"fmt"
-"image"
-"image/color"
-"image/draw"
-"image/jpeg"
-"image/png"
-"os"
-"path/filepath"
-"strings"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 )
 
-// VehicleDetector handles vehicle detection in images
-type VehicleDetector struct {
-	ModelPath string
-	Threshold float64
+// VehicleDetector finds vehicles in camera frames. Implementations must be safe
+// for concurrent use.
+type VehicleDetector interface {
+	// DetectCamera returns the vehicles in a frame of the given camera in image
+	// coordinates, best score first
+	DetectCamera(img image.Image, camera string) ([]BoundingBox, error)
 }
 
-// NewVehicleDetector creates a new vehicle detector instance
-func NewVehicleDetector(modelPath string, threshold float64) *VehicleDetector {
-	return &VehicleDetector{
-		ModelPath: modelPath,
-		Threshold: threshold,
+// Detector backends (DIMENSION_DETECTOR)
+const (
+	DetectorSegmentation = "segmentation" // Built-in, see SegmentationDetector
+	DetectorHTTP         = "http"         // External inference server, see HTTPDetector
+)
+
+// DetectorConfig selects and configures the detector backend
+type DetectorConfig struct {
+	Backend   string
+	ModelPath string  // Background model directory of the built-in detector
+	Threshold float64 // Minimum detection score
+
+	// Inference server (Backend = http)
+	Endpoint         string
+	Timeout          time.Duration // Per request
+	BatchSize        int           // Frames per request
+	BatchWait        time.Duration // Max wait for a batch to fill
+	Classes          []string      // Accepted class labels (empty = all)
+	Fallback         bool          // Use the built-in detector when the server fails
+	FallbackCooldown time.Duration // Skip the server this long after a failure
+}
+
+// NewDetector creates the configured detector backend
+func NewDetector(cfg DetectorConfig) (VehicleDetector, error) {
+	switch cfg.Backend {
+	case "", DetectorSegmentation:
+		return NewSegmentationDetector(cfg.ModelPath, cfg.Threshold), nil
+	case DetectorHTTP:
+		if cfg.Endpoint == "" {
+			return nil, fmt.Errorf("detector backend %q needs an inference endpoint", cfg.Backend)
+		}
+		remote := NewHTTPDetector(cfg.Endpoint, cfg.Threshold)
+		if cfg.Timeout > 0 {
+			remote.Timeout = cfg.Timeout
+		}
+		if cfg.BatchSize > 0 {
+			remote.BatchSize = cfg.BatchSize
+		}
+		if cfg.BatchWait > 0 {
+			remote.BatchWait = cfg.BatchWait
+		}
+		remote.SetClasses(cfg.Classes)
+		if !cfg.Fallback {
+			return remote, nil
+		}
+		return NewFallbackDetector(remote, NewSegmentationDetector(cfg.ModelPath, cfg.Threshold), cfg.FallbackCooldown), nil
+	default:
+		return nil, fmt.Errorf("unknown detector backend: %q", cfg.Backend)
+	}
+}
+
+// FallbackDetector uses Primary and switches to Fallback when Primary fails.
+// After a failure Primary is skipped for Cooldown so that every capture does not
+// wait for a timeout while the server is down.
+type FallbackDetector struct {
+	Primary  VehicleDetector
+	Fallback VehicleDetector
+	Cooldown time.Duration
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+// NewFallbackDetector creates a detector falling back from primary to fallback
+func NewFallbackDetector(primary, fallback VehicleDetector, cooldown time.Duration) *FallbackDetector {
+	return &FallbackDetector{Primary: primary, Fallback: fallback, Cooldown: cooldown}
+}
+
+// DetectCamera implements VehicleDetector
+func (fd *FallbackDetector) DetectCamera(img image.Image, camera string) ([]BoundingBox, error) {
+	fd.mu.Lock()
+	down := time.Now().Before(fd.downUntil)
+	fd.mu.Unlock()
+
+	if !down {
+		boxes, err := fd.Primary.DetectCamera(img, camera)
+		if err == nil {
+			return boxes, nil
+		}
+		log.Printf("[DETECTOR] Primary detector failed, using fallback for %v: %v", fd.Cooldown, err)
+		fd.mu.Lock()
+		fd.downUntil = time.Now().Add(fd.Cooldown)
+		fd.mu.Unlock()
 	}
+	return fd.Fallback.DetectCamera(img, camera)
 }
 
-// DetectVehicle detects vehicles in an image and returns bounding boxes
-func (vd *VehicleDetector) DetectVehicle(imagePath string) ([]BoundingBox, error) {
+// DetectVehicle loads an image file and detects vehicles in it
+func DetectVehicle(d VehicleDetector, imagePath string) ([]BoundingBox, error) {
 	img, err := loadImage(imagePath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load image: %w", err)
 	}
-
-	bounds := img.Bounds()
-	width := bounds.Dx()
-	height := bounds.Dy()
-
-	// Mock detection - replace with real detection
-	mockBoxes := []BoundingBox{
-		{
-			X:      width / 4,
-			Y:      height / 3,
-			Width:  width / 2,
-			Height: height / 2,
-			Label:  "vehicle",
-			Score:  0.95,
-		},
-	}
-
-	return mockBoxes, nil
+	return d.DetectCamera(img, "")
 }
 
-// DrawBoundingBoxes draws bounding boxes on image
-func (vd *VehicleDetector) DrawBoundingBoxes(imagePath string, boxes []BoundingBox, outputPath string) error {
+// DrawBoundingBoxes draws bounding boxes on an image file and saves the result
+func DrawBoundingBoxes(imagePath string, boxes []BoundingBox, outputPath string) error {
 	img, err := loadImage(imagePath)
 	if err != nil {
 		return fmt.Errorf("failed to load image: %w", err)
 	}
 
+	return saveImage(DrawBoxes(img, boxes), outputPath)
+}
+
+// DrawBoxes returns a copy of img with the bounding boxes drawn on it
+func DrawBoxes(img image.Image, boxes []BoundingBox) *image.RGBA {
 	bounds := img.Bounds()
 	rgba := image.NewRGBA(bounds)
 	draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
@@ -68,7 +140,7 @@ func (vd *VehicleDetector) DrawBoundingBoxes(imagePath string, boxes []BoundingB
 		drawRect(rgba, box.X, box.Y, box.Width, box.Height, red, 3)
 	}
 
-	return saveImage(rgba, outputPath)
+	return rgba
 }
 
 func loadImage(path string) (image.Image, error) {
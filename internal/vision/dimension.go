@@ -2,19 +2,30 @@ package vision
 
 import (
 	"fmt"
+	"image"
+	"io"
 	"log"
+	"time"
 )
 
+// CalibrationSource provides the calibration of a camera in effect at a capture
+// time, e.g. from calibration profiles in the database
+type CalibrationSource interface {
+	// CalibrationFor returns nil when the camera has no calibration of its own
+	CalibrationFor(camera string, at time.Time) (*CameraCalibration, error)
+}
+
 // DimensionService handles vehicle dimension calculations
 type DimensionService struct {
-	Detector    *VehicleDetector
-	Calibration *CameraCalibration
+	Detector    VehicleDetector
+	Calibration *CameraCalibration // Used when Profiles has no calibration for the camera
+	Profiles    CalibrationSource  // Optional per-camera calibration
 }
 
 // NewDimensionService creates a new dimension service
-func NewDimensionService(modelPath string, threshold float64) *DimensionService {
+func NewDimensionService(detector VehicleDetector) *DimensionService {
 	return &DimensionService{
-		Detector:    NewVehicleDetector(modelPath, threshold),
+		Detector:    detector,
 		Calibration: NewCameraCalibration(),
 	}
 }
@@ -28,12 +39,40 @@ func (ds *DimensionService) SetCalibration(calibration *CameraCalibration) error
 	return nil
 }
 
-// ProcessImage processes an image and returns vehicle dimensions
+// ProcessImage loads an image file and returns vehicle dimensions
 func (ds *DimensionService) ProcessImage(imagePath string) ([]VehicleDimensions, error) {
-	log.Printf("[DIMENSION] Processing image: %s", imagePath)
+	img, err := loadImage(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image: %w", err)
+	}
+	return ds.ProcessDecoded(img, imagePath)
+}
+
+// ProcessReader decodes an image from r and returns vehicle dimensions.
+// source identifies the image in results and logs (file path or object name).
+func (ds *DimensionService) ProcessReader(r io.Reader, source string) ([]VehicleDimensions, error) {
+	img, err := DecodeImage(r)
+	if err != nil {
+		return nil, err
+	}
+	return ds.ProcessDecoded(img, source)
+}
+
+// ProcessDecoded returns vehicle dimensions for an already decoded image
+func (ds *DimensionService) ProcessDecoded(img image.Image, source string) ([]VehicleDimensions, error) {
+	return ds.ProcessCamera(img, source, "", time.Now())
+}
+
+// ProcessCamera returns vehicle dimensions for a decoded frame of a camera captured
+// at the given time; the detector keeps a background model per camera and the
+// calibration is the one in effect for the camera at that time
+func (ds *DimensionService) ProcessCamera(img image.Image, source, camera string, capturedAt time.Time) ([]VehicleDimensions, error) {
+	log.Printf("[DIMENSION] Processing image: %s", source)
+
+	calibration := ds.calibrationFor(camera, capturedAt)
 
 	// Detect vehicles in the image
-	boxes, err := ds.Detector.DetectVehicle(imagePath)
+	boxes, err := ds.Detector.DetectCamera(img, camera)
 	if err != nil {
 		return nil, fmt.Errorf("vehicle detection failed: %w", err)
 	}
@@ -50,14 +89,15 @@ func (ds *DimensionService) ProcessImage(imagePath string) ([]VehicleDimensions,
 	for i, box := range boxes {
 		log.Printf("[DIMENSION] Calculating dimensions for vehicle %d (score: %.2f)", i+1, box.Score)
 
-		dims, err := ds.Calibration.CalculateGroundDimensions(box)
+		dims, err := calibration.CalculateGroundDimensions(box)
 		if err != nil {
 			log.Printf("[DIMENSION] Warning: Failed to calculate dimensions for vehicle %d: %v", i+1, err)
 			continue
 		}
 
 		// Set additional metadata
-		dims.ImagePath = imagePath
+		dims.ImagePath = source
+		dims.CalibrationID = calibration.ProfileID
 
 		// Adjust confidence based on detection score
 		dims.Confidence = dims.Confidence * box.Score
@@ -71,6 +111,23 @@ func (ds *DimensionService) ProcessImage(imagePath string) ([]VehicleDimensions,
 	return results, nil
 }
 
+// calibrationFor returns the calibration of the camera, falling back to the
+// service calibration when there is no profile or it cannot be loaded
+func (ds *DimensionService) calibrationFor(camera string, at time.Time) *CameraCalibration {
+	if ds.Profiles == nil {
+		return ds.Calibration
+	}
+	calibration, err := ds.Profiles.CalibrationFor(camera, at)
+	if err != nil {
+		log.Printf("[DIMENSION] Warning: Using default calibration for camera %q: %v", camera, err)
+		return ds.Calibration
+	}
+	if calibration == nil {
+		return ds.Calibration
+	}
+	return calibration
+}
+
 // ClassifyVehicle classifies vehicle type based on dimensions
 func (ds *DimensionService) ClassifyVehicle(dims VehicleDimensions) VehicleClass {
 	// Vehicle classification based on typical dimensions
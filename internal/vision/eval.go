@@ -0,0 +1,150 @@
+package vision
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// LabelBox is a ground truth vehicle box in a label set
+type LabelBox struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+// LabelledImage is one frame of a label set. Frames of a camera are listed in
+// capture order so the detector's background model sees them as it would live.
+type LabelledImage struct {
+	Image  string     `json:"image"` // Relative to the label file
+	Camera string     `json:"camera"`
+	Boxes  []LabelBox `json:"boxes"`
+}
+
+// LabelSet is a labelled image set used to evaluate a detector
+type LabelSet struct {
+	Description string          `json:"description"`
+	Images      []LabelledImage `json:"images"`
+
+	dir string
+}
+
+// LoadLabelSet reads a label file (see internal/vision/testdata/detector/labels.json)
+func LoadLabelSet(path string) (*LabelSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var set LabelSet
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	set.dir = filepath.Dir(path)
+	return &set, nil
+}
+
+// ImageResult is the evaluation of one frame
+type ImageResult struct {
+	Image          string
+	Camera         string
+	Detections     []BoundingBox
+	Labels         int
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	IoU            []float64 // IoU of each true positive
+}
+
+// EvalReport summarizes a detector over a label set
+type EvalReport struct {
+	Images         []ImageResult
+	IoUThreshold   float64
+	TruePositives  int
+	FalsePositives int
+	FalseNegatives int
+	Precision      float64
+	Recall         float64
+	MeanIoU        float64 // Over true positives
+}
+
+// Evaluate runs the detector over every frame of the set and matches detections
+// to labels greedily by score; a detection is a true positive when its IoU with
+// an unmatched label is at least iouThreshold
+func Evaluate(vd VehicleDetector, set *LabelSet, iouThreshold float64) (*EvalReport, error) {
+	report := &EvalReport{IoUThreshold: iouThreshold}
+	var iouSum float64
+
+	for _, li := range set.Images {
+		img, err := loadImage(set.ImagePath(li.Image))
+		if err != nil {
+			return nil, fmt.Errorf("load %s: %w", li.Image, err)
+		}
+		boxes, err := vd.DetectCamera(img, li.Camera)
+		if err != nil {
+			return nil, fmt.Errorf("detect %s: %w", li.Image, err)
+		}
+
+		res := ImageResult{Image: li.Image, Camera: li.Camera, Detections: boxes, Labels: len(li.Boxes)}
+		sorted := append([]BoundingBox(nil), boxes...)
+		sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Score > sorted[j].Score })
+		used := make([]bool, len(li.Boxes))
+		for _, d := range sorted {
+			best, bestIoU := -1, iouThreshold
+			for k, l := range li.Boxes {
+				if v := IoU(d, l.Box()); !used[k] && v >= bestIoU {
+					best, bestIoU = k, v
+				}
+			}
+			if best < 0 {
+				res.FalsePositives++
+				continue
+			}
+			used[best] = true
+			res.TruePositives++
+			res.IoU = append(res.IoU, bestIoU)
+			iouSum += bestIoU
+		}
+		res.FalseNegatives = len(li.Boxes) - res.TruePositives
+
+		report.TruePositives += res.TruePositives
+		report.FalsePositives += res.FalsePositives
+		report.FalseNegatives += res.FalseNegatives
+		report.Images = append(report.Images, res)
+	}
+
+	if n := report.TruePositives + report.FalsePositives; n > 0 {
+		report.Precision = float64(report.TruePositives) / float64(n)
+	}
+	if n := report.TruePositives + report.FalseNegatives; n > 0 {
+		report.Recall = float64(report.TruePositives) / float64(n)
+	}
+	if report.TruePositives > 0 {
+		report.MeanIoU = iouSum / float64(report.TruePositives)
+	}
+	return report, nil
+}
+
+// Box converts a label to a bounding box
+func (l LabelBox) Box() BoundingBox {
+	return BoundingBox{X: l.X, Y: l.Y, Width: l.Width, Height: l.Height, Label: "vehicle", Score: 1}
+}
+
+// IoU is the intersection over union of two boxes
+func IoU(a, b BoundingBox) float64 {
+	x0, y0 := max(a.X, b.X), max(a.Y, b.Y)
+	x1, y1 := min(a.X+a.Width, b.X+b.Width), min(a.Y+a.Height, b.Y+b.Height)
+	if x1 <= x0 || y1 <= y0 {
+		return 0
+	}
+	inter := float64((x1 - x0) * (y1 - y0))
+	union := float64(a.Width*a.Height+b.Width*b.Height) - inter
+	return inter / union
+}
+
+// ImagePath resolves an image name of the set to its file
+func (s *LabelSet) ImagePath(image string) string {
+	return filepath.Join(s.dir, image)
+}
@@ -0,0 +1,124 @@
+package vision
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Floors for the built-in detector on testdata/detector. The set is synthetic
+// except studio.jpg, so this is a smoke test guarding against regressions of the
+// detector, not a measure of its accuracy on site cameras.
+const (
+	smokeMinPrecision = 0.95
+	smokeMinRecall    = 0.95
+	smokeMinMeanIoU   = 0.80
+	smokeMinFrameIoU  = 0.50 // Every true positive; studio.jpg is the weakest frame
+)
+
+// Floors for the built-in detector on testdata/detector/site, hand-labelled frames
+// from site cameras. Each camera must meet them on its own so that a good camera
+// does not hide a bad one.
+const (
+	siteMinFrames    = 50 // Per camera
+	siteMinPrecision = 0.90
+	siteMinRecall    = 0.90
+	siteMinMeanIoU   = 0.75
+)
+
+func TestEvaluateSyntheticSmoke(t *testing.T) {
+	set, err := LoadLabelSet(filepath.Join("testdata", "detector", "labels.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := Evaluate(NewSegmentationDetector("", 0.5), set, 0.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(report.Images) != len(set.Images) {
+		t.Fatalf("evaluated %d images, want %d", len(report.Images), len(set.Images))
+	}
+	if report.Precision < smokeMinPrecision {
+		t.Errorf("precision = %.3f, want >= %.2f", report.Precision, smokeMinPrecision)
+	}
+	if report.Recall < smokeMinRecall {
+		t.Errorf("recall = %.3f, want >= %.2f", report.Recall, smokeMinRecall)
+	}
+	if report.MeanIoU < smokeMinMeanIoU {
+		t.Errorf("mean IoU = %.3f, want >= %.2f", report.MeanIoU, smokeMinMeanIoU)
+	}
+	for _, r := range report.Images {
+		if r.FalsePositives > 0 || r.FalseNegatives > 0 {
+			t.Errorf("%s: FP=%d FN=%d, want 0", r.Image, r.FalsePositives, r.FalseNegatives)
+		}
+		for _, v := range r.IoU {
+			if v < smokeMinFrameIoU {
+				t.Errorf("%s: IoU %.3f, want >= %.2f", r.Image, v, smokeMinFrameIoU)
+			}
+		}
+	}
+}
+
+func TestEvaluateSiteFrames(t *testing.T) {
+	set, err := LoadLabelSet(filepath.Join("testdata", "detector", "site", "labels.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(set.Images) == 0 {
+		t.Skip("no site frames labelled yet (testdata/detector/site/labels.json)")
+	}
+
+	// Per kamera, dengan urutan capture dari label file
+	var cameras []string
+	frames := make(map[string][]LabelledImage)
+	for _, li := range set.Images {
+		if li.Camera == "" {
+			t.Fatalf("%s: no camera", li.Image)
+		}
+		if _, ok := frames[li.Camera]; !ok {
+			cameras = append(cameras, li.Camera)
+		}
+		frames[li.Camera] = append(frames[li.Camera], li)
+	}
+	for _, camera := range cameras {
+		t.Run(camera, func(t *testing.T) {
+			if n := len(frames[camera]); n < siteMinFrames {
+				t.Fatalf("%d frames, want >= %d", n, siteMinFrames)
+			}
+			sub := &LabelSet{Images: frames[camera], dir: set.dir}
+			report, err := Evaluate(NewSegmentationDetector("", 0.5), sub, 0.5)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Precision < siteMinPrecision {
+				t.Errorf("precision = %.3f, want >= %.2f", report.Precision, siteMinPrecision)
+			}
+			if report.Recall < siteMinRecall {
+				t.Errorf("recall = %.3f, want >= %.2f", report.Recall, siteMinRecall)
+			}
+			if report.MeanIoU < siteMinMeanIoU {
+				t.Errorf("mean IoU = %.3f, want >= %.2f", report.MeanIoU, siteMinMeanIoU)
+			}
+		})
+	}
+}
+
+func TestIoU(t *testing.T) {
+	a := BoundingBox{X: 0, Y: 0, Width: 10, Height: 10}
+	tests := []struct {
+		name string
+		b    BoundingBox
+		want float64
+	}{
+		{"identical", a, 1},
+		{"disjoint", BoundingBox{X: 20, Y: 20, Width: 10, Height: 10}, 0},
+		{"touching", BoundingBox{X: 10, Y: 0, Width: 10, Height: 10}, 0},
+		{"half overlap", BoundingBox{X: 5, Y: 0, Width: 10, Height: 10}, 50.0 / 150},
+		{"contained", BoundingBox{X: 0, Y: 0, Width: 5, Height: 10}, 0.5},
+	}
+	for _, tt := range tests {
+		if got := IoU(a, tt.b); got != tt.want {
+			t.Errorf("%s: IoU = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,434 @@
+package vision
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// ControlPoint pairs an image pixel with its position on the road plane in metres.
+// The ground axes are chosen by whoever measures the points; by convention X runs
+// across the road and Y along it.
+type ControlPoint struct {
+	PixelX  float64 `json:"pixel_x"`
+	PixelY  float64 `json:"pixel_y"`
+	GroundX float64 `json:"ground_x"`
+	GroundY float64 `json:"ground_y"`
+}
+
+// ParseControlPoints parses "px,py,gx,gy;px,py,gx,gy;..." (pixels, then metres)
+func ParseControlPoints(s string) ([]ControlPoint, error) {
+	var points []ControlPoint
+	for i, item := range strings.Split(s, ";") {
+		if item = strings.TrimSpace(item); item == "" {
+			continue
+		}
+		fields := strings.Split(item, ",")
+		if len(fields) != 4 {
+			return nil, fmt.Errorf("control point %d: want px,py,gx,gy, got %q", i+1, item)
+		}
+		var v [4]float64
+		for j, f := range fields {
+			n, err := strconv.ParseFloat(strings.TrimSpace(f), 64)
+			if err != nil {
+				return nil, fmt.Errorf("control point %d: %w", i+1, err)
+			}
+			v[j] = n
+		}
+		points = append(points, ControlPoint{PixelX: v[0], PixelY: v[1], GroundX: v[2], GroundY: v[3]})
+	}
+	return points, nil
+}
+
+// Homography is a row-major 3x3 projective transform
+type Homography [9]float64
+
+// Apply maps (x, y); ok is false for points on or beyond the horizon line
+func (h Homography) Apply(x, y float64) (float64, float64, bool) {
+	w := h[6]*x + h[7]*y + h[8]
+	if math.Abs(w) < 1e-12 {
+		return 0, 0, false
+	}
+	return (h[0]*x + h[1]*y + h[2]) / w, (h[3]*x + h[4]*y + h[5]) / w, true
+}
+
+func (h Homography) mul(o Homography) Homography {
+	var r Homography
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				r[i*3+j] += h[i*3+k] * o[k*3+j]
+			}
+		}
+	}
+	return r
+}
+
+// Inverse returns the inverse transform
+func (h Homography) Inverse() (Homography, error) {
+	det := h[0]*(h[4]*h[8]-h[5]*h[7]) - h[1]*(h[3]*h[8]-h[5]*h[6]) + h[2]*(h[3]*h[7]-h[4]*h[6])
+	if math.Abs(det) < 1e-15 {
+		return Homography{}, errors.New("homography is singular")
+	}
+	return Homography{
+		(h[4]*h[8] - h[5]*h[7]) / det, (h[2]*h[7] - h[1]*h[8]) / det, (h[1]*h[5] - h[2]*h[4]) / det,
+		(h[5]*h[6] - h[3]*h[8]) / det, (h[0]*h[8] - h[2]*h[6]) / det, (h[2]*h[3] - h[0]*h[5]) / det,
+		(h[3]*h[7] - h[4]*h[6]) / det, (h[1]*h[6] - h[0]*h[7]) / det, (h[0]*h[4] - h[1]*h[3]) / det,
+	}, nil
+}
+
+// CalibrationResidual reports how well the homography fits its control points
+type CalibrationResidual struct {
+	Points         int       `json:"points"`
+	RMSMeters      float64   `json:"rms_m"`             // Ground error of the projected pixels
+	MaxMeters      float64   `json:"max_m"`             // Worst control point
+	RMSPixels      float64   `json:"rms_px"`            // Image error of the projected ground points
+	PointMeters    []float64 `json:"point_m"`           // Ground error per control point
+	LeaveOneOutRMS *float64  `json:"loo_rms_m"`         // Error of each point when solved without it (≥ 5 points)
+	Exact          bool      `json:"exact"`             // 4 points: the fit is exact, errors say nothing
+	Warning        string    `json:"warning,omitempty"` // Poor conditioning, large residual, ...
+}
+
+// CameraPose is the pinhole camera recovered from the homography, assuming square
+// pixels and the principal point at the given image position
+type CameraPose struct {
+	FocalLengthPixels float64 `json:"focal_length_px"`
+	HeightMeters      float64 `json:"height_m"` // Camera above the road plane
+	TiltDegrees       float64 `json:"tilt_deg"` // 0 = horizontal, 90 = looking straight down
+	GroundX           float64 `json:"ground_x"` // Camera foot point on the road
+	GroundY           float64 `json:"ground_y"`
+}
+
+// GroundCalibration maps image pixels onto the road plane
+type GroundCalibration struct {
+	ControlPoints []ControlPoint       `json:"control_points"`
+	ImageToGround Homography           `json:"image_to_ground"`
+	GroundToImage Homography           `json:"ground_to_image"`
+	Residual      *CalibrationResidual `json:"residual"`
+	Pose          *CameraPose          `json:"pose"` // nil when the pose cannot be recovered
+}
+
+// ErrDegenerateControlPoints is returned when the points do not pin down a homography
+var ErrDegenerateControlPoints = errors.New("control points are degenerate (fewer than 4, duplicated or collinear)")
+
+// NewGroundCalibration solves the image→ground homography from four or more control
+// points and reports its residual error. principalX/Y (usually the image centre)
+// is used to recover the camera pose.
+func NewGroundCalibration(points []ControlPoint, principalX, principalY float64) (*GroundCalibration, error) {
+	h, err := solveHomography(points)
+	if err != nil {
+		return nil, err
+	}
+	inv, err := h.Inverse()
+	if err != nil {
+		return nil, ErrDegenerateControlPoints
+	}
+
+	// Semua titik harus berada di sisi horizon yang sama
+	var sign float64
+	for _, p := range points {
+		w := h[6]*p.PixelX + h[7]*p.PixelY + h[8]
+		if sign == 0 {
+			sign = math.Copysign(1, w)
+		} else if w*sign <= 0 {
+			return nil, fmt.Errorf("control points lie on both sides of the horizon")
+		}
+	}
+
+	gc := &GroundCalibration{
+		ControlPoints: append([]ControlPoint(nil), points...),
+		ImageToGround: h,
+		GroundToImage: inv,
+	}
+	gc.Residual = residual(points, h, inv)
+	gc.Pose = recoverPose(inv, principalX, principalY)
+	return gc, nil
+}
+
+// Project maps an image pixel onto the road plane; ok is false above the horizon
+func (gc *GroundCalibration) Project(px, py float64) (x, y float64, ok bool) {
+	x, y, ok = gc.ImageToGround.Apply(px, py)
+	if !ok || gc.horizon(px, py) {
+		return 0, 0, false
+	}
+	return x, y, true
+}
+
+// Unproject maps a road point back to the image
+func (gc *GroundCalibration) Unproject(gx, gy float64) (px, py float64, ok bool) {
+	return gc.GroundToImage.Apply(gx, gy)
+}
+
+// horizon reports whether a pixel is on the other side of the horizon than the control points
+func (gc *GroundCalibration) horizon(px, py float64) bool {
+	h := gc.ImageToGround
+	ref := gc.ControlPoints[0]
+	w0 := h[6]*ref.PixelX + h[7]*ref.PixelY + h[8]
+	w := h[6]*px + h[7]*py + h[8]
+	return w*w0 <= 0
+}
+
+// solveHomography is the normalized DLT with h33 = 1, least squares for more than 4 points
+func solveHomography(points []ControlPoint) (Homography, error) {
+	if len(points) < 4 {
+		return Homography{}, ErrDegenerateControlPoints
+	}
+	src := make([][2]float64, len(points))
+	dst := make([][2]float64, len(points))
+	for i, p := range points {
+		src[i] = [2]float64{p.PixelX, p.PixelY}
+		dst[i] = [2]float64{p.GroundX, p.GroundY}
+	}
+	ts, ok1 := normalize(src)
+	td, ok2 := normalize(dst)
+	if !ok1 || !ok2 {
+		return Homography{}, ErrDegenerateControlPoints
+	}
+	// Empat titik dengan tiga titik segaris tidak menentukan homography
+	if len(points) == 4 && (collinearTriple(src, ts) || collinearTriple(dst, td)) {
+		return Homography{}, ErrDegenerateControlPoints
+	}
+
+	// Normal equations AᵀA h = Aᵀb, 8 unknowns
+	ata := make([][]float64, 8)
+	for i := range ata {
+		ata[i] = make([]float64, 8)
+	}
+	atb := make([]float64, 8)
+	for i := range src {
+		x, y, _ := ts.Apply(src[i][0], src[i][1])
+		u, v, _ := td.Apply(dst[i][0], dst[i][1])
+		rows := [2][8]float64{
+			{x, y, 1, 0, 0, 0, -x * u, -y * u},
+			{0, 0, 0, x, y, 1, -x * v, -y * v},
+		}
+		rhs := [2]float64{u, v}
+		for r := range rows {
+			for a := 0; a < 8; a++ {
+				atb[a] += rows[r][a] * rhs[r]
+				for b := 0; b < 8; b++ {
+					ata[a][b] += rows[r][a] * rows[r][b]
+				}
+			}
+		}
+	}
+	sol, ok := solveLinear(ata, atb)
+	if !ok {
+		return Homography{}, ErrDegenerateControlPoints
+	}
+	hn := Homography{sol[0], sol[1], sol[2], sol[3], sol[4], sol[5], sol[6], sol[7], 1}
+
+	tdInv, err := td.Inverse()
+	if err != nil {
+		return Homography{}, ErrDegenerateControlPoints
+	}
+	h := tdInv.mul(hn).mul(ts)
+	if math.Abs(h[8]) > 1e-12 {
+		for i := range h {
+			h[i] /= h[8]
+		}
+	}
+	return h, nil
+}
+
+// normalize returns the similarity moving the points' centroid to the origin with
+// mean distance √2 (Hartley normalization)
+func normalize(pts [][2]float64) (Homography, bool) {
+	var cx, cy float64
+	for _, p := range pts {
+		cx += p[0]
+		cy += p[1]
+	}
+	cx /= float64(len(pts))
+	cy /= float64(len(pts))
+	var d float64
+	for _, p := range pts {
+		d += math.Hypot(p[0]-cx, p[1]-cy)
+	}
+	d /= float64(len(pts))
+	if d < 1e-9 {
+		return Homography{}, false
+	}
+	s := math.Sqrt2 / d
+	return Homography{s, 0, -s * cx, 0, s, -s * cy, 0, 0, 1}, true
+}
+
+// collinearTriple reports whether three of the points are (nearly) on one line,
+// measured after normalization t so the tolerance does not depend on the units
+func collinearTriple(pts [][2]float64, t Homography) bool {
+	n := make([][2]float64, len(pts))
+	for i, p := range pts {
+		n[i][0], n[i][1], _ = t.Apply(p[0], p[1])
+	}
+	for i := 0; i < len(n); i++ {
+		for j := i + 1; j < len(n); j++ {
+			for k := j + 1; k < len(n); k++ {
+				area := (n[j][0]-n[i][0])*(n[k][1]-n[i][1]) - (n[j][1]-n[i][1])*(n[k][0]-n[i][0])
+				if math.Abs(area) < 0.01 {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// solveLinear solves a square system by Gaussian elimination with partial pivoting.
+// a and b are modified.
+func solveLinear(a [][]float64, b []float64) ([]float64, bool) {
+	n := len(b)
+	var scale float64
+	for i := range a {
+		for j := range a[i] {
+			scale = math.Max(scale, math.Abs(a[i][j]))
+		}
+	}
+	for col := 0; col < n; col++ {
+		pivot := col
+		for r := col + 1; r < n; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[pivot][col]) {
+				pivot = r
+			}
+		}
+		if math.Abs(a[pivot][col]) < 1e-10*scale {
+			return nil, false
+		}
+		a[col], a[pivot] = a[pivot], a[col]
+		b[col], b[pivot] = b[pivot], b[col]
+		for r := col + 1; r < n; r++ {
+			f := a[r][col] / a[col][col]
+			for c := col; c < n; c++ {
+				a[r][c] -= f * a[col][c]
+			}
+			b[r] -= f * b[col]
+		}
+	}
+	x := make([]float64, n)
+	for r := n - 1; r >= 0; r-- {
+		s := b[r]
+		for c := r + 1; c < n; c++ {
+			s -= a[r][c] * x[c]
+		}
+		x[r] = s / a[r][r]
+	}
+	return x, true
+}
+
+// residual measures the fit on its own control points and, with spare points,
+// the leave-one-out error which shows how well the calibration generalizes
+func residual(points []ControlPoint, h, inv Homography) *CalibrationResidual {
+	res := &CalibrationResidual{Points: len(points), Exact: len(points) == 4, PointMeters: make([]float64, len(points))}
+	var sumM, sumPx float64
+	for i, p := range points {
+		x, y, _ := h.Apply(p.PixelX, p.PixelY)
+		e := math.Hypot(x-p.GroundX, y-p.GroundY)
+		res.PointMeters[i] = round3(e)
+		sumM += e * e
+		res.MaxMeters = math.Max(res.MaxMeters, e)
+
+		px, py, _ := inv.Apply(p.GroundX, p.GroundY)
+		sumPx += (px-p.PixelX)*(px-p.PixelX) + (py-p.PixelY)*(py-p.PixelY)
+	}
+	res.RMSMeters = round3(math.Sqrt(sumM / float64(len(points))))
+	res.MaxMeters = round3(res.MaxMeters)
+	res.RMSPixels = round3(math.Sqrt(sumPx / float64(len(points))))
+
+	if len(points) >= 5 {
+		var sum float64
+		var n int
+		for i := range points {
+			rest := append(append([]ControlPoint(nil), points[:i]...), points[i+1:]...)
+			hl, err := solveHomography(rest)
+			if err != nil {
+				continue
+			}
+			x, y, ok := hl.Apply(points[i].PixelX, points[i].PixelY)
+			if !ok {
+				continue
+			}
+			e := math.Hypot(x-points[i].GroundX, y-points[i].GroundY)
+			sum += e * e
+			n++
+		}
+		if n > 0 {
+			loo := round3(math.Sqrt(sum / float64(n)))
+			res.LeaveOneOutRMS = &loo
+		}
+	}
+
+	switch {
+	case res.Exact:
+		res.Warning = "4 control points fit exactly; add more points to measure the error"
+	case res.RMSMeters > 0.1:
+		res.Warning = fmt.Sprintf("residual %.2f m is large; check the control points", res.RMSMeters)
+	}
+	return res
+}
+
+// recoverPose decomposes the ground→image homography into focal length, camera
+// height and tilt (Zhang's constraints with the principal point known)
+func recoverPose(g2i Homography, cx, cy float64) *CameraPose {
+	// Geser principal point ke origin
+	h := Homography{1, 0, -cx, 0, 1, -cy, 0, 0, 1}.mul(g2i)
+	h11, h12, h13 := h[0], h[1], h[2]
+	h21, h22, h23 := h[3], h[4], h[5]
+	h31, h32, h33 := h[6], h[7], h[8]
+
+	// r1 ⊥ r2 dan |r1| = |r2| masing-masing memberi estimasi f²
+	var f2s []float64
+	if d := h31 * h32; math.Abs(d) > 1e-15 {
+		if f2 := -(h11*h12 + h21*h22) / d; f2 > 0 {
+			f2s = append(f2s, f2)
+		}
+	}
+	if d := h32*h32 - h31*h31; math.Abs(d) > 1e-15 {
+		if f2 := (h11*h11 + h21*h21 - h12*h12 - h22*h22) / d; f2 > 0 {
+			f2s = append(f2s, f2)
+		}
+	}
+	if len(f2s) == 0 {
+		return nil
+	}
+	var f2 float64
+	for _, v := range f2s {
+		f2 += v
+	}
+	f := math.Sqrt(f2 / float64(len(f2s)))
+
+	b1 := [3]float64{h11 / f, h21 / f, h31}
+	b2 := [3]float64{h12 / f, h22 / f, h32}
+	b3 := [3]float64{h13 / f, h23 / f, h33}
+	lambda := 2 / (norm3(b1) + norm3(b2))
+	if b3[2] < 0 {
+		lambda = -lambda // Titik origin jalan harus di depan kamera
+	}
+	r1, r2, t := scale3(b1, lambda), scale3(b2, lambda), scale3(b3, lambda)
+	r3 := cross3(r1, r2)
+
+	// Pusat kamera C = -Rᵀt
+	c := [3]float64{-dot3(r1, t), -dot3(r2, t), -dot3(r3, t)}
+	tilt := math.Asin(math.Min(1, math.Abs(r3[2]))) * 180 / math.Pi
+	return &CameraPose{
+		FocalLengthPixels: round3(f),
+		HeightMeters:      round3(math.Abs(c[2])),
+		TiltDegrees:       round3(tilt),
+		GroundX:           round3(c[0]),
+		GroundY:           round3(c[1]),
+	}
+}
+
+func norm3(v [3]float64) float64 { return math.Sqrt(dot3(v, v)) }
+
+func dot3(a, b [3]float64) float64 { return a[0]*b[0] + a[1]*b[1] + a[2]*b[2] }
+
+func scale3(v [3]float64, s float64) [3]float64 { return [3]float64{v[0] * s, v[1] * s, v[2] * s} }
+
+func cross3(a, b [3]float64) [3]float64 {
+	return [3]float64{a[1]*b[2] - a[2]*b[1], a[2]*b[0] - a[0]*b[2], a[0]*b[1] - a[1]*b[0]}
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
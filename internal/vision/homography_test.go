@@ -0,0 +1,117 @@
+package vision
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestNewGroundCalibration(t *testing.T) {
+	tests := []struct {
+		name   string
+		camera pinholeCamera
+		ground [][2]float64
+	}{
+		{"four points", pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}, laneRectangle[:4]},
+		{"five points", pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}, laneRectangle},
+		{"steep gantry", pinholeCamera{Focal: 1400, CX: 960, CY: 540, X: 1.75, Y: -3, Height: 7.5, Tilt: 55}, [][2]float64{{0, 0}, {3.5, 0}, {3.5, 8}, {0, 8}, {1.75, 4}, {0, 4}}},
+		{"off-centre camera", pinholeCamera{Focal: 2000, CX: 1280, CY: 720, X: -2, Y: -25, Height: 8, Tilt: 12}, [][2]float64{{0, 0}, {7, 0}, {7, 30}, {0, 30}, {3.5, 15}, {7, 15}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gc, err := NewGroundCalibration(tt.camera.controlPoints(tt.ground), tt.camera.CX, tt.camera.CY)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Homography hanya tertentu sampai faktor skala
+			got, want := gc.GroundToImage, tt.camera.groundToImage()
+			for i := range got {
+				got[i] /= gc.GroundToImage[8]
+			}
+			for i := range want {
+				if math.Abs(got[i]-want[i]) > 1e-6*math.Max(1, math.Abs(want[i])) {
+					t.Errorf("ground→image H = %v, want %v", got, want)
+					break
+				}
+			}
+
+			r := gc.Residual
+			if r.Points != len(tt.ground) || r.RMSMeters > 1e-3 || r.MaxMeters > 1e-3 || r.RMSPixels > 1e-3 {
+				t.Errorf("residual = %+v, want %d points and ~0 error", r, len(tt.ground))
+			}
+			if exact := len(tt.ground) == 4; r.Exact != exact || (r.Warning != "") != exact {
+				t.Errorf("exact = %v, warning = %q for %d points", r.Exact, r.Warning, len(tt.ground))
+			}
+			if len(tt.ground) >= 5 && (r.LeaveOneOutRMS == nil || *r.LeaveOneOutRMS > 1e-3) {
+				t.Errorf("leave-one-out = %v, want ~0", r.LeaveOneOutRMS)
+			}
+
+			p := gc.Pose
+			if p == nil {
+				t.Fatal("pose not recovered")
+			}
+			c := tt.camera
+			if math.Abs(p.FocalLengthPixels-c.Focal) > 0.5 || math.Abs(p.HeightMeters-c.Height) > 0.005 ||
+				math.Abs(p.TiltDegrees-c.Tilt) > 0.01 || math.Abs(p.GroundX-c.X) > 0.005 || math.Abs(p.GroundY-c.Y) > 0.005 {
+				t.Errorf("pose = %+v, want focal %.0f height %.2f tilt %.1f at (%.2f, %.2f)", *p, c.Focal, c.Height, c.Tilt, c.X, c.Y)
+			}
+
+			// Titik jalan lain (bukan control point) harus kembali ke posisinya
+			for _, g := range [][2]float64{{1, 3}, {2.5, 7.5}, {-1, 12}} {
+				px, py := c.project(g[0], g[1], 0)
+				x, y, ok := gc.Project(px, py)
+				if !ok || math.Hypot(x-g[0], y-g[1]) > 1e-6 {
+					t.Errorf("Project(%.1f, %.1f) = (%.4f, %.4f, %v), want (%.1f, %.1f)", px, py, x, y, ok, g[0], g[1])
+				}
+			}
+			if _, _, ok := gc.Project(c.CX, c.CY-c.Focal*math.Tan(c.Tilt*math.Pi/180)-10); ok {
+				t.Error("pixel above the horizon projected onto the road")
+			}
+		})
+	}
+}
+
+func TestNewGroundCalibrationDegenerate(t *testing.T) {
+	camera := pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}
+	tests := []struct {
+		name   string
+		ground [][2]float64
+	}{
+		{"three points", [][2]float64{{0, 0}, {3.5, 0}, {3.5, 20}}},
+		{"duplicate point", [][2]float64{{0, 0}, {3.5, 0}, {3.5, 20}, {3.5, 20}}},
+		{"all duplicates", [][2]float64{{1, 5}, {1, 5}, {1, 5}, {1, 5}, {1, 5}}},
+		{"three collinear", [][2]float64{{0, 0}, {1.75, 0}, {3.5, 0}, {0, 20}}},
+		{"all collinear", [][2]float64{{0, 0}, {0, 5}, {0, 10}, {0, 15}, {0, 20}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewGroundCalibration(camera.controlPoints(tt.ground), camera.CX, camera.CY)
+			if !errors.Is(err, ErrDegenerateControlPoints) {
+				t.Errorf("err = %v, want ErrDegenerateControlPoints", err)
+			}
+		})
+	}
+}
+
+func TestParseControlPoints(t *testing.T) {
+	points, err := ParseControlPoints(" 612,968,0,0; 1318,972,3.5,0 ;;1130,402,3.5,10;")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ControlPoint{{612, 968, 0, 0}, {1318, 972, 3.5, 0}, {1130, 402, 3.5, 10}}
+	if len(points) != len(want) {
+		t.Fatalf("got %d points, want %d", len(points), len(want))
+	}
+	for i := range want {
+		if points[i] != want[i] {
+			t.Errorf("point %d = %+v, want %+v", i, points[i], want[i])
+		}
+	}
+
+	for _, s := range []string{"612,968,0", "612,968,0,x"} {
+		if _, err := ParseControlPoints(s); err == nil {
+			t.Errorf("ParseControlPoints(%q) succeeded, want error", s)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	_ "image/jpeg" // register JPEG decoder for image.Decode
+	_ "image/png"  // register PNG decoder for image.Decode
+	"io"
+)
+
+// DecodeImage decodes a JPEG or PNG image from r
+func DecodeImage(r io.Reader) (image.Image, error) {
+	img, format, err := image.Decode(r)
+	if err != nil {
+		return nil, fmt.Errorf("decode image: %w", err)
+	}
+	if format != "jpeg" && format != "png" {
+		return nil, fmt.Errorf("unsupported image format: %s", format)
+	}
+	return img, nil
+}
+
+// StreamDecoder decodes an image from bytes written to it while they are being
+// streamed elsewhere (e.g. FTP -> MinIO), so the image is read and decoded only once.
+// Writes never block on a failed decode: remaining bytes are drained and discarded.
+type StreamDecoder struct {
+	pw   *io.PipeWriter
+	done chan struct{}
+	img  image.Image
+	err  error
+}
+
+// NewStreamDecoder starts decoding in the background; call Close when the stream ends
+func NewStreamDecoder() *StreamDecoder {
+	pr, pw := io.Pipe()
+	d := &StreamDecoder{pw: pw, done: make(chan struct{})}
+
+	go func() {
+		defer close(d.done)
+		d.img, d.err = DecodeImage(pr)
+		// The decoder may stop before EOF (trailing bytes, or a decode error)
+		io.Copy(io.Discard, pr)
+	}()
+
+	return d
+}
+
+// Write feeds streamed bytes to the decoder
+func (d *StreamDecoder) Write(p []byte) (int, error) {
+	return d.pw.Write(p)
+}
+
+// Close ends the stream. A non-nil err (e.g. a failed upload) aborts the decode.
+func (d *StreamDecoder) Close(err error) {
+	d.pw.CloseWithError(err)
+}
+
+// Image waits for the decode to finish and returns the decoded image
+func (d *StreamDecoder) Image() (image.Image, error) {
+	<-d.done
+	return d.img, d.err
+}
@@ -0,0 +1,252 @@
+package vision
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Inference server protocol: one JSON POST per batch of frames.
+//
+//	request:  {"images": [{"id": "1", "camera": "cam-1", "width": 1920, "height": 1080, "data": "<base64 JPEG>"}]}
+//	response: {"results": [{"id": "1", "boxes": [{"x": 10, "y": 20, "width": 300, "height": 200, "label": "truck", "score": 0.91}]}]}
+//
+// Boxes are in pixels of the submitted frame. A result may carry "error" instead
+// of boxes when only that frame failed.
+type inferenceRequest struct {
+	Images []inferenceImage `json:"images"`
+}
+
+type inferenceImage struct {
+	ID     string `json:"id"`
+	Camera string `json:"camera"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Data   string `json:"data"`
+}
+
+type inferenceResponse struct {
+	Results []inferenceResult `json:"results"`
+}
+
+type inferenceResult struct {
+	ID    string         `json:"id"`
+	Boxes []inferenceBox `json:"boxes"`
+	Error string         `json:"error,omitempty"`
+}
+
+type inferenceBox struct {
+	X      int     `json:"x"`
+	Y      int     `json:"y"`
+	Width  int     `json:"width"`
+	Height int     `json:"height"`
+	Label  string  `json:"label"`
+	Score  float64 `json:"score"`
+}
+
+// inferenceCall is one frame waiting in the batch queue
+type inferenceCall struct {
+	image inferenceImage
+	reply chan inferenceReply
+}
+
+type inferenceReply struct {
+	boxes []inferenceBox
+	err   error
+}
+
+// HTTPDetector sends frames to an external inference server (e.g. a YOLO model
+// behind a small HTTP wrapper), so no ML runtime is linked into the service.
+// Concurrent calls are grouped into batches of up to BatchSize frames, waiting at
+// most BatchWait for a batch to fill. Settings must not change after the first call.
+type HTTPDetector struct {
+	Endpoint  string
+	Threshold float64       // Minimum box score
+	Timeout   time.Duration // Per request
+	BatchSize int
+	BatchWait time.Duration
+	Client    *http.Client
+
+	classes map[string]bool // nil = all labels
+
+	start sync.Once
+	queue chan *inferenceCall
+	seq   atomic.Uint64
+}
+
+// NewHTTPDetector creates an inference server detector with default timeout and batching
+func NewHTTPDetector(endpoint string, threshold float64) *HTTPDetector {
+	return &HTTPDetector{
+		Endpoint:  endpoint,
+		Threshold: threshold,
+		Timeout:   2 * time.Second,
+		BatchSize: 4,
+		BatchWait: 20 * time.Millisecond,
+		Client:    &http.Client{},
+	}
+}
+
+// SetClasses limits the accepted box labels (case-insensitive); empty accepts all
+func (hd *HTTPDetector) SetClasses(classes []string) {
+	hd.classes = nil
+	for _, c := range classes {
+		if c = strings.ToLower(strings.TrimSpace(c)); c != "" {
+			if hd.classes == nil {
+				hd.classes = make(map[string]bool)
+			}
+			hd.classes[c] = true
+		}
+	}
+}
+
+// DetectCamera implements VehicleDetector
+func (hd *HTTPDetector) DetectCamera(img image.Image, camera string) ([]BoundingBox, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+		return nil, fmt.Errorf("encode frame: %w", err)
+	}
+	b := img.Bounds()
+	call := &inferenceCall{
+		image: inferenceImage{
+			ID:     strconv.FormatUint(hd.seq.Add(1), 10),
+			Camera: camera,
+			Width:  b.Dx(),
+			Height: b.Dy(),
+			Data:   base64.StdEncoding.EncodeToString(buf.Bytes()),
+		},
+		reply: make(chan inferenceReply, 1),
+	}
+
+	hd.start.Do(func() {
+		hd.queue = make(chan *inferenceCall, max(hd.BatchSize, 1)*4)
+		go hd.batch()
+	})
+
+	deadline := time.NewTimer(hd.BatchWait + hd.Timeout)
+	defer deadline.Stop()
+	select {
+	case hd.queue <- call:
+	case <-deadline.C:
+		return nil, fmt.Errorf("inference queue full")
+	}
+
+	var reply inferenceReply
+	select {
+	case reply = <-call.reply:
+	case <-deadline.C:
+		return nil, fmt.Errorf("inference timed out after %v", hd.BatchWait+hd.Timeout)
+	}
+	if reply.err != nil {
+		return nil, reply.err
+	}
+
+	var boxes []BoundingBox
+	for _, ib := range reply.boxes {
+		if ib.Score < hd.Threshold || (hd.classes != nil && !hd.classes[strings.ToLower(ib.Label)]) {
+			continue
+		}
+		// Box di luar frame dipotong; box yang tersisa kosong dibuang
+		r := image.Rect(ib.X, ib.Y, ib.X+ib.Width, ib.Y+ib.Height).Intersect(image.Rect(0, 0, b.Dx(), b.Dy()))
+		if r.Empty() {
+			continue
+		}
+		boxes = append(boxes, BoundingBox{
+			X:      b.Min.X + r.Min.X,
+			Y:      b.Min.Y + r.Min.Y,
+			Width:  r.Dx(),
+			Height: r.Dy(),
+			Label:  ib.Label,
+			Score:  ib.Score,
+		})
+	}
+	sort.SliceStable(boxes, func(i, j int) bool { return boxes[i].Score > boxes[j].Score })
+	return boxes, nil
+}
+
+// batch groups queued frames and sends each batch in its own goroutine, so a slow
+// request does not hold up the next batch
+func (hd *HTTPDetector) batch() {
+	for first := range hd.queue {
+		calls := []*inferenceCall{first}
+		wait := time.NewTimer(hd.BatchWait)
+	fill:
+		for len(calls) < hd.BatchSize {
+			select {
+			case c := <-hd.queue:
+				calls = append(calls, c)
+			case <-wait.C:
+				break fill
+			}
+		}
+		wait.Stop()
+		go hd.send(calls)
+	}
+}
+
+// send posts one batch and hands each frame its result
+func (hd *HTTPDetector) send(calls []*inferenceCall) {
+	results, err := hd.post(calls)
+	for _, c := range calls {
+		switch res, ok := results[c.image.ID]; {
+		case err != nil:
+			c.reply <- inferenceReply{err: err}
+		case !ok:
+			c.reply <- inferenceReply{err: fmt.Errorf("inference server returned no result for frame %s", c.image.ID)}
+		case res.Error != "":
+			c.reply <- inferenceReply{err: fmt.Errorf("inference server: %s", res.Error)}
+		default:
+			c.reply <- inferenceReply{boxes: res.Boxes}
+		}
+	}
+}
+
+func (hd *HTTPDetector) post(calls []*inferenceCall) (map[string]inferenceResult, error) {
+	req := inferenceRequest{Images: make([]inferenceImage, len(calls))}
+	for i, c := range calls {
+		req.Images[i] = c.image
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("marshal inference request: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), hd.Timeout)
+	defer cancel()
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, hd.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create inference request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := hd.Client.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("inference request: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("inference server returned %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var out inferenceResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("decode inference response: %w", err)
+	}
+	results := make(map[string]inferenceResult, len(out.Results))
+	for _, r := range out.Results {
+		results[r.ID] = r
+	}
+	return results, nil
+}
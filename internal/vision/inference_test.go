@@ -0,0 +1,325 @@
+package vision_test
+
+import (
+	"encoding/json"
+	"errors"
+	"image"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"wim-service/internal/vision"
+	"wim-service/internal/vision/visiontest"
+)
+
+// inferenceServer is a stub inference server; reply builds the result of each frame
+type inferenceServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	batches []int // Frames per request
+}
+
+func newInferenceServer(t *testing.T, reply func(visiontest.Image) visiontest.Result) *inferenceServer {
+	s := &inferenceServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req visiontest.Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.mu.Lock()
+		s.batches = append(s.batches, len(req.Images))
+		s.mu.Unlock()
+
+		var resp visiontest.Response
+		for _, im := range req.Images {
+			res := reply(im)
+			res.ID = im.ID
+			resp.Results = append(resp.Results, res)
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *inferenceServer) Batches() []int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]int(nil), s.batches...)
+}
+
+func frame() image.Image {
+	return image.NewRGBA(image.Rect(0, 0, 100, 80))
+}
+
+// detectAll runs one DetectCamera call per camera concurrently
+func detectAll(d vision.VehicleDetector, cameras []string) ([][]vision.BoundingBox, []error) {
+	boxes := make([][]vision.BoundingBox, len(cameras))
+	errs := make([]error, len(cameras))
+	var wg sync.WaitGroup
+	for i, camera := range cameras {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			boxes[i], errs[i] = d.DetectCamera(frame(), camera)
+		}()
+	}
+	wg.Wait()
+	return boxes, errs
+}
+
+func TestHTTPDetectorBatching(t *testing.T) {
+	server := newInferenceServer(t, func(visiontest.Image) visiontest.Result {
+		return visiontest.Result{Boxes: []visiontest.Box{{X: 10, Y: 10, Width: 20, Height: 20, Label: "truck", Score: 0.9}}}
+	})
+	d := vision.NewHTTPDetector(server.URL, 0.5)
+	d.BatchSize = 2
+	d.BatchWait = 500 * time.Millisecond // Long enough for concurrent calls to fill a batch
+
+	boxes, errs := detectAll(d, []string{"cam-1", "cam-1", "cam-1", "cam-1", "cam-1"})
+	for i, err := range errs {
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if len(boxes[i]) != 1 {
+			t.Errorf("call %d: %d boxes, want 1", i, len(boxes[i]))
+		}
+	}
+
+	batches := server.Batches()
+	total := 0
+	for _, n := range batches {
+		if n > d.BatchSize {
+			t.Errorf("batch of %d frames, want at most %d", n, d.BatchSize)
+		}
+		total += n
+	}
+	if total != 5 || len(batches) != 3 {
+		t.Errorf("batches = %v, want 5 frames in 3 requests", batches)
+	}
+}
+
+func TestHTTPDetectorTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(server.Close)
+	t.Cleanup(func() { close(release) }) // Runs before server.Close
+
+	d := vision.NewHTTPDetector(server.URL, 0.5)
+	d.Timeout = 50 * time.Millisecond
+	d.BatchWait = time.Millisecond
+
+	start := time.Now()
+	_, err := d.DetectCamera(frame(), "cam-1")
+	if err == nil {
+		t.Fatal("DetectCamera() succeeded, want timeout error")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("DetectCamera() returned after %v, want about %v", elapsed, d.Timeout)
+	}
+}
+
+func TestHTTPDetectorServerErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    string
+	}{
+		{
+			name: "status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+			},
+			want: "model not loaded",
+		},
+		{
+			name: "missing result",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`{"results": []}`))
+			},
+			want: "no result",
+		},
+		{
+			name: "invalid response",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte(`not json`))
+			},
+			want: "decode inference response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(tt.handler)
+			defer server.Close()
+			d := vision.NewHTTPDetector(server.URL, 0.5)
+			d.BatchWait = time.Millisecond
+
+			_, err := d.DetectCamera(frame(), "cam-1")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("DetectCamera() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPDetectorFrameError(t *testing.T) {
+	server := newInferenceServer(t, func(im visiontest.Image) visiontest.Result {
+		if im.Camera == "broken" {
+			return visiontest.Result{Error: "corrupt frame"}
+		}
+		return visiontest.Result{Boxes: []visiontest.Box{{X: 0, Y: 0, Width: 50, Height: 40, Label: "car", Score: 0.8}}}
+	})
+	d := vision.NewHTTPDetector(server.URL, 0.5)
+	d.BatchSize = 2
+	d.BatchWait = 500 * time.Millisecond
+
+	boxes, errs := detectAll(d, []string{"broken", "cam-1"})
+	if errs[0] == nil || !strings.Contains(errs[0].Error(), "corrupt frame") {
+		t.Errorf("broken frame error = %v, want %q", errs[0], "corrupt frame")
+	}
+	if errs[1] != nil || len(boxes[1]) != 1 {
+		t.Errorf("other frame = %v, %v, want 1 box", boxes[1], errs[1])
+	}
+	if batches := server.Batches(); !reflect.DeepEqual(batches, []int{2}) {
+		t.Errorf("batches = %v, want both frames in one request", batches)
+	}
+}
+
+func TestHTTPDetectorFiltersAndClips(t *testing.T) {
+	server := newInferenceServer(t, func(visiontest.Image) visiontest.Result {
+		return visiontest.Result{Boxes: []visiontest.Box{
+			{X: 60, Y: 10, Width: 20, Height: 20, Label: "car", Score: 0.7},
+			{X: -10, Y: -5, Width: 50, Height: 30, Label: "TRUCK", Score: 0.9}, // Clipped at top-left
+			{X: 80, Y: 60, Width: 40, Height: 40, Label: "bus", Score: 0.8},    // Clipped at bottom-right
+			{X: 10, Y: 10, Width: 10, Height: 10, Label: "person", Score: 0.95},
+			{X: 10, Y: 10, Width: 10, Height: 10, Label: "car", Score: 0.3},
+			{X: 200, Y: 10, Width: 20, Height: 20, Label: "truck", Score: 0.9}, // Outside the frame
+		}}
+	})
+	d := vision.NewHTTPDetector(server.URL, 0.5)
+	d.BatchWait = time.Millisecond
+	d.SetClasses([]string{" truck", "Bus ", "car", ""})
+
+	boxes, err := d.DetectCamera(frame(), "cam-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []vision.BoundingBox{
+		{X: 0, Y: 0, Width: 40, Height: 25, Label: "TRUCK", Score: 0.9},
+		{X: 80, Y: 60, Width: 20, Height: 20, Label: "bus", Score: 0.8},
+		{X: 60, Y: 10, Width: 20, Height: 20, Label: "car", Score: 0.7},
+	}
+	if !reflect.DeepEqual(boxes, want) {
+		t.Errorf("boxes = %+v, want %+v", boxes, want)
+	}
+
+	d2 := vision.NewHTTPDetector(server.URL, 0.5)
+	d2.BatchWait = time.Millisecond
+	boxes, err = d2.DetectCamera(frame(), "cam-1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(boxes) != 4 {
+		t.Errorf("without classes: %d boxes, want 4 (every label above the threshold)", len(boxes))
+	}
+}
+
+func TestInferenceHandlerRoundTrip(t *testing.T) {
+	local := &fakeDetector{boxes: []vision.BoundingBox{{X: 5, Y: 6, Width: 30, Height: 20, Score: 0.75}}}
+	server := httptest.NewServer(visiontest.InferenceHandler(local, "vehicle"))
+	defer server.Close()
+
+	d := vision.NewHTTPDetector(server.URL, 0.5)
+	d.BatchWait = time.Millisecond
+	boxes, err := d.DetectCamera(frame(), "cam-7")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []vision.BoundingBox{{X: 5, Y: 6, Width: 30, Height: 20, Label: "vehicle", Score: 0.75}}
+	if !reflect.DeepEqual(boxes, want) {
+		t.Errorf("boxes = %+v, want %+v", boxes, want)
+	}
+	if local.camera != "cam-7" {
+		t.Errorf("camera = %q, want cam-7", local.camera)
+	}
+}
+
+// fakeDetector returns fixed boxes, or err, and counts calls
+type fakeDetector struct {
+	mu     sync.Mutex
+	boxes  []vision.BoundingBox
+	err    error
+	calls  int
+	camera string
+}
+
+func (f *fakeDetector) DetectCamera(img image.Image, camera string) ([]vision.BoundingBox, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls++
+	f.camera = camera
+	return f.boxes, f.err
+}
+
+func (f *fakeDetector) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls
+}
+
+func (f *fakeDetector) SetErr(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.err = err
+}
+
+func TestFallbackDetectorCooldown(t *testing.T) {
+	primary := &fakeDetector{boxes: []vision.BoundingBox{{Label: "primary"}}}
+	fallback := &fakeDetector{boxes: []vision.BoundingBox{{Label: "fallback"}}}
+	d := vision.NewFallbackDetector(primary, fallback, 100*time.Millisecond)
+
+	detect := func(want string, primaryCalls, fallbackCalls int) {
+		t.Helper()
+		boxes, err := d.DetectCamera(frame(), "cam-1")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(boxes) != 1 || boxes[0].Label != want {
+			t.Errorf("boxes = %+v, want from %s", boxes, want)
+		}
+		if primary.Calls() != primaryCalls || fallback.Calls() != fallbackCalls {
+			t.Errorf("calls primary=%d fallback=%d, want %d/%d", primary.Calls(), fallback.Calls(), primaryCalls, fallbackCalls)
+		}
+	}
+
+	detect("primary", 1, 0)
+
+	primary.SetErr(errors.New("connection refused"))
+	detect("fallback", 2, 1) // Primary tried and failed
+	detect("fallback", 2, 2) // Cooldown: primary skipped
+
+	primary.SetErr(nil)
+	detect("fallback", 2, 3) // Still in cooldown although primary recovered
+
+	time.Sleep(150 * time.Millisecond)
+	detect("primary", 3, 3)
+}
+
+func TestFallbackDetectorBothFail(t *testing.T) {
+	primary := &fakeDetector{err: errors.New("timeout")}
+	fallback := &fakeDetector{err: errors.New("no background model")}
+	d := vision.NewFallbackDetector(primary, fallback, time.Minute)
+
+	if _, err := d.DetectCamera(frame(), "cam-1"); err == nil || err.Error() != "no background model" {
+		t.Errorf("DetectCamera() error = %v, want the fallback error", err)
+	}
+}
@@ -0,0 +1,183 @@
+package vision
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+var (
+	gridColor    = color.RGBA{R: 0, G: 200, B: 255, A: 255}
+	markColor    = color.RGBA{R: 255, G: 0, B: 255, A: 255}
+	reprojColor  = color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	goodColor    = color.RGBA{R: 0, G: 220, B: 0, A: 255}
+	warnColor    = color.RGBA{R: 255, G: 200, B: 0, A: 255}
+	badColor     = color.RGBA{R: 255, G: 0, B: 0, A: 255}
+	horizonColor = color.RGBA{R: 255, G: 128, B: 0, A: 255}
+)
+
+// DrawCalibration returns a copy of the reference frame annotated with the ground
+// calibration:
+//   - a 1 m road grid around the markings (cyan), which should line up with the lane markings
+//   - each measured distance coloured by its error: green < 2 %, yellow < 5 %, red beyond
+//   - each marking as picked (magenta cross, numbered in request order) and its
+//     position reprojected from the road (white circle)
+//   - the horizon (orange) when it is inside the frame
+func DrawCalibration(img image.Image, gc *GroundCalibration, markings []Marking, errs []DistanceError) *image.RGBA {
+	bounds := img.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, img, bounds.Min, draw.Src)
+	thick := max(1, bounds.Dx()/640)
+
+	// Grid 1 m di sekitar control point (+2 m)
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, p := range gc.ControlPoints {
+		minX, maxX = math.Min(minX, p.GroundX), math.Max(maxX, p.GroundX)
+		minY, maxY = math.Min(minY, p.GroundY), math.Max(maxY, p.GroundY)
+	}
+	minX, minY = math.Floor(minX)-2, math.Floor(minY)-2
+	maxX, maxY = math.Ceil(maxX)+2, math.Ceil(maxY)+2
+	for x := minX; x <= maxX; x++ {
+		gc.drawGroundLine(out, x, minY, x, maxY, gridColor, 1)
+	}
+	for y := minY; y <= maxY; y++ {
+		gc.drawGroundLine(out, minX, y, maxX, y, gridColor, 1)
+	}
+
+	gc.drawHorizon(out, thick)
+
+	index := make(map[string]Marking, len(markings))
+	for _, m := range markings {
+		index[m.Name] = m
+	}
+	for _, e := range errs {
+		a, b := index[e.From], index[e.To]
+		col := goodColor
+		switch pct := math.Abs(e.ErrorPct); {
+		case pct >= 5:
+			col = badColor
+		case pct >= 2:
+			col = warnColor
+		}
+		drawLine(out, a.PixelX, a.PixelY, b.PixelX, b.PixelY, col, thick)
+	}
+
+	size := float64(6 * thick)
+	for i, m := range markings {
+		drawLine(out, m.PixelX-size, m.PixelY-size, m.PixelX+size, m.PixelY+size, markColor, thick)
+		drawLine(out, m.PixelX-size, m.PixelY+size, m.PixelX+size, m.PixelY-size, markColor, thick)
+		if i < len(gc.ControlPoints) {
+			p := gc.ControlPoints[i]
+			if px, py, ok := gc.Unproject(p.GroundX, p.GroundY); ok {
+				drawCircle(out, px, py, size, reprojColor, thick)
+			}
+		}
+		drawNumber(out, int(m.PixelX+size+float64(2*thick)), int(m.PixelY+size), i+1, markColor, 2*thick)
+	}
+	return out
+}
+
+// drawGroundLine draws a road line in pieces, skipping pieces beyond the horizon
+func (gc *GroundCalibration) drawGroundLine(img *image.RGBA, x0, y0, x1, y1 float64, col color.RGBA, thick int) {
+	const steps = 40
+	var px, py float64
+	var prev bool
+	for s := 0; s <= steps; s++ {
+		t := float64(s) / steps
+		gx, gy := x0+(x1-x0)*t, y0+(y1-y0)*t
+		x, y, ok := gc.Unproject(gx, gy)
+		ok = ok && gc.inFront(gx, gy)
+		if ok && prev {
+			drawLine(img, px, py, x, y, col, thick)
+		}
+		px, py, prev = x, y, ok
+	}
+}
+
+// inFront reports whether a road point is on the camera side of the horizon
+func (gc *GroundCalibration) inFront(gx, gy float64) bool {
+	h := gc.GroundToImage
+	ref := gc.ControlPoints[0]
+	w0 := h[6]*ref.GroundX + h[7]*ref.GroundY + h[8]
+	return (h[6]*gx+h[7]*gy+h[8])*w0 > 0
+}
+
+// drawHorizon draws the image line where the road plane vanishes
+func (gc *GroundCalibration) drawHorizon(img *image.RGBA, thick int) {
+	// Horizon: baris ketiga homography image→ground = 0
+	h := gc.ImageToGround
+	b := img.Bounds()
+	if math.Abs(h[7]) < 1e-12 {
+		return
+	}
+	x0, x1 := float64(b.Min.X), float64(b.Max.X)
+	y0, y1 := -(h[6]*x0+h[8])/h[7], -(h[6]*x1+h[8])/h[7]
+	if math.Max(y0, y1) < float64(b.Min.Y) || math.Min(y0, y1) > float64(b.Max.Y) {
+		return
+	}
+	drawLine(img, x0, y0, x1, y1, horizonColor, thick)
+}
+
+// drawLine draws a line of the given thickness, clipped to the image
+func drawLine(img *image.RGBA, x0, y0, x1, y1 float64, col color.RGBA, thick int) {
+	b := img.Bounds()
+	// Batasi panjang agar garis yang hampir ke horizon tidak membuat loop sangat panjang
+	limit := float64(4 * (b.Dx() + b.Dy()))
+	if math.Abs(x0) > limit || math.Abs(y0) > limit || math.Abs(x1) > limit || math.Abs(y1) > limit {
+		return
+	}
+	n := int(math.Max(math.Abs(x1-x0), math.Abs(y1-y0))) + 1
+	for s := 0; s <= n; s++ {
+		t := float64(s) / float64(n)
+		x, y := int(math.Round(x0+(x1-x0)*t)), int(math.Round(y0+(y1-y0)*t))
+		fillSquare(img, x, y, thick, col)
+	}
+}
+
+func drawCircle(img *image.RGBA, cx, cy, r float64, col color.RGBA, thick int) {
+	n := int(2*math.Pi*r) + 8
+	for s := 0; s < n; s++ {
+		a := 2 * math.Pi * float64(s) / float64(n)
+		fillSquare(img, int(math.Round(cx+r*math.Cos(a))), int(math.Round(cy+r*math.Sin(a))), thick, col)
+	}
+}
+
+func fillSquare(img *image.RGBA, x, y, size int, col color.RGBA) {
+	r := image.Rect(x-size/2, y-size/2, x-size/2+size, y-size/2+size).Intersect(img.Bounds())
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.SetRGBA(px, py, col)
+		}
+	}
+}
+
+// digits is a 3x5 bitmap font, one row per 3 bits
+var digits = [10][5]uint8{
+	{7, 5, 5, 5, 7}, {2, 6, 2, 2, 7}, {7, 1, 7, 4, 7}, {7, 1, 7, 1, 7}, {5, 5, 7, 1, 1},
+	{7, 4, 7, 1, 7}, {7, 4, 7, 5, 7}, {7, 1, 1, 1, 1}, {7, 5, 7, 5, 7}, {7, 5, 7, 1, 7},
+}
+
+// drawNumber writes n with its top-left corner at (x, y), scale pixels per font dot
+func drawNumber(img *image.RGBA, x, y, n int, col color.RGBA, scale int) {
+	var s []int
+	for {
+		s = append([]int{n % 10}, s...)
+		if n /= 10; n == 0 {
+			break
+		}
+	}
+	for _, d := range s {
+		for row, bits := range digits[d] {
+			for c := 0; c < 3; c++ {
+				if bits&(4>>c) == 0 {
+					continue
+				}
+				r := image.Rect(x+c*scale, y+row*scale, x+(c+1)*scale, y+(row+1)*scale).Intersect(img.Bounds())
+				draw.Draw(img, r, image.NewUniform(col), image.Point{}, draw.Src)
+			}
+		}
+		x += 4 * scale
+	}
+}
@@ -0,0 +1,223 @@
+package vision
+
+import (
+	"image"
+	"math"
+)
+
+// workFrame is a downscaled copy of an image used for segmentation: luma for edges,
+// RGB planes for the background difference (a red car can have the luma of asphalt)
+type workFrame struct {
+	w, h  int
+	pix   []float32    // Luma 0..255
+	rgb   [3][]float32 // 0..255
+	scale float64      // Original pixels per working pixel
+}
+
+// newWorkFrame box-averages img down to at most maxWidth pixels wide
+func newWorkFrame(img image.Image, maxWidth int) *workFrame {
+	b := img.Bounds()
+	factor := 1
+	if maxWidth > 0 && b.Dx() > maxWidth {
+		factor = int(math.Ceil(float64(b.Dx()) / float64(maxWidth)))
+	}
+	w, h := b.Dx()/factor, b.Dy()/factor
+	g := &workFrame{w: w, h: h, pix: make([]float32, w*h), scale: float64(factor)}
+	for c := range g.rgb {
+		g.rgb[c] = make([]float32, w*h)
+	}
+
+	n := float32(factor*factor) * 257 // 16-bit channels -> 0..255
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var sr, sg, sb uint32
+			for dy := 0; dy < factor; dy++ {
+				for dx := 0; dx < factor; dx++ {
+					r, gg, bb, _ := img.At(b.Min.X+x*factor+dx, b.Min.Y+y*factor+dy).RGBA()
+					sr, sg, sb = sr+r, sg+gg, sb+bb
+				}
+			}
+			i := y*w + x
+			g.rgb[0][i], g.rgb[1][i], g.rgb[2][i] = float32(sr)/n, float32(sg)/n, float32(sb)/n
+			// ITU-R 601 luma
+			g.pix[i] = 0.299*g.rgb[0][i] + 0.587*g.rgb[1][i] + 0.114*g.rgb[2][i]
+		}
+	}
+	return g
+}
+
+// sobel returns the gradient magnitude of the frame (border pixels are 0)
+func (g *workFrame) sobel() []float32 {
+	out := make([]float32, g.w*g.h)
+	p := func(x, y int) float32 { return g.pix[y*g.w+x] }
+	for y := 1; y < g.h-1; y++ {
+		for x := 1; x < g.w-1; x++ {
+			gx := p(x+1, y-1) + 2*p(x+1, y) + p(x+1, y+1) - p(x-1, y-1) - 2*p(x-1, y) - p(x-1, y+1)
+			gy := p(x-1, y+1) + 2*p(x, y+1) + p(x+1, y+1) - p(x-1, y-1) - 2*p(x, y-1) - p(x+1, y-1)
+			out[y*g.w+x] = float32(math.Hypot(float64(gx), float64(gy)))
+		}
+	}
+	return out
+}
+
+// meanStd returns the mean and standard deviation of v
+func meanStd(v []float32) (float64, float64) {
+	if len(v) == 0 {
+		return 0, 0
+	}
+	var sum, sq float64
+	for _, x := range v {
+		sum += float64(x)
+		sq += float64(x) * float64(x)
+	}
+	mean := sum / float64(len(v))
+	return mean, math.Sqrt(math.Max(0, sq/float64(len(v))-mean*mean))
+}
+
+// integral is a summed-area table of a binary mask for O(1) window counts
+type integral struct {
+	w, h int
+	sum  []int32 // (w+1) x (h+1)
+}
+
+func newIntegral(mask []bool, w, h int) *integral {
+	in := &integral{w: w, h: h, sum: make([]int32, (w+1)*(h+1))}
+	for y := 0; y < h; y++ {
+		var row int32
+		for x := 0; x < w; x++ {
+			if mask[y*w+x] {
+				row++
+			}
+			in.sum[(y+1)*(w+1)+x+1] = in.sum[y*(w+1)+x+1] + row
+		}
+	}
+	return in
+}
+
+// count returns the set pixels in [x0,x1) x [y0,y1), clamped to the frame
+func (in *integral) count(x0, y0, x1, y1 int) int {
+	x0, y0 = max(x0, 0), max(y0, 0)
+	x1, y1 = min(x1, in.w), min(y1, in.h)
+	if x1 <= x0 || y1 <= y0 {
+		return 0
+	}
+	s := in.sum
+	w := in.w + 1
+	return int(s[y1*w+x1] - s[y0*w+x1] - s[y1*w+x0] + s[y0*w+x0])
+}
+
+// density marks pixels whose (2r+1)² window has at least frac of its pixels set
+func density(mask []bool, w, h, r int, frac float64) []bool {
+	in := newIntegral(mask, w, h)
+	out := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			x0, y0, x1, y1 := x-r, y-r, x+r+1, y+r+1
+			area := (min(x1, w) - max(x0, 0)) * (min(y1, h) - max(y0, 0))
+			out[y*w+x] = float64(in.count(x0, y0, x1, y1)) >= frac*float64(area)
+		}
+	}
+	return out
+}
+
+// dilate sets every pixel with a set pixel in its (2r+1)² window
+func dilate(mask []bool, w, h, r int) []bool {
+	in := newIntegral(mask, w, h)
+	out := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			out[y*w+x] = in.count(x-r, y-r, x+r+1, y+r+1) > 0
+		}
+	}
+	return out
+}
+
+// erode keeps only pixels whose whole (2r+1)² window (inside the frame) is set
+func erode(mask []bool, w, h, r int) []bool {
+	in := newIntegral(mask, w, h)
+	out := make([]bool, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			x0, y0, x1, y1 := x-r, y-r, x+r+1, y+r+1
+			area := (min(x1, w) - max(x0, 0)) * (min(y1, h) - max(y0, 0))
+			out[y*w+x] = in.count(x0, y0, x1, y1) == area
+		}
+	}
+	return out
+}
+
+// blob is one connected component of a mask in working coordinates
+type blob struct {
+	x0, y0, x1, y1 int // Bounding box, x1/y1 exclusive
+	area           int // Set pixels
+}
+
+func (b blob) width() int  { return b.x1 - b.x0 }
+func (b blob) height() int { return b.y1 - b.y0 }
+func (b blob) boxArea() int {
+	return b.width() * b.height()
+}
+
+// components labels the 8-connected components of mask
+func components(mask []bool, w, h int) []blob {
+	visited := make([]bool, w*h)
+	var blobs []blob
+	var stack []int
+	for start := range mask {
+		if !mask[start] || visited[start] {
+			continue
+		}
+		b := blob{x0: w, y0: h}
+		visited[start] = true
+		stack = append(stack[:0], start)
+		for len(stack) > 0 {
+			i := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			x, y := i%w, i/w
+			b.area++
+			b.x0, b.y0 = min(b.x0, x), min(b.y0, y)
+			b.x1, b.y1 = max(b.x1, x+1), max(b.y1, y+1)
+			for dy := -1; dy <= 1; dy++ {
+				for dx := -1; dx <= 1; dx++ {
+					nx, ny := x+dx, y+dy
+					if nx < 0 || ny < 0 || nx >= w || ny >= h {
+						continue
+					}
+					j := ny*w + nx
+					if mask[j] && !visited[j] {
+						visited[j] = true
+						stack = append(stack, j)
+					}
+				}
+			}
+		}
+		blobs = append(blobs, b)
+	}
+	return blobs
+}
+
+// mergeBlobs merges blobs whose boxes overlap or lie within gap pixels of each other,
+// so the parts of one vehicle (body, windows, wheels) end up in a single box
+func mergeBlobs(blobs []blob, gap int) []blob {
+	merged := true
+	for merged {
+		merged = false
+		for i := 0; i < len(blobs) && !merged; i++ {
+			for j := i + 1; j < len(blobs); j++ {
+				a, b := blobs[i], blobs[j]
+				if a.x0-gap > b.x1 || b.x0-gap > a.x1 || a.y0-gap > b.y1 || b.y0-gap > a.y1 {
+					continue
+				}
+				blobs[i] = blob{
+					x0: min(a.x0, b.x0), y0: min(a.y0, b.y0),
+					x1: max(a.x1, b.x1), y1: max(a.y1, b.y1),
+					area: a.area + b.area,
+				}
+				blobs = append(blobs[:j], blobs[j+1:]...)
+				merged = true
+				break
+			}
+		}
+	}
+	return blobs
+}
@@ -0,0 +1,274 @@
+package vision
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+	"sort"
+	"sync"
+)
+
+// SegmentationDetector is the built-in detector: a classical CPU-only pipeline of a
+// per-camera background model (edge density where no background is known yet),
+// morphology, connected components and box merging
+type SegmentationDetector struct {
+	ModelPath string  // Directory where per-camera background models are persisted ("" = memory only)
+	Threshold float64 // Minimum detection score (0..1)
+	Params    DetectorParams
+
+	mu          sync.Mutex
+	backgrounds map[string]*background
+}
+
+// DetectorParams tunes the segmentation. Sizes are in working pixels, i.e. after
+// the image is downscaled to WorkWidth.
+type DetectorParams struct {
+	WorkWidth      int     // Images are downscaled to at most this width
+	MinAreaFrac    float64 // Smallest vehicle box as a fraction of the frame
+	MaxAreaFrac    float64 // Foreground above this fraction means the background is stale
+	EdgeRadius     int     // Half size of the edge density window
+	EdgeDensity    float64 // Edge fraction of the window marking foreground
+	OpenRadius     int     // Edge regions thinner than 2r+1 are dropped
+	DiffThreshold  float64 // Minimum RGB channel difference from the background
+	CloseRadius    int     // Closing radius joining the parts of a vehicle
+	MergeGap       int     // Boxes closer than this are merged
+	BackgroundRate float64 // Running average rate of background pixels (0..1)
+	SaveEvery      int     // Persist a background model every N frames (0 = never)
+}
+
+// DefaultDetectorParams returns parameters suited to roadside ANPR frames
+func DefaultDetectorParams() DetectorParams {
+	return DetectorParams{
+		WorkWidth:      320,
+		MinAreaFrac:    0.01,
+		MaxAreaFrac:    0.6,
+		EdgeRadius:     4,
+		EdgeDensity:    0.2,
+		OpenRadius:     3,
+		DiffThreshold:  18,
+		CloseRadius:    3,
+		MergeGap:       4,
+		BackgroundRate: 0.05,
+		SaveEvery:      20,
+	}
+}
+
+// NewSegmentationDetector creates the built-in detector
+func NewSegmentationDetector(modelPath string, threshold float64) *SegmentationDetector {
+	return &SegmentationDetector{
+		ModelPath:   modelPath,
+		Threshold:   threshold,
+		Params:      DefaultDetectorParams(),
+		backgrounds: make(map[string]*background),
+	}
+}
+
+// DetectCamera detects vehicles in a frame of the given camera and updates the
+// camera's background model. Boxes are in image coordinates, best score first.
+func (sd *SegmentationDetector) DetectCamera(img image.Image, camera string) ([]BoundingBox, error) {
+	b := img.Bounds()
+	if b.Dx() < 16 || b.Dy() < 16 {
+		return nil, fmt.Errorf("image too small: %dx%d", b.Dx(), b.Dy())
+	}
+	p := sd.Params
+	g := newWorkFrame(img, p.WorkWidth)
+
+	bg, err := sd.background(camera, g.w, g.h)
+	if err != nil {
+		log.Printf("[DETECTOR] Warning: background model for camera %q not loaded: %v", camera, err)
+	}
+	bg.mu.Lock()
+	defer bg.mu.Unlock()
+
+	edges := sd.edgeMask(g)
+	mask := sd.foreground(g, bg, edges)
+	if bg.ready() && fraction(mask) > p.MaxAreaFrac {
+		// Hampir seluruh frame berbeda: kamera bergeser atau pencahayaan berubah total
+		log.Printf("[DETECTOR] Background of camera %q is stale, resetting", camera)
+		bg.reset()
+		mask = sd.foreground(g, bg, edges)
+	}
+
+	mask = erode(dilate(mask, g.w, g.h, p.CloseRadius), g.w, g.h, p.CloseRadius)
+	mask = dilate(erode(mask, g.w, g.h, 1), g.w, g.h, 1)
+
+	blobs := mergeBlobs(components(mask, g.w, g.h), p.MergeGap)
+	maskSum, edgeSum := newIntegral(mask, g.w, g.h), newIntegral(edges, g.w, g.h)
+	frame := float64(g.w * g.h)
+
+	var boxes []BoundingBox
+	vehicle := make([]bool, g.w*g.h)
+	for _, bl := range blobs {
+		areaFrac := float64(bl.boxArea()) / frame
+		if areaFrac < p.MinAreaFrac || bl.width() < 8 || bl.height() < 8 {
+			continue
+		}
+		aspect := float64(bl.width()) / float64(bl.height())
+		if aspect > 6 || aspect < 1.0/6 {
+			continue // Marka jalan, bayangan tiang, dll.
+		}
+
+		boxArea := float64(bl.boxArea())
+		fill := float64(maskSum.count(bl.x0, bl.y0, bl.x1, bl.y1)) / boxArea
+		edge := float64(edgeSum.count(bl.x0, bl.y0, bl.x1, bl.y1)) / boxArea
+		score := 0.4*clamp01((fill-0.3)/0.5) + 0.3*clamp01(areaFrac/0.05) + 0.3*clamp01(edge/(2*p.EdgeDensity))
+		score = math.Round(score*1000) / 1000
+		if score < sd.Threshold {
+			continue
+		}
+
+		for y := bl.y0; y < bl.y1; y++ {
+			for x := bl.x0; x < bl.x1; x++ {
+				vehicle[y*g.w+x] = true
+			}
+		}
+		x0, y0 := int(float64(bl.x0)*g.scale), int(float64(bl.y0)*g.scale)
+		x1, y1 := min(int(float64(bl.x1)*g.scale), b.Dx()), min(int(float64(bl.y1)*g.scale), b.Dy())
+		boxes = append(boxes, BoundingBox{
+			X:      b.Min.X + x0,
+			Y:      b.Min.Y + y0,
+			Width:  x1 - x0,
+			Height: y1 - y0,
+			Label:  "vehicle",
+			Score:  score,
+		})
+	}
+	sort.SliceStable(boxes, func(i, j int) bool { return boxes[i].Score > boxes[j].Score })
+
+	bg.update(g, dilate(vehicle, g.w, g.h, 2), p.BackgroundRate)
+	if sd.ModelPath != "" && p.SaveEvery > 0 && bg.frames%p.SaveEvery == 0 {
+		if err := bg.save(backgroundFile(sd.ModelPath, camera)); err != nil {
+			log.Printf("[DETECTOR] Warning: failed to save background of camera %q: %v", camera, err)
+		}
+	}
+
+	return boxes, nil
+}
+
+// ResetBackground forgets the background model of a camera (e.g. after it was moved)
+func (sd *SegmentationDetector) ResetBackground(camera string) {
+	sd.mu.Lock()
+	bg := sd.backgrounds[camera]
+	sd.mu.Unlock()
+	if bg != nil {
+		bg.mu.Lock()
+		bg.reset()
+		bg.mu.Unlock()
+	}
+}
+
+// background returns the model of a camera, loading a persisted one on first use.
+// A model of another frame size is replaced.
+func (sd *SegmentationDetector) background(camera string, w, h int) (*background, error) {
+	sd.mu.Lock()
+	defer sd.mu.Unlock()
+	if sd.backgrounds == nil {
+		sd.backgrounds = make(map[string]*background)
+	}
+	if bg, ok := sd.backgrounds[camera]; ok && bg.w == w && bg.h == h {
+		return bg, nil
+	}
+
+	var err error
+	var bg *background
+	if sd.ModelPath != "" {
+		bg, err = loadBackground(backgroundFile(sd.ModelPath, camera), w, h)
+	}
+	if bg == nil {
+		bg = newBackground(w, h)
+	}
+	sd.backgrounds[camera] = bg
+	return bg, err
+}
+
+// edgeMask marks edge-dense regions: Sobel magnitude above an adaptive threshold,
+// then the fraction of edge pixels in a window
+func (sd *SegmentationDetector) edgeMask(g *workFrame) []bool {
+	mag := g.sobel()
+	mean, std := meanStd(mag)
+	thr := float32(math.Max(40, mean+1.5*std))
+	edges := make([]bool, len(mag))
+	for i, m := range mag {
+		edges[i] = m > thr
+	}
+	dense := density(edges, g.w, g.h, sd.Params.EdgeRadius, sd.Params.EdgeDensity)
+	// Opening removes thin edge-dense structures: lane markings, curbs, cables
+	r := sd.Params.OpenRadius
+	return dilate(erode(dense, g.w, g.h, r), g.w, g.h, r)
+}
+
+// foreground compares the frame with the background where it is known and falls
+// back to the edge mask elsewhere. The difference is the largest of the RGB channel
+// differences; a global brightness or white balance change is compensated by the
+// median difference of each channel.
+func (sd *SegmentationDetector) foreground(g *workFrame, bg *background, edges []bool) []bool {
+	if !bg.ready() {
+		return append([]bool(nil), edges...)
+	}
+
+	var offset [3]float32
+	var n int
+	for c := range offset {
+		var hist [511]int
+		n = 0
+		for i, v := range g.rgb[c] {
+			if bg.seen[i] {
+				hist[int(v-bg.mean[c][i])+255]++
+				n++
+			}
+		}
+		offset[c] = float32(median(hist[:], n) - 255)
+	}
+
+	diff := make([]float32, len(g.pix))
+	var dev [256]int
+	for i := range diff {
+		if !bg.seen[i] {
+			continue
+		}
+		for c := range offset {
+			d := g.rgb[c][i] - bg.mean[c][i] - offset[c]
+			diff[i] = max(diff[i], d, -d)
+		}
+		dev[min(int(diff[i]), 255)]++
+	}
+	// Noise level: 3 x median absolute deviation
+	thr := float32(math.Max(sd.Params.DiffThreshold, 3*float64(median(dev[:], n))))
+
+	mask := make([]bool, len(g.pix))
+	for i := range mask {
+		if bg.seen[i] {
+			mask[i] = diff[i] > thr
+		} else {
+			mask[i] = edges[i]
+		}
+	}
+	return mask
+}
+
+// median returns the index of the median in a histogram of n values
+func median(hist []int, n int) int {
+	var acc int
+	for i, c := range hist {
+		acc += c
+		if acc*2 >= n {
+			return i
+		}
+	}
+	return len(hist) - 1
+}
+
+func fraction(mask []bool) float64 {
+	var n int
+	for _, m := range mask {
+		if m {
+			n++
+		}
+	}
+	return float64(n) / float64(len(mask))
+}
+
+func clamp01(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
@@ -0,0 +1,247 @@
+package vision
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Marking is a point on the road picked in a reference frame, e.g. the corner of a
+// lane marking or a painted survey dot
+type Marking struct {
+	Name   string  `json:"name"`
+	PixelX float64 `json:"pixel_x"`
+	PixelY float64 `json:"pixel_y"`
+}
+
+// MeasuredDistance is a tape measurement on the road between two markings
+type MeasuredDistance struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Meters float64 `json:"meters"`
+}
+
+// DistanceError compares a measured distance with the same distance through the calibration
+type DistanceError struct {
+	From        string  `json:"from"`
+	To          string  `json:"to"`
+	Meters      float64 `json:"meters"`
+	Calibrated  float64 `json:"calibrated_m"` // Distance between the projected markings
+	ErrorMeters float64 `json:"error_m"`
+	ErrorPct    float64 `json:"error_pct"`
+}
+
+// ErrUnderdetermined is returned when the distances do not fix every marking on the road
+var ErrUnderdetermined = errors.New("distances do not fix every marking (each needs distances to two markings already placed)")
+
+// SurveyControlPoints places the markings on the road plane from the distances
+// between them and returns them as control points. N markings need at least 2N-3
+// distances, e.g. the four sides and a diagonal of a rectangle; further distances
+// are reconciled by least squares. The ground frame has the first measured marking
+// at the origin and the other end of that distance on the X axis; its handedness
+// follows the image so that the camera is above the road.
+func SurveyControlPoints(markings []Marking, distances []MeasuredDistance) ([]ControlPoint, error) {
+	if len(markings) < 4 {
+		return nil, ErrDegenerateControlPoints
+	}
+	index := make(map[string]int, len(markings))
+	for i, m := range markings {
+		name := strings.TrimSpace(m.Name)
+		if name == "" {
+			return nil, fmt.Errorf("marking %d has no name", i+1)
+		}
+		if _, dup := index[name]; dup {
+			return nil, fmt.Errorf("marking %q is listed twice", name)
+		}
+		index[name] = i
+	}
+
+	type edge struct {
+		i, j int
+		d    float64
+	}
+	var edges []edge
+	known := make(map[[2]int]float64)
+	for _, d := range distances {
+		i, ok1 := index[strings.TrimSpace(d.From)]
+		j, ok2 := index[strings.TrimSpace(d.To)]
+		switch {
+		case !ok1 || !ok2:
+			return nil, fmt.Errorf("distance %s-%s refers to an unknown marking", d.From, d.To)
+		case i == j:
+			return nil, fmt.Errorf("distance %s-%s joins a marking to itself", d.From, d.To)
+		case d.Meters <= 0:
+			return nil, fmt.Errorf("distance %s-%s must be positive", d.From, d.To)
+		}
+		edges = append(edges, edge{i, j, d.Meters})
+		known[[2]int{i, j}] = d.Meters
+		known[[2]int{j, i}] = d.Meters
+	}
+	if len(edges) < 2*len(markings)-3 {
+		return nil, fmt.Errorf("%w: %d markings need at least %d distances", ErrUnderdetermined, len(markings), 2*len(markings)-3)
+	}
+
+	// Trilaterasi: titik berikutnya dari dua titik yang sudah ditempatkan,
+	// pilih pasangan dengan sudut perpotongan terbaik
+	pos := make([][2]float64, len(markings))
+	placed := make([]bool, len(markings))
+	first := edges[0]
+	placed[first.i], placed[first.j] = true, true
+	pos[first.j] = [2]float64{first.d, 0}
+	for n := 2; n < len(markings); n++ {
+		best, bestQ := -1, -1.0
+		var bestPos [2]float64
+		for k := range markings {
+			if placed[k] {
+				continue
+			}
+			for i := range markings {
+				dik, ok := known[[2]int{i, k}]
+				if !placed[i] || !ok {
+					continue
+				}
+				for j := i + 1; j < len(markings); j++ {
+					djk, ok := known[[2]int{j, k}]
+					if !placed[j] || !ok {
+						continue
+					}
+					p, q := trilaterate(markings, pos, i, j, k, dik, djk)
+					if q > bestQ {
+						best, bestQ, bestPos = k, q, p
+					}
+				}
+			}
+		}
+		if best < 0 {
+			return nil, ErrUnderdetermined
+		}
+		placed[best], pos[best] = true, bestPos
+	}
+
+	// Kuadrat terkecil atas semua jarak. Titik pertama tetap dan titik kedua tetap
+	// di sumbu X, sehingga frame ground tidak ikut bergeser/berputar
+	param := make([][2]int, len(markings)) // Index parameter x, y (-1 = tetap)
+	n := 0
+	for k := range markings {
+		param[k] = [2]int{-1, -1}
+		if k == first.i {
+			continue
+		}
+		param[k][0] = n
+		n++
+		if k != first.j {
+			param[k][1] = n
+			n++
+		}
+	}
+	for iter := 0; iter < 50; iter++ {
+		jtj := make([][]float64, n)
+		for r := range jtj {
+			jtj[r] = make([]float64, n)
+		}
+		jtr := make([]float64, n)
+		for _, e := range edges {
+			dx, dy := pos[e.i][0]-pos[e.j][0], pos[e.i][1]-pos[e.j][1]
+			l := math.Hypot(dx, dy)
+			if l < 1e-9 {
+				continue
+			}
+			r := l - e.d
+			var row [4]float64 // d r / d (xi, yi, xj, yj)
+			row[0], row[1], row[2], row[3] = dx/l, dy/l, -dx/l, -dy/l
+			idx := [4]int{param[e.i][0], param[e.i][1], param[e.j][0], param[e.j][1]}
+			for a := range idx {
+				if idx[a] < 0 {
+					continue
+				}
+				jtr[idx[a]] -= row[a] * r
+				for b := range idx {
+					if idx[b] >= 0 {
+						jtj[idx[a]][idx[b]] += row[a] * row[b]
+					}
+				}
+			}
+		}
+		for r := range jtj {
+			jtj[r][r] += 1e-9
+		}
+		step, ok := solveLinear(jtj, jtr)
+		if !ok {
+			break
+		}
+		var moved float64
+		for k := range markings {
+			for c := 0; c < 2; c++ {
+				if p := param[k][c]; p >= 0 {
+					pos[k][c] += step[p]
+					moved = math.Max(moved, math.Abs(step[p]))
+				}
+			}
+		}
+		if moved < 1e-7 {
+			break
+		}
+	}
+
+	points := make([]ControlPoint, len(markings))
+	for k, m := range markings {
+		points[k] = ControlPoint{PixelX: m.PixelX, PixelY: m.PixelY, GroundX: round3(pos[k][0]), GroundY: round3(pos[k][1])}
+	}
+	return points, nil
+}
+
+// trilaterate places marking k from placed markings i and j. Of the two circle
+// intersections it takes the one turning the same way as in the image (the image
+// Y axis points down, so the turn is reversed). The quality is the height of k
+// above the line i-j relative to the distances; 0 means i, j and k are collinear.
+func trilaterate(markings []Marking, pos [][2]float64, i, j, k int, dik, djk float64) ([2]float64, float64) {
+	pi, pj := pos[i], pos[j]
+	base := math.Hypot(pj[0]-pi[0], pj[1]-pi[1])
+	if base < 1e-6 {
+		return [2]float64{}, -1
+	}
+	ux, uy := (pj[0]-pi[0])/base, (pj[1]-pi[1])/base
+	a := (dik*dik - djk*djk + base*base) / (2 * base)
+	h := math.Sqrt(math.Max(dik*dik-a*a, 0))
+
+	mi, mj, mk := markings[i], markings[j], markings[k]
+	turn := (mj.PixelX-mi.PixelX)*(mk.PixelY-mi.PixelY) - (mj.PixelY-mi.PixelY)*(mk.PixelX-mi.PixelX)
+	if turn > 0 {
+		h = -h
+	}
+	p := [2]float64{pi[0] + a*ux - h*uy, pi[1] + a*uy + h*ux}
+	return p, math.Abs(h) / math.Max(dik, djk)
+}
+
+// MeasureDistances compares the measured distances with the distances between the
+// markings projected onto the road
+func (gc *GroundCalibration) MeasureDistances(markings []Marking, distances []MeasuredDistance) []DistanceError {
+	index := make(map[string]Marking, len(markings))
+	for _, m := range markings {
+		index[strings.TrimSpace(m.Name)] = m
+	}
+	errs := make([]DistanceError, 0, len(distances))
+	for _, d := range distances {
+		a, ok1 := index[strings.TrimSpace(d.From)]
+		b, ok2 := index[strings.TrimSpace(d.To)]
+		if !ok1 || !ok2 || d.Meters <= 0 {
+			continue
+		}
+		ax, ay, ok1 := gc.Project(a.PixelX, a.PixelY)
+		bx, by, ok2 := gc.Project(b.PixelX, b.PixelY)
+		if !ok1 || !ok2 {
+			continue
+		}
+		l := math.Hypot(bx-ax, by-ay)
+		errs = append(errs, DistanceError{
+			From:        d.From,
+			To:          d.To,
+			Meters:      d.Meters,
+			Calibrated:  round3(l),
+			ErrorMeters: round3(l - d.Meters),
+			ErrorPct:    round3((l - d.Meters) / d.Meters * 100),
+		})
+	}
+	return errs
+}
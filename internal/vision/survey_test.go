@@ -0,0 +1,159 @@
+package vision
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+// surveyMarkings projects named road points into the image as markings
+func surveyMarkings(c pinholeCamera, names []string, ground [][2]float64) []Marking {
+	markings := make([]Marking, len(names))
+	for i, name := range names {
+		px, py := c.project(ground[i][0], ground[i][1], 0)
+		markings[i] = Marking{Name: name, PixelX: px, PixelY: py}
+	}
+	return markings
+}
+
+// tape measures the true distance between two of the ground points, plus an error
+func tape(names []string, ground [][2]float64, from, to string, errMeters float64) MeasuredDistance {
+	index := func(name string) [2]float64 {
+		for i, n := range names {
+			if n == name {
+				return ground[i]
+			}
+		}
+		panic("unknown marking " + name)
+	}
+	a, b := index(from), index(to)
+	return MeasuredDistance{From: from, To: to, Meters: math.Hypot(b[0]-a[0], b[1]-a[1]) + errMeters}
+}
+
+func TestSurveyControlPoints(t *testing.T) {
+	camera := pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}
+	names := []string{"A", "B", "C", "D", "E"}
+	ground := [][2]float64{{0, 0}, {3.5, 0}, {3.5, 20}, {0, 20}, {1.75, 10}}
+
+	type leg struct {
+		from, to string
+		err      float64
+	}
+	tests := []struct {
+		name      string
+		markings  int
+		legs      []leg
+		tolerance float64 // Pairwise distance and pose error
+	}{
+		{"rectangle and diagonal", 4, []leg{{"A", "B", 0}, {"B", "C", 0}, {"C", "D", 0}, {"D", "A", 0}, {"A", "C", 0}}, 0.005},
+		{"along the road first", 4, []leg{{"D", "A", 0}, {"A", "B", 0}, {"B", "C", 0}, {"C", "D", 0}, {"B", "D", 0}}, 0.005},
+		{"redundant with tape error", 5, []leg{
+			{"A", "B", 0.01}, {"B", "C", -0.01}, {"C", "D", 0.01}, {"D", "A", 0},
+			{"A", "C", 0}, {"B", "D", -0.01}, {"A", "E", 0}, {"C", "E", 0.01}, {"B", "E", 0},
+		}, 0.05},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, g := names[:tt.markings], ground[:tt.markings]
+			distances := make([]MeasuredDistance, len(tt.legs))
+			for i, l := range tt.legs {
+				distances[i] = tape(n, g, l.from, l.to, l.err)
+			}
+			markings := surveyMarkings(camera, n, g)
+
+			points, err := SurveyControlPoints(markings, distances)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			// Frame: ujung pertama jarak pertama di origin, ujung kedua di sumbu X
+			first := distances[0]
+			for i, m := range markings {
+				p := points[i]
+				if p.PixelX != m.PixelX || p.PixelY != m.PixelY {
+					t.Errorf("%s: pixel (%.1f, %.1f), want (%.1f, %.1f)", m.Name, p.PixelX, p.PixelY, m.PixelX, m.PixelY)
+				}
+				if m.Name == first.From && (p.GroundX != 0 || p.GroundY != 0) {
+					t.Errorf("%s = (%.3f, %.3f), want the origin", m.Name, p.GroundX, p.GroundY)
+				}
+				if m.Name == first.To && (p.GroundY != 0 || p.GroundX <= 0) {
+					t.Errorf("%s = (%.3f, %.3f), want on the positive X axis", m.Name, p.GroundX, p.GroundY)
+				}
+			}
+			// Bentuk di jalan sama dengan sebenarnya (sampai rotasi dan translasi)
+			for i := range points {
+				for j := i + 1; j < len(points); j++ {
+					got := math.Hypot(points[j].GroundX-points[i].GroundX, points[j].GroundY-points[i].GroundY)
+					want := math.Hypot(g[j][0]-g[i][0], g[j][1]-g[i][1])
+					if math.Abs(got-want) > tt.tolerance {
+						t.Errorf("%s-%s = %.3f m, want %.3f", n[i], n[j], got, want)
+					}
+				}
+			}
+
+			// Handedness sama dengan jalan sebenarnya (bukan cerminannya)
+			turn := func(a, b, c [2]float64) float64 {
+				return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+			}
+			at := func(i int) [2]float64 { return [2]float64{points[i].GroundX, points[i].GroundY} }
+			if turn(at(0), at(1), at(2))*turn(g[0], g[1], g[2]) <= 0 {
+				t.Error("survey frame is mirrored")
+			}
+
+			gc, err := NewGroundCalibration(points, camera.CX, camera.CY)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p := gc.Pose; p == nil || math.Abs(p.HeightMeters-camera.Height) > 10*tt.tolerance ||
+				math.Abs(p.TiltDegrees-camera.Tilt) > 100*tt.tolerance {
+				t.Errorf("pose = %+v, want height %.2f tilt %.1f", p, camera.Height, camera.Tilt)
+			}
+			for _, e := range gc.MeasureDistances(markings, distances) {
+				if math.Abs(e.ErrorMeters) > tt.tolerance {
+					t.Errorf("%s-%s: error %.3f m", e.From, e.To, e.ErrorMeters)
+				}
+			}
+		})
+	}
+}
+
+func TestSurveyControlPointsInvalid(t *testing.T) {
+	camera := pinholeCamera{Focal: 1000, CX: 960, CY: 540, X: 1.75, Y: -12, Height: 6, Tilt: 20}
+	names := []string{"A", "B", "C", "D"}
+	ground := [][2]float64{{0, 0}, {3.5, 0}, {3.5, 20}, {0, 20}}
+	markings := surveyMarkings(camera, names, ground)
+	rectangle := []MeasuredDistance{
+		tape(names, ground, "A", "B", 0), tape(names, ground, "B", "C", 0), tape(names, ground, "C", "D", 0),
+		tape(names, ground, "D", "A", 0), tape(names, ground, "A", "C", 0),
+	}
+	with := func(d ...MeasuredDistance) []MeasuredDistance {
+		return append(append([]MeasuredDistance(nil), rectangle...), d...)
+	}
+
+	tests := []struct {
+		name      string
+		markings  []Marking
+		distances []MeasuredDistance
+		want      error // nil = any error
+	}{
+		{"three markings", markings[:3], rectangle, ErrDegenerateControlPoints},
+		{"unnamed marking", append([]Marking{{Name: " "}}, markings...), rectangle, nil},
+		{"duplicate name", append([]Marking{markings[0]}, markings...), rectangle, nil},
+		{"unknown marking", markings, with(MeasuredDistance{From: "A", To: "Z", Meters: 1}), nil},
+		{"marking to itself", markings, with(MeasuredDistance{From: "A", To: "A", Meters: 1}), nil},
+		{"zero distance", markings, with(MeasuredDistance{From: "B", To: "D", Meters: 0}), nil},
+		{"too few distances", markings, rectangle[:4], ErrUnderdetermined},
+		{"marking not fixed", markings, []MeasuredDistance{
+			tape(names, ground, "A", "B", 0), tape(names, ground, "B", "C", 0), tape(names, ground, "A", "C", 0),
+			tape(names, ground, "A", "B", 0), tape(names, ground, "C", "D", 0),
+		}, ErrUnderdetermined},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := SurveyControlPoints(tt.markings, tt.distances)
+			if err == nil || (tt.want != nil && !errors.Is(err, tt.want)) {
+				t.Errorf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,216 @@
+// Generates the synthetic frames and labels.json of the detector label set:
+//
+//	go run ./internal/vision/testdata/detector/gen
+//
+// Each camera has a fixed road (asphalt texture, lane markings, verge) with per
+// frame sensor noise and brightness; vehicles are drawn as seen from a gantry
+// camera (body, rear window, roof, lights, plate, shadow). The label is the body
+// box, shadow excluded. studio.jpg is a real photo labelled by hand.
+package main
+
+import (
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"log"
+	"math/rand"
+	"os"
+	"path/filepath"
+)
+
+const (
+	width  = 640
+	height = 360
+	dir    = "internal/vision/testdata/detector"
+)
+
+type box struct {
+	X      int `json:"x"`
+	Y      int `json:"y"`
+	Width  int `json:"width"`
+	Height int `json:"height"`
+}
+
+type labelled struct {
+	Image  string `json:"image"`
+	Camera string `json:"camera"`
+	Boxes  []box  `json:"boxes"`
+}
+
+type vehicle struct {
+	box
+	body color.RGBA
+}
+
+type frame struct {
+	brightness float64
+	vehicles   []vehicle
+}
+
+var (
+	white  = color.RGBA{235, 235, 230, 255}
+	red    = color.RGBA{170, 30, 30, 255}
+	blue   = color.RGBA{40, 60, 150, 255}
+	silver = color.RGBA{180, 182, 185, 255}
+	black  = color.RGBA{35, 35, 38, 255}
+	yellow = color.RGBA{210, 170, 40, 255}
+	green  = color.RGBA{40, 110, 60, 255}
+)
+
+func v(x, y, w, h int, c color.RGBA) vehicle {
+	return vehicle{box{x, y, w, h}, c}
+}
+
+func main() {
+	cameras := []struct {
+		name    string
+		seed    int64
+		asphalt float64
+		frames  []frame
+	}{
+		{"gantry-1", 1, 100, []frame{
+			{0, nil},
+			{0, []vehicle{v(240, 90, 150, 200, white)}},
+			{5, []vehicle{v(40, 60, 170, 240, red)}},
+			{-5, []vehicle{v(450, 120, 140, 180, blue)}},
+			{0, []vehicle{v(60, 100, 150, 190, silver), v(430, 70, 160, 230, yellow)}},
+			{20, []vehicle{v(235, 40, 180, 290, green)}},
+			{0, nil},
+			{-15, []vehicle{v(250, 110, 140, 170, black)}},
+		}},
+		{"gantry-2", 2, 80, []frame{
+			// Kendaraan sudah ada di frame pertama (belum ada model latar)
+			{0, []vehicle{v(220, 80, 170, 230, white)}},
+			{0, []vehicle{v(60, 90, 150, 210, yellow)}},
+			{10, []vehicle{v(410, 60, 170, 250, red)}},
+			{0, []vehicle{v(230, 100, 160, 200, silver)}},
+			{-10, nil},
+			{0, []vehicle{v(240, 70, 200, 270, blue), v(30, 150, 120, 150, white)}},
+		}},
+	}
+
+	var labels []labelled
+	for _, cam := range cameras {
+		rng := rand.New(rand.NewSource(cam.seed))
+		road := drawRoad(rng, cam.asphalt)
+		for i, f := range cam.frames {
+			img := image.NewRGBA(road.Rect)
+			copy(img.Pix, road.Pix)
+			l := labelled{Image: name(cam.name, i), Camera: cam.name, Boxes: []box{}}
+			for _, veh := range f.vehicles {
+				drawVehicle(img, veh)
+				l.Boxes = append(l.Boxes, veh.box)
+			}
+			finish(img, rng, f.brightness)
+			save(filepath.Join(dir, l.Image), img)
+			labels = append(labels, l)
+		}
+	}
+	labels = append(labels, labelled{
+		Image: "studio.jpg", Camera: "studio",
+		Boxes: []box{{200, 165, 560, 297}},
+	})
+
+	set := map[string]any{
+		"description": "Vehicle detector smoke test: synthetic gantry frames (see gen/main.go) and one studio photo; accuracy is measured on site/labels.json",
+		"images":      labels,
+	}
+	data, err := json.MarshalIndent(set, "", "  ")
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "labels.json"), append(data, '\n'), 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func name(camera string, i int) string {
+	return camera + "_" + string(rune('0'+i/10)) + string(rune('0'+i%10)) + ".jpg"
+}
+
+// drawRoad draws the static scene of a camera: three lanes, dashed markings, verges
+func drawRoad(rng *rand.Rand, base float64) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			g := uint8(clamp(base + rng.NormFloat64()*6))
+			img.SetRGBA(x, y, color.RGBA{g, g, g, 255})
+		}
+	}
+	// Bercak aspal (tambalan) berintensitas sedikit berbeda
+	for i := 0; i < 6; i++ {
+		fill(img, rng.Intn(width-80), rng.Intn(height-60), 40+rng.Intn(60), 20+rng.Intn(40), func(c color.RGBA) color.RGBA {
+			return shade(c, 0.9)
+		})
+	}
+	for _, lx := range []int{213, 426} {
+		for y := 0; y < height; y += 60 {
+			fill(img, lx-2, y, 5, 30, func(color.RGBA) color.RGBA { return color.RGBA{210, 210, 205, 255} })
+		}
+	}
+	for _, lx := range []int{8, 628} {
+		fill(img, lx, 0, 4, height, func(color.RGBA) color.RGBA { return color.RGBA{200, 200, 195, 255} })
+	}
+	return img
+}
+
+// drawVehicle draws a vehicle seen from behind and above inside its box
+func drawVehicle(img *image.RGBA, v vehicle) {
+	x, y, w, h := v.X, v.Y, v.Width, v.Height
+	// Bayangan di kanan bawah, di luar label
+	fill(img, x+8, y+h-10, w, 16, func(c color.RGBA) color.RGBA { return shade(c, 0.55) })
+	fill(img, x, y, w, h, func(color.RGBA) color.RGBA { return v.body })
+	// Atap lebih terang, kaca belakang gelap
+	fill(img, x+w/8, y+h/10, w*3/4, h*4/10, func(color.RGBA) color.RGBA { return shade(v.body, 1.15) })
+	fill(img, x+w/6, y+h*55/100, w*2/3, h/5, func(color.RGBA) color.RGBA { return color.RGBA{30, 35, 45, 255} })
+	// Lampu belakang dan plat nomor
+	fill(img, x+4, y+h*80/100, w/8, h/14, func(color.RGBA) color.RGBA { return color.RGBA{200, 20, 20, 255} })
+	fill(img, x+w-4-w/8, y+h*80/100, w/8, h/14, func(color.RGBA) color.RGBA { return color.RGBA{200, 20, 20, 255} })
+	fill(img, x+w/2-w/6, y+h*86/100, w/3, h/12, func(color.RGBA) color.RGBA { return color.RGBA{20, 20, 20, 255} })
+	fill(img, x+w/2-w/6+3, y+h*86/100+3, w/3-6, h/12-6, func(color.RGBA) color.RGBA { return white })
+}
+
+// finish applies the frame brightness and sensor noise
+func finish(img *image.RGBA, rng *rand.Rand, brightness float64) {
+	for i := 0; i < len(img.Pix); i += 4 {
+		n := rng.NormFloat64() * 3
+		for c := 0; c < 3; c++ {
+			img.Pix[i+c] = uint8(clamp(float64(img.Pix[i+c]) + brightness + n))
+		}
+	}
+}
+
+func fill(img *image.RGBA, x, y, w, h int, f func(color.RGBA) color.RGBA) {
+	r := image.Rect(x, y, x+w, y+h).Intersect(img.Rect)
+	for py := r.Min.Y; py < r.Max.Y; py++ {
+		for px := r.Min.X; px < r.Max.X; px++ {
+			img.SetRGBA(px, py, f(img.RGBAAt(px, py)))
+		}
+	}
+}
+
+func shade(c color.RGBA, k float64) color.RGBA {
+	return color.RGBA{uint8(clamp(float64(c.R) * k)), uint8(clamp(float64(c.G) * k)), uint8(clamp(float64(c.B) * k)), 255}
+}
+
+func clamp(v float64) float64 {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
+
+func save(path string, img image.Image) {
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := jpeg.Encode(f, img, &jpeg.Options{Quality: 85}); err != nil {
+		log.Fatal(err)
+	}
+}
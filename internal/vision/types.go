@@ -16,13 +16,14 @@ type BoundingBox struct {
 type VehicleDimensions struct {
 	LengthMeters   float64   // Vehicle length in meters
 	WidthMeters    float64   // Vehicle width in meters
-	HeightMeters   float64   // Vehicle height in meters (estimated)
+	HeightMeters   float64   // Vehicle height in meters (ratio of the length, not measured)
 	DistanceMeters float64   // Distance from camera in meters
 	CenterX        int       // Center X coordinate in image
 	CenterY        int       // Center Y coordinate in image
 	Confidence     float64   // Confidence score (0-1)
 	Timestamp      time.Time // When the measurement was taken
 	ImagePath      string    // Path to the source image
+	CalibrationID  string    // Calibration profile used ("" = config calibration)
 }
 
 // VehicleClass represents vehicle classification based on dimensions
@@ -0,0 +1,85 @@
+// Package visiontest provides helpers for trying and testing the vision detectors
+// without external services.
+package visiontest
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image"
+	"image/jpeg"
+	"net/http"
+
+	"wim-service/internal/vision"
+)
+
+// Wire format of the inference server protocol (see vision.HTTPDetector)
+type (
+	Request struct {
+		Images []Image `json:"images"`
+	}
+	Image struct {
+		ID     string `json:"id"`
+		Camera string `json:"camera"`
+		Width  int    `json:"width"`
+		Height int    `json:"height"`
+		Data   string `json:"data"`
+	}
+	Response struct {
+		Results []Result `json:"results"`
+	}
+	Result struct {
+		ID    string `json:"id"`
+		Boxes []Box  `json:"boxes"`
+		Error string `json:"error,omitempty"`
+	}
+	Box struct {
+		X      int     `json:"x"`
+		Y      int     `json:"y"`
+		Width  int     `json:"width"`
+		Height int     `json:"height"`
+		Label  string  `json:"label"`
+		Score  float64 `json:"score"`
+	}
+)
+
+// InferenceHandler serves the inference protocol with a local detector, labelling
+// every box with label. It stands in for a real inference server (see
+// cmd/detector-eval -stub).
+func InferenceHandler(d vision.VehicleDetector, label string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		resp := Response{Results: make([]Result, 0, len(req.Images))}
+		for _, im := range req.Images {
+			res := Result{ID: im.ID, Boxes: []Box{}}
+			data, err := base64.StdEncoding.DecodeString(im.Data)
+			var img image.Image
+			if err == nil {
+				img, err = jpeg.Decode(bytes.NewReader(data))
+			}
+			var boxes []vision.BoundingBox
+			if err == nil {
+				boxes, err = d.DetectCamera(img, im.Camera)
+			}
+			if err != nil {
+				res.Error = err.Error()
+			}
+			for _, b := range boxes {
+				res.Boxes = append(res.Boxes, Box{X: b.X, Y: b.Y, Width: b.Width, Height: b.Height, Label: label, Score: b.Score})
+			}
+			resp.Results = append(resp.Results, res)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	})
+}
@@ -0,0 +1,87 @@
+package watchlist
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Matcher checks ANPR captures against active watchlist entries
+type Matcher struct {
+	Service  *Service
+	Notifier *Notifier // Optional: nil disables alert delivery
+}
+
+// NewMatcher creates a new watchlist matcher
+func NewMatcher(service *Service, notifier *Notifier) *Matcher {
+	return &Matcher{
+		Service:  service,
+		Notifier: notifier,
+	}
+}
+
+// CheckCapture matches a stored ANPR capture against the watchlists, records a hit for
+// every active entry and dispatches alerts for new (or undelivered) hits. It returns
+// the number of hits recorded.
+func (m *Matcher) CheckCapture(ctx context.Context, capture Capture) (int, error) {
+	plate := NormalizePlate(capture.PlateNo)
+	if plate == "" {
+		return 0, nil
+	}
+
+	// Entries are evaluated at capture time so a late-processed file still honours expiry
+	at := capture.CapturedAt
+	if at.IsZero() {
+		at = time.Now()
+	}
+
+	matches, err := m.Service.findActiveEntries(ctx, plate, at)
+	if err != nil {
+		return 0, fmt.Errorf("find active entries: %w", err)
+	}
+
+	hits := 0
+	for _, match := range matches {
+		hitID, notify, err := m.Service.recordHit(ctx, match, capture)
+		if err != nil {
+			log.Printf("[WATCHLIST] Failed to record hit for plate %s on list %s: %v",
+				capture.PlateNo, match.Watchlist.Code, err)
+			continue
+		}
+		hits++
+
+		log.Printf("[WATCHLIST] HIT plate=%s list=%s reason=%q capture=%s",
+			capture.PlateNo, match.Watchlist.Code, match.Entry.Reason, capture.ID)
+
+		if m.Notifier != nil && notify {
+			m.Notifier.Dispatch(hitID, match.Watchlist.WebhookURL, buildAlert(hitID, match, capture))
+		}
+	}
+
+	return hits, nil
+}
+
+func buildAlert(hitID string, match activeMatch, capture Capture) Alert {
+	alert := Alert{
+		Event:         "watchlist.hit",
+		HitID:         hitID,
+		WatchlistID:   match.Watchlist.ID,
+		WatchlistCode: match.Watchlist.Code,
+		WatchlistName: match.Watchlist.ListName,
+		Agency:        match.Watchlist.Agency,
+		EntryID:       match.Entry.ID,
+		ListedPlate:   match.Entry.PlateNo,
+		Reason:        match.Entry.Reason,
+		CapturedPlate: capture.PlateNo,
+		AnprCaptureID: capture.ID,
+		SiteID:        capture.SiteID,
+		CameraID:      capture.CameraID,
+		Location:      capture.Location,
+	}
+	if !capture.CapturedAt.IsZero() {
+		t := capture.CapturedAt
+		alert.CapturedAt = &t
+	}
+	return alert
+}
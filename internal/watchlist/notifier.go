@@ -0,0 +1,121 @@
+package watchlist
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Longest a background delivery may run; a hit still PENDING after this was lost
+// (e.g. the service stopped mid-delivery)
+const deliveryTimeout = 2 * time.Minute
+
+// Notifier posts hit alerts to the configured webhooks
+type Notifier struct {
+	Service    *Service
+	URLs       []string // Global webhooks notified for every hit
+	Client     *http.Client
+	MaxRetries int
+}
+
+// NewNotifier creates a webhook notifier
+func NewNotifier(service *Service, urls []string, timeout time.Duration) *Notifier {
+	if timeout <= 0 {
+		timeout = 5 * time.Second
+	}
+	return &Notifier{
+		Service:    service,
+		URLs:       urls,
+		Client:     &http.Client{Timeout: timeout},
+		MaxRetries: 3,
+	}
+}
+
+// Dispatch delivers the alert in the background so ANPR ingestion is never blocked
+// by a slow webhook. The delivery outcome is written back to the hit row.
+func (n *Notifier) Dispatch(hitID, listWebhook string, alert Alert) {
+	targets := make([]string, 0, len(n.URLs)+1)
+	targets = append(targets, n.URLs...)
+	if listWebhook != "" {
+		targets = append(targets, listWebhook)
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), deliveryTimeout)
+		defer cancel()
+
+		status, attempts, err := n.deliver(ctx, targets, alert)
+		if err != nil {
+			log.Printf("[WATCHLIST] Alert delivery for hit %s failed: %v", hitID, err)
+		}
+
+		if err := n.Service.UpdateHitDelivery(ctx, hitID, status, attempts, err); err != nil {
+			log.Printf("[WATCHLIST] Failed to update delivery status for hit %s: %v", hitID, err)
+		}
+	}()
+}
+
+// deliver posts the alert to every target, retrying each with a linear backoff
+func (n *Notifier) deliver(ctx context.Context, targets []string, alert Alert) (string, int, error) {
+	if len(targets) == 0 {
+		return AlertNoTarget, 0, nil
+	}
+
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return AlertFailed, 0, fmt.Errorf("marshal alert: %w", err)
+	}
+
+	attempts := 0
+	var errs []error
+	for _, url := range targets {
+		var lastErr error
+		for try := 1; try <= n.MaxRetries; try++ {
+			attempts++
+			if lastErr = n.post(ctx, url, body); lastErr == nil {
+				break
+			}
+			select {
+			case <-ctx.Done():
+				lastErr = ctx.Err()
+			case <-time.After(time.Duration(try) * time.Second):
+				continue
+			}
+			break
+		}
+		if lastErr != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", url, lastErr))
+		} else {
+			log.Printf("[WATCHLIST] Alert %s delivered to %s", alert.HitID, url)
+		}
+	}
+
+	if len(errs) > 0 {
+		return AlertFailed, attempts, errors.Join(errs...)
+	}
+	return AlertDelivered, attempts, nil
+}
+
+func (n *Notifier) post(ctx context.Context, url string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
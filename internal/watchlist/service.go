@@ -0,0 +1,474 @@
+package watchlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ErrNotFound is returned when a watchlist, entry or hit does not exist
+var ErrNotFound = errors.New("watchlist: not found")
+
+// Service handles watchlist persistence
+type Service struct {
+	DB *sql.DB
+}
+
+// NewService creates a new watchlist service
+func NewService(db *sql.DB) *Service {
+	return &Service{DB: db}
+}
+
+// NormalizePlate uppercases a plate and strips everything except letters and digits,
+// so "b 1234-xyz" and "B1234XYZ" match the same entry
+func NormalizePlate(plate string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToUpper(plate) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// ===== Watchlists =====
+
+const watchlistColumns = `
+	w.id, w.code, w.list_name, COALESCE(w.agency, ''), COALESCE(w.description, ''),
+	COALESCE(w.webhook_url, ''), COALESCE(w.is_active, true),
+	(SELECT COUNT(*) FROM public.master_watchlist_entry e WHERE e.watchlist_id = w.id AND e.is_deleted = false),
+	w.created_date, w.updated_date`
+
+func scanWatchlist(row interface{ Scan(...any) error }) (*Watchlist, error) {
+	w := &Watchlist{}
+	err := row.Scan(&w.ID, &w.Code, &w.ListName, &w.Agency, &w.Description,
+		&w.WebhookURL, &w.IsActive, &w.EntryCount, &w.CreatedDate, &w.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// ListWatchlists returns all non-deleted watchlists
+func (s *Service) ListWatchlists(ctx context.Context) ([]Watchlist, error) {
+	query := `SELECT ` + watchlistColumns + `
+		FROM public.master_watchlist w
+		WHERE w.is_deleted = false
+		ORDER BY w.list_name`
+
+	rows, err := s.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("query watchlists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := []Watchlist{}
+	for rows.Next() {
+		w, err := scanWatchlist(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan watchlist: %w", err)
+		}
+		lists = append(lists, *w)
+	}
+	return lists, rows.Err()
+}
+
+// GetWatchlist returns a single watchlist by id
+func (s *Service) GetWatchlist(ctx context.Context, id string) (*Watchlist, error) {
+	query := `SELECT ` + watchlistColumns + `
+		FROM public.master_watchlist w
+		WHERE w.id = $1 AND w.is_deleted = false`
+
+	w, err := scanWatchlist(s.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query watchlist: %w", err)
+	}
+	return w, nil
+}
+
+// CreateWatchlist inserts a new watchlist
+func (s *Service) CreateWatchlist(ctx context.Context, req WatchlistRequest) (*Watchlist, error) {
+	if strings.TrimSpace(req.Code) == "" || strings.TrimSpace(req.ListName) == "" {
+		return nil, fmt.Errorf("code and list_name are required")
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	var id string
+	query := `
+		INSERT INTO public.master_watchlist (code, list_name, agency, description, webhook_url, is_active)
+		VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6)
+		RETURNING id`
+
+	err := s.DB.QueryRowContext(ctx, query,
+		strings.TrimSpace(req.Code), strings.TrimSpace(req.ListName),
+		req.Agency, req.Description, req.WebhookURL, isActive,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert watchlist: %w", err)
+	}
+
+	return s.GetWatchlist(ctx, id)
+}
+
+// UpdateWatchlist updates an existing watchlist; empty fields are left unchanged
+func (s *Service) UpdateWatchlist(ctx context.Context, id string, req WatchlistRequest) (*Watchlist, error) {
+	query := `
+		UPDATE public.master_watchlist SET
+			code = COALESCE(NULLIF($2, ''), code),
+			list_name = COALESCE(NULLIF($3, ''), list_name),
+			agency = COALESCE(NULLIF($4, ''), agency),
+			description = COALESCE(NULLIF($5, ''), description),
+			webhook_url = COALESCE(NULLIF($6, ''), webhook_url),
+			is_active = COALESCE($7, is_active),
+			updated_date = now()
+		WHERE id = $1 AND is_deleted = false`
+
+	res, err := s.DB.ExecContext(ctx, query, id,
+		strings.TrimSpace(req.Code), strings.TrimSpace(req.ListName),
+		req.Agency, req.Description, req.WebhookURL, nullBool(req.IsActive),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("update watchlist: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetWatchlist(ctx, id)
+}
+
+// DeleteWatchlist soft-deletes a watchlist together with its entries
+func (s *Service) DeleteWatchlist(ctx context.Context, id string) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`UPDATE public.master_watchlist SET is_deleted = true, updated_date = now() WHERE id = $1 AND is_deleted = false`, id)
+	if err != nil {
+		return fmt.Errorf("delete watchlist: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+
+	if _, err := tx.ExecContext(ctx,
+		`UPDATE public.master_watchlist_entry SET is_deleted = true, updated_date = now() WHERE watchlist_id = $1`, id); err != nil {
+		return fmt.Errorf("delete watchlist entries: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// ===== Entries =====
+
+const entryColumns = `
+	e.id, e.watchlist_id, e.plate_no, e.plate_normalized, COALESCE(e.reason, ''),
+	e.valid_from, e.expires_at, COALESCE(e.is_active, true), e.created_date, e.updated_date`
+
+func scanEntry(row interface{ Scan(...any) error }) (*Entry, error) {
+	e := &Entry{}
+	var expiresAt sql.NullTime
+	err := row.Scan(&e.ID, &e.WatchlistID, &e.PlateNo, &e.PlateNormalized, &e.Reason,
+		&e.ValidFrom, &expiresAt, &e.IsActive, &e.CreatedDate, &e.UpdatedDate)
+	if err != nil {
+		return nil, err
+	}
+	if expiresAt.Valid {
+		e.ExpiresAt = &expiresAt.Time
+	}
+	return e, nil
+}
+
+// ListEntries returns all non-deleted entries of a watchlist
+func (s *Service) ListEntries(ctx context.Context, watchlistID string) ([]Entry, error) {
+	query := `SELECT ` + entryColumns + `
+		FROM public.master_watchlist_entry e
+		WHERE e.watchlist_id = $1 AND e.is_deleted = false
+		ORDER BY e.plate_normalized`
+
+	rows, err := s.DB.QueryContext(ctx, query, watchlistID)
+	if err != nil {
+		return nil, fmt.Errorf("query entries: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []Entry{}
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan entry: %w", err)
+		}
+		entries = append(entries, *e)
+	}
+	return entries, rows.Err()
+}
+
+// GetEntry returns a single entry by id
+func (s *Service) GetEntry(ctx context.Context, id string) (*Entry, error) {
+	query := `SELECT ` + entryColumns + `
+		FROM public.master_watchlist_entry e
+		WHERE e.id = $1 AND e.is_deleted = false`
+
+	e, err := scanEntry(s.DB.QueryRowContext(ctx, query, id))
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("query entry: %w", err)
+	}
+	return e, nil
+}
+
+// CreateEntry adds a plate to a watchlist
+func (s *Service) CreateEntry(ctx context.Context, watchlistID string, req EntryRequest) (*Entry, error) {
+	normalized := NormalizePlate(req.PlateNo)
+	if normalized == "" {
+		return nil, fmt.Errorf("plate_no is required")
+	}
+	if req.ValidFrom != nil && req.ExpiresAt != nil && !req.ExpiresAt.After(*req.ValidFrom) {
+		return nil, fmt.Errorf("expires_at must be after valid_from")
+	}
+
+	if _, err := s.GetWatchlist(ctx, watchlistID); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	var id string
+	query := `
+		INSERT INTO public.master_watchlist_entry
+			(watchlist_id, plate_no, plate_normalized, reason, valid_from, expires_at, is_active)
+		VALUES ($1, $2, $3, NULLIF($4, ''), COALESCE($5, now()), $6, $7)
+		RETURNING id`
+
+	err := s.DB.QueryRowContext(ctx, query,
+		watchlistID, strings.TrimSpace(req.PlateNo), normalized, req.Reason,
+		nullTime(req.ValidFrom), nullTime(req.ExpiresAt), isActive,
+	).Scan(&id)
+	if err != nil {
+		return nil, fmt.Errorf("insert entry: %w", err)
+	}
+
+	return s.GetEntry(ctx, id)
+}
+
+// UpdateEntry updates an entry; empty fields are left unchanged
+func (s *Service) UpdateEntry(ctx context.Context, id string, req EntryRequest) (*Entry, error) {
+	normalized := NormalizePlate(req.PlateNo)
+
+	query := `
+		UPDATE public.master_watchlist_entry SET
+			plate_no = COALESCE(NULLIF($2, ''), plate_no),
+			plate_normalized = COALESCE(NULLIF($3, ''), plate_normalized),
+			reason = COALESCE(NULLIF($4, ''), reason),
+			valid_from = COALESCE($5, valid_from),
+			expires_at = COALESCE($6, expires_at),
+			is_active = COALESCE($7, is_active),
+			updated_date = now()
+		WHERE id = $1 AND is_deleted = false`
+
+	res, err := s.DB.ExecContext(ctx, query, id,
+		strings.TrimSpace(req.PlateNo), normalized, req.Reason,
+		nullTime(req.ValidFrom), nullTime(req.ExpiresAt), nullBool(req.IsActive),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("update entry: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return nil, ErrNotFound
+	}
+
+	return s.GetEntry(ctx, id)
+}
+
+// DeleteEntry soft-deletes an entry
+func (s *Service) DeleteEntry(ctx context.Context, id string) error {
+	res, err := s.DB.ExecContext(ctx,
+		`UPDATE public.master_watchlist_entry SET is_deleted = true, updated_date = now() WHERE id = $1 AND is_deleted = false`, id)
+	if err != nil {
+		return fmt.Errorf("delete entry: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ===== Matching & hits =====
+
+// activeMatch is an entry that is in force for a plate at a point in time
+type activeMatch struct {
+	Entry     Entry
+	Watchlist Watchlist
+}
+
+// findActiveEntries returns entries on active lists that match the plate at the given time
+func (s *Service) findActiveEntries(ctx context.Context, plateNormalized string, at time.Time) ([]activeMatch, error) {
+	query := `SELECT ` + entryColumns + `, ` + watchlistColumns + `
+		FROM public.master_watchlist_entry e
+		JOIN public.master_watchlist w ON w.id = e.watchlist_id
+		WHERE e.plate_normalized = $1
+		  AND e.is_deleted = false AND COALESCE(e.is_active, true)
+		  AND w.is_deleted = false AND COALESCE(w.is_active, true)
+		  AND e.valid_from <= $2
+		  AND (e.expires_at IS NULL OR e.expires_at > $2)`
+
+	rows, err := s.DB.QueryContext(ctx, query, plateNormalized, at)
+	if err != nil {
+		return nil, fmt.Errorf("query active entries: %w", err)
+	}
+	defer rows.Close()
+
+	var matches []activeMatch
+	for rows.Next() {
+		var m activeMatch
+		var expiresAt sql.NullTime
+		err := rows.Scan(
+			&m.Entry.ID, &m.Entry.WatchlistID, &m.Entry.PlateNo, &m.Entry.PlateNormalized, &m.Entry.Reason,
+			&m.Entry.ValidFrom, &expiresAt, &m.Entry.IsActive, &m.Entry.CreatedDate, &m.Entry.UpdatedDate,
+			&m.Watchlist.ID, &m.Watchlist.Code, &m.Watchlist.ListName, &m.Watchlist.Agency, &m.Watchlist.Description,
+			&m.Watchlist.WebhookURL, &m.Watchlist.IsActive, &m.Watchlist.EntryCount, &m.Watchlist.CreatedDate, &m.Watchlist.UpdatedDate,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("scan active entry: %w", err)
+		}
+		if expiresAt.Valid {
+			m.Entry.ExpiresAt = &expiresAt.Time
+		}
+		matches = append(matches, m)
+	}
+	return matches, rows.Err()
+}
+
+// recordHit inserts a hit; re-processing the same capture returns the existing hit id.
+// notify is true for a new hit and for an existing hit whose alert was never delivered
+// (FAILED, or PENDING longer than a delivery can take), so re-ingest does not alert twice.
+func (s *Service) recordHit(ctx context.Context, m activeMatch, capture Capture) (id string, notify bool, err error) {
+	var capturedAt sql.NullTime
+	if !capture.CapturedAt.IsZero() {
+		capturedAt = sql.NullTime{Time: capture.CapturedAt, Valid: true}
+	}
+
+	query := `
+		INSERT INTO public.transact_watchlist_hit
+			(watchlist_id, entry_id, anpr_capture_id, site_id, plate_no, captured_at, camera_id)
+		VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, NULLIF($7, ''))
+		ON CONFLICT (anpr_capture_id, entry_id) DO UPDATE SET updated_date = now()
+		RETURNING id,
+		          xmax = 0
+		          OR alert_status = $8
+		          OR (alert_status = $9 AND created_date < now() - make_interval(secs => $10))`
+
+	err = s.DB.QueryRowContext(ctx, query,
+		m.Watchlist.ID, m.Entry.ID, capture.ID, capture.SiteID,
+		capture.PlateNo, capturedAt, capture.CameraID,
+		AlertFailed, AlertPending, deliveryTimeout.Seconds(),
+	).Scan(&id, &notify)
+	if err != nil {
+		return "", false, fmt.Errorf("insert hit: %w", err)
+	}
+	return id, notify, nil
+}
+
+// UpdateHitDelivery records the outcome of an alert delivery attempt
+func (s *Service) UpdateHitDelivery(ctx context.Context, hitID, status string, attempts int, deliveryErr error) error {
+	var errText sql.NullString
+	if deliveryErr != nil {
+		errText = sql.NullString{String: deliveryErr.Error(), Valid: true}
+	}
+
+	query := `
+		UPDATE public.transact_watchlist_hit SET
+			alert_status = $2,
+			alert_attempts = alert_attempts + $3,
+			alert_error = $4,
+			alert_delivered_at = CASE WHEN $2 = 'DELIVERED' THEN now() ELSE alert_delivered_at END,
+			updated_date = now()
+		WHERE id = $1`
+
+	if _, err := s.DB.ExecContext(ctx, query, hitID, status, attempts, errText); err != nil {
+		return fmt.Errorf("update hit delivery: %w", err)
+	}
+	return nil
+}
+
+// ListHits returns the most recent hits matching the filter
+func (s *Service) ListHits(ctx context.Context, filter HitFilter) ([]Hit, error) {
+	if filter.Limit <= 0 || filter.Limit > 500 {
+		filter.Limit = 100
+	}
+
+	query := `
+		SELECT h.id, h.watchlist_id, w.code, h.entry_id, h.anpr_capture_id,
+		       COALESCE(h.site_id::text, ''), h.plate_no, COALESCE(e.reason, ''),
+		       h.captured_at, COALESCE(h.camera_id, ''),
+		       h.alert_status, h.alert_attempts, COALESCE(h.alert_error, ''),
+		       h.alert_delivered_at, h.created_date
+		FROM public.transact_watchlist_hit h
+		JOIN public.master_watchlist w ON w.id = h.watchlist_id
+		JOIN public.master_watchlist_entry e ON e.id = h.entry_id
+		WHERE h.is_deleted = false
+		  AND ($1 = '' OR h.watchlist_id::text = $1)
+		  AND ($2 = '' OR h.plate_no ILIKE '%' || $2 || '%')
+		  AND ($3 = '' OR h.alert_status = $3)
+		ORDER BY h.created_date DESC
+		LIMIT $4`
+
+	rows, err := s.DB.QueryContext(ctx, query, filter.WatchlistID, filter.PlateNo, filter.AlertStatus, filter.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("query hits: %w", err)
+	}
+	defer rows.Close()
+
+	hits := []Hit{}
+	for rows.Next() {
+		var h Hit
+		var capturedAt, deliveredAt sql.NullTime
+		err := rows.Scan(&h.ID, &h.WatchlistID, &h.WatchlistCode, &h.EntryID, &h.AnprCaptureID,
+			&h.SiteID, &h.PlateNo, &h.Reason, &capturedAt, &h.CameraID,
+			&h.AlertStatus, &h.AlertAttempts, &h.AlertError, &deliveredAt, &h.CreatedDate)
+		if err != nil {
+			return nil, fmt.Errorf("scan hit: %w", err)
+		}
+		if capturedAt.Valid {
+			h.CapturedAt = &capturedAt.Time
+		}
+		if deliveredAt.Valid {
+			h.AlertDeliveredAt = &deliveredAt.Time
+		}
+		hits = append(hits, h)
+	}
+	return hits, rows.Err()
+}
+
+func nullBool(b *bool) sql.NullBool {
+	if b == nil {
+		return sql.NullBool{}
+	}
+	return sql.NullBool{Bool: *b, Valid: true}
+}
+
+func nullTime(t *time.Time) sql.NullTime {
+	if t == nil {
+		return sql.NullTime{}
+	}
+	return sql.NullTime{Time: *t, Valid: true}
+}
@@ -0,0 +1,114 @@
+package watchlist
+
+import "time"
+
+// Alert delivery status stored in transact_watchlist_hit.alert_status
+const (
+	AlertPending   = "PENDING"
+	AlertDelivered = "DELIVERED"
+	AlertFailed    = "FAILED"
+	AlertNoTarget  = "NO_TARGET"
+)
+
+// Watchlist is a plate list supplied by a partner agency
+type Watchlist struct {
+	ID          string    `json:"id"`
+	Code        string    `json:"code"`
+	ListName    string    `json:"list_name"`
+	Agency      string    `json:"agency"`
+	Description string    `json:"description"`
+	WebhookURL  string    `json:"webhook_url"`
+	IsActive    bool      `json:"is_active"`
+	EntryCount  int       `json:"entry_count"`
+	CreatedDate time.Time `json:"created_date"`
+	UpdatedDate time.Time `json:"updated_date"`
+}
+
+// Entry is a single plate on a watchlist
+type Entry struct {
+	ID              string     `json:"id"`
+	WatchlistID     string     `json:"watchlist_id"`
+	PlateNo         string     `json:"plate_no"`
+	PlateNormalized string     `json:"plate_normalized"`
+	Reason          string     `json:"reason"`
+	ValidFrom       time.Time  `json:"valid_from"`
+	ExpiresAt       *time.Time `json:"expires_at"`
+	IsActive        bool       `json:"is_active"`
+	CreatedDate     time.Time  `json:"created_date"`
+	UpdatedDate     time.Time  `json:"updated_date"`
+}
+
+// Hit is an ANPR capture that matched an active entry
+type Hit struct {
+	ID               string     `json:"id"`
+	WatchlistID      string     `json:"watchlist_id"`
+	WatchlistCode    string     `json:"watchlist_code"`
+	EntryID          string     `json:"entry_id"`
+	AnprCaptureID    string     `json:"anpr_capture_id"`
+	SiteID           string     `json:"site_id"`
+	PlateNo          string     `json:"plate_no"`
+	Reason           string     `json:"reason"`
+	CapturedAt       *time.Time `json:"captured_at"`
+	CameraID         string     `json:"camera_id"`
+	AlertStatus      string     `json:"alert_status"`
+	AlertAttempts    int        `json:"alert_attempts"`
+	AlertError       string     `json:"alert_error,omitempty"`
+	AlertDeliveredAt *time.Time `json:"alert_delivered_at"`
+	CreatedDate      time.Time  `json:"created_date"`
+}
+
+// Capture is the subset of an ANPR capture needed for matching
+type Capture struct {
+	ID         string // transact_anpr_capture.id
+	SiteID     string
+	PlateNo    string
+	CapturedAt time.Time
+	CameraID   string
+	Location   string
+}
+
+// WatchlistRequest is the body for creating/updating a watchlist
+type WatchlistRequest struct {
+	Code        string `json:"code"`
+	ListName    string `json:"list_name"`
+	Agency      string `json:"agency"`
+	Description string `json:"description"`
+	WebhookURL  string `json:"webhook_url"`
+	IsActive    *bool  `json:"is_active"`
+}
+
+// EntryRequest is the body for creating/updating a watchlist entry
+type EntryRequest struct {
+	PlateNo   string     `json:"plate_no"`
+	Reason    string     `json:"reason"`
+	ValidFrom *time.Time `json:"valid_from"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	IsActive  *bool      `json:"is_active"`
+}
+
+// HitFilter narrows the hit listing
+type HitFilter struct {
+	WatchlistID string
+	PlateNo     string
+	AlertStatus string
+	Limit       int
+}
+
+// Alert is the JSON payload posted to webhooks
+type Alert struct {
+	Event         string     `json:"event"`
+	HitID         string     `json:"hit_id"`
+	WatchlistID   string     `json:"watchlist_id"`
+	WatchlistCode string     `json:"watchlist_code"`
+	WatchlistName string     `json:"watchlist_name"`
+	Agency        string     `json:"agency"`
+	EntryID       string     `json:"entry_id"`
+	ListedPlate   string     `json:"listed_plate"`
+	Reason        string     `json:"reason"`
+	CapturedPlate string     `json:"captured_plate"`
+	AnprCaptureID string     `json:"anpr_capture_id"`
+	SiteID        string     `json:"site_id"`
+	CameraID      string     `json:"camera_id"`
+	Location      string     `json:"location"`
+	CapturedAt    *time.Time `json:"captured_at"`
+}
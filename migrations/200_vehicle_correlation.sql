@@ -0,0 +1,58 @@
+-- Korelasi ANPR ↔ axle: satu row per kendaraan di transact_vehicle.
+-- Dicocokkan per site dengan selisih waktu terkecil di dalam jendela waktu (CORRELATION_WINDOW_SEC).
+-- Run: psql -d wim_db -f migrations/200_vehicle_correlation.sql
+
+-- public.transact_vehicle definition
+
+CREATE TABLE IF NOT EXISTS public.transact_vehicle (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	site_id uuid NOT NULL,
+	anpr_id uuid NULL,
+	axle_id uuid NULL,
+	plate_no varchar(32) NOT NULL DEFAULT 'UNKNOWN', -- Dari ANPR saja; 'UNKNOWN' untuk AXLE_ONLY
+	correlation_status varchar(20) NOT NULL, -- MATCHED / ANPR_ONLY / AXLE_ONLY
+	anpr_captured_at timestamptz NULL,
+	axle_captured_at timestamptz NULL,
+	first_detected_at timestamptz NULL, -- Waktu capture paling awal dari kedua sisi
+	time_diff_seconds numeric(10, 3) NULL, -- |axle - anpr|, hanya untuk MATCHED
+	toll_golongan int2 NULL, -- Golongan tarif tol dari axle capture
+	correlated_at timestamptz NULL DEFAULT now(),
+	created_date timestamptz NULL DEFAULT now(),
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT transact_vehicle_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_vehicle_anpr UNIQUE (anpr_id),
+	CONSTRAINT uq_vehicle_axle UNIQUE (axle_id),
+	CONSTRAINT transact_vehicle_status_check CHECK (((correlation_status)::text = ANY (ARRAY['MATCHED'::text, 'ANPR_ONLY'::text, 'AXLE_ONLY'::text]))),
+	CONSTRAINT ck_vehicle_sides CHECK (
+		(((correlation_status)::text = 'MATCHED'::text) AND (anpr_id IS NOT NULL) AND (axle_id IS NOT NULL)) OR
+		(((correlation_status)::text = 'ANPR_ONLY'::text) AND (anpr_id IS NOT NULL) AND (axle_id IS NULL)) OR
+		(((correlation_status)::text = 'AXLE_ONLY'::text) AND (anpr_id IS NULL) AND (axle_id IS NOT NULL))),
+	CONSTRAINT fk_vehicle_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+	CONSTRAINT fk_vehicle_anpr FOREIGN KEY (anpr_id) REFERENCES public.transact_anpr_capture(id) ON DELETE CASCADE,
+	CONSTRAINT fk_vehicle_axle FOREIGN KEY (axle_id) REFERENCES public.transact_axle_capture(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_vehicle_status ON public.transact_vehicle USING btree (correlation_status);
+CREATE INDEX IF NOT EXISTS idx_vehicle_site ON public.transact_vehicle USING btree (site_id, first_detected_at);
+CREATE INDEX IF NOT EXISTS idx_vehicle_plate ON public.transact_vehicle USING btree (plate_no);
+
+COMMENT ON TABLE public.transact_vehicle IS 'One row per vehicle: ANPR and axle captures correlated by site and nearest capture time';
+
+-- Index untuk pencarian kandidat per site dan waktu
+CREATE INDEX IF NOT EXISTS idx_anpr_site_captured ON public.transact_anpr_capture USING btree (site_id, captured_at);
+CREATE INDEX IF NOT EXISTS idx_axle_site_captured ON public.transact_axle_capture USING btree (site_id, captured_at);
+
+-- public.view_vehicle_complete definition
+
+CREATE OR REPLACE VIEW public.view_vehicle_complete AS
+SELECT v.id, v.correlation_status, v.plate_no, v.first_detected_at, v.time_diff_seconds,
+       v.toll_golongan, v.correlated_at,
+       ms.id AS site_id, ms.code AS site_code, ms.site_name,
+       an.id AS anpr_id, an.captured_at AS anpr_captured_at, an.confidence AS anpr_confidence,
+       an.camera_id AS anpr_camera_id, an.minio_full_image_object, an.minio_plate_image_object,
+       ax.id AS axle_id, ax.captured_at AS axle_captured_at, ax.camera_id AS axle_camera_id,
+       ax.length_mm, ax.total_axles, ax.total_wheels,
+       ax.vehicle_category, ax.vehicle_body_type, ax.minio_image_object AS axle_image_object
+FROM public.transact_vehicle v
+JOIN public.master_site ms ON ms.id = v.site_id
+LEFT JOIN public.transact_anpr_capture an ON an.id = v.anpr_id
+LEFT JOIN public.transact_axle_capture ax ON ax.id = v.axle_id;
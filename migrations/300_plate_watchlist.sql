@@ -0,0 +1,83 @@
+-- Plate watchlist: daftar plat dicari/curian dari instansi mitra
+-- Run: psql -d wim_db -f migrations/300_plate_watchlist.sql
+
+-- public.master_watchlist definition
+
+CREATE TABLE IF NOT EXISTS public.master_watchlist (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	code varchar(50) NOT NULL, -- Unique list code (e.g., POLDA-STOLEN)
+	list_name varchar(150) NOT NULL,
+	agency varchar(150) NULL, -- Partner agency that owns the list
+	description text NULL,
+	webhook_url text NULL, -- Optional per-list alert endpoint (in addition to WATCHLIST_WEBHOOK_URLS)
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by uuid NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_by uuid NULL,
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_watchlist_code_key UNIQUE (code),
+	CONSTRAINT master_watchlist_pkey PRIMARY KEY (id)
+);
+CREATE INDEX IF NOT EXISTS idx_master_watchlist_active ON public.master_watchlist USING btree (is_active) WHERE (is_deleted = false);
+
+COMMENT ON TABLE public.master_watchlist IS 'Plate watchlists supplied by partner agencies';
+
+
+-- public.master_watchlist_entry definition
+
+CREATE TABLE IF NOT EXISTS public.master_watchlist_entry (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	watchlist_id uuid NOT NULL,
+	plate_no varchar(32) NOT NULL, -- Plate as supplied by the agency
+	plate_normalized varchar(32) NOT NULL, -- Uppercase, spaces/punctuation removed (used for matching)
+	reason text NULL, -- Why the plate is listed (stolen, wanted, tax arrears, ...)
+	valid_from timestamptz NOT NULL DEFAULT now(),
+	expires_at timestamptz NULL, -- NULL = never expires
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by uuid NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_by uuid NULL,
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_watchlist_entry_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_watchlist_entry_list FOREIGN KEY (watchlist_id) REFERENCES public.master_watchlist(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_watchlist_entry_list ON public.master_watchlist_entry USING btree (watchlist_id);
+CREATE INDEX IF NOT EXISTS idx_watchlist_entry_plate ON public.master_watchlist_entry USING btree (plate_normalized) WHERE (is_deleted = false AND is_active = true);
+
+
+-- public.transact_watchlist_hit definition
+
+CREATE TABLE IF NOT EXISTS public.transact_watchlist_hit (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	watchlist_id uuid NOT NULL,
+	entry_id uuid NOT NULL,
+	anpr_capture_id uuid NOT NULL,
+	site_id uuid NULL,
+	plate_no varchar(32) NOT NULL, -- Plate as read by the camera
+	captured_at timestamptz NULL,
+	camera_id varchar(100) NULL,
+	alert_status varchar(20) NOT NULL DEFAULT 'PENDING', -- PENDING / DELIVERED / FAILED / NO_TARGET
+	alert_attempts int4 NOT NULL DEFAULT 0,
+	alert_error text NULL,
+	alert_delivered_at timestamptz NULL,
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by uuid NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_by uuid NULL,
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT transact_watchlist_hit_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_watchlist_hit_capture_entry UNIQUE (anpr_capture_id, entry_id),
+	CONSTRAINT transact_watchlist_hit_status_check CHECK (((alert_status)::text = ANY (ARRAY['PENDING'::text, 'DELIVERED'::text, 'FAILED'::text, 'NO_TARGET'::text]))),
+	CONSTRAINT fk_watchlist_hit_list FOREIGN KEY (watchlist_id) REFERENCES public.master_watchlist(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT fk_watchlist_hit_entry FOREIGN KEY (entry_id) REFERENCES public.master_watchlist_entry(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT fk_watchlist_hit_anpr FOREIGN KEY (anpr_capture_id) REFERENCES public.transact_anpr_capture(id) ON DELETE CASCADE ON UPDATE CASCADE,
+	CONSTRAINT fk_watchlist_hit_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_watchlist_hit_list ON public.transact_watchlist_hit USING btree (watchlist_id);
+CREATE INDEX IF NOT EXISTS idx_watchlist_hit_captured ON public.transact_watchlist_hit USING btree (captured_at DESC);
+CREATE INDEX IF NOT EXISTS idx_watchlist_hit_status ON public.transact_watchlist_hit USING btree (alert_status) WHERE (alert_status <> 'DELIVERED');
+
+COMMENT ON TABLE public.transact_watchlist_hit IS 'ANPR captures that matched an active watchlist entry';
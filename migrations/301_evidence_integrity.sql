@@ -0,0 +1,41 @@
+-- Evidence integrity: SHA-256 digest setiap object yang disalin dari FTP ke MinIO
+-- dan audit trail akses evidence (chain-of-custody)
+-- Run: psql -d wim_db -f migrations/301_evidence_integrity.sql
+
+ALTER TABLE public.transact_anpr_capture
+	ADD COLUMN IF NOT EXISTS xml_sha256 char(64) NULL, -- SHA-256 (hex) of minio_xml_object
+	ADD COLUMN IF NOT EXISTS full_image_sha256 char(64) NULL, -- SHA-256 (hex) of minio_full_image_object
+	ADD COLUMN IF NOT EXISTS plate_image_sha256 char(64) NULL, -- SHA-256 (hex) of minio_plate_image_object
+	ADD COLUMN IF NOT EXISTS evidence_hashed_at timestamptz NULL;
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS xml_sha256 char(64) NULL, -- SHA-256 (hex) of minio_xml_object
+	ADD COLUMN IF NOT EXISTS image_sha256 char(64) NULL, -- SHA-256 (hex) of minio_image_object
+	ADD COLUMN IF NOT EXISTS evidence_hashed_at timestamptz NULL;
+
+COMMENT ON COLUMN public.transact_anpr_capture.xml_sha256 IS 'SHA-256 (hex) computed while streaming the XML from FTP to MinIO';
+COMMENT ON COLUMN public.transact_axle_capture.xml_sha256 IS 'SHA-256 (hex) computed while streaming the XML from FTP to MinIO';
+
+
+-- public.transact_evidence_access definition
+
+CREATE TABLE IF NOT EXISTS public.transact_evidence_access (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	capture_type varchar(10) NOT NULL, -- ANPR / AXLE
+	capture_id uuid NOT NULL,
+	object_kind varchar(20) NULL, -- xml / full_image / plate_image / image (NULL = whole capture)
+	action varchar(20) NOT NULL, -- VIEW / DOWNLOAD / VERIFY
+	result varchar(20) NULL, -- VERIFIED / MISMATCH / MISSING / NO_DIGEST (VERIFY only)
+	user_id int4 NULL, -- users.id from the JWT
+	username varchar(100) NULL,
+	ip_address varchar(100) NULL,
+	user_agent text NULL,
+	accessed_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT transact_evidence_access_pkey PRIMARY KEY (id),
+	CONSTRAINT transact_evidence_access_type_check CHECK (((capture_type)::text = ANY (ARRAY['ANPR'::text, 'AXLE'::text]))),
+	CONSTRAINT transact_evidence_access_action_check CHECK (((action)::text = ANY (ARRAY['VIEW'::text, 'DOWNLOAD'::text, 'VERIFY'::text])))
+);
+CREATE INDEX IF NOT EXISTS idx_evidence_access_capture ON public.transact_evidence_access USING btree (capture_type, capture_id, accessed_at DESC);
+CREATE INDEX IF NOT EXISTS idx_evidence_access_user ON public.transact_evidence_access USING btree (username, accessed_at DESC);
+
+COMMENT ON TABLE public.transact_evidence_access IS 'Chain-of-custody: every API access to capture evidence (append-only)';
@@ -0,0 +1,56 @@
+-- Signed evidence bundles: zip (XML, images, record.json, manifest) ditandatangani
+-- dengan Ed25519 key per site
+-- Run: psql -d wim_db -f migrations/302_evidence_bundle.sql
+
+-- public.master_site_signing_key definition
+
+CREATE TABLE IF NOT EXISTS public.master_site_signing_key (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	site_id uuid NULL,
+	key_id varchar(32) NOT NULL, -- First 16 hex chars of SHA-256(public key)
+	algorithm varchar(20) NOT NULL DEFAULT 'Ed25519',
+	public_key_pem text NOT NULL, -- PKIX PEM, published for offline verification
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by uuid NULL,
+	created_date timestamptz NOT NULL DEFAULT now(),
+	updated_by uuid NULL,
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_site_signing_key_pkey PRIMARY KEY (id),
+	CONSTRAINT master_site_signing_key_key_id_key UNIQUE (key_id),
+	CONSTRAINT fk_signing_key_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_signing_key_site ON public.master_site_signing_key USING btree (site_id);
+
+COMMENT ON TABLE public.master_site_signing_key IS 'Ed25519 public keys used to verify evidence bundles per site';
+
+
+-- public.transact_evidence_bundle definition
+
+CREATE TABLE IF NOT EXISTS public.transact_evidence_bundle (
+	id uuid NOT NULL, -- Same as manifest bundle_id
+	subject_type varchar(20) NOT NULL, -- ANPR / AXLE
+	subject_id uuid NOT NULL,
+	site_id uuid NULL,
+	minio_bucket varchar(100) NOT NULL,
+	minio_object text NOT NULL,
+	bundle_sha256 char(64) NOT NULL, -- SHA-256 of the zip itself
+	size_bytes int8 NOT NULL,
+	key_id varchar(32) NOT NULL,
+	signature text NOT NULL, -- Base64 Ed25519 signature of manifest.json
+	created_by_username varchar(100) NULL,
+	created_date timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT transact_evidence_bundle_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_evidence_bundle_key FOREIGN KEY (key_id) REFERENCES public.master_site_signing_key(key_id) ON DELETE RESTRICT ON UPDATE CASCADE,
+	CONSTRAINT fk_evidence_bundle_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_evidence_bundle_subject ON public.transact_evidence_bundle USING btree (subject_type, subject_id, created_date DESC);
+
+COMMENT ON TABLE public.transact_evidence_bundle IS 'Signed, tamper-evident evidence packages stored in MinIO';
+
+
+-- Pembuatan bundle dicatat di chain-of-custody sebagai GENERATE (bukan DOWNLOAD)
+
+ALTER TABLE public.transact_evidence_access DROP CONSTRAINT IF EXISTS transact_evidence_access_action_check;
+ALTER TABLE public.transact_evidence_access ADD CONSTRAINT transact_evidence_access_action_check
+	CHECK (((action)::text = ANY (ARRAY['VIEW'::text, 'DOWNLOAD'::text, 'VERIFY'::text, 'GENERATE'::text])));
@@ -0,0 +1,27 @@
+-- Atomic capture ingestion: object MinIO yang tertinggal (orphan) dan row DB yang
+-- object-nya hilang dicatat di sini oleh rollback ingestion dan job reconciliation
+-- Run: psql -d wim_db -f migrations/303_ingest_reconciliation.sql
+
+-- public.transact_orphan_object definition
+
+CREATE TABLE IF NOT EXISTS public.transact_orphan_object (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	source varchar(10) NOT NULL, -- ANPR / AXLE
+	minio_bucket varchar(100) NOT NULL,
+	minio_object text NOT NULL,
+	external_id varchar(100) NULL, -- Capture external_id when known (rollback)
+	capture_id uuid NULL, -- Capture row that references a missing object (MISSING_OBJECT)
+	reason varchar(20) NOT NULL, -- INGEST_ROLLBACK / NO_DB_ROW / MISSING_OBJECT
+	status varchar(20) NOT NULL DEFAULT 'ORPHANED', -- ORPHANED / REMOVED / RESOLVED
+	last_error text NULL,
+	detected_at timestamptz NOT NULL DEFAULT now(),
+	last_seen_at timestamptz NOT NULL DEFAULT now(),
+	resolved_at timestamptz NULL,
+	CONSTRAINT transact_orphan_object_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_orphan_object UNIQUE (minio_bucket, minio_object, reason),
+	CONSTRAINT transact_orphan_object_reason_check CHECK (((reason)::text = ANY (ARRAY['INGEST_ROLLBACK'::text, 'NO_DB_ROW'::text, 'MISSING_OBJECT'::text]))),
+	CONSTRAINT transact_orphan_object_status_check CHECK (((status)::text = ANY (ARRAY['ORPHANED'::text, 'REMOVED'::text, 'RESOLVED'::text])))
+);
+CREATE INDEX IF NOT EXISTS idx_orphan_object_status ON public.transact_orphan_object USING btree (status, source) WHERE ((status)::text = 'ORPHANED'::text);
+
+COMMENT ON TABLE public.transact_orphan_object IS 'MinIO objects without a capture row, and capture rows whose objects are missing';
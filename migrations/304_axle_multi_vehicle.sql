@@ -0,0 +1,18 @@
+-- Multi-vehicle VAC: satu event axle bisa berisi beberapa vehicleN (mis. truk beriringan).
+-- Setiap kendaraan disimpan sebagai row transact_axle_capture sendiri dengan vehicle_index.
+-- Run: psql -d wim_db -f migrations/304_axle_multi_vehicle.sql
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS vehicle_index int4 NOT NULL DEFAULT 0, -- N dari elemen vac/vehicleN
+	ADD COLUMN IF NOT EXISTS vehicle_count int4 NOT NULL DEFAULT 1; -- Jumlah kendaraan dalam event
+
+-- external_id (ID event) tidak lagi unik per row, tapi per (event, kendaraan)
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS transact_axle_capture_external_id_key;
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS uq_axle_capture_vehicle;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT uq_axle_capture_vehicle UNIQUE (external_id, vehicle_index);
+
+COMMENT ON COLUMN public.transact_axle_capture.vehicle_index IS 'Index N of the vac/vehicleN element within the sensor event';
+COMMENT ON COLUMN public.transact_axle_capture.vehicle_count IS 'Number of vehicles reported in the same sensor event';
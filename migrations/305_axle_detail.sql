@@ -0,0 +1,25 @@
+-- Per-axle detail dari WIM: beban, jarak antar sumbu, konfigurasi ban dan grup sumbu
+-- (tandem/tridem), untuk penegakan muatan lebih per sumbu
+-- Run: psql -d wim_db -f migrations/305_axle_detail.sql
+
+-- public.transact_axle_detail definition
+
+CREATE TABLE IF NOT EXISTS public.transact_axle_detail (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	axle_capture_id uuid NOT NULL,
+	axle_no int4 NOT NULL, -- 1 = sumbu paling depan
+	weight_kg int4 NULL, -- Beban sumbu
+	spacing_mm int4 NULL, -- Jarak ke sumbu sebelumnya (NULL untuk sumbu pertama)
+	wheel_count int4 NULL,
+	tyre_config varchar(10) NULL, -- SINGLE / DUAL
+	group_no int4 NOT NULL,
+	group_type varchar(10) NOT NULL, -- SINGLE / TANDEM / TRIDEM / MULTI
+	created_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT transact_axle_detail_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_axle_detail_axle UNIQUE (axle_capture_id, axle_no),
+	CONSTRAINT transact_axle_detail_tyre_check CHECK (((tyre_config)::text = ANY (ARRAY['SINGLE'::text, 'DUAL'::text]))),
+	CONSTRAINT transact_axle_detail_group_check CHECK (((group_type)::text = ANY (ARRAY['SINGLE'::text, 'TANDEM'::text, 'TRIDEM'::text, 'MULTI'::text]))),
+	CONSTRAINT fk_axle_detail_capture FOREIGN KEY (axle_capture_id) REFERENCES public.transact_axle_capture(id) ON DELETE CASCADE
+);
+
+COMMENT ON TABLE public.transact_axle_detail IS 'Per-axle load, spacing, tyre configuration and axle group of an axle capture';
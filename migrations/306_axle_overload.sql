@@ -0,0 +1,49 @@
+-- Gross weight & overload: setiap axle capture dibandingkan dengan JBI (berat yang
+-- diizinkan) dari master_vehicle_class sesuai kelas jalan site
+-- Run: psql -d wim_db -f migrations/306_axle_overload.sql
+
+-- Kelas jalan site menentukan kolom batas berat (class_2_weight / class_3_weight)
+ALTER TABLE public.master_site
+	ADD COLUMN IF NOT EXISTS road_class int2 NOT NULL DEFAULT 2;
+ALTER TABLE public.master_site
+	DROP CONSTRAINT IF EXISTS ck_master_site_road_class;
+ALTER TABLE public.master_site
+	ADD CONSTRAINT ck_master_site_road_class CHECK ((road_class = ANY (ARRAY[2, 3])));
+
+COMMENT ON COLUMN public.master_site.road_class IS 'Road class at the site (2 or 3); selects master_vehicle_class.class_2_weight or class_3_weight';
+COMMENT ON COLUMN public.master_vehicle_class.class_2_weight IS 'Permitted gross weight on class II roads, in weight_unit';
+COMMENT ON COLUMN public.master_vehicle_class.class_3_weight IS 'Permitted gross weight on class III roads, in weight_unit';
+
+-- Satuan class_2_weight / class_3_weight tidak ditetapkan oleh data master, jadi
+-- harus diisi eksplisit per kelas ('kg' atau 't'). NULL = belum diisi, overload UNKNOWN.
+ALTER TABLE public.master_vehicle_class
+	ADD COLUMN IF NOT EXISTS weight_unit varchar(2) NULL;
+ALTER TABLE public.master_vehicle_class
+	DROP CONSTRAINT IF EXISTS ck_vehicle_class_weight_unit;
+ALTER TABLE public.master_vehicle_class
+	ADD CONSTRAINT ck_vehicle_class_weight_unit CHECK (((weight_unit)::text = ANY (ARRAY['kg'::text, 't'::text])));
+
+COMMENT ON COLUMN public.master_vehicle_class.weight_unit IS 'Unit of class_2_weight / class_3_weight: kg or t (tonnes); NULL = not set, overload is not evaluated';
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS gross_weight_kg int4 NULL, -- Berat total (sensor atau jumlah beban sumbu)
+	ADD COLUMN IF NOT EXISTS vehicle_class_id uuid NULL,
+	ADD COLUMN IF NOT EXISTS road_class int2 NULL,
+	ADD COLUMN IF NOT EXISTS permitted_weight_kg numeric(10, 2) NULL,
+	ADD COLUMN IF NOT EXISTS overload_kg numeric(10, 2) NULL,
+	ADD COLUMN IF NOT EXISTS overload_pct numeric(7, 2) NULL, -- (gross - permitted) / permitted * 100
+	ADD COLUMN IF NOT EXISTS overload_status varchar(20) NULL, -- LEGAL / WITHIN_TOLERANCE / OVERLOADED / UNKNOWN
+	ADD COLUMN IF NOT EXISTS overload_evaluated_at timestamptz NULL;
+
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS fk_axle_vehicle_class;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT fk_axle_vehicle_class FOREIGN KEY (vehicle_class_id) REFERENCES public.master_vehicle_class(id) ON DELETE SET NULL;
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS transact_axle_capture_overload_status_check;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT transact_axle_capture_overload_status_check CHECK (((overload_status)::text = ANY (ARRAY['LEGAL'::text, 'WITHIN_TOLERANCE'::text, 'OVERLOADED'::text, 'UNKNOWN'::text])));
+
+CREATE INDEX IF NOT EXISTS idx_axle_overload_status ON public.transact_axle_capture USING btree (overload_status, captured_at);
+
+COMMENT ON COLUMN public.transact_axle_capture.overload_status IS 'LEGAL / WITHIN_TOLERANCE / OVERLOADED, UNKNOWN when class or weight is missing';
@@ -0,0 +1,59 @@
+-- Vehicle class resolution: aturan pencocokan axle capture ke master_vehicle_class
+-- disimpan di database. Capture yang tidak cocok ditandai untuk review.
+-- Run: psql -d wim_db -f migrations/307_vehicle_class_rules.sql
+
+-- public.master_vehicle_class_rule definition
+
+CREATE TABLE IF NOT EXISTS public.master_vehicle_class_rule (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	vehicle_class_id uuid NOT NULL,
+	rule_name varchar(150) NOT NULL,
+	priority int4 NOT NULL DEFAULT 100, -- Angka kecil menang jika confidence sama
+	min_axles int4 NULL, -- Wajib terpenuhi (NULL = tanpa batas)
+	max_axles int4 NULL,
+	min_length_mm int4 NULL, -- Kriteria lunak: menurunkan confidence jika tidak cocok
+	max_length_mm int4 NULL,
+	body_types text NULL, -- Daftar body_type dipisah koma (case-insensitive)
+	categories text NULL, -- Daftar vehicle_category dipisah koma (case-insensitive)
+	base_confidence numeric(4, 3) NOT NULL DEFAULT 1.000,
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by uuid NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_by uuid NULL,
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_vehicle_class_rule_pkey PRIMARY KEY (id),
+	CONSTRAINT ck_class_rule_confidence CHECK (((base_confidence > (0)::numeric) AND (base_confidence <= (1)::numeric))),
+	CONSTRAINT fk_class_rule_class FOREIGN KEY (vehicle_class_id) REFERENCES public.master_vehicle_class(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_class_rule_active ON public.master_vehicle_class_rule USING btree (is_active) WHERE (is_deleted = false);
+
+COMMENT ON TABLE public.master_vehicle_class_rule IS 'Rules resolving axle captures (axle count, length, body type) to master_vehicle_class';
+
+-- Aturan awal: satu aturan per kelas berdasarkan jumlah sumbu dan panjang maksimum kelas
+INSERT INTO public.master_vehicle_class_rule
+	(vehicle_class_id, rule_name, priority, min_axles, max_axles, max_length_mm, base_confidence)
+SELECT vc.id, vc.type || ' (' || vc.total_axle || ' axles)', 100, vc.total_axle, vc.total_axle,
+       (vc.length * 1000)::int4, 0.900
+FROM public.master_vehicle_class vc
+WHERE vc.is_deleted = false
+  AND NOT EXISTS (SELECT 1 FROM public.master_vehicle_class_rule r WHERE r.vehicle_class_id = vc.id);
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS class_rule_id uuid NULL,
+	ADD COLUMN IF NOT EXISTS class_confidence numeric(4, 3) NULL,
+	ADD COLUMN IF NOT EXISTS class_status varchar(20) NULL, -- MATCHED / LOW_CONFIDENCE / UNMATCHED / MANUAL
+	ADD COLUMN IF NOT EXISTS class_review_required bool NOT NULL DEFAULT false,
+	ADD COLUMN IF NOT EXISTS class_resolved_at timestamptz NULL,
+	ADD COLUMN IF NOT EXISTS class_resolved_by varchar(100) NULL; -- Username untuk MANUAL
+
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS fk_axle_class_rule;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT fk_axle_class_rule FOREIGN KEY (class_rule_id) REFERENCES public.master_vehicle_class_rule(id) ON DELETE SET NULL;
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS transact_axle_capture_class_status_check;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT transact_axle_capture_class_status_check CHECK (((class_status)::text = ANY (ARRAY['MATCHED'::text, 'LOW_CONFIDENCE'::text, 'UNMATCHED'::text, 'MANUAL'::text])));
+
+CREATE INDEX IF NOT EXISTS idx_axle_class_review ON public.transact_axle_capture USING btree (captured_at) WHERE (class_review_required = true);
@@ -0,0 +1,45 @@
+-- Over-dimension (ODOL): dimensi terukur (panjang dari axle, panjang/lebar dari vision)
+-- dibandingkan dengan batas master_vehicle_class dengan toleransi per dimensi.
+-- Tinggi belum dicek: vision_height_m hanya estimasi (rasio dari panjang), bukan pengukuran
+-- Run: psql -d wim_db -f migrations/308_over_dimension.sql
+
+-- Dimensi hasil vision disimpan di capture ANPR (kendaraan dengan confidence tertinggi)
+ALTER TABLE public.transact_anpr_capture
+	ADD COLUMN IF NOT EXISTS vision_length_m numeric(10, 3) NULL,
+	ADD COLUMN IF NOT EXISTS vision_width_m numeric(10, 3) NULL,
+	ADD COLUMN IF NOT EXISTS vision_height_m numeric(10, 3) NULL, -- Estimasi, tidak dipakai untuk ODOL
+	ADD COLUMN IF NOT EXISTS vision_confidence numeric(5, 4) NULL,
+	ADD COLUMN IF NOT EXISTS vision_measured_at timestamptz NULL;
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS over_dimension bool NULL, -- NULL = belum dievaluasi / tidak ada kelas
+	ADD COLUMN IF NOT EXISTS dimension_evaluated_at timestamptz NULL;
+
+CREATE INDEX IF NOT EXISTS idx_axle_over_dimension ON public.transact_axle_capture USING btree (captured_at) WHERE (over_dimension = true);
+
+-- public.transact_dimension_check definition
+
+CREATE TABLE IF NOT EXISTS public.transact_dimension_check (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	axle_capture_id uuid NOT NULL,
+	dimension varchar(10) NOT NULL, -- LENGTH / WIDTH (HEIGHT dicadangkan untuk pengukuran tinggi)
+	source varchar(10) NOT NULL, -- AXLE / VISION
+	anpr_capture_id uuid NULL, -- Sumber pengukuran vision
+	vehicle_class_id uuid NULL,
+	measured_mm int4 NOT NULL,
+	limit_mm int4 NOT NULL,
+	tolerance_mm int4 NOT NULL,
+	excess_mm int4 NOT NULL, -- measured - limit (negatif = di bawah batas)
+	excess_pct numeric(7, 2) NOT NULL,
+	is_violation bool NOT NULL, -- excess_mm > tolerance_mm
+	evaluated_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT transact_dimension_check_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_dimension_check UNIQUE (axle_capture_id, dimension),
+	CONSTRAINT transact_dimension_check_dimension_check CHECK (((dimension)::text = ANY (ARRAY['LENGTH'::text, 'WIDTH'::text, 'HEIGHT'::text]))),
+	CONSTRAINT transact_dimension_check_source_check CHECK (((source)::text = ANY (ARRAY['AXLE'::text, 'VISION'::text]))),
+	CONSTRAINT fk_dimension_check_capture FOREIGN KEY (axle_capture_id) REFERENCES public.transact_axle_capture(id) ON DELETE CASCADE,
+	CONSTRAINT fk_dimension_check_anpr FOREIGN KEY (anpr_capture_id) REFERENCES public.transact_anpr_capture(id) ON DELETE SET NULL
+);
+
+COMMENT ON TABLE public.transact_dimension_check IS 'Per-dimension comparison of measured vehicle size against master_vehicle_class limits';
+COMMENT ON COLUMN public.transact_anpr_capture.vision_height_m IS 'Estimated height (ratio of the vision length), not a measurement; not used for over-dimension checks';
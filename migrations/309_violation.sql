@@ -0,0 +1,72 @@
+-- Violation lifecycle: pelanggaran overload / over-dimension dari axle capture
+-- dikerjakan petugas (review, confirm/dismiss, issue, close) dengan catatan dan audit trail
+-- Capture, pelanggaran, catatan dan audit tidak pernah dihapus fisik (FK RESTRICT); capture lama
+-- ditandai is_deleted saat file diproses ulang.
+-- Run: psql -d wim_db -f migrations/309_violation.sql
+
+-- public.transact_violation definition
+
+CREATE TABLE IF NOT EXISTS public.transact_violation (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	axle_capture_id uuid NOT NULL,
+	violation_type varchar(20) NOT NULL, -- OVERLOAD / OVER_DIMENSION
+	status varchar(20) NOT NULL DEFAULT 'OPEN', -- OPEN / UNDER_REVIEW / CONFIRMED / DISMISSED / ISSUED / CLOSED
+	site_id uuid NULL,
+	plate_no varchar(32) NULL,
+	captured_at timestamptz NULL,
+	excess_pct numeric(7, 2) NULL, -- Overload % atau kelebihan dimensi terbesar (%)
+	detail text NULL, -- Ringkasan pengukuran saat terdeteksi / dievaluasi ulang
+	still_detected bool NOT NULL DEFAULT true, -- false jika evaluasi ulang tidak lagi mendeteksi (atau tidak bisa lagi mengevaluasi) pelanggaran
+	auto_dismissed bool NOT NULL DEFAULT false, -- true jika DISMISSED otomatis oleh evaluasi ulang (dibuka lagi jika terdeteksi lagi)
+	assigned_to uuid NULL,
+	assigned_at timestamptz NULL,
+	closed_at timestamptz NULL,
+	is_deleted bool NULL DEFAULT false,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT transact_violation_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_violation_capture_type UNIQUE (axle_capture_id, violation_type),
+	CONSTRAINT transact_violation_type_check CHECK (((violation_type)::text = ANY (ARRAY['OVERLOAD'::text, 'OVER_DIMENSION'::text]))),
+	CONSTRAINT transact_violation_status_check CHECK (((status)::text = ANY (ARRAY['OPEN'::text, 'UNDER_REVIEW'::text, 'CONFIRMED'::text, 'DISMISSED'::text, 'ISSUED'::text, 'CLOSED'::text]))),
+	CONSTRAINT fk_violation_capture FOREIGN KEY (axle_capture_id) REFERENCES public.transact_axle_capture(id) ON DELETE RESTRICT,
+	CONSTRAINT fk_violation_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE,
+	CONSTRAINT fk_violation_assigned_to FOREIGN KEY (assigned_to) REFERENCES public.master_user(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_violation_status ON public.transact_violation USING btree (status, captured_at);
+CREATE INDEX IF NOT EXISTS idx_violation_assigned_to ON public.transact_violation USING btree (assigned_to) WHERE (assigned_to IS NOT NULL);
+CREATE INDEX IF NOT EXISTS idx_violation_site ON public.transact_violation USING btree (site_id);
+
+COMMENT ON TABLE public.transact_violation IS 'Overload / over-dimension violation case raised from an axle capture';
+COMMENT ON COLUMN public.transact_violation.still_detected IS 'False when re-evaluation of the capture no longer detects, or can no longer evaluate, the violation';
+COMMENT ON COLUMN public.transact_violation.auto_dismissed IS 'True when the pipeline dismissed the case because the finding disappeared; reopened if it is detected again';
+
+-- public.transact_violation_note definition
+
+CREATE TABLE IF NOT EXISTS public.transact_violation_note (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	violation_id uuid NOT NULL,
+	note text NOT NULL,
+	created_by varchar(100) NOT NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT transact_violation_note_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_violation_note_violation FOREIGN KEY (violation_id) REFERENCES public.transact_violation(id) ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_violation_note_violation ON public.transact_violation_note USING btree (violation_id, created_date);
+
+-- public.transact_violation_audit definition
+
+CREATE TABLE IF NOT EXISTS public.transact_violation_audit (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	violation_id uuid NOT NULL,
+	action varchar(20) NOT NULL, -- CREATED / STATUS_CHANGED / ASSIGNED / NOTE_ADDED / REEVALUATED
+	from_status varchar(20) NULL,
+	to_status varchar(20) NULL,
+	detail text NULL,
+	actor varchar(100) NOT NULL, -- Username, atau 'system' untuk pipeline
+	created_date timestamptz NULL DEFAULT clock_timestamp(), -- clock_timestamp: urutan tetap benar dalam satu transaksi
+	CONSTRAINT transact_violation_audit_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_violation_audit_violation FOREIGN KEY (violation_id) REFERENCES public.transact_violation(id) ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_violation_audit_violation ON public.transact_violation_audit USING btree (violation_id, created_date);
+
+COMMENT ON TABLE public.transact_violation_audit IS 'Append-only audit trail of every change to a violation';
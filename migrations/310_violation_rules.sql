@@ -0,0 +1,69 @@
+-- Regulation rule engine: toleransi dan batas pelanggaran dengan tanggal berlaku dan versi,
+-- sehingga perubahan regulasi tidak perlu redeploy site
+-- Run: psql -d wim_db -f migrations/310_violation_rules.sql
+
+-- public.master_violation_rule definition
+
+CREATE TABLE IF NOT EXISTS public.master_violation_rule (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	code varchar(50) NOT NULL, -- Identitas aturan; setiap perubahan = versi baru dengan code yang sama
+	"version" int4 NOT NULL,
+	rule_type varchar(20) NOT NULL, -- OVERLOAD / OVER_DIMENSION
+	description text NULL,
+	road_class int2 NULL, -- NULL = semua kelas jalan
+	vehicle_class_id uuid NULL, -- NULL = semua kelas kendaraan
+	tolerance_pct numeric(5, 2) NULL, -- OVERLOAD: kelebihan berat (%) yang masih ditoleransi
+	permitted_weight_kg numeric(10, 2) NULL, -- OVERLOAD: ganti batas berat kelas (NULL = pakai master_vehicle_class)
+	length_tolerance_mm int4 NULL, -- OVER_DIMENSION
+	width_tolerance_mm int4 NULL,
+	height_tolerance_mm int4 NULL,
+	effective_from timestamptz NOT NULL,
+	effective_to timestamptz NULL, -- Eksklusif; NULL = berlaku terus
+	is_active bool NULL DEFAULT true,
+	created_by varchar(100) NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_violation_rule_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_violation_rule_version UNIQUE (code, "version"),
+	CONSTRAINT master_violation_rule_type_check CHECK (((rule_type)::text = ANY (ARRAY['OVERLOAD'::text, 'OVER_DIMENSION'::text]))),
+	CONSTRAINT master_violation_rule_road_class_check CHECK (((road_class IS NULL) OR (road_class = ANY (ARRAY[2, 3])))),
+	CONSTRAINT master_violation_rule_period_check CHECK (((effective_to IS NULL) OR (effective_to > effective_from))),
+	CONSTRAINT fk_violation_rule_vehicle_class FOREIGN KEY (vehicle_class_id) REFERENCES public.master_vehicle_class(id) ON DELETE RESTRICT
+);
+CREATE INDEX IF NOT EXISTS idx_violation_rule_lookup ON public.master_violation_rule USING btree (rule_type, effective_from);
+
+COMMENT ON TABLE public.master_violation_rule IS 'Versioned, effective-dated tolerances and limits used to evaluate overload and over-dimension';
+
+-- Aturan awal = nilai default sebelumnya (OVERLOAD_TOLERANCE_PCT / ODOL_*_TOLERANCE_MM)
+INSERT INTO public.master_violation_rule
+	(code, "version", rule_type, description, tolerance_pct, effective_from, created_by)
+VALUES ('OVERLOAD-DEFAULT', 1, 'OVERLOAD', 'Default overload tolerance', 5, '2000-01-01', 'system')
+ON CONFLICT (code, "version") DO NOTHING;
+
+INSERT INTO public.master_violation_rule
+	(code, "version", rule_type, description, length_tolerance_mm, width_tolerance_mm, height_tolerance_mm, effective_from, created_by)
+VALUES ('ODOL-DEFAULT', 1, 'OVER_DIMENSION', 'Default over-dimension tolerance', 300, 100, 100, '2000-01-01', 'system')
+ON CONFLICT (code, "version") DO NOTHING;
+
+-- Aturan yang dipakai saat evaluasi
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS overload_rule_id uuid NULL,
+	ADD COLUMN IF NOT EXISTS dimension_rule_id uuid NULL;
+
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS fk_axle_overload_rule;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT fk_axle_overload_rule FOREIGN KEY (overload_rule_id) REFERENCES public.master_violation_rule(id) ON DELETE SET NULL;
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS fk_axle_dimension_rule;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT fk_axle_dimension_rule FOREIGN KEY (dimension_rule_id) REFERENCES public.master_violation_rule(id) ON DELETE SET NULL;
+
+-- Versi aturan yang menghasilkan pelanggaran
+ALTER TABLE public.transact_violation
+	ADD COLUMN IF NOT EXISTS rule_id uuid NULL,
+	ADD COLUMN IF NOT EXISTS rule_version varchar(64) NULL; -- code@vN, tetap terbaca walau aturan dinonaktifkan
+
+ALTER TABLE public.transact_violation
+	DROP CONSTRAINT IF EXISTS fk_violation_rule;
+ALTER TABLE public.transact_violation
+	ADD CONSTRAINT fk_violation_rule FOREIGN KEY (rule_id) REFERENCES public.master_violation_rule(id) ON DELETE RESTRICT;
@@ -0,0 +1,56 @@
+-- Dispensation permits (dispensasi): izin melebihi berat/dimensi pada rute dan periode
+-- tertentu; pelanggaran yang tercakup izin dicatat sebagai PERMITTED (permitted exceedance)
+-- Run: psql -d wim_db -f migrations/311_dispensation_permit.sql
+
+-- public.master_dispensation_permit definition
+
+CREATE TABLE IF NOT EXISTS public.master_dispensation_permit (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	permit_no varchar(100) NOT NULL, -- Nomor surat dispensasi
+	plate_no varchar(32) NOT NULL,
+	plate_normalized varchar(32) NOT NULL, -- Uppercase, spaces/punctuation removed (used for matching)
+	holder varchar(150) NULL, -- Pemegang izin / operator
+	route text NULL, -- Deskripsi rute yang diizinkan
+	max_gross_weight_kg int4 NULL, -- NULL = berat tidak dicakup izin
+	max_length_mm int4 NULL,
+	max_width_mm int4 NULL,
+	max_height_mm int4 NULL,
+	valid_from timestamptz NOT NULL,
+	valid_to timestamptz NOT NULL, -- Eksklusif
+	notes text NULL,
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by varchar(100) NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_dispensation_permit_pkey PRIMARY KEY (id),
+	CONSTRAINT master_dispensation_permit_no_key UNIQUE (permit_no),
+	CONSTRAINT master_dispensation_permit_period_check CHECK ((valid_to > valid_from))
+);
+CREATE INDEX IF NOT EXISTS idx_dispensation_permit_plate ON public.master_dispensation_permit USING btree (plate_normalized, valid_from) WHERE (is_deleted = false AND is_active = true);
+
+COMMENT ON TABLE public.master_dispensation_permit IS 'Oversize/overweight dispensation permits; covered exceedances are recorded as PERMITTED violations';
+
+-- public.master_dispensation_permit_site definition (tanpa baris = berlaku di semua site)
+
+CREATE TABLE IF NOT EXISTS public.master_dispensation_permit_site (
+	permit_id uuid NOT NULL,
+	site_id uuid NOT NULL,
+	CONSTRAINT master_dispensation_permit_site_pkey PRIMARY KEY (permit_id, site_id),
+	CONSTRAINT fk_permit_site_permit FOREIGN KEY (permit_id) REFERENCES public.master_dispensation_permit(id) ON DELETE CASCADE,
+	CONSTRAINT fk_permit_site_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE CASCADE
+);
+
+-- Status PERMITTED: pelanggaran tercakup dispensasi (bukan kasus untuk ditindak)
+ALTER TABLE public.transact_violation
+	DROP CONSTRAINT IF EXISTS transact_violation_status_check;
+ALTER TABLE public.transact_violation
+	ADD CONSTRAINT transact_violation_status_check CHECK (((status)::text = ANY (ARRAY['OPEN'::text, 'UNDER_REVIEW'::text, 'CONFIRMED'::text, 'DISMISSED'::text, 'ISSUED'::text, 'CLOSED'::text, 'PERMITTED'::text])));
+
+ALTER TABLE public.transact_violation
+	ADD COLUMN IF NOT EXISTS permit_id uuid NULL;
+
+ALTER TABLE public.transact_violation
+	DROP CONSTRAINT IF EXISTS fk_violation_permit;
+ALTER TABLE public.transact_violation
+	ADD CONSTRAINT fk_violation_permit FOREIGN KEY (permit_id) REFERENCES public.master_dispensation_permit(id) ON DELETE RESTRICT;
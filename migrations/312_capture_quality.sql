@@ -0,0 +1,43 @@
+-- Validasi field ANPR/axle: nilai yang ditolak disimpan NULL dengan flag kualitas,
+-- dan jumlah kegagalan validasi dihitung per device
+-- Run: psql -d wim_db -f migrations/312_capture_quality.sql
+
+ALTER TABLE public.transact_anpr_capture
+	ADD COLUMN IF NOT EXISTS quality_status varchar(10) NOT NULL DEFAULT 'VALID', -- VALID / WARNING / INVALID
+	ADD COLUMN IF NOT EXISTS quality_issues jsonb NOT NULL DEFAULT '[]'::jsonb; -- [{field, location, flag, value, message, required}]
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS quality_status varchar(10) NOT NULL DEFAULT 'VALID',
+	ADD COLUMN IF NOT EXISTS quality_issues jsonb NOT NULL DEFAULT '[]'::jsonb;
+
+ALTER TABLE public.transact_anpr_capture DROP CONSTRAINT IF EXISTS transact_anpr_capture_quality_status_check;
+ALTER TABLE public.transact_anpr_capture ADD CONSTRAINT transact_anpr_capture_quality_status_check
+	CHECK (((quality_status)::text = ANY (ARRAY['VALID'::text, 'WARNING'::text, 'INVALID'::text])));
+
+ALTER TABLE public.transact_axle_capture DROP CONSTRAINT IF EXISTS transact_axle_capture_quality_status_check;
+ALTER TABLE public.transact_axle_capture ADD CONSTRAINT transact_axle_capture_quality_status_check
+	CHECK (((quality_status)::text = ANY (ARRAY['VALID'::text, 'WARNING'::text, 'INVALID'::text])));
+
+CREATE INDEX IF NOT EXISTS idx_anpr_quality ON public.transact_anpr_capture USING btree (captured_at) WHERE ((quality_status)::text <> 'VALID'::text);
+CREATE INDEX IF NOT EXISTS idx_axle_quality ON public.transact_axle_capture USING btree (captured_at) WHERE ((quality_status)::text <> 'VALID'::text);
+
+-- public.transact_device_validation_stat definition
+
+CREATE TABLE IF NOT EXISTS public.transact_device_validation_stat (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	site_id uuid NOT NULL,
+	device_type varchar(10) NOT NULL, -- ANPR / AXLE
+	device_id varchar(100) NOT NULL, -- cameraid dari XML ('' jika tidak ada)
+	field varchar(50) NOT NULL, -- contoh: length, axle.weight
+	flag varchar(20) NOT NULL, -- MISSING / MALFORMED / OUT_OF_RANGE
+	failure_count int8 NOT NULL DEFAULT 0,
+	last_value text NULL, -- Nilai mentah terakhir yang ditolak
+	first_seen_at timestamptz NOT NULL DEFAULT now(),
+	last_seen_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT transact_device_validation_stat_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_device_validation_stat UNIQUE (site_id, device_type, device_id, field, flag),
+	CONSTRAINT transact_device_validation_stat_type_check CHECK (((device_type)::text = ANY (ARRAY['ANPR'::text, 'AXLE'::text]))),
+	CONSTRAINT fk_device_validation_stat_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE CASCADE ON UPDATE CASCADE
+);
+
+COMMENT ON TABLE public.transact_device_validation_stat IS 'Running count of rejected ANPR/axle field values per device, field and flag';
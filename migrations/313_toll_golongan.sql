@@ -0,0 +1,58 @@
+-- Golongan tarif tol (I–V) per kendaraan berdasarkan jumlah sumbu dan jenis kendaraan.
+-- Aturan pemetaan disimpan di database dan bisa diubah lewat API.
+-- Run: psql -d wim_db -f migrations/313_toll_golongan.sql
+
+-- public.master_toll_golongan_rule definition
+
+CREATE TABLE IF NOT EXISTS public.master_toll_golongan_rule (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	golongan int2 NOT NULL, -- 1..5 (Golongan I..V)
+	rule_name varchar(150) NOT NULL,
+	priority int4 NOT NULL DEFAULT 100, -- Aturan cocok dengan angka terkecil yang dipakai
+	min_axles int4 NULL, -- Semua kriteria wajib terpenuhi (NULL = tanpa batas)
+	max_axles int4 NULL,
+	min_length_mm int4 NULL,
+	max_length_mm int4 NULL,
+	body_types text NULL, -- Daftar body_type dipisah koma (case-insensitive)
+	categories text NULL, -- Daftar vehicle_category dipisah koma (case-insensitive)
+	vehicle_class_id uuid NULL, -- Kelas kendaraan hasil resolusi
+	is_active bool NULL DEFAULT true,
+	is_deleted bool NULL DEFAULT false,
+	created_by uuid NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	updated_by uuid NULL,
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_toll_golongan_rule_pkey PRIMARY KEY (id),
+	CONSTRAINT ck_golongan_rule_golongan CHECK (((golongan >= 1) AND (golongan <= 5))),
+	CONSTRAINT fk_golongan_rule_class FOREIGN KEY (vehicle_class_id) REFERENCES public.master_vehicle_class(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_golongan_rule_active ON public.master_toll_golongan_rule USING btree (priority) WHERE (is_deleted = false);
+
+COMMENT ON TABLE public.master_toll_golongan_rule IS 'Rules mapping vehicles (axle count, type, class) to Indonesian toll Golongan I-V';
+
+-- Aturan awal sesuai penggolongan tarif tol:
+-- Gol I: sedan, jip, pick up/truk kecil, bus; Gol II-V: truk 2, 3, 4, 5+ sumbu
+INSERT INTO public.master_toll_golongan_rule (golongan, rule_name, priority, min_axles, max_axles, max_length_mm, body_types)
+SELECT v.golongan, v.rule_name, v.priority, v.min_axles, v.max_axles, v.max_length_mm, v.body_types
+FROM (VALUES
+	(1::int2, 'Bus', 10, NULL::int4, NULL::int4, NULL::int4, 'BUS'),
+	(1::int2, 'Sedan / jip / pick up', 20, NULL, 2, NULL, 'CAR,SEDAN,JEEP,SUV,MPV,VAN,MINIBUS,PICKUP,PICK UP'),
+	(1::int2, 'Truk kecil 2 sumbu (<= 5,5 m)', 30, NULL, 2, 5500, NULL),
+	(2::int2, 'Truk 2 sumbu', 100, 2, 2, NULL, NULL),
+	(3::int2, 'Truk 3 sumbu', 100, 3, 3, NULL, NULL),
+	(4::int2, 'Truk 4 sumbu', 100, 4, 4, NULL, NULL),
+	(5::int2, 'Truk 5 sumbu atau lebih', 100, 5, NULL, NULL, NULL)
+) AS v(golongan, rule_name, priority, min_axles, max_axles, max_length_mm, body_types)
+WHERE NOT EXISTS (SELECT 1 FROM public.master_toll_golongan_rule);
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS toll_golongan int2 NULL, -- NULL = tidak ada aturan yang cocok / belum dihitung
+	ADD COLUMN IF NOT EXISTS golongan_rule_id uuid NULL,
+	ADD COLUMN IF NOT EXISTS golongan_assigned_at timestamptz NULL;
+
+ALTER TABLE public.transact_axle_capture
+	DROP CONSTRAINT IF EXISTS fk_axle_golongan_rule;
+ALTER TABLE public.transact_axle_capture
+	ADD CONSTRAINT fk_axle_golongan_rule FOREIGN KEY (golongan_rule_id) REFERENCES public.master_toll_golongan_rule(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_axle_golongan ON public.transact_axle_capture USING btree (site_id, captured_at, toll_golongan);
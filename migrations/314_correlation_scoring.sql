@@ -0,0 +1,17 @@
+-- Skor korelasi: selisih waktu, kemiripan plat (anpr/text di XML axle vs plat ANPR) dan kesesuaian lajur.
+-- Pasangan dengan skor di bawah CORRELATION_MIN_SCORE ditandai untuk review manual.
+-- Run: psql -d wim_db -f migrations/314_correlation_scoring.sql
+
+-- Lajur dari elemen <lane> di XML kamera (NULL jika tidak dilaporkan)
+ALTER TABLE public.transact_anpr_capture
+	ADD COLUMN IF NOT EXISTS lane_no int2 NULL;
+
+ALTER TABLE public.transact_axle_capture
+	ADD COLUMN IF NOT EXISTS lane_no int2 NULL;
+
+ALTER TABLE public.transact_vehicle
+	ADD COLUMN IF NOT EXISTS match_score numeric(4, 3) NULL, -- 0..1, hanya untuk MATCHED
+	ADD COLUMN IF NOT EXISTS score_breakdown jsonb NULL, -- {time_score, plate_score, lane_score, weights, ...}
+	ADD COLUMN IF NOT EXISTS review_required bool NOT NULL DEFAULT false;
+
+CREATE INDEX IF NOT EXISTS idx_vehicle_review ON public.transact_vehicle USING btree (first_detected_at) WHERE (review_required = true);
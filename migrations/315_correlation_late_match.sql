@@ -0,0 +1,24 @@
+-- Rekonsiliasi korelasi untuk data yang datang terlambat (mis. setelah FTP outage salah satu device).
+-- Job berkala mengevaluasi ulang capture ANPR_ONLY / AXLE_ONLY di dalam horizon look-back;
+-- setiap pasangan yang berhasil digabung dicatat di sini untuk laporan "late recovery".
+-- Run: psql -d wim_db -f migrations/315_correlation_late_match.sql
+
+-- public.transact_vehicle_late_match definition
+
+CREATE TABLE IF NOT EXISTS public.transact_vehicle_late_match (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	vehicle_id uuid NULL, -- Row transact_vehicle hasil gabungan (NULL jika kemudian dihapus)
+	site_id uuid NOT NULL,
+	anpr_id uuid NOT NULL,
+	axle_id uuid NOT NULL,
+	match_score numeric(4, 3) NULL,
+	time_diff_seconds numeric(10, 3) NULL, -- |axle - anpr| saat digabung
+	recovery_delay_seconds numeric(12, 3) NULL, -- Waktu gabung - first_detected_at
+	merged_at timestamptz NOT NULL DEFAULT now(),
+	CONSTRAINT transact_vehicle_late_match_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_late_match_vehicle FOREIGN KEY (vehicle_id) REFERENCES public.transact_vehicle(id) ON DELETE SET NULL,
+	CONSTRAINT fk_late_match_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_late_match_site ON public.transact_vehicle_late_match USING btree (site_id, merged_at);
+
+COMMENT ON TABLE public.transact_vehicle_late_match IS 'ANPR/axle pairs merged by the late-arrival reconciliation job after both sides were recorded unmatched';
@@ -0,0 +1,33 @@
+-- Override manual hasil korelasi oleh supervisor: unlink pasangan yang salah, link ANPR ↔ axle tertentu,
+-- atau tandai capture "tanpa pasangan". Setiap aksi dicatat dengan user dan alasan.
+-- Run: psql -d wim_db -f migrations/316_vehicle_override.sql
+
+ALTER TABLE public.transact_vehicle
+	ADD COLUMN IF NOT EXISTS no_partner bool NOT NULL DEFAULT false, -- Tidak dicocokkan lagi oleh korelasi otomatis
+	ADD COLUMN IF NOT EXISTS override_action varchar(20) NULL, -- Override manual terakhir: LINK / UNLINK / NO_PARTNER
+	ADD COLUMN IF NOT EXISTS override_by varchar(100) NULL,
+	ADD COLUMN IF NOT EXISTS override_reason text NULL,
+	ADD COLUMN IF NOT EXISTS override_at timestamptz NULL;
+
+-- public.transact_vehicle_override definition
+
+CREATE TABLE IF NOT EXISTS public.transact_vehicle_override (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	vehicle_id uuid NULL, -- NULL jika row kendaraan kemudian dihapus/digabung
+	site_id uuid NOT NULL,
+	"action" varchar(20) NOT NULL, -- LINK / UNLINK / NO_PARTNER
+	anpr_id uuid NULL,
+	axle_id uuid NULL,
+	reason text NOT NULL,
+	actor varchar(100) NOT NULL,
+	created_date timestamptz NULL DEFAULT clock_timestamp(),
+	CONSTRAINT transact_vehicle_override_pkey PRIMARY KEY (id),
+	CONSTRAINT transact_vehicle_override_action_check CHECK (((action)::text = ANY (ARRAY['LINK'::text, 'UNLINK'::text, 'NO_PARTNER'::text]))),
+	CONSTRAINT fk_vehicle_override_vehicle FOREIGN KEY (vehicle_id) REFERENCES public.transact_vehicle(id) ON DELETE SET NULL,
+	CONSTRAINT fk_vehicle_override_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_vehicle_override_vehicle ON public.transact_vehicle_override USING btree (vehicle_id, created_date);
+-- Pasangan yang di-UNLINK tidak dicocokkan lagi oleh korelasi otomatis
+CREATE INDEX IF NOT EXISTS idx_vehicle_override_pair ON public.transact_vehicle_override USING btree (anpr_id, axle_id) WHERE ((action)::text = 'UNLINK'::text);
+
+COMMENT ON TABLE public.transact_vehicle_override IS 'Audit trail of manual correlation overrides (link, unlink, no partner) with user and reason';
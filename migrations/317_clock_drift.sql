@@ -0,0 +1,61 @@
+-- Estimasi clock drift antar device (kamera ANPR ↔ kamera/WIM axle) dari offset_ms pasangan MATCHED
+-- dengan skor tinggi. Offset berjalan (EWMA) per pasangan device; alert jika melewati ambang batas.
+-- Run: psql -d wim_db -f migrations/317_clock_drift.sql
+
+-- Offset bertanda per kendaraan MATCHED (axle - anpr, sebelum koreksi), diisi saat korelasi
+
+ALTER TABLE public.transact_vehicle ADD COLUMN IF NOT EXISTS offset_ms int4 NULL;
+
+-- public.view_vehicle_complete definition (tambah offset_ms di akhir)
+
+CREATE OR REPLACE VIEW public.view_vehicle_complete AS
+SELECT v.id, v.correlation_status, v.plate_no, v.first_detected_at, v.time_diff_seconds,
+       v.toll_golongan, v.correlated_at,
+       ms.id AS site_id, ms.code AS site_code, ms.site_name,
+       an.id AS anpr_id, an.captured_at AS anpr_captured_at, an.confidence AS anpr_confidence,
+       an.camera_id AS anpr_camera_id, an.minio_full_image_object, an.minio_plate_image_object,
+       ax.id AS axle_id, ax.captured_at AS axle_captured_at, ax.camera_id AS axle_camera_id,
+       ax.length_mm, ax.total_axles, ax.total_wheels,
+       ax.vehicle_category, ax.vehicle_body_type, ax.minio_image_object AS axle_image_object,
+       v.offset_ms
+FROM public.transact_vehicle v
+JOIN public.master_site ms ON ms.id = v.site_id
+LEFT JOIN public.transact_anpr_capture an ON an.id = v.anpr_id
+LEFT JOIN public.transact_axle_capture ax ON ax.id = v.axle_id;
+
+-- public.transact_clock_drift definition
+
+CREATE TABLE IF NOT EXISTS public.transact_clock_drift (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	site_id uuid NOT NULL,
+	anpr_camera_id varchar(100) NOT NULL, -- camera_id capture ANPR ('' jika tidak ada)
+	axle_camera_id varchar(100) NOT NULL, -- camera_id capture axle ('' jika tidak ada)
+	offset_ms numeric(12, 3) NOT NULL, -- Estimasi berjalan axle - anpr (EWMA)
+	last_offset_ms int4 NOT NULL, -- Sampel terakhir
+	samples int4 NOT NULL DEFAULT 0,
+	drift_status varchar(10) NOT NULL DEFAULT 'OK', -- OK / DRIFT
+	first_sample_at timestamptz NULL DEFAULT now(),
+	updated_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT transact_clock_drift_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_clock_drift_pair UNIQUE (site_id, anpr_camera_id, axle_camera_id),
+	CONSTRAINT transact_clock_drift_status_check CHECK (((drift_status)::text = ANY (ARRAY['OK'::text, 'DRIFT'::text]))),
+	CONSTRAINT fk_clock_drift_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+
+COMMENT ON TABLE public.transact_clock_drift IS 'Running clock offset estimate per ANPR/axle device pair from confidently matched vehicles';
+
+-- public.transact_clock_drift_alert definition
+
+CREATE TABLE IF NOT EXISTS public.transact_clock_drift_alert (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	drift_id uuid NOT NULL,
+	offset_ms numeric(12, 3) NOT NULL, -- Estimasi saat alert dibuat
+	threshold_ms int4 NOT NULL,
+	raised_at timestamptz NOT NULL DEFAULT now(),
+	cleared_at timestamptz NULL, -- NULL = masih aktif
+	CONSTRAINT transact_clock_drift_alert_pkey PRIMARY KEY (id),
+	CONSTRAINT fk_clock_drift_alert_drift FOREIGN KEY (drift_id) REFERENCES public.transact_clock_drift(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_clock_drift_alert_open ON public.transact_clock_drift_alert USING btree (drift_id) WHERE (cleared_at IS NULL);
+
+COMMENT ON TABLE public.transact_clock_drift_alert IS 'Clock drift alerts raised when a device pair offset exceeds the threshold';
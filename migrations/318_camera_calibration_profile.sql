@@ -0,0 +1,45 @@
+-- Profil kalibrasi kamera per site dan camera_id dengan versi dan tanggal berlaku, sehingga
+-- satu ANPR watcher bisa mengukur dimensi dari beberapa kamera dengan tinggi/tilt berbeda.
+-- Tanpa profil yang berlaku, kalibrasi CAMERA_* dari config yang dipakai.
+-- Run: psql -d wim_db -f migrations/318_camera_calibration_profile.sql
+
+-- public.master_camera_calibration definition
+
+CREATE TABLE IF NOT EXISTS public.master_camera_calibration (
+	id uuid NOT NULL DEFAULT uuid_generate_v4(),
+	site_id uuid NOT NULL,
+	camera_id varchar(100) NOT NULL, -- camera_id capture ANPR; '' = default untuk semua kamera site
+	"version" int4 NOT NULL, -- Setiap perubahan = versi baru untuk site + camera_id yang sama
+	description text NULL,
+	image_width int4 NOT NULL,
+	image_height int4 NOT NULL,
+	focal_length_px numeric(10, 3) NOT NULL, -- Hasil pose jika dihitung dari control point
+	camera_height_m numeric(8, 3) NOT NULL,
+	tilt_angle_deg numeric(6, 3) NOT NULL,
+	ref_pixel_length int4 NULL, -- Kalibrasi objek referensi (tanpa control point)
+	ref_real_length_m numeric(8, 3) NULL,
+	ref_distance_m numeric(8, 3) NULL,
+	control_points jsonb NULL, -- [{"pixel_x", "pixel_y", "ground_x", "ground_y"}]; NULL = objek referensi
+	residual jsonb NULL, -- vision.CalibrationResidual saat profil dibuat
+	effective_from timestamptz NOT NULL,
+	effective_to timestamptz NULL, -- Eksklusif; NULL = berlaku terus
+	is_active bool NULL DEFAULT true,
+	created_by varchar(100) NULL,
+	created_date timestamptz NULL DEFAULT now(),
+	CONSTRAINT master_camera_calibration_pkey PRIMARY KEY (id),
+	CONSTRAINT uq_camera_calibration_version UNIQUE (site_id, camera_id, "version"),
+	CONSTRAINT master_camera_calibration_period_check CHECK (((effective_to IS NULL) OR (effective_to > effective_from))),
+	CONSTRAINT fk_camera_calibration_site FOREIGN KEY (site_id) REFERENCES public.master_site(id) ON DELETE RESTRICT ON UPDATE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_camera_calibration_lookup ON public.master_camera_calibration USING btree (site_id, camera_id, effective_from);
+
+COMMENT ON TABLE public.master_camera_calibration IS 'Versioned, effective-dated camera calibration profiles per site and ANPR camera used for vision dimensions';
+
+-- Profil yang dipakai untuk mengukur vision_* (NULL = kalibrasi config)
+ALTER TABLE public.transact_anpr_capture
+	ADD COLUMN IF NOT EXISTS vision_calibration_id uuid NULL;
+
+ALTER TABLE public.transact_anpr_capture
+	DROP CONSTRAINT IF EXISTS fk_anpr_vision_calibration;
+ALTER TABLE public.transact_anpr_capture
+	ADD CONSTRAINT fk_anpr_vision_calibration FOREIGN KEY (vision_calibration_id) REFERENCES public.master_camera_calibration(id) ON DELETE SET NULL;